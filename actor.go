@@ -0,0 +1,171 @@
+package statekit
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// ErrActorStopped is returned when sending to an actor that has been stopped
+var ErrActorStopped = errors.New("actor stopped")
+
+// Actor runs an interpreter in its own goroutine behind a channel-based API.
+// Events sent with Send go through a bounded inbox and are processed one at a
+// time in the order they were sent; state changes are published on States.
+type Actor[C any] struct {
+	interp *Interpreter[C]
+	events chan Event
+	states chan State[C]
+
+	stopping chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	// sendMu is held for reading by Send and for writing by run before the
+	// final drain, so no event can enter the inbox once it is drained
+	sendMu sync.RWMutex
+
+	statesMu     sync.Mutex
+	statesClosed bool
+	unsubscribe  func()
+}
+
+// NewActor starts an interpreter for the machine and spawns the goroutine that
+// processes its events. buffer is the capacity of the inbox and of the States
+// channel: Send blocks while the inbox is full, which applies backpressure to
+// fast senders. NewActor returns the error of the machine's context validator,
+// if it rejects the context.
+func NewActor[C any](machine *ir.MachineConfig[C], buffer int, opts ...InterpreterOption) (*Actor[C], error) {
+	buffer = max(buffer, 0)
+	a := &Actor[C]{
+		interp:   NewInterpreter(machine, opts...),
+		events:   make(chan Event, buffer),
+		states:   make(chan State[C], max(buffer, 1)),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	a.unsubscribe = a.interp.Subscribe(a.publish)
+	if err := a.interp.StartErr(); err != nil {
+		a.unsubscribe()
+		return nil, err
+	}
+
+	go a.run()
+	return a, nil
+}
+
+// Send queues an event for the actor, blocking while the inbox is full.
+// It returns ErrActorStopped once Stop has been called; an event for which Send
+// returns nil is always processed.
+func (a *Actor[C]) Send(event Event) error {
+	a.sendMu.RLock()
+	defer a.sendMu.RUnlock()
+
+	select {
+	case <-a.stopping:
+		return ErrActorStopped
+	default:
+	}
+
+	select {
+	case a.events <- event:
+		return nil
+	case <-a.stopping:
+		return ErrActorStopped
+	}
+}
+
+// States returns the channel on which the actor publishes its state after the
+// initial state is entered and after every transition, including those fired by
+// timers. A reader that falls behind does not stall the actor: when the channel
+// is full, the oldest state is dropped, so the latest state is always delivered.
+// The channel is closed when the actor has stopped.
+func (a *Actor[C]) States() <-chan State[C] {
+	return a.states
+}
+
+// State returns the current state of the actor's interpreter
+func (a *Actor[C]) State() State[C] {
+	return a.interp.State()
+}
+
+// Stop stops accepting events, processes the events already in the inbox, and
+// then stops the interpreter and closes the States channel. It returns nil once
+// the actor has stopped, or the context's error if it is done first; the actor
+// still stops in the background. Stop may be called more than once.
+func (a *Actor[C]) Stop(ctx context.Context) error {
+	a.stopOnce.Do(func() { close(a.stopping) })
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run processes events until the actor is stopped
+func (a *Actor[C]) run() {
+	defer close(a.done)
+
+	for {
+		select {
+		case event := <-a.events:
+			a.interp.Send(event)
+		case <-a.stopping:
+			// Blocked senders give up on stopping; wait for them so that
+			// the inbox is final before draining it
+			a.sendMu.Lock()
+			a.drain()
+			a.sendMu.Unlock()
+			a.unsubscribe()
+			a.interp.Stop()
+			a.closeStates()
+			return
+		}
+	}
+}
+
+// drain processes the events left in the inbox
+func (a *Actor[C]) drain() {
+	for {
+		select {
+		case event := <-a.events:
+			a.interp.Send(event)
+		default:
+			return
+		}
+	}
+}
+
+// publish sends a state to the States channel, dropping the oldest state if the
+// channel is full
+func (a *Actor[C]) publish(state State[C]) {
+	a.statesMu.Lock()
+	defer a.statesMu.Unlock()
+	if a.statesClosed {
+		return
+	}
+
+	for {
+		select {
+		case a.states <- state:
+			return
+		default:
+		}
+		select {
+		case <-a.states:
+		default:
+		}
+	}
+}
+
+// closeStates closes the States channel once no state can be published
+func (a *Actor[C]) closeStates() {
+	a.statesMu.Lock()
+	defer a.statesMu.Unlock()
+	a.statesClosed = true
+	close(a.states)
+}
@@ -0,0 +1,241 @@
+package statekit
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type actorContext struct {
+	Count int
+}
+
+// TestActor_ProcessesStream tests that an actor processes a stream of events in order and closes cleanly
+func TestActor_ProcessesStream(t *testing.T) {
+	machine, err := NewMachine[actorContext]("actor").
+		WithInitial("counting").
+		State("counting").
+		On("INC").Target("counting").Internal().
+		Assign(func(ctx *actorContext, e Event) { ctx.Count++ }).
+		On("HALT").Target("halted").
+		Done().
+		State("halted").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	actor, err := NewActor(machine, 4)
+	if err != nil {
+		t.Fatalf("Failed to start actor: %v", err)
+	}
+
+	// Read states concurrently until the channel is closed
+	lastState := make(chan State[actorContext], 1)
+	go func() {
+		var last State[actorContext]
+		for state := range actor.States() {
+			last = state
+		}
+		lastState <- last
+	}()
+
+	const events = 200
+	for range events {
+		if err := actor.Send(Event{Type: "INC"}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+	}
+	if err := actor.Send(Event{Type: "HALT"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := actor.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop actor: %v", err)
+	}
+
+	last := <-lastState
+	if last.Value != "halted" || last.Context.Count != events {
+		t.Errorf("Expected last state 'halted' with count %d, got %s with count %d", events, last.Value, last.Context.Count)
+	}
+	if err := actor.Send(Event{Type: "INC"}); !errors.Is(err, ErrActorStopped) {
+		t.Errorf("Expected ErrActorStopped after Stop, got %v", err)
+	}
+	if err := actor.Stop(ctx); err != nil {
+		t.Errorf("Expected a second Stop to succeed, got %v", err)
+	}
+}
+
+// TestActor_Backpressure tests that Send blocks while the inbox is full
+func TestActor_Backpressure(t *testing.T) {
+	// BLOCK waits for release to be closed
+	release := make(chan struct{})
+	machine, err := NewMachine[actorContext]("actor").
+		WithInitial("counting").
+		State("counting").
+		On("INC").Target("counting").Internal().
+		Assign(func(ctx *actorContext, e Event) { ctx.Count++ }).
+		On("BLOCK").Target("counting").Internal().
+		Assign(func(ctx *actorContext, e Event) { <-release }).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	actor, err := NewActor(machine, 1)
+	if err != nil {
+		t.Fatalf("Failed to start actor: %v", err)
+	}
+
+	// BLOCK occupies the actor; once it is taken from the inbox, one more event fits
+	if err := actor.Send(Event{Type: "BLOCK"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if err := actor.Send(Event{Type: "INC"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	sent := make(chan struct{})
+	go func() {
+		_ = actor.Send(Event{Type: "INC"})
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+		t.Fatal("Expected Send to block while the inbox is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Send to complete once the actor caught up")
+	}
+
+	if err := actor.Stop(context.Background()); err != nil {
+		t.Fatalf("Failed to stop actor: %v", err)
+	}
+	if count := actor.State().Context.Count; count != 2 {
+		t.Errorf("Expected count 2, got %d", count)
+	}
+}
+
+// TestActor_SendDuringStop tests that every event accepted by Send is
+// processed when Send races with Stop
+func TestActor_SendDuringStop(t *testing.T) {
+	machine, err := NewMachine[actorContext]("actor").
+		WithInitial("counting").
+		State("counting").
+		On("INC").Target("counting").Internal().
+		Assign(func(ctx *actorContext, e Event) { ctx.Count++ }).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	for range 100 {
+		actor, err := NewActor(machine, 1)
+		if err != nil {
+			t.Fatalf("Failed to start actor: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var accepted atomic.Int64
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					err := actor.Send(Event{Type: "INC"})
+					if errors.Is(err, ErrActorStopped) {
+						return
+					}
+					if err != nil {
+						t.Errorf("Unexpected error: %v", err)
+						return
+					}
+					accepted.Add(1)
+				}
+			}()
+		}
+
+		// Stop while the senders are filling the inbox
+		for accepted.Load() < 16 {
+			runtime.Gosched()
+		}
+		if err := actor.Stop(context.Background()); err != nil {
+			t.Fatalf("Failed to stop actor: %v", err)
+		}
+		wg.Wait()
+
+		if count := int64(actor.State().Context.Count); count != accepted.Load() {
+			t.Fatalf("Expected %d accepted events to be processed, got %d", accepted.Load(), count)
+		}
+	}
+}
+
+// TestActor_StopDeadline tests that Stop returns the context's error when the actor does not stop in time
+func TestActor_StopDeadline(t *testing.T) {
+	release := make(chan struct{})
+	machine, err := NewMachine[actorContext]("actor").
+		WithInitial("counting").
+		State("counting").
+		On("BLOCK").Target("counting").Internal().
+		Assign(func(ctx *actorContext, e Event) { <-release }).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	actor, err := NewActor(machine, 1)
+	if err != nil {
+		t.Fatalf("Failed to start actor: %v", err)
+	}
+	if err := actor.Send(Event{Type: "BLOCK"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := actor.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+	if err := actor.Stop(context.Background()); err != nil {
+		t.Errorf("Expected the actor to stop once released, got %v", err)
+	}
+}
+
+// TestActor_InvalidContext tests that NewActor returns the context validator's error
+func TestActor_InvalidContext(t *testing.T) {
+	machine, err := NewMachine[actorContext]("actor").
+		WithInitial("counting").
+		WithContext(actorContext{Count: -1}).
+		WithContextValidator(func(ctx actorContext) error {
+			if ctx.Count < 0 {
+				return errors.New("negative count")
+			}
+			return nil
+		}).
+		State("counting").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	if _, err := NewActor(machine, 1); err == nil {
+		t.Error("Expected an error for an invalid context")
+	}
+}
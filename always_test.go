@@ -0,0 +1,325 @@
+package statekit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/export"
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+type readyContext struct {
+	Ready   bool
+	Entries int
+}
+
+// TestAlways_OnStart tests that eventless transitions fire when entering the initial state
+func TestAlways_OnStart(t *testing.T) {
+	machine, err := NewMachine[readyContext]("always_start").
+		WithInitial("checking").
+		WithContext(readyContext{Ready: true}).
+		WithGuard("isReady", func(ctx readyContext, e Event) bool {
+			return ctx.Ready
+		}).
+		State("checking").
+		Always().Target("ready").Guard("isReady").
+		Always().Target("waiting").
+		Done().
+		State("ready").Done().
+		State("waiting").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	if interp.State().Value != "ready" {
+		t.Errorf("Expected state 'ready', got %s", interp.State().Value)
+	}
+}
+
+// TestAlways_FallsThroughToUnguarded tests first-match ordering of eventless transitions
+func TestAlways_FallsThroughToUnguarded(t *testing.T) {
+	machine, err := NewMachine[readyContext]("always_fallthrough").
+		WithInitial("idle").
+		WithGuard("isReady", func(ctx readyContext, e Event) bool {
+			return ctx.Ready
+		}).
+		State("idle").
+		On("CHECK").Target("checking").
+		Done().
+		State("checking").
+		Always().Target("ready").Guard("isReady").
+		Always().Target("waiting").
+		Done().
+		State("ready").Done().
+		State("waiting").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "CHECK"})
+
+	if interp.State().Value != "waiting" {
+		t.Errorf("Expected state 'waiting', got %s", interp.State().Value)
+	}
+}
+
+// TestAlways_Chained tests that eventless transitions are followed until none apply
+func TestAlways_Chained(t *testing.T) {
+	machine, err := NewMachine[readyContext]("always_chain").
+		WithInitial("a").
+		WithAction("count", func(ctx *readyContext, e Event) {
+			ctx.Entries++
+		}).
+		State("a").OnEntry("count").Always().Target("b").Done().
+		State("b").OnEntry("count").Always().Target("c").Done().
+		State("c").OnEntry("count").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	if interp.State().Value != "c" {
+		t.Errorf("Expected state 'c', got %s", interp.State().Value)
+	}
+	if interp.State().Context.Entries != 3 {
+		t.Errorf("Expected 3 entries, got %d", interp.State().Context.Entries)
+	}
+}
+
+// TestAlways_MaxIterations tests that a guarded infinite eventless loop is cut off
+func TestAlways_MaxIterations(t *testing.T) {
+	machine, err := NewMachine[readyContext]("always_loop").
+		WithInitial("ping").
+		WithAction("count", func(ctx *readyContext, e Event) {
+			ctx.Entries++
+		}).
+		WithGuard("keepGoing", func(ctx readyContext, e Event) bool {
+			return true
+		}).
+		State("ping").OnEntry("count").Always().Target("pong").Guard("keepGoing").Done().
+		State("pong").OnEntry("count").Always().Target("ping").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithMaxAlwaysIterations(5))
+	interp.Start()
+
+	// Initial entry plus 5 eventless transitions
+	if interp.State().Context.Entries != 6 {
+		t.Errorf("Expected 6 entries, got %d", interp.State().Context.Entries)
+	}
+	if interp.State().Value != "pong" {
+		t.Errorf("Expected state 'pong', got %s", interp.State().Value)
+	}
+}
+
+// TestAlways_UnguardedCycleRejected tests that an unconditional eventless loop fails validation
+func TestAlways_UnguardedCycleRejected(t *testing.T) {
+	_, err := NewMachine[readyContext]("always_cycle").
+		WithInitial("a").
+		State("a").Always().Target("b").Done().
+		State("b").Always().Target("a").Done().
+		Build()
+	if err == nil {
+		t.Fatal("Expected validation error for unguarded eventless cycle")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %T", err)
+	}
+	if validationErr.Issues[0].Code != ir.ErrCodePotentialInfiniteLoop {
+		t.Errorf("Expected POTENTIAL_INFINITE_LOOP, got %s", validationErr.Issues[0].Code)
+	}
+	if !strings.Contains(validationErr.Issues[0].Message, "a -> b -> a") {
+		t.Errorf("Expected message to describe the loop, got %q", validationErr.Issues[0].Message)
+	}
+}
+
+// TestAlways_NotTriggeredByEmptyEvent tests that sending an empty event does not take always transitions
+func TestAlways_NotTriggeredByEmptyEvent(t *testing.T) {
+	machine, err := NewMachine[readyContext]("always_empty").
+		WithInitial("idle").
+		WithGuard("isReady", func(ctx readyContext, e Event) bool {
+			return ctx.Ready
+		}).
+		State("idle").
+		Always().Target("ready").Guard("isReady").
+		Done().
+		State("ready").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{})
+	if interp.State().Value != "idle" {
+		t.Errorf("Expected state 'idle', got %s", interp.State().Value)
+	}
+}
+
+// TestAlways_InParallelRegion tests eventless transitions within a parallel region
+func TestAlways_InParallelRegion(t *testing.T) {
+	machine, err := NewMachine[readyContext]("always_parallel").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("left").
+		WithInitial("l1").
+		State("l1").Always().Target("l2").EndState().
+		State("l2").EndState().
+		EndRegion().
+		Region("right").
+		WithInitial("r1").
+		State("r1").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	if interp.State().ActiveInParallel["left"] != "l2" {
+		t.Errorf("Expected left region 'l2', got %s", interp.State().ActiveInParallel["left"])
+	}
+	if interp.State().ActiveInParallel["right"] != "r1" {
+		t.Errorf("Expected right region 'r1', got %s", interp.State().ActiveInParallel["right"])
+	}
+
+	interp.Stop()
+}
+
+// TestAlways_XStateExport tests that eventless transitions export to the "always" key
+func TestAlways_XStateExport(t *testing.T) {
+	machine, err := NewMachine[readyContext]("always_export").
+		WithInitial("checking").
+		WithGuard("isReady", func(ctx readyContext, e Event) bool {
+			return ctx.Ready
+		}).
+		State("checking").
+		Always().Target("ready").Guard("isReady").
+		Always().Target("waiting").
+		Done().
+		State("ready").Done().
+		State("waiting").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	exported, err := export.NewXStateExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	checking := exported.States["checking"]
+	if len(checking.Always) != 2 {
+		t.Fatalf("Expected 2 always transitions, got %d", len(checking.Always))
+	}
+	if checking.Always[0].Target != "ready" || checking.Always[0].Guard != "isReady" {
+		t.Errorf("Unexpected first always transition: %+v", checking.Always[0])
+	}
+	if checking.Always[1].Target != "waiting" {
+		t.Errorf("Expected second always target 'waiting', got %s", checking.Always[1].Target)
+	}
+	if len(checking.On) != 0 {
+		t.Errorf("Expected no 'on' transitions, got %v", checking.On)
+	}
+}
+
+type routeContext struct {
+	Premium   bool
+	Returning bool
+}
+
+// TestChoice_Branches tests that a choice takes the first passing branch, or the fallback
+func TestChoice_Branches(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      routeContext
+		expected StateID
+	}{
+		{"first branch", routeContext{Premium: true, Returning: true}, "priority"},
+		{"second branch", routeContext{Returning: true}, "standard"},
+		{"otherwise", routeContext{}, "onboarding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine, err := NewMachine[routeContext]("choice").
+				WithInitial("idle").
+				WithContext(tt.ctx).
+				WithGuard("isPremium", func(ctx routeContext, e Event) bool { return ctx.Premium }).
+				WithGuard("isReturning", func(ctx routeContext, e Event) bool { return ctx.Returning }).
+				State("idle").On("ROUTE").Target("route").Done().
+				State("route").
+				Choice().
+				When("isPremium").Target("priority").
+				When("isReturning").Target("standard").
+				Otherwise("onboarding").
+				Done().
+				State("priority").Done().
+				State("standard").Done().
+				State("onboarding").Done().
+				Build()
+			if err != nil {
+				t.Fatalf("Failed to build machine: %v", err)
+			}
+
+			interp := NewInterpreter(machine)
+			interp.Start()
+			interp.Send(Event{Type: "ROUTE"})
+
+			if interp.State().Value != tt.expected {
+				t.Errorf("Expected state '%s', got %s", tt.expected, interp.State().Value)
+			}
+		})
+	}
+}
+
+// TestChoice_CompilesToAlwaysTransitions tests the transitions generated by a choice
+func TestChoice_CompilesToAlwaysTransitions(t *testing.T) {
+	machine, err := NewMachine[routeContext]("choice").
+		WithInitial("route").
+		WithGuard("isPremium", func(ctx routeContext, e Event) bool { return ctx.Premium }).
+		State("route").
+		Choice().
+		When("isPremium").Target("priority").
+		Otherwise("onboarding").
+		Done().
+		State("priority").Done().
+		State("onboarding").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	transitions := machine.States["route"].Transitions
+	if len(transitions) != 2 {
+		t.Fatalf("Expected 2 transitions, got %d", len(transitions))
+	}
+	if !transitions[0].Always || transitions[0].Guard != "isPremium" || transitions[0].Target != "priority" {
+		t.Errorf("Unexpected first branch: %+v", transitions[0])
+	}
+	if !transitions[1].Always || transitions[1].Guard != "" || transitions[1].Target != "onboarding" {
+		t.Errorf("Unexpected fallback: %+v", transitions[1])
+	}
+}
@@ -0,0 +1,212 @@
+package statekit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/export"
+)
+
+type assignContext struct {
+	Name  string
+	Count int
+	Log   []string
+}
+
+// TestAssign_TransitionOrder tests that inline assigns run in declaration order
+// relative to named transition actions
+func TestAssign_TransitionOrder(t *testing.T) {
+	machine, err := NewMachine[assignContext]("assign").
+		WithInitial("idle").
+		WithAction("logNamed", func(ctx *assignContext, e Event) {
+			ctx.Log = append(ctx.Log, "named:"+ctx.Name)
+		}).
+		State("idle").
+		On("RENAME").Target("renamed").
+		Do("logNamed").
+		Assign(func(ctx *assignContext, e Event) {
+			ctx.Name, _ = PayloadOf[string](e)
+		}).
+		Do("logNamed").
+		Assign(func(ctx *assignContext, e Event) {
+			ctx.Count++
+		}).
+		Done().
+		State("renamed").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "RENAME", Payload: "alice"})
+
+	ctx := interp.State().Context
+	if got := strings.Join(ctx.Log, ","); got != "named:,named:alice" {
+		t.Errorf("Expected assign between named actions, got %s", got)
+	}
+	if ctx.Count != 1 {
+		t.Errorf("Expected count 1, got %d", ctx.Count)
+	}
+}
+
+// TestAssign_EntryExit tests inline entry and exit assigns
+func TestAssign_EntryExit(t *testing.T) {
+	machine, err := NewMachine[assignContext]("assign_entry_exit").
+		WithInitial("a").
+		WithAction("logEnterB", func(ctx *assignContext, e Event) {
+			ctx.Log = append(ctx.Log, "named:enter:b")
+		}).
+		State("a").
+		OnExitFunc(func(ctx *assignContext, e Event) {
+			ctx.Log = append(ctx.Log, "exit:a")
+		}).
+		On("NEXT").Target("b").
+		Assign(func(ctx *assignContext, e Event) {
+			ctx.Log = append(ctx.Log, "transition")
+		}).
+		Done().
+		State("b").
+		OnEntryFunc(func(ctx *assignContext, e Event) {
+			ctx.Log = append(ctx.Log, "enter:b")
+		}).
+		OnEntry("logEnterB").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "NEXT"})
+
+	expected := "exit:a,transition,enter:b,named:enter:b"
+	if got := strings.Join(interp.State().Context.Log, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+// TestAssign_GeneratedNames tests that inline assigns get unique names that pass validation
+func TestAssign_GeneratedNames(t *testing.T) {
+	noop := func(ctx *assignContext, e Event) {}
+
+	machine, err := NewMachine[assignContext]("assign_names").
+		WithInitial("a").
+		State("a").
+		OnEntryFunc(noop).
+		On("NEXT").Target("a").Assign(noop).Assign(noop).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	names := map[ActionType]bool{machine.States["a"].Entry[0]: true}
+	for _, name := range machine.States["a"].Transitions[0].Actions {
+		names[name] = true
+	}
+	if len(names) != 3 {
+		t.Errorf("Expected 3 unique action names, got %v", names)
+	}
+	for name := range names {
+		if machine.GetAction(name) == nil {
+			t.Errorf("Expected generated action %s to be registered", name)
+		}
+	}
+}
+
+// TestAssign_NestedEntryExitOrder tests that inline entry and exit actions keep
+// the hierarchical order alongside named actions, and are exported by name
+func TestAssign_NestedEntryExitOrder(t *testing.T) {
+	logAs := func(label string) func(ctx *assignContext, e Event) {
+		return func(ctx *assignContext, e Event) { ctx.Log = append(ctx.Log, label) }
+	}
+
+	machine, err := NewMachine[assignContext]("assign_nested").
+		WithInitial("idle").
+		WithAction("enterParent", logAs("named:enter:parent")).
+		WithAction("exitChild", logAs("named:exit:child")).
+		State("idle").On("GO").Target("parent").Done().
+		State("parent").
+		OnEntry("enterParent").
+		OnEntryFunc(logAs("inline:enter:parent")).
+		OnExitFunc(logAs("inline:exit:parent")).
+		WithInitial("child").
+		State("child").
+		OnEntryFunc(logAs("inline:enter:child")).
+		OnExit("exitChild").
+		OnExitFunc(logAs("inline:exit:child")).
+		End().
+		On("LEAVE").Target("idle").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "GO"})
+	interp.Send(Event{Type: "LEAVE"})
+
+	expected := strings.Join([]string{
+		"named:enter:parent", "inline:enter:parent", "inline:enter:child",
+		"named:exit:child", "inline:exit:child", "inline:exit:parent",
+	}, ",")
+	if got := strings.Join(interp.State().Context.Log, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	exported, err := export.NewXStateExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	entry := exported.States["parent"].Entry
+	if len(entry) != 2 || entry[0] != "enterParent" || !strings.HasPrefix(entry[1], "statekit.assign.") {
+		t.Errorf("Expected named and generated entry actions, got %v", entry)
+	}
+}
+
+// TestStateBuilder_OnEntryFunc tests that inline entry and exit closures run in
+// order with named entry and exit actions
+func TestStateBuilder_OnEntryFunc(t *testing.T) {
+	logAs := func(label string) func(ctx *assignContext, e Event) {
+		return func(ctx *assignContext, e Event) { ctx.Log = append(ctx.Log, label) }
+	}
+
+	machine, err := NewMachine[assignContext]("entry_func").
+		WithInitial("idle").
+		WithAction("enterNamed", logAs("named:enter")).
+		WithAction("exitNamed", logAs("named:exit")).
+		State("idle").On("GO").Target("active").Done().
+		State("active").
+		OnEntryFunc(logAs("func:enter:1")).
+		OnEntry("enterNamed").
+		OnEntryFunc(logAs("func:enter:2")).
+		OnExit("exitNamed").
+		OnExitFunc(logAs("func:exit")).
+		On("STOP").Target("idle").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "GO"})
+	interp.Send(Event{Type: "STOP"})
+
+	expected := "func:enter:1,named:enter,func:enter:2,named:exit,func:exit"
+	if got := strings.Join(interp.State().Context.Log, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	entry := machine.GetState("active").Entry
+	if len(entry) != 3 || !strings.HasPrefix(string(entry[0]), "statekit.assign.") || entry[1] != "enterNamed" {
+		t.Errorf("Expected generated names around the named entry action, got %v", entry)
+	}
+}
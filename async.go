@@ -0,0 +1,92 @@
+package statekit
+
+import (
+	"sync"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// asyncInbox holds the events sent to an asynchronous interpreter. A background
+// goroutine drains it in FIFO order; it is started when events arrive and exits
+// once the inbox is empty, so an idle interpreter holds no goroutine.
+type asyncInbox struct {
+	mu       sync.Mutex
+	events   []asyncEvent
+	draining bool
+}
+
+// asyncEvent is a queued event and, for SendSync, the channel awaiting its result
+type asyncEvent struct {
+	event  Event
+	result chan TransitionResult
+}
+
+// NewInterpreterAsync creates an interpreter whose Send enqueues the event and
+// returns immediately. A background goroutine processes queued events one at a
+// time in the order they were sent, so callers on many goroutines never block
+// on each other or on running actions. Use SendSync to wait for an event to be
+// processed and get its result.
+//
+// Actions and listeners run on the background goroutine. They may call Send,
+// but must not call SendSync, which would wait for the goroutine it runs on.
+// SendResult, Start, and Stop remain synchronous.
+func NewInterpreterAsync[C any](machine *ir.MachineConfig[C], opts ...InterpreterOption) *Interpreter[C] {
+	interp := NewInterpreter(machine, opts...)
+	interp.inbox = &asyncInbox{}
+	return interp
+}
+
+// SendSync sends an event and blocks until it has been processed, returning
+// the result as SendResult does. In an asynchronous interpreter the event is
+// processed after every event sent before it. In a synchronous interpreter
+// SendSync is the same as SendResult.
+func (i *Interpreter[C]) SendSync(event Event) TransitionResult {
+	if i.inbox == nil {
+		return i.SendResult(event)
+	}
+	result := make(chan TransitionResult, 1)
+	i.enqueue(asyncEvent{event: event, result: result})
+	return <-result
+}
+
+// enqueue adds an event to the inbox, starting the background goroutine if it
+// is not already draining
+func (i *Interpreter[C]) enqueue(ev asyncEvent) {
+	i.inbox.mu.Lock()
+	i.inbox.events = append(i.inbox.events, ev)
+	if i.inbox.draining {
+		i.inbox.mu.Unlock()
+		return
+	}
+	i.inbox.draining = true
+	i.inbox.mu.Unlock()
+
+	go i.drainInbox()
+}
+
+// drainInbox processes queued events until the inbox is empty
+func (i *Interpreter[C]) drainInbox() {
+	for {
+		i.inbox.mu.Lock()
+		if len(i.inbox.events) == 0 {
+			i.inbox.draining = false
+			i.inbox.mu.Unlock()
+			return
+		}
+		ev := i.inbox.events[0]
+		i.inbox.events = i.inbox.events[1:]
+		i.inbox.mu.Unlock()
+
+		// Wait for the step even if another goroutine, such as a timer, is
+		// running the queue and processes it
+		result := &TransitionResult{}
+		step := i.sendStep(ev.event, result)
+		done := make(chan struct{})
+		if !i.process(func() bool { defer close(done); return step() }) {
+			<-done
+		}
+		if ev.result != nil {
+			ev.result <- *result
+		}
+	}
+}
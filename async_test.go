@@ -0,0 +1,142 @@
+package statekit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type asyncContext struct {
+	Count int
+}
+
+// TestInterpreterAsync_ConcurrentSend tests that events sent from many goroutines are all processed
+func TestInterpreterAsync_ConcurrentSend(t *testing.T) {
+	machine, err := NewMachine[asyncContext]("async").
+		WithInitial("counting").
+		State("counting").
+		On("INC").Target("counting").Internal().
+		Assign(func(ctx *asyncContext, e Event) { ctx.Count++ }).
+		On("HALT").Target("halted").
+		Done().
+		State("halted").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreterAsync(machine)
+	interp.Start()
+
+	const goroutines, perGoroutine = 20, 50
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perGoroutine {
+				interp.Send(Event{Type: "INC"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// SendSync is processed after every event sent before it
+	result := interp.SendSync(Event{Type: "HALT"})
+	if !result.Handled || result.To != "halted" {
+		t.Errorf("Expected HALT to reach 'halted', got %+v", result)
+	}
+
+	if count := interp.State().Context.Count; count != goroutines*perGoroutine {
+		t.Errorf("Expected count %d, got %d", goroutines*perGoroutine, count)
+	}
+}
+
+// TestInterpreterAsync_SendFromAction tests that actions can send events without blocking
+func TestInterpreterAsync_SendFromAction(t *testing.T) {
+	var interp *Interpreter[asyncContext]
+	machine, err := NewMachine[asyncContext]("async_raise").
+		WithInitial("idle").
+		WithAction("raise", func(ctx *asyncContext, e Event) {
+			interp.Send(Event{Type: "NEXT"})
+		}).
+		State("idle").On("GO").Target("first").Done().
+		State("first").OnEntry("raise").On("NEXT").Target("second").Done().
+		State("second").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp = NewInterpreterAsync(machine)
+	interp.Start()
+
+	result := interp.SendSync(Event{Type: "GO"})
+	if result.To != "first" {
+		t.Errorf("Expected GO to reach 'first', got %s", result.To)
+	}
+
+	// The raised event was queued behind GO; a later SendSync waits for it
+	interp.SendSync(Event{Type: "PING"})
+	if interp.State().Value != "second" {
+		t.Errorf("Expected state 'second', got %s", interp.State().Value)
+	}
+}
+
+// TestInterpreterAsync_WithTimers tests SendSync while delayed transitions fire concurrently
+func TestInterpreterAsync_WithTimers(t *testing.T) {
+	machine, err := NewMachine[asyncContext]("async_timer").
+		WithInitial("counting").
+		State("counting").
+		On("INC").Target("counting").Internal().
+		Assign(func(ctx *asyncContext, e Event) { ctx.Count++ }).
+		After(time.Millisecond).Target("counting").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreterAsync(machine, WithClock(clock))
+	interp.Start()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			clock.Advance(time.Millisecond)
+		}
+	}()
+	for range 100 {
+		if result := interp.SendSync(Event{Type: "INC"}); result.Queued {
+			t.Fatal("Expected SendSync to wait for the event to be processed")
+		}
+	}
+	wg.Wait()
+
+	if count := interp.State().Context.Count; count != 100 {
+		t.Errorf("Expected count 100, got %d", count)
+	}
+}
+
+// TestSendSync_Synchronous tests that SendSync on a synchronous interpreter behaves like SendResult
+func TestSendSync_Synchronous(t *testing.T) {
+	machine, err := NewMachine[asyncContext]("sync").
+		WithInitial("a").
+		State("a").On("GO").Target("b").Done().
+		State("b").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	if result := interp.SendSync(Event{Type: "GO"}); !result.Handled || result.To != "b" {
+		t.Errorf("Expected transition to 'b', got %+v", result)
+	}
+}
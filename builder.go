@@ -1,6 +1,10 @@
 package statekit
 
 import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"time"
 
 	"github.com/felixgeelhaar/statekit/internal/ir"
@@ -8,12 +12,40 @@ import (
 
 // MachineBuilder provides a fluent API for constructing state machines
 type MachineBuilder[C any] struct {
-	id      string
-	initial StateID
-	context C
-	states  []*StateBuilder[C]
-	actions map[ActionType]Action[C]
-	guards  map[GuardType]Guard[C]
+	id       string
+	initial  StateID
+	context  C
+	states   []*StateBuilder[C]
+	actions  map[ActionType]Action[C]
+	guards   map[GuardType]Guard[C]
+	services map[ServiceType]Service[C]
+	delays   map[DelayType]DelayResolver[C]
+
+	fallibleActions map[ActionType]FallibleAction[C]
+
+	// Actions that can also raise events
+	schedulerActions map[ActionType]SchedulerAction[C]
+
+	// Guards that also inspect the active states
+	stateGuards map[GuardType]StateGuard[C]
+
+	// Guards combined from other guards, composed at build time
+	composites map[GuardType]guardComposite
+
+	// Parameterized guards, instantiated at build time for each guard call
+	guardFactories map[GuardType]GuardFactory[C]
+
+	// Checks the context before the interpreter starts
+	contextValidator func(C) error
+
+	// State entered when a fallible action vetoes a transition
+	errorTarget StateID
+
+	// Expected payload types, keyed by event
+	eventSchemas map[EventType]reflect.Type
+
+	// Counter for generated inline (assign) action names
+	assignCount int
 }
 
 // StateBuilder provides a fluent API for constructing states
@@ -24,10 +56,15 @@ type StateBuilder[C any] struct {
 	id          StateID
 	stateType   StateType
 	initial     StateID // Initial child state (for compound states)
+	choices     []ir.InitialChoice
 	children    []*StateBuilder[C]
 	entry       []ActionType
 	exit        []ActionType
 	transitions []*TransitionBuilder[C]
+	invoke      []ServiceType
+	deferred    []EventType
+	output      Output[C]
+	meta        map[string]any
 
 	// History state fields (v2.0)
 	historyType    HistoryType
@@ -48,26 +85,72 @@ type RegionBuilder[C any] struct {
 	id       StateID
 	initial  StateID
 	children []*StateBuilder[C]
+	entry    []ActionType
+	exit     []ActionType
+}
+
+// ChoiceBuilder provides a fluent API for constructing a decision: ordered
+// guarded eventless transitions with an unguarded fallback
+type ChoiceBuilder[C any] struct {
+	state *StateBuilder[C]
+}
+
+// ChoiceBranch is a guarded branch of a choice awaiting its target
+type ChoiceBranch[C any] struct {
+	choice *ChoiceBuilder[C]
+	guard  GuardType
 }
 
 // TransitionBuilder provides a fluent API for constructing transitions
 type TransitionBuilder[C any] struct {
-	state   *StateBuilder[C]
-	event   EventType
-	target  StateID
-	guard   GuardType
-	actions []ActionType
+	state    *StateBuilder[C]
+	event    EventType
+	target   StateID
+	guard    GuardType
+	actions  []ActionType
+	fallible []ActionType
+
+	// Actions that run only if their guard passes
+	conditional []ir.ConditionalAction
 
 	// Delayed transition fields (v2.0)
-	delay time.Duration
+	delay     time.Duration
+	delayName DelayType
+	repeat    bool
+
+	// Eventless, internal, and bubbling transition flags
+	always   bool
+	internal bool
+	bubble   bool
+}
+
+// StateRef is a handle to a state declared with DeclareState. Using handles as
+// transition targets turns a mistyped state name into a compile error instead
+// of a validation error at Build.
+type StateRef struct {
+	id StateID
+}
+
+// ID returns the ID of the referenced state
+func (r StateRef) ID() StateID {
+	return r.id
 }
 
 // NewMachine creates a new MachineBuilder with the given ID
 func NewMachine[C any](id string) *MachineBuilder[C] {
 	return &MachineBuilder[C]{
-		id:      id,
-		actions: make(map[ActionType]Action[C]),
-		guards:  make(map[GuardType]Guard[C]),
+		id:         id,
+		actions:    make(map[ActionType]Action[C]),
+		guards:     make(map[GuardType]Guard[C]),
+		services:   make(map[ServiceType]Service[C]),
+		delays:     make(map[DelayType]DelayResolver[C]),
+		composites: make(map[GuardType]guardComposite),
+
+		fallibleActions:  make(map[ActionType]FallibleAction[C]),
+		schedulerActions: make(map[ActionType]SchedulerAction[C]),
+		stateGuards:      make(map[GuardType]StateGuard[C]),
+		guardFactories:   make(map[GuardType]GuardFactory[C]),
+		eventSchemas:     make(map[EventType]reflect.Type),
 	}
 }
 
@@ -83,18 +166,119 @@ func (b *MachineBuilder[C]) WithContext(ctx C) *MachineBuilder[C] {
 	return b
 }
 
+// WithContextValidator sets a function that checks the context before the
+// interpreter enters the initial state. A non-nil error prevents the start;
+// it is returned by Interpreter.StartErr.
+func (b *MachineBuilder[C]) WithContextValidator(validate func(C) error) *MachineBuilder[C] {
+	b.contextValidator = validate
+	return b
+}
+
+// WithErrorTarget sets a state the interpreter enters when a fallible action
+// vetoes a transition. The active states are exited and the target is entered
+// with an event of type ErrorEvent whose payload is the veto error, so entry
+// actions of the target can inspect it. The route is queued after the vetoed
+// event, like done events.
+func (b *MachineBuilder[C]) WithErrorTarget(target StateID) *MachineBuilder[C] {
+	b.errorTarget = target
+	return b
+}
+
+// WithEventSchema registers the expected payload type of an event, e.g.
+// reflect.TypeOf(PaymentInfo{}). Payloads are only checked by interpreters
+// created with WithStrictPayloads.
+func (b *MachineBuilder[C]) WithEventSchema(event EventType, payload reflect.Type) *MachineBuilder[C] {
+	b.eventSchemas[event] = payload
+	return b
+}
+
 // WithAction registers a named action
 func (b *MachineBuilder[C]) WithAction(name ActionType, action Action[C]) *MachineBuilder[C] {
 	b.actions[name] = action
 	return b
 }
 
+// WithFallibleAction registers a named transition action that can veto its transition
+func (b *MachineBuilder[C]) WithFallibleAction(name ActionType, action FallibleAction[C]) *MachineBuilder[C] {
+	b.fallibleActions[name] = action
+	return b
+}
+
+// WithSchedulerAction registers a named action that can also raise events
+// (see SchedulerAction)
+func (b *MachineBuilder[C]) WithSchedulerAction(name ActionType, action SchedulerAction[C]) *MachineBuilder[C] {
+	b.schedulerActions[name] = action
+	return b
+}
+
 // WithGuard registers a named guard
 func (b *MachineBuilder[C]) WithGuard(name GuardType, guard Guard[C]) *MachineBuilder[C] {
 	b.guards[name] = guard
 	return b
 }
 
+// WithStateGuard registers a named guard that also inspects the active states
+// of the interpreter (see StateGuard)
+func (b *MachineBuilder[C]) WithStateGuard(name GuardType, guard StateGuard[C]) *MachineBuilder[C] {
+	b.stateGuards[name] = guard
+	return b
+}
+
+// WithGuardAnd registers a named guard that passes when all of the given guards pass.
+// The component guards may be registered in any order, including other combined
+// guards; missing components are reported by Build.
+func (b *MachineBuilder[C]) WithGuardAnd(name GuardType, guards ...GuardType) *MachineBuilder[C] {
+	b.composites[name] = guardComposite{op: guardAnd, guards: guards}
+	return b
+}
+
+// WithGuardOr registers a named guard that passes when any of the given guards passes
+func (b *MachineBuilder[C]) WithGuardOr(name GuardType, guards ...GuardType) *MachineBuilder[C] {
+	b.composites[name] = guardComposite{op: guardOr, guards: guards}
+	return b
+}
+
+// WithGuardNot registers a named guard that passes when the given guard fails
+func (b *MachineBuilder[C]) WithGuardNot(name GuardType, guard GuardType) *MachineBuilder[C] {
+	b.composites[name] = guardComposite{op: guardNot, guards: []GuardType{guard}}
+	return b
+}
+
+// WithGuardFactory registers a parameterized guard. Transitions and combined
+// guards reference it with a guard call such as "hasAtLeast(3)"; Build calls
+// the factory once per distinct call (see GuardFactory).
+func (b *MachineBuilder[C]) WithGuardFactory(name GuardType, factory GuardFactory[C]) *MachineBuilder[C] {
+	b.guardFactories[name] = factory
+	return b
+}
+
+// WithService registers a named service that states can invoke
+func (b *MachineBuilder[C]) WithService(name ServiceType, service Service[C]) *MachineBuilder[C] {
+	b.services[name] = service
+	return b
+}
+
+// WithDelay registers a named delay for AfterDelay transitions
+func (b *MachineBuilder[C]) WithDelay(name DelayType, resolver DelayResolver[C]) *MachineBuilder[C] {
+	b.delays[name] = resolver
+	return b
+}
+
+// assign registers an inline action under a generated unique name
+func (b *MachineBuilder[C]) assign(fn Action[C]) ActionType {
+	b.assignCount++
+	name := ActionType(fmt.Sprintf("statekit.assign.%d", b.assignCount))
+	b.actions[name] = fn
+	return name
+}
+
+// DeclareState returns a handle to the state with the given ID, for use with
+// TargetState. The state must still be defined with State; Build reports
+// targets that reference undefined states.
+func (b *MachineBuilder[C]) DeclareState(id StateID) StateRef {
+	return StateRef{id: id}
+}
+
 // State starts building a new state with the given ID
 func (b *MachineBuilder[C]) State(id StateID) *StateBuilder[C] {
 	sb := &StateBuilder[C]{
@@ -107,8 +291,41 @@ func (b *MachineBuilder[C]) State(id StateID) *StateBuilder[C] {
 	return sb
 }
 
+// Ring adds a top-level atomic state for each ID, where the event moves each
+// state to the next and the last state back to the first. It is shorthand for
+// numbered steps or round-robin cycles:
+//
+//	Ring([]StateID{"slot0", "slot1", "slot2"}, "NEXT")
+//
+// is equivalent to State("slot0").On("NEXT").Target("slot1").Done() and so on,
+// ending with State("slot2").On("NEXT").Target("slot0").Done().
+func (b *MachineBuilder[C]) Ring(ids []StateID, event EventType) *MachineBuilder[C] {
+	for n, id := range ids {
+		b.State(id).On(event).Target(ids[(n+1)%len(ids)])
+	}
+	return b
+}
+
 // Build constructs the final MachineConfig from the builder
 func (b *MachineBuilder[C]) Build() (*ir.MachineConfig[C], error) {
+	return b.build(ir.Validate[C])
+}
+
+// BuildStrict is like Build, but also rejects states that can never be
+// reached from the initial state
+func (b *MachineBuilder[C]) BuildStrict() (*ir.MachineConfig[C], error) {
+	return b.build(ir.ValidateStrict[C])
+}
+
+// BuildLint is like Build, but also rejects transitions that have no effect,
+// such as an unguarded self-transition without actions, and transitions
+// shadowed by an earlier unguarded transition for the same event
+func (b *MachineBuilder[C]) BuildLint() (*ir.MachineConfig[C], error) {
+	return b.build(ir.ValidateLint[C])
+}
+
+// build constructs the MachineConfig and checks it with the given validator
+func (b *MachineBuilder[C]) build(validate func(*ir.MachineConfig[C]) *ir.ValidationError) (*ir.MachineConfig[C], error) {
 	machine := ir.NewMachineConfig(b.id, b.initial, b.context)
 
 	// Copy actions and guards (convert from statekit types to ir types)
@@ -118,22 +335,50 @@ func (b *MachineBuilder[C]) Build() (*ir.MachineConfig[C], error) {
 	for name, guard := range b.guards {
 		machine.Guards[name] = ir.Guard[C](guard)
 	}
+	for name, guard := range b.stateGuards {
+		machine.StateGuards[name] = guard
+	}
+	for name, service := range b.services {
+		machine.Services[name] = ir.Service[C](service)
+	}
+	for name, resolver := range b.delays {
+		machine.Delays[name] = resolver
+	}
+	for name, action := range b.fallibleActions {
+		machine.FallibleActions[name] = action
+	}
+	for name, action := range b.schedulerActions {
+		machine.SchedulerActions[name] = action
+	}
+	for event, payload := range b.eventSchemas {
+		machine.EventSchemas[event] = payload
+	}
+	machine.ContextValidator = b.contextValidator
+	machine.ErrorTarget = b.errorTarget
 
 	// Build states recursively
+	errs := &ir.ValidationError{}
 	for _, sb := range b.states {
-		buildStateRecursive(sb, "", machine)
+		buildStateRecursive(sb, "", machine, errs)
 	}
 
+	// Instantiate guard calls, then combine guards from their registered components
+	instantiateGuards(machine, b.guardFactories, guardReferences(machine, b.composites), errs)
+	composeGuards(machine, b.composites, errs)
+
 	// Validate the machine configuration
-	if err := ir.Validate(machine); err != nil {
-		return nil, err
+	if err := validate(machine); err != nil {
+		errs.Issues = append(errs.Issues, err.Issues...)
+	}
+	if errs.HasIssues() {
+		return nil, errs
 	}
 
 	return machine, nil
 }
 
 // buildStateRecursive adds a state and its children to the machine config
-func buildStateRecursive[C any](sb *StateBuilder[C], parentID ir.StateID, machine *ir.MachineConfig[C]) {
+func buildStateRecursive[C any](sb *StateBuilder[C], parentID ir.StateID, machine *ir.MachineConfig[C], errs *ir.ValidationError) {
 	// Determine state type
 	stateType := sb.stateType
 	if len(sb.children) > 0 && sb.stateType == StateTypeAtomic {
@@ -146,6 +391,7 @@ func buildStateRecursive[C any](sb *StateBuilder[C], parentID ir.StateID, machin
 	// Set initial for compound states
 	if len(sb.children) > 0 {
 		state.Initial = sb.initial
+		state.InitialChoices = slices.Clone(sb.choices)
 		for _, child := range sb.children {
 			state.Children = append(state.Children, child.id)
 		}
@@ -160,22 +406,71 @@ func buildStateRecursive[C any](sb *StateBuilder[C], parentID ir.StateID, machin
 	// Convert entry/exit actions
 	state.Entry = append(state.Entry, sb.entry...)
 	state.Exit = append(state.Exit, sb.exit...)
+	state.Invoke = append(state.Invoke, sb.invoke...)
+	state.Deferred = append(state.Deferred, sb.deferred...)
+	state.Meta = maps.Clone(sb.meta)
+	if sb.output != nil {
+		machine.Outputs[sb.id] = sb.output
+	}
 
 	// Build transitions
 	for _, tb := range sb.transitions {
 		trans := ir.NewTransitionConfig(tb.event, tb.target)
 		trans.Guard = tb.guard
 		trans.Actions = append(trans.Actions, tb.actions...)
+		trans.FallibleActions = append(trans.FallibleActions, tb.fallible...)
+		trans.ConditionalActions = slices.Clone(tb.conditional)
 		trans.Delay = tb.delay // Delayed transitions (v2.0)
+		trans.DelayName = tb.delayName
+		trans.Always = tb.always
+		trans.Internal = tb.internal
+		trans.Bubble = tb.bubble
+		trans.Repeat = tb.repeat
 		state.Transitions = append(state.Transitions, trans)
 	}
 
-	machine.States[sb.id] = state
+	if !registerState(machine, state, errs) {
+		return
+	}
 
 	// Recursively build children
 	for _, child := range sb.children {
-		buildStateRecursive(child, sb.id, machine)
+		buildStateRecursive(child, sb.id, machine, errs)
+	}
+}
+
+// registerState adds a state to the machine's flat state map. State IDs must be
+// unique across the whole machine, so a state whose ID is already registered is
+// reported as a duplicate and not added.
+func registerState[C any](machine *ir.MachineConfig[C], state *ir.StateConfig, errs *ir.ValidationError) bool {
+	existing, ok := machine.States[state.ID]
+	if !ok {
+		machine.States[state.ID] = state
+		machine.Order = append(machine.Order, state.ID)
+		return true
+	}
+
+	path := []string{"states"}
+	if state.Parent != "" {
+		for _, id := range machine.GetPath(state.Parent) {
+			path = append(path, string(id))
+		}
+	}
+	path = append(path, string(state.ID))
+
+	errs.AddIssue(ir.ErrCodeDuplicateState,
+		fmt.Sprintf("state '%s' under %s is already defined under %s",
+			state.ID, describeParent(state.Parent), describeParent(existing.Parent)),
+		path...)
+	return false
+}
+
+// describeParent names a parent state for error messages
+func describeParent(parentID ir.StateID) string {
+	if parentID == "" {
+		return "the root"
 	}
+	return fmt.Sprintf("'%s'", parentID)
 }
 
 // --- StateBuilder methods ---
@@ -186,6 +481,14 @@ func (b *StateBuilder[C]) Final() *StateBuilder[C] {
 	return b
 }
 
+// Output sets the function computing the machine's result when this final
+// state is entered. Only top-level final states may have an output; the
+// result is available from Interpreter.Output.
+func (b *StateBuilder[C]) Output(output Output[C]) *StateBuilder[C] {
+	b.output = output
+	return b
+}
+
 // OnEntry adds an entry action to the state
 func (b *StateBuilder[C]) OnEntry(action ActionType) *StateBuilder[C] {
 	b.entry = append(b.entry, action)
@@ -198,12 +501,76 @@ func (b *StateBuilder[C]) OnExit(action ActionType) *StateBuilder[C] {
 	return b
 }
 
+// OnEntryFunc adds an inline entry action, such as a small context update or
+// logging, without registering it by name. It is registered under a generated
+// name and runs in order with the other entry actions.
+func (b *StateBuilder[C]) OnEntryFunc(fn func(ctx *C, e Event)) *StateBuilder[C] {
+	return b.OnEntry(b.machine.assign(fn))
+}
+
+// OnExitFunc adds an inline exit action without registering it by name
+func (b *StateBuilder[C]) OnExitFunc(fn func(ctx *C, e Event)) *StateBuilder[C] {
+	return b.OnExit(b.machine.assign(fn))
+}
+
+// OnEntryAssign adds an inline entry action like OnEntryFunc.
+//
+// Deprecated: Use OnEntryFunc.
+func (b *StateBuilder[C]) OnEntryAssign(fn func(ctx *C, e Event)) *StateBuilder[C] {
+	return b.OnEntryFunc(fn)
+}
+
+// OnExitAssign adds an inline exit action like OnExitFunc.
+//
+// Deprecated: Use OnExitFunc.
+func (b *StateBuilder[C]) OnExitAssign(fn func(ctx *C, e Event)) *StateBuilder[C] {
+	return b.OnExitFunc(fn)
+}
+
+// Invoke starts the named service when the state is entered and cancels it
+// when the state is exited. The service result is delivered as a
+// "done.invoke.<name>" event, and a failure as "error.invoke.<name>".
+func (b *StateBuilder[C]) Invoke(service ServiceType) *StateBuilder[C] {
+	b.invoke = append(b.invoke, service)
+	return b
+}
+
+// Defer holds the given events while the state (or one of its descendants) is
+// active and no transition handles them. Deferred events are re-delivered, in
+// the order they arrived, after the next transition, so a state that cannot
+// handle an event yet can leave it for a later state.
+func (b *StateBuilder[C]) Defer(events ...EventType) *StateBuilder[C] {
+	b.deferred = append(b.deferred, events...)
+	return b
+}
+
+// Meta attaches a metadata value to the state, such as a description or a UI
+// hint. Metadata is exported to XState and available at runtime through
+// Interpreter.Meta.
+func (b *StateBuilder[C]) Meta(key string, value any) *StateBuilder[C] {
+	if b.meta == nil {
+		b.meta = make(map[string]any)
+	}
+	b.meta[key] = value
+	return b
+}
+
 // WithInitial sets the initial child state for a compound state
 func (b *StateBuilder[C]) WithInitial(initial StateID) *StateBuilder[C] {
 	b.initial = initial
 	return b
 }
 
+// WithInitialGuarded adds a guarded initial child. When the compound state is
+// entered, guarded initial children are tried in the order they were added and
+// the first whose guard passes is entered; if none passes, the child set with
+// WithInitial is entered. Guards are evaluated before any entry action of the
+// compound state runs.
+func (b *StateBuilder[C]) WithInitialGuarded(guard GuardType, child StateID) *StateBuilder[C] {
+	b.choices = append(b.choices, ir.InitialChoice{Guard: guard, Target: child})
+	return b
+}
+
 // State starts building a nested child state
 func (b *StateBuilder[C]) State(id StateID) *StateBuilder[C] {
 	child := &StateBuilder[C]{
@@ -226,6 +593,18 @@ func (b *StateBuilder[C]) On(event EventType) *TransitionBuilder[C] {
 	return tb
 }
 
+// OnAny starts building a wildcard transition triggered by any event that
+// no specific transition on this state handles. Equivalent to On(WildcardEvent).
+func (b *StateBuilder[C]) OnAny() *TransitionBuilder[C] {
+	return b.On(WildcardEvent)
+}
+
+// OnDone starts building a transition taken when this compound or parallel
+// state completes. Equivalent to On(DoneStateEvent(id)).
+func (b *StateBuilder[C]) OnDone() *TransitionBuilder[C] {
+	return b.On(DoneStateEvent(b.id))
+}
+
 // Done completes the state definition and returns to the parent builder
 // For nested states, returns to the parent StateBuilder
 // For root states, returns to the MachineBuilder
@@ -277,7 +656,11 @@ func (b *StateBuilder[C]) Region(id StateID) *RegionBuilder[C] {
 }
 
 // After starts building a delayed transition that triggers automatically
-// after the specified duration (v2.0)
+// after the specified duration (v2.0). The timer starts when the state is
+// entered and is canceled when it exits, so re-entering the state, for example
+// with an external self-transition, restarts it. If the transition's guard
+// fails when the timer fires, the transition is dropped unless it is marked
+// with Repeat.
 func (b *StateBuilder[C]) After(d time.Duration) *TransitionBuilder[C] {
 	tb := &TransitionBuilder[C]{
 		state: b,
@@ -287,6 +670,60 @@ func (b *StateBuilder[C]) After(d time.Duration) *TransitionBuilder[C] {
 	return tb
 }
 
+// AfterDelay starts building a delayed transition whose duration is computed by
+// the named delay resolver (see WithDelay) each time the state is entered
+func (b *StateBuilder[C]) AfterDelay(name DelayType) *TransitionBuilder[C] {
+	tb := &TransitionBuilder[C]{
+		state:     b,
+		delayName: name,
+	}
+	b.transitions = append(b.transitions, tb)
+	return tb
+}
+
+// Always starts building an eventless transition that is taken as soon as
+// the state is active and its guard (if any) passes
+func (b *StateBuilder[C]) Always() *TransitionBuilder[C] {
+	tb := &TransitionBuilder[C]{
+		state:  b,
+		always: true,
+	}
+	b.transitions = append(b.transitions, tb)
+	return tb
+}
+
+// Choice starts building a decision on this state. Each When branch becomes
+// a guarded eventless transition, tried in declaration order, and Otherwise
+// adds the unguarded fallback:
+//
+//	State("route").
+//		Choice().
+//		When("isPremium").Target("priority").
+//		When("isReturning").Target("standard").
+//		Otherwise("onboarding")
+func (b *StateBuilder[C]) Choice() *ChoiceBuilder[C] {
+	return &ChoiceBuilder[C]{state: b}
+}
+
+// --- ChoiceBuilder methods ---
+
+// When starts a branch taken if the guard passes and no earlier branch was taken
+func (b *ChoiceBuilder[C]) When(guard GuardType) *ChoiceBranch[C] {
+	return &ChoiceBranch[C]{choice: b, guard: guard}
+}
+
+// Otherwise adds the branch taken when every guard fails, and returns to the state
+func (b *ChoiceBuilder[C]) Otherwise(target StateID) *StateBuilder[C] {
+	b.state.Always().Target(target)
+	return b.state
+}
+
+// Target sets the branch target and returns to the choice
+func (b *ChoiceBranch[C]) Target(target StateID) *ChoiceBuilder[C] {
+	b.choice.state.Always().Target(target).Guard(b.guard)
+	return b.choice
+}
+
 // --- HistoryBuilder methods (v2.0) ---
 
 // Shallow sets the history type to shallow (remembers immediate child)
@@ -343,6 +780,21 @@ func (b *RegionBuilder[C]) State(id StateID) *StateBuilder[C] {
 	return child
 }
 
+// OnEntry adds an entry action to the region. It runs when the parallel state
+// is entered, after the parallel state's entry actions and before those of the
+// region's initial state.
+func (b *RegionBuilder[C]) OnEntry(action ActionType) *RegionBuilder[C] {
+	b.entry = append(b.entry, action)
+	return b
+}
+
+// OnExit adds an exit action to the region. It runs when the parallel state
+// is exited, after the exit actions of the region's active states.
+func (b *RegionBuilder[C]) OnExit(action ActionType) *RegionBuilder[C] {
+	b.exit = append(b.exit, action)
+	return b
+}
+
 // EndRegion completes the region and returns to the parent parallel state
 func (b *RegionBuilder[C]) EndRegion() *StateBuilder[C] {
 	// Create a StateBuilder for the region (as a compound state)
@@ -353,6 +805,8 @@ func (b *RegionBuilder[C]) EndRegion() *StateBuilder[C] {
 		stateType: StateTypeCompound,
 		initial:   b.initial,
 		children:  b.children,
+		entry:     b.entry,
+		exit:      b.exit,
 	}
 
 	// Fix the parent references for all children
@@ -372,28 +826,121 @@ func (b *TransitionBuilder[C]) Target(target StateID) *TransitionBuilder[C] {
 	return b
 }
 
+// TargetState sets the target state of the transition from a declared handle
+func (b *TransitionBuilder[C]) TargetState(ref StateRef) *TransitionBuilder[C] {
+	return b.Target(ref.id)
+}
+
 // Guard sets the guard condition for the transition
 func (b *TransitionBuilder[C]) Guard(guard GuardType) *TransitionBuilder[C] {
 	b.guard = guard
 	return b
 }
 
+// Guards sets a guard that passes only when all of the given guards pass.
+// The combined guard is registered as "g1 && g2 && ...".
+func (b *TransitionBuilder[C]) Guards(guards ...GuardType) *TransitionBuilder[C] {
+	if len(guards) == 1 {
+		return b.Guard(guards[0])
+	}
+	if len(guards) > 1 {
+		name := combinedGuardName(guardAnd, guards)
+		b.state.machine.composites[name] = guardComposite{op: guardAnd, guards: guards}
+		b.guard = name
+	}
+	return b
+}
+
 // Do adds an action to be executed during the transition
 func (b *TransitionBuilder[C]) Do(action ActionType) *TransitionBuilder[C] {
 	b.actions = append(b.actions, action)
 	return b
 }
 
+// DoWhen adds a transition action that runs only if the guard passes. Unlike
+// Guard, it does not stop the transition: when the guard fails, the action is
+// skipped and the transition completes. Conditional actions run after the
+// actions added with Do, in the order they were added.
+func (b *TransitionBuilder[C]) DoWhen(guard GuardType, action ActionType) *TransitionBuilder[C] {
+	b.conditional = append(b.conditional, ir.ConditionalAction{Guard: guard, Action: action})
+	return b
+}
+
+// DoFallible adds a fallible action that runs before the transition exits any
+// state and vetoes the transition if it returns an error (see FallibleAction)
+func (b *TransitionBuilder[C]) DoFallible(action ActionType) *TransitionBuilder[C] {
+	b.fallible = append(b.fallible, action)
+	return b
+}
+
+// Assign adds an inline transition action, typically a small context update,
+// without registering it by name. Inline and named actions run in the order
+// they were added.
+func (b *TransitionBuilder[C]) Assign(fn func(ctx *C, e Event)) *TransitionBuilder[C] {
+	return b.Do(b.state.machine.assign(fn))
+}
+
+// Internal marks the transition as internal. An internal transition that
+// targets its own source state runs its actions without exiting or
+// re-entering the state, so entry and exit actions do not fire.
+func (b *TransitionBuilder[C]) Internal() *TransitionBuilder[C] {
+	b.internal = true
+	return b
+}
+
+// Bubble lets ancestors handle the event first. Normally the transition of the
+// deepest state wins; a bubbling transition is only taken if no ancestor of its
+// state has a transition for the same event whose guard passes.
+func (b *TransitionBuilder[C]) Bubble() *TransitionBuilder[C] {
+	b.bubble = true
+	return b
+}
+
+// Repeat re-arms a delayed transition that is not taken when its timer fires,
+// because its guard fails or a fallible action vetoes it, for another delay
+// (named delays are resolved again). The transition is retried on every
+// interval until it is taken or its state is exited. Only delayed transitions
+// can repeat; Build reports an INVALID_REPEAT issue otherwise, and an
+// INVALID_REPEAT_DELAY issue for After with a delay of zero or less. A named
+// delay that resolves to zero or less when the transition is re-armed ends the
+// repetition.
+//
+//	After(time.Second).Target("ready").Guard("isConnected").Repeat()
+func (b *TransitionBuilder[C]) Repeat() *TransitionBuilder[C] {
+	b.repeat = true
+	return b
+}
+
 // On starts a new transition on the same state (chainable)
 func (b *TransitionBuilder[C]) On(event EventType) *TransitionBuilder[C] {
 	return b.state.On(event)
 }
 
+// OnAny starts a new wildcard transition on the same state (chainable)
+func (b *TransitionBuilder[C]) OnAny() *TransitionBuilder[C] {
+	return b.state.OnAny()
+}
+
+// OnDone starts a new completion transition on the same state (chainable)
+func (b *TransitionBuilder[C]) OnDone() *TransitionBuilder[C] {
+	return b.state.OnDone()
+}
+
 // After starts a new delayed transition on the same state (chainable) (v2.0)
 func (b *TransitionBuilder[C]) After(d time.Duration) *TransitionBuilder[C] {
 	return b.state.After(d)
 }
 
+// AfterDelay starts a new named delayed transition on the same state (chainable)
+func (b *TransitionBuilder[C]) AfterDelay(name DelayType) *TransitionBuilder[C] {
+	return b.state.AfterDelay(name)
+}
+
+// Always starts a new eventless transition on the same state (chainable)
+func (b *TransitionBuilder[C]) Always() *TransitionBuilder[C] {
+	return b.state.Always()
+}
+
 // Done completes the state definition and returns to the machine builder
 func (b *TransitionBuilder[C]) Done() *MachineBuilder[C] {
 	return b.state.Done()
@@ -1,6 +1,8 @@
 package statekit
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/felixgeelhaar/statekit/internal/ir"
@@ -209,3 +211,212 @@ func TestMachineBuilder_MultipleTransitions(t *testing.T) {
 		t.Errorf("expected 2 transitions on idle, got %d", len(idleState.Transitions))
 	}
 }
+
+func TestMachineBuilder_BuildStrict(t *testing.T) {
+	builder := NewMachine[testContext]("test").
+		WithInitial("idle").
+		State("idle").On("START").Target("running").Done().
+		State("running").Done().
+		State("orphan").Done()
+
+	// Build accepts unreachable states
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := builder.BuildStrict()
+	if err == nil {
+		t.Fatal("expected error for unreachable state")
+	}
+	verr, ok := err.(*ir.ValidationError)
+	if !ok || len(verr.Issues) != 1 || verr.Issues[0].Code != ir.ErrCodeUnreachableState {
+		t.Errorf("expected single UNREACHABLE_STATE issue, got: %v", err)
+	}
+}
+
+func TestMachineBuilder_BuildLint(t *testing.T) {
+	builder := NewMachine[testContext]("test").
+		WithInitial("idle").
+		WithGuard("isReady", func(ctx testContext, e Event) bool { return true }).
+		State("idle").
+		On("X").Target("idle").
+		On("Y").Target("idle").Guard("isReady").
+		On("START").Target("running").
+		Done().
+		State("running").Done()
+
+	// Build accepts useless transitions
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := builder.BuildLint()
+	if err == nil {
+		t.Fatal("expected error for useless transition")
+	}
+	verr, ok := err.(*ir.ValidationError)
+	if !ok || len(verr.Issues) != 1 || verr.Issues[0].Code != ir.ErrCodeUselessTransition {
+		t.Fatalf("expected single USELESS_TRANSITION issue, got: %v", err)
+	}
+	if got := strings.Join(verr.Issues[0].Path, "."); got != "states.idle.transitions.0" {
+		t.Errorf("expected path 'states.idle.transitions.0', got %q", got)
+	}
+}
+
+func TestMachineBuilder_DeclareState(t *testing.T) {
+	b := NewMachine[testContext]("test")
+	idle := b.DeclareState("idle")
+	working := b.DeclareState("working")
+
+	machine, err := b.
+		WithInitial(idle.ID()).
+		State(idle.ID()).On("GO").TargetState(working).Done().
+		State(working.ID()).On("STOP").TargetState(idle).Done().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target := machine.States["idle"].Transitions[0].Target; target != "working" {
+		t.Errorf("expected target 'working', got %s", target)
+	}
+
+	// A mistyped string target is only caught at Build
+	_, err = NewMachine[testContext]("test").
+		WithInitial("idle").
+		State("idle").On("GO").Target("wroking").Done().
+		State("working").Done().
+		Build()
+	verr, ok := err.(*ir.ValidationError)
+	if !ok || len(verr.Issues) != 1 || verr.Issues[0].Code != ir.ErrCodeInvalidTarget {
+		t.Errorf("expected single INVALID_TARGET issue, got: %v", err)
+	}
+}
+
+func TestMachineBuilder_DeclareStateUndefined(t *testing.T) {
+	b := NewMachine[testContext]("test")
+	missing := b.DeclareState("missing")
+
+	_, err := b.
+		WithInitial("idle").
+		State("idle").On("GO").TargetState(missing).Done().
+		Build()
+	if err == nil {
+		t.Fatal("expected error for declared but undefined state")
+	}
+}
+
+func TestMachineBuilder_Ring(t *testing.T) {
+	ids := []StateID{"slot0", "slot1", "slot2", "slot3", "slot4"}
+	machine, err := NewMachine[testContext]("ring").
+		WithInitial("slot0").
+		Ring(ids, "NEXT").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(machine.States) != len(ids) {
+		t.Fatalf("expected %d states, got %d", len(ids), len(machine.States))
+	}
+	for n, id := range ids {
+		transitions := machine.States[id].Transitions
+		want := ids[(n+1)%len(ids)]
+		if len(transitions) != 1 || transitions[0].Event != "NEXT" || transitions[0].Target != want {
+			t.Errorf("expected %s to go to %s on NEXT, got %+v", id, want, transitions)
+		}
+	}
+
+	// Sending NEXT once per state wraps around to the first
+	interp := NewInterpreter(machine)
+	interp.Start()
+	for range ids {
+		interp.Send(Event{Type: "NEXT"})
+	}
+	if interp.State().Value != "slot0" {
+		t.Errorf("expected wraparound to slot0, got %s", interp.State().Value)
+	}
+	interp.Send(Event{Type: "NEXT"})
+	if interp.State().Value != "slot1" {
+		t.Errorf("expected slot1, got %s", interp.State().Value)
+	}
+}
+
+func TestMachineBuilder_Meta(t *testing.T) {
+	machine, err := NewMachine[testContext]("meta").
+		WithInitial("idle").
+		State("idle").
+		Meta("description", "Waiting for input").
+		Meta("color", "grey").
+		Meta("priority", 2).
+		On("START").Target("running").
+		Done().
+		State("running").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	idle := machine.States["idle"]
+	if idle.Meta["description"] != "Waiting for input" || idle.Meta["color"] != "grey" || idle.Meta["priority"] != 2 {
+		t.Errorf("unexpected idle metadata: %v", idle.Meta)
+	}
+	if machine.States["running"].Meta != nil {
+		t.Errorf("expected no metadata on running, got %v", machine.States["running"].Meta)
+	}
+
+	// Runtime lookup returns a copy
+	interp := NewInterpreter(machine)
+	meta := interp.Meta("idle")
+	if meta["color"] != "grey" {
+		t.Errorf("expected color grey, got %v", meta["color"])
+	}
+	meta["color"] = "red"
+	if interp.Meta("idle")["color"] != "grey" {
+		t.Error("expected Meta to return a copy")
+	}
+	if interp.Meta("running") != nil || interp.Meta("missing") != nil {
+		t.Error("expected nil metadata for states without metadata")
+	}
+}
+
+func TestMachineBuilder_ReferencedNames(t *testing.T) {
+	noop := func(ctx *testContext, e Event) {}
+	pass := func(ctx testContext, e Event) bool { return true }
+
+	machine, err := NewMachine[testContext]("referenced").
+		WithInitial("idle").
+		WithAction("log", noop).
+		WithAction("notify", noop).
+		WithAction("charge", noop).
+		WithAction("unused", noop).
+		WithFallibleAction("reserve", func(ctx *testContext, e Event) error { return nil }).
+		WithGuard("isReady", pass).
+		WithGuard("isPaid", pass).
+		WithGuard("isVIP", pass).
+		WithGuard("unusedGuard", pass).
+		State("idle").
+		OnEntry("log").
+		On("START").Target("running").Guard("isReady").DoFallible("reserve").Do("charge").
+		Done().
+		State("running").
+		WithInitial("fast").
+		WithInitialGuarded("isVIP", "fast").
+		OnExit("log").
+		On("STOP").Target("idle").DoWhen("isPaid", "notify").End().
+		On("CHECK").Target("idle").Guard(In("fast")).End().
+		State("fast").End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	actions := machine.ReferencedActions()
+	if expected := []ActionType{"charge", "log", "notify", "reserve"}; !slices.Equal(actions, expected) {
+		t.Errorf("expected actions %v, got %v", expected, actions)
+	}
+	guards := machine.ReferencedGuards()
+	if expected := []GuardType{"isPaid", "isReady", "isVIP"}; !slices.Equal(guards, expected) {
+		t.Errorf("expected guards %v, got %v", expected, guards)
+	}
+}
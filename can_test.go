@@ -0,0 +1,150 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+)
+
+type cartContext struct {
+	Items int
+}
+
+// TestCan_GuardsAndBubbling tests Can with guarded and ancestor transitions
+func TestCan_GuardsAndBubbling(t *testing.T) {
+	machine, err := NewMachine[cartContext]("can").
+		WithInitial("shopping").
+		WithGuard("hasItems", func(ctx cartContext, e Event) bool {
+			return ctx.Items > 0
+		}).
+		State("shopping").
+		WithInitial("browsing").
+		On("LOGOUT").Target("done").End().
+		State("browsing").
+		On("CHECKOUT").Target("checkout").Guard("hasItems").
+		On("VIEW").Target("browsing").
+		End().
+		End().
+		Done().
+		State("checkout").On("PAY").Target("done").Done().
+		State("done").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+
+	if interp.Can("VIEW") {
+		t.Error("Expected Can to be false before Start")
+	}
+
+	interp.Start()
+
+	if !interp.Can("VIEW") {
+		t.Error("Expected VIEW to be accepted")
+	}
+	if !interp.Can("LOGOUT") {
+		t.Error("Expected LOGOUT to be accepted by ancestor")
+	}
+	if interp.Can("CHECKOUT") {
+		t.Error("Expected CHECKOUT to be rejected while guard fails")
+	}
+	if interp.Can("PAY") {
+		t.Error("Expected PAY to be rejected outside checkout")
+	}
+
+	interp.UpdateContext(func(ctx *cartContext) {
+		ctx.Items = 1
+	})
+	if !interp.Can("CHECKOUT") {
+		t.Error("Expected CHECKOUT to be accepted once guard passes")
+	}
+
+	// Can does not change state
+	if interp.State().Value != "browsing" {
+		t.Errorf("Expected state 'browsing', got %s", interp.State().Value)
+	}
+}
+
+// TestCan_ParallelRegions tests Can and NextEvents across parallel regions
+func TestCan_ParallelRegions(t *testing.T) {
+	machine, err := NewMachine[cartContext]("can_parallel").
+		WithInitial("checkout").
+		State("checkout").Parallel().
+		Region("payment").
+		WithInitial("unpaid").
+		State("unpaid").On("PAY").Target("paid").EndState().
+		State("paid").EndState().
+		EndRegion().
+		Region("shipping").
+		WithInitial("unshipped").
+		State("unshipped").On("SHIP").Target("shipped").EndState().
+		State("shipped").EndState().
+		EndRegion().
+		On("CANCEL").Target("shopping").
+		Done().
+		State("shopping").On("VIEW").Target("shopping").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	for _, event := range []EventType{"PAY", "SHIP", "CANCEL"} {
+		if !interp.Can(event) {
+			t.Errorf("Expected %s to be accepted in checkout", event)
+		}
+	}
+	if interp.Can("VIEW") {
+		t.Error("Expected VIEW to be rejected in checkout")
+	}
+
+	interp.Send(Event{Type: "PAY"})
+	expected := []EventType{"CANCEL", "SHIP"}
+	if got := interp.NextEvents(); !slices.Equal(got, expected) {
+		t.Errorf("Expected next events %v, got %v", expected, got)
+	}
+}
+
+// TestNextEvents tests that NextEvents is sorted and excludes failing guards
+func TestNextEvents(t *testing.T) {
+	machine, err := NewMachine[cartContext]("next_events").
+		WithInitial("browsing").
+		WithGuard("hasItems", func(ctx cartContext, e Event) bool {
+			return ctx.Items > 0
+		}).
+		State("browsing").
+		On("VIEW").Target("browsing").
+		On("CHECKOUT").Target("checkout").Guard("hasItems").
+		On("LOGOUT").Target("done").
+		Done().
+		State("checkout").Done().
+		State("done").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	expected := []EventType{"LOGOUT", "VIEW"}
+	if got := interp.NextEvents(); !slices.Equal(got, expected) {
+		t.Errorf("Expected next events %v, got %v", expected, got)
+	}
+
+	interp.UpdateContext(func(ctx *cartContext) {
+		ctx.Items = 2
+	})
+	expected = []EventType{"CHECKOUT", "LOGOUT", "VIEW"}
+	if got := interp.NextEvents(); !slices.Equal(got, expected) {
+		t.Errorf("Expected next events %v, got %v", expected, got)
+	}
+
+	interp.Send(Event{Type: "LOGOUT"})
+	if got := interp.NextEvents(); len(got) != 0 {
+		t.Errorf("Expected no next events in final state, got %v", got)
+	}
+}
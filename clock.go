@@ -0,0 +1,17 @@
+package statekit
+
+import "github.com/felixgeelhaar/statekit/internal/clock"
+
+// Clock schedules the timers used by delayed transitions.
+// The default clock uses the time package; tests can inject a fake clock
+// (see the statekittest package) with WithClock.
+type Clock = clock.Clock
+
+// TimeSource is an optional interface of a Clock that reports the current
+// time. The interpreter reads the time from a clock that implements it to
+// report the time left on pending timers and to timestamp the transition log,
+// and uses the time package otherwise.
+type TimeSource = clock.TimeSource
+
+// Timer is a pending delayed transition scheduled by a Clock
+type Timer = clock.Timer
@@ -0,0 +1,79 @@
+package statekit
+
+import "reflect"
+
+// DeepCopy returns a deep copy of v using reflection. Pointers, slices, maps,
+// arrays, and interface values reachable through exported struct fields are
+// duplicated. Unexported struct fields are copied shallowly, and channels and
+// functions are shared. Cyclic data structures are not supported.
+//
+// DeepCopy can be used as a context cloner:
+//
+//	interp := statekit.NewInterpreter(machine, statekit.WithContextCloner(statekit.DeepCopy[MyContext]))
+func DeepCopy[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	deepCopyValue(dst, src)
+	return dst.Interface().(T)
+}
+
+// deepCopyValue copies src into the settable value dst
+func deepCopyValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		copied := reflect.New(src.Elem().Type())
+		deepCopyValue(copied.Elem(), src.Elem())
+		dst.Set(copied)
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		copied := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for idx := 0; idx < src.Len(); idx++ {
+			deepCopyValue(copied.Index(idx), src.Index(idx))
+		}
+		dst.Set(copied)
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		copied := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			deepCopyValue(value, iter.Value())
+			copied.SetMapIndex(iter.Key(), value)
+		}
+		dst.Set(copied)
+
+	case reflect.Array:
+		for idx := 0; idx < src.Len(); idx++ {
+			deepCopyValue(dst.Index(idx), src.Index(idx))
+		}
+
+	case reflect.Struct:
+		// Copy everything shallowly, then deep copy the fields we can set
+		dst.Set(src)
+		for idx := 0; idx < src.NumField(); idx++ {
+			if dst.Field(idx).CanSet() {
+				deepCopyValue(dst.Field(idx), src.Field(idx))
+			}
+		}
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		copied := reflect.New(src.Elem().Type()).Elem()
+		deepCopyValue(copied, src.Elem())
+		dst.Set(copied)
+
+	default:
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,248 @@
+package statekit
+
+import (
+	"testing"
+)
+
+type cloneContext struct {
+	Items  []string
+	Counts map[string]int
+	Owner  *cloneOwner
+}
+
+type cloneOwner struct {
+	Name string
+}
+
+// TestContextCloner_DefaultShallow tests that the default State() shares slices with the interpreter
+func TestContextCloner_DefaultShallow(t *testing.T) {
+	machine, err := NewMachine[cloneContext]("clone").
+		WithInitial("active").
+		WithContext(cloneContext{Items: []string{"a", "b"}}).
+		State("active").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.State().Context.Items[0] = "mutated"
+
+	if got := interp.State().Context.Items[0]; got != "mutated" {
+		t.Errorf("Expected shallow copy to share the slice, got %q", got)
+	}
+}
+
+// TestMachineConfig_CloneWithContext tests that interpreters of machines cloned
+// with fresh contexts do not share their initial context
+func TestMachineConfig_CloneWithContext(t *testing.T) {
+	template, err := NewMachine[cloneContext]("clone").
+		WithInitial("idle").
+		WithContext(cloneContext{Counts: map[string]int{}}).
+		WithAction("count", func(ctx *cloneContext, e Event) {
+			ctx.Counts[string(e.Type)]++
+		}).
+		State("idle").On("ADD").Target("idle").Do("count").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	fresh := func() cloneContext { return cloneContext{Counts: map[string]int{}} }
+	first := NewInterpreter(template.CloneWithContext(fresh()))
+	second := NewInterpreter(template.CloneWithContext(fresh()))
+	first.Start()
+	second.Start()
+
+	first.Send(Event{Type: "ADD"})
+	first.Send(Event{Type: "ADD"})
+	second.Send(Event{Type: "ADD"})
+
+	if got := first.State().Context.Counts["ADD"]; got != 2 {
+		t.Errorf("Expected the first interpreter to count 2, got %d", got)
+	}
+	if got := second.State().Context.Counts["ADD"]; got != 1 {
+		t.Errorf("Expected the second interpreter to count 1, got %d", got)
+	}
+	if len(template.Context.Counts) != 0 {
+		t.Errorf("Expected the template context to be unchanged, got %v", template.Context.Counts)
+	}
+
+	// Interpreters of the template itself share its map
+	shared := NewInterpreter(template)
+	shared.Start()
+	shared.Send(Event{Type: "ADD"})
+	if len(template.Context.Counts) != 1 {
+		t.Errorf("Expected the template map to be shared, got %v", template.Context.Counts)
+	}
+}
+
+// TestContextCloner_DeepCopy tests that mutating a returned context does not affect later State() calls
+func TestContextCloner_DeepCopy(t *testing.T) {
+	machine, err := NewMachine[cloneContext]("clone").
+		WithInitial("active").
+		WithContext(cloneContext{
+			Items:  []string{"a", "b"},
+			Counts: map[string]int{"a": 1},
+			Owner:  &cloneOwner{Name: "alice"},
+		}).
+		State("active").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithContextCloner(DeepCopy[cloneContext]))
+	interp.Start()
+
+	ctx := interp.State().Context
+	ctx.Items[0] = "mutated"
+	ctx.Counts["a"] = 99
+	ctx.Owner.Name = "mallory"
+
+	state := interp.State()
+	if state.Context.Items[0] != "a" {
+		t.Errorf("Expected Items[0] 'a', got %q", state.Context.Items[0])
+	}
+	if state.Context.Counts["a"] != 1 {
+		t.Errorf("Expected Counts[a] 1, got %d", state.Context.Counts["a"])
+	}
+	if state.Context.Owner.Name != "alice" {
+		t.Errorf("Expected owner 'alice', got %q", state.Context.Owner.Name)
+	}
+
+	// Snapshots are cloned too
+	snap := interp.Snapshot()
+	snap.Context.Items[1] = "mutated"
+	if got := interp.State().Context.Items[1]; got != "b" {
+		t.Errorf("Expected Items[1] 'b' after mutating snapshot, got %q", got)
+	}
+}
+
+// TestContextCloner_Custom tests that a custom cloner is used
+func TestContextCloner_Custom(t *testing.T) {
+	calls := 0
+	cloner := func(c cloneContext) cloneContext {
+		calls++
+		c.Items = append([]string(nil), c.Items...)
+		return c
+	}
+
+	machine, err := NewMachine[cloneContext]("clone").
+		WithInitial("active").
+		WithContext(cloneContext{Items: []string{"a", "b"}}).
+		State("active").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithContextCloner(cloner))
+	interp.Start()
+
+	interp.State().Context.Items[0] = "mutated"
+	if got := interp.State().Context.Items[0]; got != "a" {
+		t.Errorf("Expected Items[0] 'a', got %q", got)
+	}
+	if calls == 0 {
+		t.Error("Expected custom cloner to be called")
+	}
+}
+
+// TestDeepCopy tests copying of nested values
+func TestDeepCopy(t *testing.T) {
+	type nested struct {
+		Values [2][]int
+		Any    any
+		hidden []int
+	}
+
+	original := nested{
+		Values: [2][]int{{1}, {2}},
+		Any:    []string{"x"},
+		hidden: []int{3},
+	}
+
+	copied := DeepCopy(original)
+	copied.Values[0][0] = 10
+	copied.Any.([]string)[0] = "y"
+
+	if original.Values[0][0] != 1 {
+		t.Errorf("Expected array element slice to be copied, got %d", original.Values[0][0])
+	}
+	if original.Any.([]string)[0] != "x" {
+		t.Errorf("Expected interface value to be copied, got %q", original.Any.([]string)[0])
+	}
+	if len(copied.hidden) != 1 || copied.hidden[0] != 3 {
+		t.Errorf("Expected unexported field to be copied shallowly, got %v", copied.hidden)
+	}
+
+	var nilMap map[string]int
+	if DeepCopy(nilMap) != nil {
+		t.Error("Expected nil map to stay nil")
+	}
+}
+
+// TestInterpreter_SetContext tests that a context set with SetContext is read back by Context and State
+func TestInterpreter_SetContext(t *testing.T) {
+	machine, err := NewMachine[cloneContext]("clone").
+		WithInitial("active").
+		WithContext(cloneContext{
+			Items: []string{"a", "b"},
+			Owner: &cloneOwner{Name: "alice"},
+		}).
+		State("active").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithContextCloner(DeepCopy[cloneContext]))
+	interp.Start()
+
+	ctx := interp.Context()
+	ctx.Items = append(ctx.Items, "c")
+	ctx.Owner.Name = "bob"
+	interp.SetContext(ctx)
+
+	// The caller's value is copied, so changing it afterwards has no effect
+	ctx.Items[0] = "mutated"
+	ctx.Owner.Name = "mallory"
+
+	for _, got := range []cloneContext{interp.Context(), interp.State().Context} {
+		if len(got.Items) != 3 || got.Items[0] != "a" || got.Owner.Name != "bob" {
+			t.Errorf("Expected items [a b c] owned by bob, got %v owned by %s", got.Items, got.Owner.Name)
+		}
+	}
+	if interp.State().Value != "active" {
+		t.Errorf("Expected state 'active', got %s", interp.State().Value)
+	}
+}
+
+// TestInterpreter_SetContextNoActions tests that SetContext runs no actions and notifies no listeners
+func TestInterpreter_SetContextNoActions(t *testing.T) {
+	entered := 0
+	machine, err := NewMachine[counterContext]("set").
+		WithInitial("idle").
+		WithAction("count", func(ctx *counterContext, e Event) { entered++ }).
+		State("idle").OnEntry("count").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	notified := 0
+	interp.Subscribe(func(State[counterContext]) { notified++ })
+
+	interp.SetContext(counterContext{Count: 7})
+	if got := interp.Context().Count; got != 7 {
+		t.Errorf("Expected count 7, got %d", got)
+	}
+	if entered != 1 || notified != 0 {
+		t.Errorf("Expected no actions or notifications, got %d entries and %d notifications", entered, notified)
+	}
+}
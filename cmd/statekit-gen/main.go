@@ -0,0 +1,85 @@
+// Command statekit-gen generates typed StateID and EventType constants for a
+// machine, so that code driving it does not repeat string IDs. It reads the
+// machine as XState JSON, as written by the export CLI helper
+// (export.RunCLI -machine=ID -o FILE) or XStateExporter.ExportJSON, and writes
+// a Go file declaring a constant for every state and event.
+//
+// Usage:
+//
+//	statekit-gen -in order.json [-pkg=NAME] [-prefix=STR] [-o=FILE]
+//
+// With go:generate, the package defaults to $GOPACKAGE:
+//
+//	//go:generate go run github.com/felixgeelhaar/statekit/cmd/statekit-gen -in order.json
+//
+// The output file defaults to <machine id>_states.go in the current directory.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/felixgeelhaar/statekit/export"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "statekit-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the constants file for the given arguments and reports the
+// written file to out
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("statekit-gen", flag.ContinueOnError)
+
+	input := fs.String("in", "", "XState JSON file of the machine (required)")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "Package of the generated file (default: $GOPACKAGE)")
+	prefix := fs.String("prefix", "", "Prefix of constant names")
+	output := fs.String("o", "", "Output file (default: <machine id>_states.go)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return fmt.Errorf("-in is required")
+	}
+	if *pkg == "" {
+		return fmt.Errorf("-pkg is required outside go:generate")
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		return fmt.Errorf("read machine: %w", err)
+	}
+	var machine export.XStateMachine
+	if err := json.Unmarshal(data, &machine); err != nil {
+		return fmt.Errorf("parse %s: %w", *input, err)
+	}
+
+	source, err := export.GenerateConstants(&machine, export.ConstantsOptions{
+		Package: *pkg,
+		Prefix:  *prefix,
+	})
+	if err != nil {
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		if machine.ID == "" {
+			return fmt.Errorf("-o is required for a machine without an ID")
+		}
+		path = machine.ID + "_states.go"
+	}
+	if err := os.WriteFile(path, source, 0o644); err != nil {
+		return fmt.Errorf("write constants: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(out, "wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const trafficJSON = `{
+  "id": "traffic_light",
+  "initial": "green",
+  "states": {
+    "green": {"on": {"TIMER": {"target": "yellow"}}},
+    "yellow": {"on": {"TIMER": {"target": "red"}}},
+    "red": {"on": {"TIMER": {"target": "green"}, "EMERGENCY_STOP": {"target": "red"}}}
+  }
+}`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "traffic.json")
+	if err := os.WriteFile(input, []byte(trafficJSON), 0o644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	output := filepath.Join(dir, "traffic_states.go")
+
+	var out bytes.Buffer
+	if err := run([]string{"-in", input, "-pkg", "traffic", "-o", output}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), output) {
+		t.Errorf("expected the written file to be reported, got %q", out.String())
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, want := range []string{
+		"package traffic",
+		`StateGreen  statekit.StateID = "green"`,
+		`EventEmergencyStop statekit.EventType = "EMERGENCY_STOP"`,
+		`EventTimer         statekit.EventType = "TIMER"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in output:\n%s", want, data)
+		}
+	}
+}
+
+func TestRun_PackageFromGoGenerate(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "traffic.json")
+	if err := os.WriteFile(input, []byte(trafficJSON), 0o644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	t.Chdir(dir)
+	t.Setenv("GOPACKAGE", "lights")
+
+	if err := run([]string{"-in", input}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "traffic_light_states.go"))
+	if err != nil {
+		t.Fatalf("expected the default output file: %v", err)
+	}
+	if !strings.Contains(string(data), "package lights") {
+		t.Errorf("expected the package from GOPACKAGE, got:\n%s", data)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := map[string][]string{
+		"missing input": {"-pkg", "p"},
+		"missing file":  {"-in", filepath.Join(t.TempDir(), "missing.json"), "-pkg", "p"},
+		"invalid flag":  {"-invalid"},
+	}
+	t.Setenv("GOPACKAGE", "")
+	for name, args := range tests {
+		if err := run(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := run([]string{"-in", "machine.json"}, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "-pkg") {
+		t.Errorf("expected -pkg to be required without GOPACKAGE, got %v", err)
+	}
+}
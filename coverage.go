@@ -0,0 +1,80 @@
+package statekit
+
+import "github.com/felixgeelhaar/statekit/internal/ir"
+
+// CoverageReport lists the parts of a machine that an interpreter has not
+// exercised, as recorded with WithCoverage
+type CoverageReport struct {
+	// UnvisitedStates are the states never entered, in declaration order
+	UnvisitedStates []StateID
+	// UntakenTransitions are the declared transitions never taken, in the
+	// order of MachineConfig.Transitions
+	UntakenTransitions []Edge
+}
+
+// Complete reports whether every state was entered and every transition taken
+func (r CoverageReport) Complete() bool {
+	return len(r.UnvisitedStates) == 0 && len(r.UntakenTransitions) == 0
+}
+
+// WithCoverage records the states the interpreter enters and the transitions
+// it takes, so that Coverage can report the parts of the machine a test run
+// did not exercise. Coverage accumulates across Reset.
+func WithCoverage() InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.coverage = true
+	}
+}
+
+// coverage holds the states entered and transitions taken by an interpreter
+type coverage struct {
+	states      map[ir.StateID]bool
+	transitions map[*ir.TransitionConfig]bool
+}
+
+func newCoverage() *coverage {
+	return &coverage{
+		states:      make(map[ir.StateID]bool),
+		transitions: make(map[*ir.TransitionConfig]bool),
+	}
+}
+
+// visit records an entered state; it does nothing on a nil coverage
+func (c *coverage) visit(id ir.StateID) {
+	if c != nil {
+		c.states[id] = true
+	}
+}
+
+// take records a taken transition; it does nothing on a nil coverage
+func (c *coverage) take(trans *ir.TransitionConfig) {
+	if c != nil {
+		c.transitions[trans] = true
+	}
+}
+
+// Coverage reports the states that were never entered and the transitions
+// that were never taken. Without WithCoverage nothing is recorded, so every
+// state and transition is reported.
+func (i *Interpreter[C]) Coverage() CoverageReport {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	cov := i.coverage
+	if cov == nil {
+		cov = newCoverage()
+	}
+
+	var report CoverageReport
+	for _, id := range i.machine.StateIDs() {
+		if !cov.states[id] {
+			report.UnvisitedStates = append(report.UnvisitedStates, id)
+		}
+		for _, trans := range i.machine.States[id].Transitions {
+			if !cov.transitions[trans] {
+				report.UntakenTransitions = append(report.UntakenTransitions, ir.NewEdge(id, trans))
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,119 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type coverageContext struct {
+	Approved bool
+}
+
+// TestCoverage_PartialRun tests that the report lists the branches a run did not reach
+func TestCoverage_PartialRun(t *testing.T) {
+	// A review workflow with an approval branch, a rejection branch, and a delayed expiry
+	machine, err := NewMachine[coverageContext]("review").
+		WithInitial("draft").
+		WithGuard("isApproved", func(ctx coverageContext, e Event) bool { return ctx.Approved }).
+		State("draft").
+		On("SUBMIT").Target("review").
+		Done().
+		State("review").
+		On("DECIDE").Target("published").Guard("isApproved").
+		On("DECIDE").Target("rejected").
+		After(24 * time.Hour).Target("expired").
+		Done().
+		State("published").Final().Done().
+		State("rejected").Final().Done().
+		State("expired").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithCoverage())
+	interp.Start()
+	interp.Send(Event{Type: "SUBMIT"})
+	interp.Send(Event{Type: "DECIDE"}) // Not approved: rejected
+
+	report := interp.Coverage()
+	if report.Complete() {
+		t.Error("Expected incomplete coverage")
+	}
+	if want := []StateID{"published", "expired"}; !slices.Equal(report.UnvisitedStates, want) {
+		t.Errorf("Expected unvisited states %v, got %v", want, report.UnvisitedStates)
+	}
+	want := []Edge{
+		{From: "review", To: "published", Event: "DECIDE", Guard: "isApproved"},
+		{From: "review", To: "expired", Delay: 24 * time.Hour},
+	}
+	if !slices.Equal(report.UntakenTransitions, want) {
+		t.Errorf("Expected untaken transitions %v, got %v", want, report.UntakenTransitions)
+	}
+}
+
+// TestCoverage_AccumulatesAcrossReset tests that runs along different paths add up to full coverage
+func TestCoverage_AccumulatesAcrossReset(t *testing.T) {
+	machine, err := NewMachine[coverageContext]("review").
+		WithInitial("draft").
+		WithGuard("isApproved", func(ctx coverageContext, e Event) bool { return ctx.Approved }).
+		State("draft").
+		On("SUBMIT").Target("review").
+		Done().
+		State("review").
+		On("DECIDE").Target("published").Guard("isApproved").
+		On("DECIDE").Target("rejected").
+		After(24 * time.Hour).Target("expired").
+		Done().
+		State("published").Final().Done().
+		State("rejected").Final().Done().
+		State("expired").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithCoverage(), WithClock(clock))
+	interp.Start()
+	interp.Send(Event{Type: "SUBMIT"})
+	interp.Send(Event{Type: "DECIDE"})
+
+	interp.Reset()
+	interp.UpdateContext(func(ctx *coverageContext) { ctx.Approved = true })
+	interp.Send(Event{Type: "SUBMIT"})
+	interp.Send(Event{Type: "DECIDE"})
+
+	interp.Reset()
+	interp.Send(Event{Type: "SUBMIT"})
+	clock.Advance(24 * time.Hour)
+
+	if report := interp.Coverage(); !report.Complete() {
+		t.Errorf("Expected complete coverage, got %+v", report)
+	}
+}
+
+// TestCoverage_Disabled tests that without WithCoverage every state and transition is reported
+func TestCoverage_Disabled(t *testing.T) {
+	machine, err := NewMachine[coverageContext]("review").
+		WithInitial("draft").
+		State("draft").On("SUBMIT").Target("review").Done().
+		State("review").On("PUBLISH").Target("published").Done().
+		State("published").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "SUBMIT"})
+
+	report := interp.Coverage()
+	if len(report.UnvisitedStates) != 3 || len(report.UntakenTransitions) != 2 {
+		t.Errorf("Expected 3 unvisited states and 2 untaken transitions, got %+v", report)
+	}
+}
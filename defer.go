@@ -0,0 +1,47 @@
+package statekit
+
+import "slices"
+
+// deferUnlocked holds an unhandled event if an active state defers it and
+// reports whether it did (caller must hold mu)
+func (i *Interpreter[C]) deferUnlocked(event Event) bool {
+	for _, stateID := range i.activeStatesUnlocked() {
+		stateConfig := i.machine.GetState(stateID)
+		if stateConfig != nil && slices.Contains(stateConfig.Deferred, event.Type) {
+			i.deferred = append(i.deferred, event)
+			if i.result != nil {
+				i.result.Deferred = true
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// releaseDeferred re-delivers the deferred events after a transition. They are
+// processed, in the order they arrived, before any other queued event; events
+// the new states still defer are held again (caller must hold mu and be
+// running a queue step).
+func (i *Interpreter[C]) releaseDeferred() {
+	if len(i.deferred) == 0 {
+		return
+	}
+
+	i.queueMu.Lock()
+	steps := make([]queuedStep, len(i.deferred))
+	for n, event := range i.deferred {
+		steps[n] = i.newStep(func() bool { return i.deliverUnlocked(event) })
+		steps[n].parent = i.running
+	}
+	i.queue = append(steps, i.queue...)
+	i.queueMu.Unlock()
+	i.deferred = nil
+}
+
+// DeferredEvents returns the events currently held by deferring states, in the
+// order they arrived
+func (i *Interpreter[C]) DeferredEvents() []Event {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return slices.Clone(i.deferred)
+}
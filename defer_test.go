@@ -0,0 +1,116 @@
+package statekit
+
+import (
+	"testing"
+)
+
+// TestDefer_ReplayedAfterTransition tests that an early event is held and fires in a handling state
+func TestDefer_ReplayedAfterTransition(t *testing.T) {
+	machine, err := NewMachine[struct{}]("player").
+		WithInitial("loading").
+		State("loading").
+		Defer("PAUSE").
+		On("LOADED").Target("playing").
+		Done().
+		State("playing").On("PAUSE").Target("paused").Done().
+		State("paused").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithStrictEvents())
+	interp.Start()
+
+	var states []StateID
+	interp.Subscribe(func(s State[struct{}]) { states = append(states, s.Value) })
+
+	result := interp.SendResult(Event{Type: "PAUSE", Payload: 42})
+	if result.Handled || !result.Deferred || result.Err != nil {
+		t.Errorf("Expected PAUSE to be deferred without error, got %+v", result)
+	}
+	if interp.State().Value != "loading" {
+		t.Fatalf("Expected state 'loading', got %s", interp.State().Value)
+	}
+	if deferred := interp.DeferredEvents(); len(deferred) != 1 || deferred[0].Payload != 42 {
+		t.Errorf("Expected PAUSE to be held with its payload, got %+v", deferred)
+	}
+
+	interp.Send(Event{Type: "LOADED"})
+	if interp.State().Value != "paused" {
+		t.Errorf("Expected deferred PAUSE to fire in 'playing', got %s", interp.State().Value)
+	}
+	if len(interp.DeferredEvents()) != 0 {
+		t.Errorf("Expected no deferred events, got %+v", interp.DeferredEvents())
+	}
+	if len(states) != 2 || states[0] != "playing" || states[1] != "paused" {
+		t.Errorf("Expected listeners notified of playing then paused, got %v", states)
+	}
+	if stats := interp.Stats(); stats.EventsReceived != 2 || stats.EventsIgnored != 0 {
+		t.Errorf("Expected 2 events received and none ignored, got %+v", stats)
+	}
+}
+
+// TestDefer_CompoundState tests that a compound state defers events for all its descendants
+func TestDefer_CompoundState(t *testing.T) {
+	machine, err := NewMachine[struct{}]("wizard").
+		WithInitial("busy").
+		State("busy").
+		WithInitial("step1").
+		Defer("SAVE", "CLOSE").
+		On("FINISH").Target("idle").End().
+		State("step1").On("NEXT").Target("step2").End().End().
+		State("step2").End().
+		Done().
+		State("idle").
+		On("SAVE").Target("saved").
+		Done().
+		State("saved").
+		On("CLOSE").Target("closed").
+		Done().
+		State("closed").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "CLOSE"})
+	interp.Send(Event{Type: "SAVE"})
+	interp.Send(Event{Type: "UNKNOWN"})
+
+	// A transition inside the deferring state re-delivers the events, which are held again
+	interp.Send(Event{Type: "NEXT"})
+	if interp.State().Value != "step2" {
+		t.Fatalf("Expected state 'step2', got %s", interp.State().Value)
+	}
+	deferred := interp.DeferredEvents()
+	if len(deferred) != 2 || deferred[0].Type != "CLOSE" || deferred[1].Type != "SAVE" {
+		t.Fatalf("Expected CLOSE and SAVE held in order, got %+v", deferred)
+	}
+
+	// Leaving: CLOSE is not handled by idle and is dropped, then SAVE is taken
+	interp.Send(Event{Type: "FINISH"})
+	if interp.State().Value != "saved" {
+		t.Errorf("Expected state 'saved', got %s", interp.State().Value)
+	}
+	if len(interp.DeferredEvents()) != 0 {
+		t.Errorf("Expected no deferred events, got %+v", interp.DeferredEvents())
+	}
+	if stats := interp.Stats(); stats.EventsIgnored != 2 {
+		t.Errorf("Expected UNKNOWN and CLOSE to be ignored, got %+v", stats)
+	}
+
+	// Reset drops held events
+	interp.Reset()
+	interp.Send(Event{Type: "SAVE"})
+	if len(interp.DeferredEvents()) != 1 {
+		t.Fatalf("Expected SAVE to be held, got %+v", interp.DeferredEvents())
+	}
+	interp.Reset()
+	if len(interp.DeferredEvents()) != 0 {
+		t.Errorf("Expected no deferred events after reset, got %+v", interp.DeferredEvents())
+	}
+}
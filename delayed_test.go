@@ -2,11 +2,15 @@ package statekit
 
 import (
 	"encoding/json"
+	"errors"
+	"slices"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/felixgeelhaar/statekit/export"
+	"github.com/felixgeelhaar/statekit/internal/ir"
+	"github.com/felixgeelhaar/statekit/statekittest"
 )
 
 // TestDelayedTransition_Basic tests a simple delayed transition
@@ -31,13 +35,8 @@ func TestDelayedTransition_Basic(t *testing.T) {
 		t.Errorf("Expected initial state 'loading', got %s", interp.State().Value)
 	}
 
-	// Wait for delayed transition
-	time.Sleep(100 * time.Millisecond)
-
-	// Should now be in ready
-	if interp.State().Value != "ready" {
-		t.Errorf("Expected state 'ready' after delay, got %s", interp.State().Value)
-	}
+	// Wait for the delayed transition on the real clock
+	waitForState(t, interp, "ready")
 
 	interp.Stop()
 }
@@ -59,7 +58,8 @@ func TestDelayedTransition_CancelOnExit(t *testing.T) {
 		t.Fatalf("Failed to build machine: %v", err)
 	}
 
-	interp := NewInterpreter(machine)
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
 	interp.Start()
 
 	// Should start in waiting
@@ -68,7 +68,7 @@ func TestDelayedTransition_CancelOnExit(t *testing.T) {
 	}
 
 	// Cancel before timeout fires
-	time.Sleep(30 * time.Millisecond)
+	clock.Advance(30 * time.Millisecond)
 	interp.Send(Event{Type: "CANCEL"})
 
 	// Should be in cancelled
@@ -76,8 +76,8 @@ func TestDelayedTransition_CancelOnExit(t *testing.T) {
 		t.Errorf("Expected state 'cancelled', got %s", interp.State().Value)
 	}
 
-	// Wait past the original timeout
-	time.Sleep(100 * time.Millisecond)
+	// Advance past the original timeout
+	clock.Advance(100 * time.Millisecond)
 
 	// Should still be in cancelled (timer was canceled)
 	if interp.State().Value != "cancelled" {
@@ -109,11 +109,12 @@ func TestDelayedTransition_WithGuard(t *testing.T) {
 		t.Fatalf("Failed to build machine: %v", err)
 	}
 
-	interp := NewInterpreter(machine)
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
 	interp.Start()
 
-	// Wait for delayed transition (guard will block it)
-	time.Sleep(100 * time.Millisecond)
+	// Advance past the delay (guard will block it)
+	clock.Advance(100 * time.Millisecond)
 
 	// Should still be in waiting because guard returned false
 	if interp.State().Value != "waiting" {
@@ -123,6 +124,128 @@ func TestDelayedTransition_WithGuard(t *testing.T) {
 	interp.Stop()
 }
 
+// TestDelayedTransition_Repeat tests that a repeating delayed transition re-checks its guard
+func TestDelayedTransition_Repeat(t *testing.T) {
+	type Context struct {
+		Checks int
+	}
+
+	machine, err := NewMachine[Context]("delayed_repeat").
+		WithInitial("waiting").
+		WithGuard("secondCheck", func(ctx Context, e Event) bool {
+			return ctx.Checks >= 2
+		}).
+		State("waiting").
+		After(time.Second).Target("proceeded").Guard("secondCheck").Repeat().
+		On("CHECK").Target("waiting").Internal().Assign(func(ctx *Context, e Event) { ctx.Checks++ }).
+		Done().
+		State("proceeded").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+	defer interp.Stop()
+
+	// First check: the guard fails and the timer is re-armed
+	interp.Send(Event{Type: "CHECK"})
+	clock.Advance(time.Second)
+	if interp.State().Value != "waiting" {
+		t.Fatalf("Expected state 'waiting' (guard blocked), got %s", interp.State().Value)
+	}
+	if pending := interp.PendingTimers(); len(pending) != 1 || pending[0].RemainingMs != 1000 {
+		t.Fatalf("Expected the timer re-armed for another second, got %+v", pending)
+	}
+
+	// Second check: the guard passes and the transition fires
+	interp.Send(Event{Type: "CHECK"})
+	clock.Advance(999 * time.Millisecond)
+	if interp.State().Value != "waiting" {
+		t.Fatalf("Expected state 'waiting' before the second check, got %s", interp.State().Value)
+	}
+	clock.Advance(time.Millisecond)
+	if interp.State().Value != "proceeded" {
+		t.Errorf("Expected state 'proceeded' after the second check, got %s", interp.State().Value)
+	}
+	if clock.Pending() != 0 {
+		t.Errorf("Expected no pending timers, got %d", clock.Pending())
+	}
+	if stats := interp.Stats(); stats.DelayedFired != 1 {
+		t.Errorf("Expected one delayed transition fired, got %+v", stats)
+	}
+}
+
+// TestDelayedTransition_RepeatZeroNamedDelay tests that a repeating transition
+// stops when its named delay resolves to zero
+func TestDelayedTransition_RepeatZeroNamedDelay(t *testing.T) {
+	var checks atomic.Int32
+	delay := 100 * time.Millisecond
+	machine, err := NewMachine[struct{}]("repeat_zero_named").
+		WithInitial("waiting").
+		WithDelay("backoff", func(ctx struct{}, e Event) time.Duration {
+			current := delay
+			delay = 0
+			return current
+		}).
+		WithGuard("never", func(ctx struct{}, e Event) bool {
+			checks.Add(1)
+			return false
+		}).
+		State("waiting").
+		AfterDelay("backoff").Target("proceeded").Guard("never").Repeat().
+		Done().
+		State("proceeded").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+	clock.Advance(time.Second)
+
+	if n := checks.Load(); n != 1 || clock.Pending() != 0 {
+		t.Errorf("Expected one check and no re-armed timer, got %d checks and %d pending", n, clock.Pending())
+	}
+}
+
+// TestDelayedTransition_RepeatUntilExit tests that a repeating timer stops when its state is exited
+func TestDelayedTransition_RepeatUntilExit(t *testing.T) {
+	machine, err := NewMachine[struct{}]("delayed_repeat_exit").
+		WithInitial("waiting").
+		WithGuard("never", func(ctx struct{}, e Event) bool { return false }).
+		State("waiting").
+		After(time.Second).Target("proceeded").Guard("never").Repeat().
+		On("CANCEL").Target("cancelled").
+		Done().
+		State("proceeded").Done().
+		State("cancelled").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+	defer interp.Stop()
+
+	clock.Advance(5 * time.Second)
+	if interp.State().Value != "waiting" || clock.Pending() != 1 {
+		t.Fatalf("Expected to keep waiting with one armed timer, got %s and %d timers", interp.State().Value, clock.Pending())
+	}
+
+	interp.Send(Event{Type: "CANCEL"})
+	if clock.Pending() != 0 {
+		t.Errorf("Expected the repeating timer canceled on exit, got %d timers", clock.Pending())
+	}
+}
+
 // TestDelayedTransition_WithAction tests delayed transitions with actions
 func TestDelayedTransition_WithAction(t *testing.T) {
 	type Context struct {
@@ -144,7 +267,8 @@ func TestDelayedTransition_WithAction(t *testing.T) {
 		t.Fatalf("Failed to build machine: %v", err)
 	}
 
-	interp := NewInterpreter(machine)
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
 	interp.Start()
 
 	// Action should not have executed yet
@@ -152,8 +276,8 @@ func TestDelayedTransition_WithAction(t *testing.T) {
 		t.Error("Action should not have executed yet")
 	}
 
-	// Wait for delayed transition
-	time.Sleep(100 * time.Millisecond)
+	// Advance past the delay
+	clock.Advance(100 * time.Millisecond)
 
 	// Action should have executed
 	if !interp.State().Context.ActionExecuted {
@@ -180,19 +304,20 @@ func TestDelayedTransition_Multiple(t *testing.T) {
 		t.Fatalf("Failed to build machine: %v", err)
 	}
 
-	interp := NewInterpreter(machine)
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
 	interp.Start()
 
-	// Wait for first delayed transition
-	time.Sleep(60 * time.Millisecond)
+	// Advance past the first delay
+	clock.Advance(60 * time.Millisecond)
 
 	// Should be in first (shorter delay fires first)
 	if interp.State().Value != "first" {
 		t.Errorf("Expected state 'first', got %s", interp.State().Value)
 	}
 
-	// Wait past the second delay
-	time.Sleep(100 * time.Millisecond)
+	// Advance past the second delay
+	clock.Advance(100 * time.Millisecond)
 
 	// Should still be in first (second timer was canceled when we left start)
 	if interp.State().Value != "first" {
@@ -220,7 +345,8 @@ func TestDelayedTransition_InHierarchy(t *testing.T) {
 		t.Fatalf("Failed to build machine: %v", err)
 	}
 
-	interp := NewInterpreter(machine)
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
 	interp.Start()
 
 	// Should start in child
@@ -228,8 +354,8 @@ func TestDelayedTransition_InHierarchy(t *testing.T) {
 		t.Errorf("Expected initial state 'child', got %s", interp.State().Value)
 	}
 
-	// Wait for delayed transition
-	time.Sleep(100 * time.Millisecond)
+	// Advance past the delay
+	clock.Advance(100 * time.Millisecond)
 
 	// Should now be in done
 	if interp.State().Value != "done" {
@@ -258,14 +384,15 @@ func TestDelayedTransition_Stop(t *testing.T) {
 		t.Fatalf("Failed to build machine: %v", err)
 	}
 
-	interp := NewInterpreter(machine)
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
 	interp.Start()
 
 	// Stop immediately
 	interp.Stop()
 
-	// Wait past the delay
-	time.Sleep(100 * time.Millisecond)
+	// Advance past the delay
+	clock.Advance(100 * time.Millisecond)
 
 	// Transition should not have happened
 	if transitioned.Load() {
@@ -370,6 +497,38 @@ func TestDelayedTransition_Validation(t *testing.T) {
 		}
 	})
 
+	t.Run("repeat requires a delayed transition", func(t *testing.T) {
+		_, err := NewMachine[struct{}]("repeat_event").
+			WithInitial("start").
+			State("start").
+			On("GO").Target("end").Repeat().
+			Done().
+			State("end").
+			Done().
+			Build()
+		var validationErr *ir.ValidationError
+		if !errors.As(err, &validationErr) || !containsIssueCode(validationErr, ir.ErrCodeInvalidRepeat) {
+			t.Errorf("Expected %s issue, got %v", ir.ErrCodeInvalidRepeat, err)
+		}
+	})
+
+	t.Run("repeat requires a positive delay", func(t *testing.T) {
+		for _, delay := range []time.Duration{0, -time.Second} {
+			_, err := NewMachine[struct{}]("repeat_zero").
+				WithInitial("start").
+				State("start").
+				After(delay).Target("end").Repeat().
+				Done().
+				State("end").
+				Done().
+				Build()
+			var validationErr *ir.ValidationError
+			if !errors.As(err, &validationErr) || !containsIssueCode(validationErr, ir.ErrCodeInvalidRepeatDelay) {
+				t.Errorf("Expected %s issue for %v, got %v", ir.ErrCodeInvalidRepeatDelay, delay, err)
+			}
+		}
+	})
+
 	t.Run("positive delay is valid", func(t *testing.T) {
 		_, err := NewMachine[struct{}]("positive_delay").
 			WithInitial("start").
@@ -406,7 +565,8 @@ func TestDelayedTransition_ChainedBuilder(t *testing.T) {
 		t.Fatalf("Failed to build machine: %v", err)
 	}
 
-	interp := NewInterpreter(machine)
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
 	interp.Start()
 
 	// Transition via event before timeout
@@ -415,8 +575,8 @@ func TestDelayedTransition_ChainedBuilder(t *testing.T) {
 		t.Errorf("Expected 'middle', got %s", interp.State().Value)
 	}
 
-	// Wait for delayed transition from middle
-	time.Sleep(100 * time.Millisecond)
+	// Advance past the delay from middle
+	clock.Advance(100 * time.Millisecond)
 
 	if interp.State().Value != "end" {
 		t.Errorf("Expected 'end' after delay, got %s", interp.State().Value)
@@ -424,3 +584,279 @@ func TestDelayedTransition_ChainedBuilder(t *testing.T) {
 
 	interp.Stop()
 }
+
+// TestDelayedTransition_NamedDelay tests a delay computed from context on state entry
+func TestDelayedTransition_NamedDelay(t *testing.T) {
+	type Context struct {
+		SLA time.Duration
+	}
+
+	machine, err := NewMachine[Context]("named_delay").
+		WithInitial("idle").
+		WithContext(Context{SLA: 200 * time.Millisecond}).
+		WithDelay("sla", func(ctx Context, e Event) time.Duration {
+			return ctx.SLA
+		}).
+		State("idle").
+		On("OPEN").Target("open").
+		Done().
+		State("open").
+		AfterDelay("sla").Target("escalated").
+		On("CLOSE").Target("idle").
+		Done().
+		State("escalated").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	interp.Send(Event{Type: "OPEN"})
+	clock.Advance(150 * time.Millisecond)
+	if interp.State().Value != "open" {
+		t.Fatalf("Expected 'open' before the SLA elapsed, got %s", interp.State().Value)
+	}
+	clock.Advance(50 * time.Millisecond)
+	if interp.State().Value != "escalated" {
+		t.Fatalf("Expected 'escalated' after the SLA elapsed, got %s", interp.State().Value)
+	}
+
+	interp.Stop()
+}
+
+// TestDelayedTransition_NamedDelayResolvedOnEntry tests that the delay is resolved each time the state is entered
+func TestDelayedTransition_NamedDelayResolvedOnEntry(t *testing.T) {
+	type Context struct {
+		SLA time.Duration
+	}
+
+	machine, err := NewMachine[Context]("named_delay_entry").
+		WithInitial("idle").
+		WithContext(Context{SLA: time.Second}).
+		WithDelay("sla", func(ctx Context, e Event) time.Duration {
+			return ctx.SLA
+		}).
+		State("idle").
+		On("OPEN").Target("open").
+		Done().
+		State("open").
+		AfterDelay("sla").Target("escalated").
+		On("CLOSE").Target("idle").
+		Done().
+		State("escalated").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	interp.Send(Event{Type: "OPEN"})
+	interp.Send(Event{Type: "CLOSE"})
+
+	// A shorter SLA applies the next time the state is entered
+	interp.UpdateContext(func(ctx *Context) { ctx.SLA = 100 * time.Millisecond })
+	interp.Send(Event{Type: "OPEN"})
+	clock.Advance(100 * time.Millisecond)
+	if interp.State().Value != "escalated" {
+		t.Errorf("Expected 'escalated' after the updated SLA, got %s", interp.State().Value)
+	}
+
+	interp.Stop()
+}
+
+// TestDelayedTransition_NamedDelayExport tests that named delays are exported by name
+func TestDelayedTransition_NamedDelayExport(t *testing.T) {
+	machine, err := NewMachine[struct{}]("named_export").
+		WithInitial("waiting").
+		WithDelay("timeout", func(ctx struct{}, e Event) time.Duration { return time.Second }).
+		State("waiting").
+		AfterDelay("timeout").Target("expired").
+		Done().
+		State("expired").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	exported, err := export.NewXStateExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	after := exported.States["waiting"].After
+	if _, ok := after["timeout"]; !ok {
+		t.Errorf("Expected after key 'timeout', got %v", after)
+	}
+}
+
+// TestDelayedTransition_NamedDelayValidation tests that named delays must be registered
+func TestDelayedTransition_NamedDelayValidation(t *testing.T) {
+	_, err := NewMachine[struct{}]("missing_delay").
+		WithInitial("waiting").
+		State("waiting").
+		AfterDelay("timeout").Target("expired").
+		Done().
+		State("expired").
+		Done().
+		Build()
+	if err == nil {
+		t.Fatal("Expected validation error for unregistered delay")
+	}
+}
+
+// TestDelayedTransition_RestartOnSelfTransition tests that an external
+// self-transition cancels the delayed transition and schedules it again once
+func TestDelayedTransition_RestartOnSelfTransition(t *testing.T) {
+	var fired atomic.Int32
+	machine, err := NewMachine[struct{}]("delayed_restart").
+		WithInitial("waiting").
+		WithAction("expire", func(ctx *struct{}, e Event) { fired.Add(1) }).
+		State("waiting").
+		After(100 * time.Millisecond).Target("timeout").Do("expire").
+		On("PING").Target("waiting").
+		Done().
+		State("timeout").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	// Each ping restarts the delay
+	for range 3 {
+		clock.Advance(60 * time.Millisecond)
+		interp.Send(Event{Type: "PING"})
+		if pending := clock.Pending(); pending != 1 {
+			t.Fatalf("Expected exactly one pending timer after PING, got %d", pending)
+		}
+	}
+	clock.Advance(60 * time.Millisecond)
+	if interp.State().Value != "waiting" {
+		t.Fatalf("Expected 'waiting' before the restarted delay elapses, got %s", interp.State().Value)
+	}
+
+	clock.Advance(40 * time.Millisecond)
+	if interp.State().Value != "timeout" {
+		t.Errorf("Expected 'timeout' once the restarted delay elapses, got %s", interp.State().Value)
+	}
+
+	clock.Advance(time.Second)
+	if n := fired.Load(); n != 1 || clock.Pending() != 0 {
+		t.Errorf("Expected the delayed transition to fire once with no timers left, got %d fires and %d pending", n, clock.Pending())
+	}
+}
+
+// TestDelayedTransition_RestartOnReentry tests that re-entering an ancestor
+// restarts its delayed transition, while an internal self-transition does not
+func TestDelayedTransition_RestartOnReentry(t *testing.T) {
+	machine, err := NewMachine[struct{}]("delayed_reentry").
+		WithInitial("session").
+		State("session").
+		WithInitial("idle").
+		After(100 * time.Millisecond).Target("expired").
+		On("TOUCH").Target("session").End().
+		State("idle").
+		On("POLL").Target("idle").Internal().
+		End().
+		Done().
+		State("expired").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	// Re-entering 'session' from its child restarts the delay
+	clock.Advance(60 * time.Millisecond)
+	interp.Send(Event{Type: "TOUCH"})
+	if pending := clock.Pending(); pending != 1 {
+		t.Fatalf("Expected exactly one pending timer after TOUCH, got %d", pending)
+	}
+
+	// The internal self-transition keeps the running timer
+	clock.Advance(60 * time.Millisecond)
+	interp.Send(Event{Type: "POLL"})
+	clock.Advance(40 * time.Millisecond)
+	if interp.State().Value != "expired" {
+		t.Errorf("Expected 'expired' 100ms after TOUCH, got %s", interp.State().Value)
+	}
+	if pending := clock.Pending(); pending != 0 {
+		t.Errorf("Expected no pending timers, got %d", pending)
+	}
+}
+
+// TestDelayedTransition_RestartInParallel tests delayed transitions of a region
+// state and of a parallel state across self-transitions
+func TestDelayedTransition_RestartInParallel(t *testing.T) {
+	machine, err := NewMachine[struct{}]("delayed_parallel").
+		WithInitial("session").
+		State("session").Parallel().
+		After(200 * time.Millisecond).Target("closed").
+		On("RESTART").Target("session").End().
+		Region("upload").
+		WithInitial("sending").
+		State("sending").
+		After(100 * time.Millisecond).Target("stalled").
+		On("PROGRESS").Target("sending").
+		EndState().
+		State("stalled").EndState().
+		EndRegion().
+		Done().
+		State("closed").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	// A region self-transition restarts only the region state's timer
+	clock.Advance(60 * time.Millisecond)
+	interp.Send(Event{Type: "PROGRESS"})
+	if pending := clock.Pending(); pending != 2 {
+		t.Fatalf("Expected two pending timers after PROGRESS, got %d", pending)
+	}
+	clock.Advance(60 * time.Millisecond)
+	if !interp.Matches("sending") {
+		t.Fatalf("Expected 'sending' before the restarted delay elapses, got %v", interp.ActiveStates())
+	}
+	clock.Advance(40 * time.Millisecond)
+	if !interp.Matches("stalled") || interp.Matches("sending") {
+		t.Fatalf("Expected only 'stalled' in the region, got %v", interp.ActiveStates())
+	}
+
+	// Re-entering the parallel state restarts its timer and re-enters the region
+	interp.Send(Event{Type: "RESTART"})
+	if !interp.MatchesAll("session", "sending") || clock.Pending() != 2 {
+		t.Fatalf("Expected 'sending' with two pending timers, got %v with %d", interp.ActiveStates(), clock.Pending())
+	}
+
+	// The parallel state's delayed transition exits every region
+	interp.Send(Event{Type: "PROGRESS"})
+	clock.Advance(200 * time.Millisecond)
+	if interp.State().Value != "closed" || !slices.Equal(interp.ActiveStates(), []StateID{"closed"}) {
+		t.Errorf("Expected only 'closed' to be active, got %v", interp.ActiveStates())
+	}
+	if pending := clock.Pending(); pending != 0 {
+		t.Errorf("Expected no pending timers, got %d", pending)
+	}
+}
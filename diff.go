@@ -0,0 +1,235 @@
+package statekit
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// ChangeKind identifies the kind of a structural change reported by Diff
+type ChangeKind string
+
+const (
+	// ChangeInitial: the initial state of the machine (State is empty) or of a
+	// compound state changed
+	ChangeInitial ChangeKind = "initial"
+	// ChangeStateAdded: State only exists in the new machine
+	ChangeStateAdded ChangeKind = "state_added"
+	// ChangeStateRemoved: State only exists in the old machine
+	ChangeStateRemoved ChangeKind = "state_removed"
+	// ChangeStateType: the type of State changed, e.g. from atomic to compound
+	ChangeStateType ChangeKind = "state_type"
+	// ChangeStateParent: State moved to another parent
+	ChangeStateParent ChangeKind = "state_parent"
+	// ChangeEntryActions: the entry actions of State changed
+	ChangeEntryActions ChangeKind = "entry_actions"
+	// ChangeExitActions: the exit actions of State changed
+	ChangeExitActions ChangeKind = "exit_actions"
+	// ChangeTransitionAdded: State has a new transition
+	ChangeTransitionAdded ChangeKind = "transition_added"
+	// ChangeTransitionRemoved: a transition of State was removed
+	ChangeTransitionRemoved ChangeKind = "transition_removed"
+	// ChangeTransitionChanged: a transition of State kept its trigger but
+	// changed its target, guard, actions, or options
+	ChangeTransitionChanged ChangeKind = "transition_changed"
+)
+
+// Change is a structural difference between two machines, as reported by Diff.
+// Which fields are set depends on Kind.
+type Change struct {
+	Kind ChangeKind
+	// State is the state the change applies to; empty for a change of the
+	// machine's initial state
+	State StateID
+
+	// Old and New are the previous and new initial state, state type, or parent
+	Old, New string
+
+	// OldEdge and NewEdge are the transition before and after the change; nil
+	// for an added and a removed transition respectively
+	OldEdge, NewEdge *Edge
+
+	// OldActions and NewActions are the previous and new entry or exit
+	// actions, or the actions of a changed transition
+	OldActions, NewActions []ActionType
+}
+
+// String describes the change on a single line, for change review
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeInitial:
+		if c.State == "" {
+			return fmt.Sprintf("initial state changed from %q to %q", c.Old, c.New)
+		}
+		return fmt.Sprintf("initial state of %q changed from %q to %q", c.State, c.Old, c.New)
+	case ChangeStateAdded:
+		return fmt.Sprintf("state %q added", c.State)
+	case ChangeStateRemoved:
+		return fmt.Sprintf("state %q removed", c.State)
+	case ChangeStateType:
+		return fmt.Sprintf("state %q changed type from %s to %s", c.State, c.Old, c.New)
+	case ChangeStateParent:
+		return fmt.Sprintf("state %q moved from %q to %q", c.State, c.Old, c.New)
+	case ChangeEntryActions:
+		return fmt.Sprintf("entry actions of %q changed from %v to %v", c.State, c.OldActions, c.NewActions)
+	case ChangeExitActions:
+		return fmt.Sprintf("exit actions of %q changed from %v to %v", c.State, c.OldActions, c.NewActions)
+	case ChangeTransitionAdded:
+		return fmt.Sprintf("transition %s added", edgeString(*c.NewEdge))
+	case ChangeTransitionRemoved:
+		return fmt.Sprintf("transition %s removed", edgeString(*c.OldEdge))
+	case ChangeTransitionChanged:
+		return fmt.Sprintf("transition %s changed to %s", edgeString(*c.OldEdge), edgeString(*c.NewEdge))
+	default:
+		return string(c.Kind)
+	}
+}
+
+// edgeString formats an edge as "from -> to on TRIGGER [guard]"
+func edgeString(edge Edge) string {
+	var trigger string
+	switch {
+	case edge.DelayName != "":
+		trigger = fmt.Sprintf("after %s", edge.DelayName)
+	case edge.Delay > 0:
+		trigger = fmt.Sprintf("after %s", edge.Delay)
+	case edge.Event == "":
+		trigger = "always"
+	default:
+		trigger = string(edge.Event)
+	}
+	s := fmt.Sprintf("%s -> %s on %s", edge.From, edge.To, trigger)
+	if edge.Guard != "" {
+		s += fmt.Sprintf(" [%s]", edge.Guard)
+	}
+	return s
+}
+
+// Diff compares two machine configurations and returns the structural changes
+// that turn a into b: added and removed states, changes to a state's type,
+// parent, initial state, and entry or exit actions, and added, removed, or
+// changed transitions. Only the declared structure is compared; the context
+// and the implementations of actions and guards are not.
+//
+// States are matched by ID, so a renamed state is reported as removed and
+// added. Transitions are matched by their trigger (event, delay, or always),
+// in declaration order among the transitions of a state with the same
+// trigger. Changes are ordered by state, removed states first, then the states
+// of b in declaration order.
+func Diff[C any](a, b *ir.MachineConfig[C]) []Change {
+	var changes []Change
+	if a.Initial != b.Initial {
+		changes = append(changes, Change{Kind: ChangeInitial, Old: string(a.Initial), New: string(b.Initial)})
+	}
+
+	for _, id := range a.StateIDs() {
+		if b.States[id] == nil {
+			changes = append(changes, Change{Kind: ChangeStateRemoved, State: id})
+		}
+	}
+
+	for _, id := range b.StateIDs() {
+		newState := b.States[id]
+		oldState := a.States[id]
+		if oldState == nil {
+			changes = append(changes, Change{Kind: ChangeStateAdded, State: id})
+			for _, trans := range newState.Transitions {
+				edge := ir.NewEdge(id, trans)
+				changes = append(changes, Change{Kind: ChangeTransitionAdded, State: id, NewEdge: &edge, NewActions: trans.Actions})
+			}
+			continue
+		}
+		changes = append(changes, diffState(oldState, newState)...)
+	}
+	return changes
+}
+
+// diffState returns the changes between two versions of a state
+func diffState(a, b *ir.StateConfig) []Change {
+	var changes []Change
+	if a.Type != b.Type {
+		changes = append(changes, Change{Kind: ChangeStateType, State: b.ID, Old: a.Type.String(), New: b.Type.String()})
+	}
+	if a.Parent != b.Parent {
+		changes = append(changes, Change{Kind: ChangeStateParent, State: b.ID, Old: string(a.Parent), New: string(b.Parent)})
+	}
+	if a.Initial != b.Initial {
+		changes = append(changes, Change{Kind: ChangeInitial, State: b.ID, Old: string(a.Initial), New: string(b.Initial)})
+	}
+	if !slices.Equal(a.Entry, b.Entry) {
+		changes = append(changes, Change{Kind: ChangeEntryActions, State: b.ID, OldActions: a.Entry, NewActions: b.Entry})
+	}
+	if !slices.Equal(a.Exit, b.Exit) {
+		changes = append(changes, Change{Kind: ChangeExitActions, State: b.ID, OldActions: a.Exit, NewActions: b.Exit})
+	}
+
+	oldTransitions := keyTransitions(a.Transitions)
+	newTransitions := keyTransitions(b.Transitions)
+	for _, old := range oldTransitions {
+		if !slices.ContainsFunc(newTransitions, old.matches) {
+			edge := ir.NewEdge(a.ID, old.trans)
+			changes = append(changes, Change{Kind: ChangeTransitionRemoved, State: b.ID, OldEdge: &edge, OldActions: old.trans.Actions})
+		}
+	}
+	for _, current := range newTransitions {
+		newEdge := ir.NewEdge(b.ID, current.trans)
+		idx := slices.IndexFunc(oldTransitions, current.matches)
+		switch {
+		case idx < 0:
+			changes = append(changes, Change{Kind: ChangeTransitionAdded, State: b.ID, NewEdge: &newEdge, NewActions: current.trans.Actions})
+		case !reflect.DeepEqual(oldTransitions[idx].trans, current.trans):
+			old := oldTransitions[idx].trans
+			oldEdge := ir.NewEdge(a.ID, old)
+			changes = append(changes, Change{
+				Kind:       ChangeTransitionChanged,
+				State:      b.ID,
+				OldEdge:    &oldEdge,
+				NewEdge:    &newEdge,
+				OldActions: old.Actions,
+				NewActions: current.trans.Actions,
+			})
+		}
+	}
+	return changes
+}
+
+// keyedTransition is a transition and the key Diff matches it by: its trigger
+// and its position among the state's transitions with the same trigger
+type keyedTransition struct {
+	event  EventType
+	always bool
+	delay  time.Duration
+	name   DelayType
+	n      int
+	trans  *ir.TransitionConfig
+}
+
+// matches reports whether two transitions have the same key
+func (k keyedTransition) matches(other keyedTransition) bool {
+	return k.event == other.event && k.always == other.always && k.delay == other.delay &&
+		k.name == other.name && k.n == other.n
+}
+
+// keyTransitions returns the transitions with their keys, in declaration order
+func keyTransitions(transitions []*ir.TransitionConfig) []keyedTransition {
+	keyed := make([]keyedTransition, 0, len(transitions))
+	for _, trans := range transitions {
+		key := keyedTransition{
+			event:  trans.Event,
+			always: trans.Always,
+			delay:  trans.Delay,
+			name:   trans.DelayName,
+			trans:  trans,
+		}
+		for _, prev := range keyed {
+			if prev.matches(key) {
+				key.n++
+			}
+		}
+		keyed = append(keyed, key)
+	}
+	return keyed
+}
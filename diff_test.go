@@ -0,0 +1,113 @@
+package statekit
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDiff_RenamedStateAndAddedTransition tests the changes reported for a modified copy
+func TestDiff_RenamedStateAndAddedTransition(t *testing.T) {
+	a, err := NewMachine[struct{}]("light").
+		WithInitial("green").
+		WithAction("log", func(ctx *struct{}, e Event) {}).
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").OnEntry("log").On("TIMER").Target("red").Done().
+		State("red").On("TIMER").Target("green").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	// 'yellow' is renamed to 'amber', and red gains an EMERGENCY transition
+	b, err := NewMachine[struct{}]("light").
+		WithInitial("green").
+		WithAction("log", func(ctx *struct{}, e Event) {}).
+		State("green").On("TIMER").Target("amber").Done().
+		State("amber").OnEntry("log").On("TIMER").Target("red").Done().
+		State("red").
+		On("TIMER").Target("green").
+		On("EMERGENCY").Target("green").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	var got []string
+	for _, change := range Diff(a, b) {
+		got = append(got, change.String())
+	}
+	expected := []string{
+		`state "yellow" removed`,
+		`transition green -> yellow on TIMER changed to green -> amber on TIMER`,
+		`state "amber" added`,
+		`transition amber -> red on TIMER added`,
+		`transition red -> green on EMERGENCY added`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected changes:\n%q\ngot:\n%q", expected, got)
+	}
+
+	changes := Diff(a, b)
+	if changes[1].Kind != ChangeTransitionChanged || changes[1].State != "green" ||
+		changes[1].OldEdge.To != "yellow" || changes[1].NewEdge.To != "amber" {
+		t.Errorf("Expected changed TIMER transition on green, got %+v", changes[1])
+	}
+	if changes[4].Kind != ChangeTransitionAdded || changes[4].OldEdge != nil || changes[4].NewEdge.Event != "EMERGENCY" {
+		t.Errorf("Expected added EMERGENCY transition, got %+v", changes[4])
+	}
+
+	// Diffing in reverse reports the inverse changes
+	var reverse []ChangeKind
+	for _, change := range Diff(b, a) {
+		reverse = append(reverse, change.Kind)
+	}
+	expectedReverse := []ChangeKind{ChangeStateRemoved, ChangeTransitionChanged, ChangeStateAdded, ChangeTransitionAdded, ChangeTransitionRemoved}
+	if !reflect.DeepEqual(reverse, expectedReverse) {
+		t.Errorf("Expected reverse changes %v, got %v", expectedReverse, reverse)
+	}
+}
+
+// TestDiff_StateChanges tests changes to a state's actions, type, and initial state
+func TestDiff_StateChanges(t *testing.T) {
+	a, err := NewMachine[struct{}]("light").
+		WithInitial("green").
+		WithAction("log", func(ctx *struct{}, e Event) {}).
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").OnEntry("log").On("TIMER").Target("red").Done().
+		State("red").On("TIMER").Target("green").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	if changes := Diff(a, a.Clone()); len(changes) != 0 {
+		t.Errorf("Expected no changes between identical machines, got %v", changes)
+	}
+
+	// The initial state, the actions of 'yellow' and 'red', and the guard of
+	// red's TIMER transition change
+	b, err := NewMachine[struct{}]("light").
+		WithInitial("red").
+		WithAction("log", func(ctx *struct{}, e Event) {}).
+		WithGuard("isNight", func(ctx struct{}, e Event) bool { return true }).
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").On("TIMER").Target("red").Done().
+		State("red").OnExit("log").On("TIMER").Target("green").Guard("isNight").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	expected := []Change{
+		{Kind: ChangeInitial, Old: "green", New: "red"},
+		{Kind: ChangeEntryActions, State: "yellow", OldActions: []ActionType{"log"}},
+		{Kind: ChangeExitActions, State: "red", NewActions: []ActionType{"log"}},
+	}
+	changes := Diff(a, b)
+	if len(changes) != 4 || !reflect.DeepEqual(changes[:3], expected) {
+		t.Fatalf("Expected changes %v plus a changed transition, got %v", expected, changes)
+	}
+	if changes[3].Kind != ChangeTransitionChanged || changes[3].NewEdge.Guard != "isNight" {
+		t.Errorf("Expected guarded TIMER transition on red, got %+v", changes[3])
+	}
+}
@@ -0,0 +1,232 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+)
+
+type doneContext struct {
+	Log []string
+}
+
+// TestDoneState_Compound tests that reaching a final child raises the parent's done event
+func TestDoneState_Compound(t *testing.T) {
+	machine, err := NewMachine[doneContext]("checkout").
+		WithInitial("payment").
+		WithAction("paid", func(ctx *doneContext, e Event) {
+			ctx.Log = append(ctx.Log, string(e.Type))
+		}).
+		State("payment").
+		WithInitial("entering").
+		State("entering").
+		On("SUBMIT").Target("authorizing").End().
+		End().
+		State("authorizing").
+		On("APPROVED").Target("approved").End().
+		End().
+		State("approved").Final().End().
+		OnDone().Target("shipping").Do("paid").
+		Done().
+		State("shipping").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "SUBMIT"})
+	if !interp.Matches("authorizing") {
+		t.Fatalf("Expected 'authorizing', got %s", interp.State().Value)
+	}
+
+	interp.Send(Event{Type: "APPROVED"})
+	if !interp.Matches("shipping") {
+		t.Errorf("Expected 'shipping' after payment completed, got %s", interp.State().Value)
+	}
+
+	expected := []string{"done.state.payment"}
+	if log := interp.State().Context.Log; !slices.Equal(log, expected) {
+		t.Errorf("Expected log %v, got %v", expected, log)
+	}
+}
+
+// TestDoneState_CompoundWithoutHandler tests that an unhandled done event leaves the final state active
+func TestDoneState_CompoundWithoutHandler(t *testing.T) {
+	machine, err := NewMachine[struct{}]("unhandled").
+		WithInitial("task").
+		State("task").
+		WithInitial("working").
+		State("working").
+		On("FINISH").Target("finished").End().
+		End().
+		State("finished").Final().End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "FINISH"})
+
+	if !interp.Matches("finished") {
+		t.Errorf("Expected 'finished', got %s", interp.State().Value)
+	}
+}
+
+// TestDoneState_Nested tests that a compound state nested in another one
+// completes, and raises its done event, independently of its parent
+func TestDoneState_Nested(t *testing.T) {
+	machine, err := NewMachine[doneContext]("order").
+		WithInitial("order").
+		WithAction("log", func(ctx *doneContext, e Event) {
+			ctx.Log = append(ctx.Log, string(e.Type))
+		}).
+		State("order").
+		WithInitial("payment").
+		State("payment").
+		WithInitial("entering").
+		State("entering").
+		On("APPROVED").Target("approved").End().
+		End().
+		State("approved").Final().End().
+		OnDone().Target("shipping").Do("log").End().
+		End().
+		State("shipping").
+		On("SHIPPED").Target("delivered").End().
+		End().
+		State("delivered").Final().End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	if interp.DoneIn("payment") {
+		t.Error("Expected DoneIn to be false before Start")
+	}
+	interp.Start()
+	if interp.DoneIn("payment") || interp.DoneIn("order") {
+		t.Error("Expected no state to be done after Start")
+	}
+
+	// Listeners see payment done in 'approved', before its done event is processed
+	var done []bool
+	interp.Subscribe(func(State[doneContext]) {
+		done = append(done, interp.DoneIn("payment"))
+	})
+	interp.Send(Event{Type: "APPROVED"})
+	if !slices.Equal(done, []bool{true, false}) {
+		t.Errorf("Expected payment to be done in 'approved' and not after leaving it, got %v", done)
+	}
+	if !interp.Matches("shipping") || interp.DoneIn("order") || interp.Done() {
+		t.Errorf("Expected 'shipping' with order not done, got %s", interp.State().Value)
+	}
+
+	interp.Send(Event{Type: "SHIPPED"})
+	if !interp.DoneIn("order") || interp.DoneIn("payment") || interp.DoneIn("shipping") {
+		t.Error("Expected only order to be done in 'delivered'")
+	}
+
+	expected := []string{"done.state.payment"}
+	if log := interp.State().Context.Log; !slices.Equal(log, expected) {
+		t.Errorf("Expected log %v, got %v", expected, log)
+	}
+}
+
+// TestDoneState_Parallel tests that the parallel done event is raised only when every region is final
+func TestDoneState_Parallel(t *testing.T) {
+	machine, err := NewMachine[struct{}]("transfer").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("upload").
+		WithInitial("uploading").
+		State("uploading").
+		On("UPLOADED").Target("uploaded").
+		EndState().
+		State("uploaded").Final().EndState().
+		EndRegion().
+		Region("download").
+		WithInitial("downloading").
+		State("downloading").
+		On("DOWNLOADED").Target("downloaded").
+		EndState().
+		State("downloaded").Final().EndState().
+		EndRegion().
+		OnDone().Target("complete").
+		Done().
+		State("complete").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	// One region finishing is not enough
+	interp.Send(Event{Type: "UPLOADED"})
+	state := interp.State()
+	if state.Value != "active" {
+		t.Fatalf("Expected to stay in 'active', got %s", state.Value)
+	}
+	if state.ActiveInParallel["upload"] != "uploaded" {
+		t.Errorf("Expected upload region 'uploaded', got %s", state.ActiveInParallel["upload"])
+	}
+
+	interp.Send(Event{Type: "DOWNLOADED"})
+	if !interp.Matches("complete") {
+		t.Errorf("Expected 'complete' after both regions finished, got %s", interp.State().Value)
+	}
+	if !interp.Done() {
+		t.Error("Expected interpreter to be done")
+	}
+}
+
+// TestDoneState_RegionDone tests that a region completing raises its own done event
+func TestDoneState_RegionDone(t *testing.T) {
+	machine, err := NewMachine[doneContext]("regions").
+		WithInitial("active").
+		WithAction("record", func(ctx *doneContext, e Event) {
+			ctx.Log = append(ctx.Log, string(e.Type))
+		}).
+		State("active").Parallel().
+		Region("upload").
+		WithInitial("uploading").
+		State("uploading").
+		On("UPLOADED").Target("uploaded").
+		EndState().
+		State("uploaded").Final().EndState().
+		EndRegion().
+		Region("download").
+		WithInitial("downloading").
+		State("downloading").
+		On(DoneStateEvent("upload")).Target("downloading").Internal().Do("record").
+		EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "UPLOADED"})
+
+	expected := []string{"done.state.upload"}
+	if log := interp.State().Context.Log; !slices.Equal(log, expected) {
+		t.Errorf("Expected log %v, got %v", expected, log)
+	}
+}
+
+// TestDoneStateEvent tests the done event naming
+func TestDoneStateEvent(t *testing.T) {
+	if got := DoneStateEvent("payment"); got != "done.state.payment" {
+		t.Errorf("Expected 'done.state.payment', got %s", got)
+	}
+}
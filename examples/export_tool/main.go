@@ -10,6 +10,7 @@
 //	go run main.go -pretty                  # Export all machines (pretty-printed)
 //	go run main.go -machine=traffic -pretty # Export specific machine
 //	go run main.go -o machines.json         # Export to file
+//	go run main.go -validate                # Validate all machines
 package main
 
 import (
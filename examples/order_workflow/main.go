@@ -3,6 +3,7 @@
 // This example shows:
 // - Reflection DSL for machine definition
 // - Guards for conditional transitions
+// - Delayed transitions for abandoned payments
 // - Actions for side effects
 // - Context for order state
 // - XState export for visualization
@@ -42,7 +43,7 @@ type OrderMachine struct {
 	Pending     PendingState
 	Validating  statekit.StateNode `on:"VALID->payment,INVALID->cancelled" entry:"validateOrder"`
 	Payment     statekit.StateNode `on:"PAID->fulfillment/recordPayment,PAYMENT_FAILED->payment_err"`
-	PaymentErr  statekit.StateNode `on:"RETRY->payment,CANCEL->cancelled"`
+	PaymentErr  statekit.StateNode `on:"RETRY->payment,CANCEL->cancelled" after:"24h->cancelled"`
 	Fulfillment statekit.StateNode `on:"SHIPPED->completed/recordShipping,OUT_OF_STOCK->refunding"`
 	Refunding   statekit.StateNode `on:"REFUNDED->refunded" entry:"processRefund"`
 	Completed   statekit.FinalNode
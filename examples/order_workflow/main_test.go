@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/felixgeelhaar/statekit"
 )
@@ -178,3 +181,168 @@ func TestOrderWorkflow_PaymentRetry(t *testing.T) {
 		t.Errorf("expected payment after retry, got %s", interp.State().Value)
 	}
 }
+
+// recordingTracer records every tracer callback as a string
+type recordingTracer struct {
+	events []string
+}
+
+func (r *recordingTracer) OnTransition(from, to statekit.StateID, event statekit.EventType) {
+	r.events = append(r.events, fmt.Sprintf("transition %s->%s on %s", from, to, event))
+}
+
+func (r *recordingTracer) OnActionStart(name statekit.ActionType) {
+	r.events = append(r.events, fmt.Sprintf("action start %s", name))
+}
+
+func (r *recordingTracer) OnActionEnd(name statekit.ActionType) {
+	r.events = append(r.events, fmt.Sprintf("action end %s", name))
+}
+
+func (r *recordingTracer) OnGuardEval(name statekit.GuardType, result bool) {
+	r.events = append(r.events, fmt.Sprintf("guard %s=%v", name, result))
+}
+
+func (r *recordingTracer) OnEventIgnored(event statekit.EventType) {
+	r.events = append(r.events, fmt.Sprintf("ignored %s", event))
+}
+
+func TestOrderWorkflow_Tracer(t *testing.T) {
+	registry := statekit.NewActionRegistry[OrderContext]().
+		WithAction("logPending", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("validateOrder", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("processRefund", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("recordPayment", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("recordShipping", func(ctx *OrderContext, e statekit.Event) {}).
+		WithGuard("hasItems", func(ctx OrderContext, e statekit.Event) bool {
+			return len(ctx.Items) > 0
+		})
+
+	machine, err := statekit.FromStructWithContext[OrderMachine, OrderContext](registry, OrderContext{OrderID: "TEST-005"})
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	tracer := &recordingTracer{}
+	interp := statekit.NewInterpreter(machine, statekit.WithTracer(tracer))
+	interp.Start()
+
+	// Empty cart: the guard blocks SUBMIT
+	interp.Send(statekit.Event{Type: "SUBMIT"})
+
+	interp.UpdateContext(func(ctx *OrderContext) {
+		ctx.Items = append(ctx.Items, OrderItem{SKU: "TEST", Name: "Test", Quantity: 1, Price: 5.00})
+	})
+	interp.Send(statekit.Event{Type: "SUBMIT"})
+	interp.Send(statekit.Event{Type: "SHIPPED"})
+	interp.Send(statekit.Event{Type: "VALID"})
+	interp.Send(statekit.Event{Type: "PAID"})
+
+	expected := []string{
+		"action start logPending",
+		"action end logPending",
+		"guard hasItems=false",
+		"ignored SUBMIT",
+		"guard hasItems=true",
+		"transition pending->validating on SUBMIT",
+		"action start validateOrder",
+		"action end validateOrder",
+		"ignored SHIPPED",
+		"transition validating->payment on VALID",
+		"transition payment->fulfillment on PAID",
+		"action start recordPayment",
+		"action end recordPayment",
+	}
+	if !slices.Equal(tracer.events, expected) {
+		t.Errorf("unexpected trace:\n got: %q\nwant: %q", tracer.events, expected)
+	}
+}
+
+func TestOrderWorkflow_Transitions(t *testing.T) {
+	registry := statekit.NewActionRegistry[OrderContext]().
+		WithAction("logPending", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("validateOrder", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("processRefund", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("recordPayment", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("recordShipping", func(ctx *OrderContext, e statekit.Event) {}).
+		WithGuard("hasItems", func(ctx OrderContext, e statekit.Event) bool {
+			return len(ctx.Items) > 0
+		})
+
+	machine, err := statekit.FromStruct[OrderMachine, OrderContext](registry)
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	edges := machine.Transitions()
+	if len(edges) != 12 {
+		t.Fatalf("expected 12 edges, got %d: %+v", len(edges), edges)
+	}
+
+	// Edges are listed in declaration order, starting with the guarded SUBMIT
+	submit := statekit.Edge{From: "pending", To: "validating", Event: "SUBMIT", Guard: "hasItems"}
+	if edges[0] != submit {
+		t.Errorf("expected first edge %+v, got %+v", submit, edges[0])
+	}
+
+	abandoned := statekit.Edge{From: "payment_err", To: "cancelled", Delay: 24 * time.Hour}
+	if !slices.Contains(edges, abandoned) {
+		t.Errorf("expected delayed edge %+v in %+v", abandoned, edges)
+	}
+
+	for _, edge := range edges {
+		if edge.From == "completed" || edge.From == "cancelled" || edge.From == "refunded" {
+			t.Errorf("expected no edges from final states, got %+v", edge)
+		}
+	}
+}
+
+func TestOrderWorkflow_Paths(t *testing.T) {
+	registry := statekit.NewActionRegistry[OrderContext]().
+		WithAction("logPending", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("validateOrder", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("processRefund", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("recordPayment", func(ctx *OrderContext, e statekit.Event) {}).
+		WithAction("recordShipping", func(ctx *OrderContext, e statekit.Event) {}).
+		WithGuard("hasItems", func(ctx OrderContext, e statekit.Event) bool {
+			return len(ctx.Items) > 0
+		})
+
+	ctx := OrderContext{
+		OrderID: "TEST-006",
+		Items:   []OrderItem{{SKU: "TEST", Name: "Test", Quantity: 1, Price: 5.00}},
+	}
+	machine, err := statekit.FromStructWithContext[OrderMachine, OrderContext](registry, ctx)
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	paths := statekit.ShortestPaths(machine)
+	if len(paths) != len(machine.States) {
+		t.Errorf("expected every state to be reachable, got paths to %d of %d", len(paths), len(machine.States))
+	}
+
+	// Each final state is reached by following its path
+	for _, final := range []statekit.StateID{"completed", "cancelled", "refunded"} {
+		path, ok := paths[final]
+		if !ok {
+			t.Errorf("expected a path to %s", final)
+			continue
+		}
+
+		interp := statekit.NewInterpreter(machine)
+		interp.Replay(path.Events())
+		if interp.State().Value != final || !interp.Done() {
+			t.Errorf("expected path %v to end in %s, got %s", path.Events(), final, interp.State().Value)
+		}
+	}
+
+	completed := []statekit.EventType{"SUBMIT", "VALID", "PAID", "SHIPPED"}
+	var events []statekit.EventType
+	for _, event := range paths["completed"].Events() {
+		events = append(events, event.Type)
+	}
+	if !slices.Equal(events, completed) {
+		t.Errorf("expected shortest path %v to completed, got %v", completed, events)
+	}
+}
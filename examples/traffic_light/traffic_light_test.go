@@ -154,3 +154,34 @@ func TestTrafficLight_NotDone(t *testing.T) {
 		t.Error("traffic light should never be done (no final state)")
 	}
 }
+
+func TestTrafficLight_Paths(t *testing.T) {
+	machine, err := NewTrafficLight()
+	if err != nil {
+		t.Fatalf("failed to create traffic light: %v", err)
+	}
+
+	paths := statekit.ShortestPaths(machine)
+	expected := map[statekit.StateID]int{StateGreen: 0, StateYellow: 1, StateRed: 2}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected paths to %d states, got %v", len(expected), paths)
+	}
+	for state, length := range expected {
+		path := paths[state]
+		if len(path.Edges) != length {
+			t.Errorf("expected a path of %d transitions to %s, got %v", length, state, path.Edges)
+		}
+
+		interp := statekit.NewInterpreter(machine)
+		trace := interp.Replay(path.Events())
+		if last := trace[len(trace)-1]; last != state {
+			t.Errorf("expected the path to %s to end there, got %s", state, last)
+		}
+	}
+
+	// The cycle back to green is not followed
+	simple := statekit.SimplePaths(machine)
+	if len(simple) != 3 {
+		t.Errorf("expected 3 simple paths, got %v", simple)
+	}
+}
@@ -2,10 +2,13 @@ package export
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
 )
 
 // MachineExporter is implemented by types that can export to XState JSON format.
@@ -14,6 +17,14 @@ type MachineExporter interface {
 	Export() (*XStateMachine, error)
 }
 
+// ValidatableMachine is implemented by exporters that can validate the machine
+// they export. XStateExporter[C] implements this interface.
+type ValidatableMachine interface {
+	// Validate returns nil for a valid machine, and otherwise an error,
+	// typically listing validation issues
+	Validate() error
+}
+
 // ExportOptions configures the export behavior.
 type ExportOptions struct {
 	// PrettyPrint enables indented JSON output
@@ -111,8 +122,56 @@ func writeJSON(v any, opts ExportOptions) error {
 	return nil
 }
 
+// ValidateAll validates machines and writes a report to out, one line per
+// machine followed by its issues. It returns an error if any machine is
+// invalid. Machines that do not implement ValidatableMachine are reported as
+// skipped. machineID filters to a specific machine ID (empty = validate all).
+func ValidateAll(machines map[string]MachineExporter, machineID string, out io.Writer) error {
+	ids := sortedKeys(machines)
+	if machineID != "" {
+		if _, ok := machines[machineID]; !ok {
+			return fmt.Errorf("machine %q not found", machineID)
+		}
+		ids = []string{machineID}
+	}
+
+	var invalid int
+	for _, id := range ids {
+		validatable, ok := machines[id].(ValidatableMachine)
+		if !ok {
+			_, _ = fmt.Fprintf(out, "%s: skipped (validation not supported)\n", id)
+			continue
+		}
+		err := validatable.Validate()
+		if err == nil {
+			_, _ = fmt.Fprintf(out, "%s: ok\n", id)
+			continue
+		}
+
+		invalid++
+		var validationErr *ir.ValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Issues) == 0 {
+			_, _ = fmt.Fprintf(out, "%s: invalid\n  - %v\n", id, err)
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "%s: %d issue(s)\n", id, len(validationErr.Issues))
+		for _, issue := range validationErr.Issues {
+			_, _ = fmt.Fprintf(out, "  - %s\n", issue)
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("validation failed for %d machine(s)", invalid)
+	}
+	return nil
+}
+
 // RunCLI provides a simple CLI for exporting machines.
 // Usage: go run export_tool.go [-pretty] [-indent=STR] [-machine=ID] [-o=FILE]
+//
+// With -validate, machines are validated instead of exported and RunCLI
+// returns an error if any is invalid, so that a caller exiting on error exits
+// with status 1.
 func RunCLI(machines map[string]MachineExporter, args []string) error {
 	fs := flag.NewFlagSet("statekit-export", flag.ContinueOnError)
 
@@ -121,6 +180,7 @@ func RunCLI(machines map[string]MachineExporter, args []string) error {
 	machineID := fs.String("machine", "", "Export only this machine ID")
 	output := fs.String("o", "", "Output file (default: stdout)")
 	list := fs.Bool("list", false, "List available machine IDs")
+	validate := fs.Bool("validate", false, "Validate machines instead of exporting them")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -135,6 +195,11 @@ func RunCLI(machines map[string]MachineExporter, args []string) error {
 		return nil
 	}
 
+	// Validate mode
+	if *validate {
+		return ValidateAll(machines, *machineID, os.Stdout)
+	}
+
 	// Build options
 	opts := ExportOptions{
 		PrettyPrint: *pretty,
@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/felixgeelhaar/statekit"
+	"github.com/felixgeelhaar/statekit/internal/ir"
 )
 
 // mockExporter implements MachineExporter for testing
@@ -269,6 +272,95 @@ func TestRunCLI_InvalidFlag(t *testing.T) {
 	}
 }
 
+// validationMachines returns a valid machine, a machine whose transition
+// targets a missing state, and a machine that cannot be validated
+func validationMachines(t *testing.T) map[string]MachineExporter {
+	t.Helper()
+	valid, err := statekit.NewMachine[struct{}]("valid").
+		WithInitial("idle").
+		State("idle").On("GO").Target("done").Done().
+		State("done").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	invalid := ir.NewMachineConfig("invalid", "idle", struct{}{})
+	idle := ir.NewStateConfig("idle", ir.StateTypeAtomic)
+	idle.Transitions = append(idle.Transitions, ir.NewTransitionConfig("GO", "missing"))
+	invalid.States["idle"] = idle
+
+	return map[string]MachineExporter{
+		"valid":   NewXStateExporter(valid),
+		"invalid": NewXStateExporter(invalid),
+		"mock":    &mockExporter{id: "mock", initial: "idle"},
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	machines := validationMachines(t)
+
+	var buf bytes.Buffer
+	err := ValidateAll(machines, "", &buf)
+	if err == nil {
+		t.Fatal("expected error for an invalid machine")
+	}
+
+	expected := "invalid: 1 issue(s)\n" +
+		"  - [INVALID_TARGET] transition target 'missing' not found (at states.idle.transitions.0)\n" +
+		"mock: skipped (validation not supported)\n" +
+		"valid: ok\n"
+	if buf.String() != expected {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// Filtering to the valid machine succeeds
+	buf.Reset()
+	if err := ValidateAll(machines, "valid", &buf); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if buf.String() != "valid: ok\n" {
+		t.Errorf("expected only the valid machine, got %q", buf.String())
+	}
+
+	if err := ValidateAll(machines, "nonexistent", &buf); err == nil {
+		t.Error("expected error for a missing machine")
+	}
+}
+
+func TestRunCLI_Validate(t *testing.T) {
+	machines := validationMachines(t)
+
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := RunCLI(machines, []string{"-validate"})
+	validErr := RunCLI(machines, []string{"-validate", "-machine=valid"})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	if err == nil {
+		t.Error("expected error for an invalid machine")
+	}
+	if validErr != nil {
+		t.Errorf("unexpected error for a valid machine: %v", validErr)
+	}
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	output := buf.String()
+
+	if !strings.Contains(output, "INVALID_TARGET") {
+		t.Error("expected the validation issue in output")
+	}
+	if strings.Contains(output, `"states"`) {
+		t.Error("expected no JSON export in validate mode")
+	}
+}
+
 func TestDefaultExportOptions(t *testing.T) {
 	opts := DefaultExportOptions()
 
@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ConstantsOptions configures GenerateConstants.
+type ConstantsOptions struct {
+	// Package is the package clause of the generated file (required)
+	Package string
+
+	// Prefix is prepended to constant names, e.g. "Order" gives
+	// OrderStatePaid and OrderEventSubmit (default: none)
+	Prefix string
+
+	// Generator names the tool in the generated-code header
+	// (default: "statekit-gen")
+	Generator string
+}
+
+// Event types raised by the interpreter itself, which have no constant:
+// build them with statekit.DoneStateEvent, DoneInvokeEvent, and ErrorInvokeEvent
+var reservedEventPrefixes = []string{"done.state.", "done.invoke.", "error.invoke."}
+
+// GenerateConstants returns gofmt-formatted Go source declaring a typed
+// constant for every state and event of the exported machine, so that code
+// driving the machine does not repeat string IDs:
+//
+//	const (
+//		StateGreen statekit.StateID = "green"
+//	)
+//	const (
+//		EventTimer statekit.EventType = "TIMER"
+//	)
+//
+// Names are "State" or "Event" followed by the ID in CamelCase, splitting it on
+// characters that cannot appear in identifiers. Events are the keys of "on"
+// transitions; the wildcard event and the done and error events raised by the
+// interpreter are left out. Constants are sorted by name. IDs that map to the
+// same name are an error.
+func GenerateConstants(exporter MachineExporter, opts ConstantsOptions) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+	generator := opts.Generator
+	if generator == "" {
+		generator = "statekit-gen"
+	}
+
+	machine, err := exporter.Export()
+	if err != nil {
+		return nil, fmt.Errorf("export failed: %w", err)
+	}
+
+	states := make(map[string]bool)
+	events := make(map[string]bool)
+	collectConstants(machine.States, states, events)
+	if len(states) == 0 {
+		return nil, fmt.Errorf("machine %q has no states", machine.ID)
+	}
+
+	stateConsts, err := constantNames(opts.Prefix+"State", states)
+	if err != nil {
+		return nil, err
+	}
+	eventConsts, err := constantNames(opts.Prefix+"Event", events)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by %s. DO NOT EDIT.\n\n", generator)
+	fmt.Fprintf(&b, "package %s\n\n", opts.Package)
+	b.WriteString("import \"github.com/felixgeelhaar/statekit\"\n")
+	writeConstBlock(&b, fmt.Sprintf("States of the %s machine", machine.ID), "statekit.StateID", stateConsts)
+	writeConstBlock(&b, fmt.Sprintf("Events of the %s machine", machine.ID), "statekit.EventType", eventConsts)
+
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w", err)
+	}
+	return source, nil
+}
+
+// collectConstants adds the IDs of nodes and their descendants to states and
+// the events of their transitions to events
+func collectConstants(nodes map[string]XStateNode, states, events map[string]bool) {
+	for id, node := range nodes {
+		states[id] = true
+		for event := range node.On {
+			if !isReservedEvent(event) {
+				events[event] = true
+			}
+		}
+		collectConstants(node.States, states, events)
+	}
+}
+
+// isReservedEvent reports whether an event type is the wildcard or raised by the interpreter
+func isReservedEvent(event string) bool {
+	if event == "*" {
+		return true
+	}
+	for _, prefix := range reservedEventPrefixes {
+		if strings.HasPrefix(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedConst is a constant declared by GenerateConstants
+type generatedConst struct {
+	name  string
+	value string
+}
+
+// constantNames names each value with the given prefix, sorted by name
+func constantNames(prefix string, values map[string]bool) ([]generatedConst, error) {
+	byName := make(map[string]string, len(values))
+	for value := range values {
+		name := prefix + camelCase(value)
+		if other, ok := byName[name]; ok {
+			if other > value {
+				other, value = value, other
+			}
+			return nil, fmt.Errorf("%q and %q both map to constant %s", other, value, name)
+		}
+		byName[name] = value
+	}
+
+	consts := make([]generatedConst, 0, len(byName))
+	for _, name := range sortedKeys(byName) {
+		consts = append(consts, generatedConst{name: name, value: byName[name]})
+	}
+	return consts, nil
+}
+
+// camelCase converts an ID such as "dont_walk" or "PEDESTRIAN_BUTTON" to
+// "DontWalk" or "PedestrianButton". Words are split on characters other than
+// letters and digits; words in upper case are lowered after their first letter,
+// while words in mixed case keep it ("checkOut" gives "CheckOut").
+func camelCase(id string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(id, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune(word)
+		if strings.ToUpper(word) == word {
+			runes = []rune(strings.ToLower(word))
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// writeConstBlock writes a documented const block, omitted when there are no constants
+func writeConstBlock(b *bytes.Buffer, doc, typ string, consts []generatedConst) {
+	if len(consts) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n// %s\nconst (\n", doc)
+	for _, c := range consts {
+		fmt.Fprintf(b, "\t%s %s = %s\n", c.name, typ, strconv.Quote(c.value))
+	}
+	b.WriteString(")\n")
+}
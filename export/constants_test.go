@@ -0,0 +1,133 @@
+package export
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit"
+)
+
+// TestGenerateConstants tests that the generated file type-checks and
+// declares a constant with the right type and value for each state and event
+func TestGenerateConstants(t *testing.T) {
+	// Done and wildcard transitions declare no event constants
+	machine, err := statekit.NewMachine[struct{}]("pedestrian").
+		WithInitial("active").
+		State("active").
+		WithInitial("dont_walk").
+		On("ENTER_MAINTENANCE").Target("maintenance").End().
+		On(statekit.WildcardEvent).Target("maintenance").End().
+		State("dont_walk").
+		On("PEDESTRIAN_BUTTON").Target("walk").End().
+		End().
+		State("walk").Final().End().
+		OnDone().Target("maintenance").End().
+		Done().
+		State("maintenance").
+		On("EXIT_MAINTENANCE").Target("active").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	source, err := GenerateConstants(NewXStateExporter(machine), ConstantsOptions{Package: "pedestrian"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(source), "// Code generated by statekit-gen. DO NOT EDIT.\n") {
+		t.Errorf("expected a generated-code header, got:\n%s", source)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "pedestrian_states.go", source, 0)
+	if err != nil {
+		t.Fatalf("generated file does not parse: %v\n%s", err, source)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("pedestrian", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("generated file does not compile: %v\n%s", err, source)
+	}
+
+	expected := map[string]struct{ typ, value string }{
+		"StateActive":           {"StateID", "active"},
+		"StateDontWalk":         {"StateID", "dont_walk"},
+		"StateWalk":             {"StateID", "walk"},
+		"StateMaintenance":      {"StateID", "maintenance"},
+		"EventEnterMaintenance": {"EventType", "ENTER_MAINTENANCE"},
+		"EventPedestrianButton": {"EventType", "PEDESTRIAN_BUTTON"},
+		"EventExitMaintenance":  {"EventType", "EXIT_MAINTENANCE"},
+	}
+	if names := pkg.Scope().Names(); len(names) != len(expected) {
+		t.Errorf("expected %d constants, got %v", len(expected), names)
+	}
+	for name, want := range expected {
+		c, ok := pkg.Scope().Lookup(name).(*types.Const)
+		if !ok {
+			t.Errorf("expected constant %s", name)
+			continue
+		}
+		if typ := types.Unalias(c.Type()).String(); typ != "github.com/felixgeelhaar/statekit/internal/ir."+want.typ {
+			t.Errorf("%s: expected type %s, got %s", name, want.typ, typ)
+		}
+		if value := constant.StringVal(c.Val()); value != want.value {
+			t.Errorf("%s: expected %q, got %q", name, want.value, value)
+		}
+	}
+}
+
+// TestGenerateConstants_Options tests the name prefix and reported errors
+func TestGenerateConstants_Options(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("pedestrian").
+		WithInitial("dont_walk").
+		State("dont_walk").On("PEDESTRIAN_BUTTON").Target("walk").Done().
+		State("walk").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+	exporter := NewXStateExporter(machine)
+
+	source, err := GenerateConstants(exporter, ConstantsOptions{Package: "lights", Prefix: "Ped", Generator: "mygen"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"// Code generated by mygen.", "package lights", "PedStateWalk ", "PedEventPedestrianButton "} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("expected %q in output:\n%s", want, source)
+		}
+	}
+
+	if _, err := GenerateConstants(exporter, ConstantsOptions{}); err == nil {
+		t.Error("expected error without a package")
+	}
+
+	colliding := &XStateMachine{ID: "colliding", States: map[string]XStateNode{"dont_walk": {}, "dont-walk": {}}}
+	_, err = GenerateConstants(colliding, ConstantsOptions{Package: "p"})
+	if err == nil || !strings.Contains(err.Error(), "StateDontWalk") {
+		t.Errorf("expected a name collision error, got %v", err)
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"green":             "Green",
+		"dont_walk":         "DontWalk",
+		"PEDESTRIAN_BUTTON": "PedestrianButton",
+		"checkOut":          "CheckOut",
+		"user.login-v2":     "UserLoginV2",
+		"3d":                "3d",
+	}
+	for id, want := range tests {
+		if got := camelCase(id); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// DOTExporter converts a MachineConfig to a Graphviz DOT digraph.
+// - Atomic states are rounded boxes, final states are double circles
+// - Compound and parallel states are drawn as clusters
+// - Transitions are edges labeled "EVENT [guard] / actions"
+type DOTExporter[C any] struct {
+	machine *ir.MachineConfig[C]
+}
+
+// NewDOTExporter creates a new DOT exporter for the given machine configuration
+func NewDOTExporter[C any](machine *ir.MachineConfig[C]) *DOTExporter[C] {
+	return &DOTExporter[C]{machine: machine}
+}
+
+// dotIndent is the indentation used per nesting level
+const dotIndent = "  "
+
+// dotStartNode is the ID of the synthetic initial pseudo-state node
+const dotStartNode = "__start"
+
+// Export returns the machine as Graphviz DOT source
+func (e *DOTExporter[C]) Export() (string, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(e.machine.ID))
+	b.WriteString(dotIndent + "compound=true;\n")
+	b.WriteString(dotIndent + "node [shape=box, style=rounded];\n")
+	fmt.Fprintf(&b, "%s%s [shape=point];\n", dotIndent, dotQuote(dotStartNode))
+
+	roots := rootStates(e.machine)
+	for _, stateID := range roots {
+		e.writeState(&b, stateID, 1)
+	}
+
+	// Initial edge
+	if e.machine.Initial != "" {
+		b.WriteString(dotIndent + e.edge(dotStartNode, e.machine.Initial, "") + ";\n")
+	}
+
+	// Transition edges, in depth-first declaration order
+	var writeEdges func(id ir.StateID)
+	writeEdges = func(id ir.StateID) {
+		state := e.machine.States[id]
+		if state == nil {
+			return
+		}
+		for _, trans := range state.Transitions {
+			b.WriteString(dotIndent + e.edge(id, trans.Target, dotLabel(trans)) + ";\n")
+		}
+		for _, childID := range state.Children {
+			writeEdges(childID)
+		}
+	}
+	for _, stateID := range roots {
+		writeEdges(stateID)
+	}
+
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// writeState writes a node for atomic, final, and history states,
+// or a cluster subgraph for compound and parallel states
+func (e *DOTExporter[C]) writeState(b *strings.Builder, stateID ir.StateID, depth int) {
+	state := e.machine.States[stateID]
+	if state == nil {
+		return
+	}
+	indent := strings.Repeat(dotIndent, depth)
+
+	if len(state.Children) > 0 && (state.Type == ir.StateTypeCompound || state.Type == ir.StateTypeParallel) {
+		fmt.Fprintf(b, "%ssubgraph %s {\n", indent, dotQuote(dotClusterID(stateID)))
+		fmt.Fprintf(b, "%s%slabel=%s;\n", indent, dotIndent, dotQuote(string(stateID)))
+		if state.Type == ir.StateTypeParallel {
+			fmt.Fprintf(b, "%s%sstyle=dashed;\n", indent, dotIndent)
+		}
+		// Initial child marker for compound states
+		if state.Type == ir.StateTypeCompound && state.Initial != "" {
+			startID := dotStartNode + "_" + string(stateID)
+			fmt.Fprintf(b, "%s%s%s [shape=point];\n", indent, dotIndent, dotQuote(startID))
+		}
+		for _, childID := range state.Children {
+			e.writeState(b, childID, depth+1)
+		}
+		if state.Type == ir.StateTypeCompound && state.Initial != "" {
+			startID := dotStartNode + "_" + string(stateID)
+			fmt.Fprintf(b, "%s%s%s;\n", indent, dotIndent, e.edge(ir.StateID(startID), state.Initial, ""))
+		}
+		fmt.Fprintf(b, "%s}\n", indent)
+		return
+	}
+
+	switch state.Type {
+	case ir.StateTypeFinal:
+		fmt.Fprintf(b, "%s%s [shape=doublecircle];\n", indent, dotQuote(string(stateID)))
+	case ir.StateTypeHistory:
+		label := "H"
+		if state.HistoryType == ir.HistoryTypeDeep {
+			label = "H*"
+		}
+		fmt.Fprintf(b, "%s%s [shape=circle, label=%s];\n", indent, dotQuote(string(stateID)), dotQuote(label))
+	default:
+		fmt.Fprintf(b, "%s%s;\n", indent, dotQuote(string(stateID)))
+	}
+}
+
+// edge formats an edge between two states. Graphviz edges must connect nodes,
+// so edges to or from clusters attach to the cluster's initial leaf and use
+// lhead/ltail to clip at the cluster border.
+func (e *DOTExporter[C]) edge(from, to ir.StateID, label string) string {
+	var attrs []string
+
+	fromNode := from
+	if e.isCluster(from) {
+		fromNode = e.anchor(from)
+		attrs = append(attrs, "ltail="+dotQuote(dotClusterID(from)))
+	}
+	toNode := to
+	if e.isCluster(to) {
+		toNode = e.anchor(to)
+		attrs = append(attrs, "lhead="+dotQuote(dotClusterID(to)))
+	}
+	if label != "" {
+		attrs = append([]string{"label=" + dotQuote(label)}, attrs...)
+	}
+
+	line := fmt.Sprintf("%s -> %s", dotQuote(string(fromNode)), dotQuote(string(toNode)))
+	if len(attrs) > 0 {
+		line += " [" + strings.Join(attrs, ", ") + "]"
+	}
+	return line
+}
+
+// isCluster returns true if the state is drawn as a cluster subgraph
+func (e *DOTExporter[C]) isCluster(stateID ir.StateID) bool {
+	state := e.machine.States[stateID]
+	return state != nil && len(state.Children) > 0 &&
+		(state.Type == ir.StateTypeCompound || state.Type == ir.StateTypeParallel)
+}
+
+// anchor returns a node inside a cluster that edges can attach to
+func (e *DOTExporter[C]) anchor(stateID ir.StateID) ir.StateID {
+	state := e.machine.States[stateID]
+	for state != nil && e.isCluster(state.ID) {
+		next := state.Initial
+		if next == "" {
+			next = state.Children[0]
+		}
+		state = e.machine.States[next]
+	}
+	if state == nil {
+		return stateID
+	}
+	return state.ID
+}
+
+// dotLabel formats a transition label as "EVENT [guard] / actions"
+func dotLabel(trans *ir.TransitionConfig) string {
+	label := triggerLabel(trans)
+	if len(trans.Actions) > 0 {
+		actions := make([]string, len(trans.Actions))
+		for i, action := range trans.Actions {
+			actions[i] = string(action)
+		}
+		label += " / " + strings.Join(actions, ", ")
+	}
+	return label
+}
+
+// dotClusterID returns the subgraph ID for a compound or parallel state.
+// Graphviz only draws subgraphs as clusters when their ID starts with "cluster".
+func dotClusterID(stateID ir.StateID) string {
+	return "cluster_" + string(stateID)
+}
+
+// dotQuote returns s as a quoted DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit"
+	pedestrianlight "github.com/felixgeelhaar/statekit/examples/pedestrian_light"
+)
+
+// assertDOTStructure checks that the output is a single digraph with
+// balanced braces and that every statement is terminated
+func assertDOTStructure(t *testing.T, dot string) {
+	t.Helper()
+
+	if !strings.HasPrefix(dot, "digraph ") {
+		t.Errorf("expected output to start with 'digraph', got:\n%s", dot)
+	}
+
+	depth := 0
+	for _, line := range strings.Split(strings.TrimSpace(dot), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasSuffix(line, "{"):
+			depth++
+		case line == "}":
+			depth--
+			if depth < 0 {
+				t.Fatalf("unbalanced closing brace in:\n%s", dot)
+			}
+		case !strings.HasSuffix(line, ";"):
+			t.Errorf("unterminated statement %q", line)
+		}
+	}
+	if depth != 0 {
+		t.Errorf("expected balanced braces, got depth %d", depth)
+	}
+}
+
+func TestDOTExporter_PedestrianLight(t *testing.T) {
+	machine, err := pedestrianlight.NewPedestrianLight()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewDOTExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	assertDOTStructure(t, result)
+	for _, want := range []string{
+		`digraph "pedestrian_signal" {`,
+		`"dont_walk" -> "walk" [label="PEDESTRIAN_BUTTON"];`,
+		`subgraph "cluster_active" {`,
+		`label="active";`,
+		`"maintenance";`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestDOTExporter_LabelsAndFinal(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("order").
+		WithInitial("pending").
+		WithGuard("hasItems", func(ctx struct{}, e statekit.Event) bool { return true }).
+		WithAction("charge", func(ctx *struct{}, e statekit.Event) {}).
+		WithAction("notify", func(ctx *struct{}, e statekit.Event) {}).
+		State("pending").
+		On("SUBMIT").Target("done").Guard("hasItems").Do("charge").Do("notify").
+		After(1 * time.Second).Target("expired").
+		Done().
+		State("done").Final().Done().
+		State("expired").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewDOTExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	assertDOTStructure(t, result)
+	for _, want := range []string{
+		`"__start" -> "pending";`,
+		`"pending";`,
+		`"done" [shape=doublecircle];`,
+		`"expired" [shape=doublecircle];`,
+		`"pending" -> "done" [label="SUBMIT [hasItems] / charge, notify"];`,
+		`"pending" -> "expired" [label="after 1000ms"];`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestDOTExporter_Clusters(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("editor").
+		WithInitial("editing").
+		State("editing").
+		WithInitial("draft").
+		On("CLOSE").Target("closed").End().
+		History("hist").Deep().Default("draft").End().
+		State("draft").On("REVIEW").Target("review").End().End().
+		State("review").End().
+		Done().
+		State("closed").
+		On("REOPEN").Target("editing").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewDOTExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	assertDOTStructure(t, result)
+	for _, want := range []string{
+		`subgraph "cluster_editing" {`,
+		`"__start_editing" [shape=point];`,
+		`"__start_editing" -> "draft";`,
+		`"hist" [shape=circle, label="H*"];`,
+		`"draft" -> "review" [label="REVIEW"];`,
+		// Edges to and from clusters attach to the initial leaf
+		`"draft" -> "closed" [label="CLOSE", ltail="cluster_editing"];`,
+		`"closed" -> "draft" [label="REOPEN", lhead="cluster_editing"];`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
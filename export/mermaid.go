@@ -0,0 +1,109 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// MermaidExporter converts a MachineConfig to a Mermaid stateDiagram-v2.
+// The output renders natively in GitHub and GitLab Markdown:
+// - Compound states become nested `state X { ... }` blocks
+// - Parallel regions are separated with `--`
+// - History states are drawn as [H] (shallow) and [H*] (deep)
+type MermaidExporter[C any] struct {
+	machine *ir.MachineConfig[C]
+}
+
+// NewMermaidExporter creates a new Mermaid exporter for the given machine configuration
+func NewMermaidExporter[C any](machine *ir.MachineConfig[C]) *MermaidExporter[C] {
+	return &MermaidExporter[C]{machine: machine}
+}
+
+// mermaidIndent is the indentation used per nesting level
+const mermaidIndent = "    "
+
+// Export returns the machine as Mermaid stateDiagram-v2 source
+func (e *MermaidExporter[C]) Export() (string, error) {
+	scoped := scopedTransitions(e.machine, func(source ir.StateID, trans *ir.TransitionConfig) string {
+		return fmt.Sprintf("%s --> %s : %s", source, trans.Target, triggerLabel(trans))
+	})
+
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	e.writeScope(&b, "", e.machine.Initial, rootStates(e.machine), scoped, 1)
+	return b.String(), nil
+}
+
+// writeScope writes the initial marker, child states, final markers, and
+// transitions that belong to a single scope (the root or a compound state)
+func (e *MermaidExporter[C]) writeScope(b *strings.Builder, scopeID, initial ir.StateID, children []ir.StateID, scoped map[ir.StateID][]string, depth int) {
+	indent := strings.Repeat(mermaidIndent, depth)
+
+	if initial != "" {
+		fmt.Fprintf(b, "%s[*] --> %s\n", indent, initial)
+	}
+
+	var finals []ir.StateID
+	for _, childID := range children {
+		child := e.machine.States[childID]
+		if child == nil {
+			continue
+		}
+		switch child.Type {
+		case ir.StateTypeCompound:
+			if len(child.Children) > 0 {
+				fmt.Fprintf(b, "%sstate %s {\n", indent, childID)
+				e.writeScope(b, childID, child.Initial, child.Children, scoped, depth+1)
+				fmt.Fprintf(b, "%s}\n", indent)
+			}
+		case ir.StateTypeParallel:
+			fmt.Fprintf(b, "%sstate %s {\n", indent, childID)
+			e.writeRegions(b, child, scoped, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+		case ir.StateTypeHistory:
+			marker := "[H]"
+			if child.HistoryType == ir.HistoryTypeDeep {
+				marker = "[H*]"
+			}
+			fmt.Fprintf(b, "%sstate \"%s\" as %s\n", indent, marker, childID)
+			if child.HistoryDefault != "" {
+				fmt.Fprintf(b, "%s%s --> %s\n", indent, childID, child.HistoryDefault)
+			}
+		case ir.StateTypeFinal:
+			finals = append(finals, childID)
+		}
+	}
+
+	for _, line := range scoped[scopeID] {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+
+	for _, finalID := range finals {
+		fmt.Fprintf(b, "%s%s --> [*]\n", indent, finalID)
+	}
+}
+
+// writeRegions writes each region of a parallel state, separated by "--"
+func (e *MermaidExporter[C]) writeRegions(b *strings.Builder, parallel *ir.StateConfig, scoped map[ir.StateID][]string, depth int) {
+	indent := strings.Repeat(mermaidIndent, depth)
+
+	for idx, regionID := range parallel.Children {
+		if idx > 0 {
+			fmt.Fprintf(b, "%s--\n", indent)
+		}
+		region := e.machine.States[regionID]
+		if region == nil {
+			continue
+		}
+		fmt.Fprintf(b, "%sstate %s {\n", indent, regionID)
+		e.writeScope(b, regionID, region.Initial, region.Children, scoped, depth+1)
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+
+	// Transitions between regions or on the parallel state's own children
+	for _, line := range scoped[parallel.ID] {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+}
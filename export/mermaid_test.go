@@ -0,0 +1,136 @@
+package export
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit"
+	pedestrianlight "github.com/felixgeelhaar/statekit/examples/pedestrian_light"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares output against a file in testdata, rewriting it with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestMermaidExporter_PedestrianLightGolden(t *testing.T) {
+	machine, err := pedestrianlight.NewPedestrianLight()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewMermaidExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	assertGolden(t, "pedestrian_light.mmd", result)
+}
+
+func TestMermaidExporter_FinalAndGuards(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("order").
+		WithInitial("pending").
+		WithGuard("hasItems", func(ctx struct{}, e statekit.Event) bool { return true }).
+		State("pending").
+		On("SUBMIT").Target("done").Guard("hasItems").
+		After(2 * time.Second).Target("expired").
+		Done().
+		State("done").Final().Done().
+		State("expired").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewMermaidExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	for _, want := range []string{
+		"stateDiagram-v2\n",
+		"    [*] --> pending\n",
+		"    pending --> done : SUBMIT [hasItems]\n",
+		"    pending --> expired : after 2000ms\n",
+		"    done --> [*]\n",
+		"    expired --> [*]\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestMermaidExporter_HistoryAndParallel(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("player").
+		WithInitial("active").
+		State("active").
+		WithInitial("playing").
+		On("STOP").Target("stopped").End().
+		History("hist").Shallow().Default("playing").End().
+		History("deepHist").Deep().Default("playing").End().
+		State("playing").On("PAUSE").Target("paused").End().End().
+		State("paused").End().
+		Done().
+		State("stopped").
+		On("RESUME").Target("hist").
+		On("START").Target("running").
+		Done().
+		State("running").Parallel().
+		Region("audio").
+		WithInitial("muted").
+		State("muted").On("UNMUTE").Target("loud").EndState().
+		State("loud").EndState().
+		EndRegion().
+		Region("video").
+		WithInitial("hidden").
+		State("hidden").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewMermaidExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	for _, want := range []string{
+		"        state \"[H]\" as hist\n",
+		"        hist --> playing\n",
+		"        state \"[H*]\" as deepHist\n",
+		"    stopped --> hist : RESUME\n",
+		"    state running {\n",
+		"        state audio {\n",
+		"            muted --> loud : UNMUTE\n",
+		"        --\n",
+		"        state video {\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// PlantUMLExporter converts a MachineConfig to a PlantUML state diagram:
+// - Compound states become nested `state X { ... }` blocks
+// - Parallel regions are separated with `--`
+// - History states are drawn as the parent's [H] (shallow) and [H*] (deep) pseudo-states
+type PlantUMLExporter[C any] struct {
+	machine *ir.MachineConfig[C]
+}
+
+// NewPlantUMLExporter creates a new PlantUML exporter for the given machine configuration
+func NewPlantUMLExporter[C any](machine *ir.MachineConfig[C]) *PlantUMLExporter[C] {
+	return &PlantUMLExporter[C]{machine: machine}
+}
+
+// plantUMLIndent is the indentation used per nesting level
+const plantUMLIndent = "  "
+
+// Export returns the machine as PlantUML source, from @startuml to @enduml
+func (e *PlantUMLExporter[C]) Export() (string, error) {
+	scoped := scopedTransitions(e.machine, func(source ir.StateID, trans *ir.TransitionConfig) string {
+		return fmt.Sprintf("%s --> %s : %s", e.node(source), e.node(trans.Target), triggerLabel(trans))
+	})
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	e.writeScope(&b, "", e.machine.Initial, rootStates(e.machine), scoped, 0)
+	b.WriteString("@enduml\n")
+	return b.String(), nil
+}
+
+// node returns the diagram name of a state. PlantUML has no standalone history
+// states, so a history state is referred to as its parent's [H] or [H*].
+func (e *PlantUMLExporter[C]) node(id ir.StateID) string {
+	state := e.machine.States[id]
+	if state == nil || state.Type != ir.StateTypeHistory {
+		return string(id)
+	}
+	if state.HistoryType == ir.HistoryTypeDeep {
+		return string(state.Parent) + "[H*]"
+	}
+	return string(state.Parent) + "[H]"
+}
+
+// writeScope writes the initial marker, child states, transitions, and final
+// markers that belong to a single scope (the root or a compound state)
+func (e *PlantUMLExporter[C]) writeScope(b *strings.Builder, scopeID, initial ir.StateID, children []ir.StateID, scoped map[ir.StateID][]string, depth int) {
+	indent := strings.Repeat(plantUMLIndent, depth)
+
+	if initial != "" {
+		fmt.Fprintf(b, "%s[*] --> %s\n", indent, e.node(initial))
+	}
+
+	var finals []ir.StateID
+	for _, childID := range children {
+		child := e.machine.States[childID]
+		if child == nil {
+			continue
+		}
+		switch child.Type {
+		case ir.StateTypeCompound:
+			if len(child.Children) == 0 {
+				fmt.Fprintf(b, "%sstate %s\n", indent, childID)
+				continue
+			}
+			fmt.Fprintf(b, "%sstate %s {\n", indent, childID)
+			e.writeScope(b, childID, child.Initial, child.Children, scoped, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+		case ir.StateTypeParallel:
+			fmt.Fprintf(b, "%sstate %s {\n", indent, childID)
+			e.writeRegions(b, child, scoped, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+		case ir.StateTypeHistory:
+			if child.HistoryDefault != "" {
+				fmt.Fprintf(b, "%s%s --> %s\n", indent, e.node(childID), e.node(child.HistoryDefault))
+			}
+		case ir.StateTypeFinal:
+			fmt.Fprintf(b, "%sstate %s\n", indent, childID)
+			finals = append(finals, childID)
+		default:
+			fmt.Fprintf(b, "%sstate %s\n", indent, childID)
+		}
+	}
+
+	for _, line := range scoped[scopeID] {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+
+	for _, finalID := range finals {
+		fmt.Fprintf(b, "%s%s --> [*]\n", indent, finalID)
+	}
+}
+
+// writeRegions writes each region of a parallel state, separated by "--"
+func (e *PlantUMLExporter[C]) writeRegions(b *strings.Builder, parallel *ir.StateConfig, scoped map[ir.StateID][]string, depth int) {
+	indent := strings.Repeat(plantUMLIndent, depth)
+
+	for idx, regionID := range parallel.Children {
+		if idx > 0 {
+			fmt.Fprintf(b, "%s--\n", indent)
+		}
+		region := e.machine.States[regionID]
+		if region == nil {
+			continue
+		}
+		fmt.Fprintf(b, "%sstate %s {\n", indent, regionID)
+		e.writeScope(b, regionID, region.Initial, region.Children, scoped, depth+1)
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+
+	// Transitions between regions or on the parallel state's own children
+	for _, line := range scoped[parallel.ID] {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+}
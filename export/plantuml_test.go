@@ -0,0 +1,80 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit"
+	pedestrianlight "github.com/felixgeelhaar/statekit/examples/pedestrian_light"
+)
+
+func TestPlantUMLExporter_PedestrianLightGolden(t *testing.T) {
+	machine, err := pedestrianlight.NewPedestrianLight()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewPlantUMLExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	assertGolden(t, "pedestrian_light.puml", result)
+}
+
+func TestPlantUMLExporter_ParallelGolden(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("player").
+		WithInitial("active").
+		WithGuard("licensed", func(ctx struct{}, e statekit.Event) bool { return true }).
+		State("active").
+		WithInitial("playing").
+		On("STOP").Target("stopped").End().
+		History("hist").Shallow().Default("playing").End().
+		History("deepHist").Deep().Default("playing").End().
+		State("playing").On("PAUSE").Target("paused").End().End().
+		State("paused").End().
+		Done().
+		State("stopped").
+		On("RESUME").Target("hist").
+		On("START").Target("running").Guard("licensed").
+		On("EJECT").Target("ejected").
+		Done().
+		State("running").Parallel().
+		Region("audio").
+		WithInitial("muted").
+		State("muted").On("UNMUTE").Target("loud").EndState().
+		State("loud").EndState().
+		EndRegion().
+		Region("video").
+		WithInitial("hidden").
+		State("hidden").EndState().
+		EndRegion().
+		Done().
+		State("ejected").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewPlantUMLExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	assertGolden(t, "player_parallel.puml", result)
+
+	for _, want := range []string{
+		"@startuml\n",
+		"  active[H] --> playing\n",
+		"  active[H*] --> playing\n",
+		"stopped --> active[H] : RESUME\n",
+		"stopped --> running : START [licensed]\n",
+		"  --\n",
+		"ejected --> [*]\n",
+		"@enduml\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// SCXMLNamespace is the W3C SCXML namespace
+const SCXMLNamespace = "http://www.w3.org/2005/07/scxml"
+
+// SCXMLExporter converts a MachineConfig to a W3C SCXML document.
+// The output can be consumed by SCXML engines and verification tools:
+// - Guards become cond attributes (In(id) guards become In('id'))
+// - Actions become <log label="..."/> placeholders, since SCXML has no named actions
+// - Delayed transitions use the <send delay>/<cancel> idiom with a generated event
+// - Invoked services become <invoke> elements whose src is the service name
+type SCXMLExporter[C any] struct {
+	machine *ir.MachineConfig[C]
+}
+
+// NewSCXMLExporter creates a new SCXML exporter for the given machine configuration
+func NewSCXMLExporter[C any](machine *ir.MachineConfig[C]) *SCXMLExporter[C] {
+	return &SCXMLExporter[C]{machine: machine}
+}
+
+// SCXMLDocument represents the root <scxml> element
+type SCXMLDocument struct {
+	XMLName xml.Name    `xml:"scxml"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Version string      `xml:"version,attr"`
+	Name    string      `xml:"name,attr,omitempty"`
+	Initial string      `xml:"initial,attr,omitempty"`
+	States  []SCXMLNode `xml:",any"`
+}
+
+// SCXMLNode represents a <state>, <parallel>, <final>, or <history> element.
+// XMLName.Local holds the element kind.
+type SCXMLNode struct {
+	XMLName     xml.Name
+	ID          string            `xml:"id,attr"`
+	Initial     string            `xml:"initial,attr,omitempty"`
+	Type        string            `xml:"type,attr,omitempty"` // "shallow" or "deep" (only for <history>)
+	OnEntry     *SCXMLExecutable  `xml:"onentry,omitempty"`
+	OnExit      *SCXMLExecutable  `xml:"onexit,omitempty"`
+	Transitions []SCXMLTransition `xml:"transition"`
+	Invoke      []SCXMLInvoke     `xml:"invoke"`
+	States      []SCXMLNode       `xml:",any"`
+}
+
+// SCXMLTransition represents a <transition> element
+type SCXMLTransition struct {
+	Event   string     `xml:"event,attr,omitempty"`
+	Cond    string     `xml:"cond,attr,omitempty"`
+	Target  string     `xml:"target,attr,omitempty"`
+	Type    string     `xml:"type,attr,omitempty"` // "internal" for internal transitions
+	Actions []SCXMLLog `xml:"log"`
+}
+
+// SCXMLExecutable represents an <onentry> or <onexit> block
+type SCXMLExecutable struct {
+	Actions []SCXMLLog    `xml:"log"`
+	Sends   []SCXMLSend   `xml:"send"`
+	Cancels []SCXMLCancel `xml:"cancel"`
+}
+
+// SCXMLLog is a <log> placeholder naming an action
+type SCXMLLog struct {
+	Label string `xml:"label,attr"`
+}
+
+// SCXMLSend represents a delayed <send> that raises a generated event
+type SCXMLSend struct {
+	ID        string `xml:"id,attr"`
+	Event     string `xml:"event,attr"`
+	Delay     string `xml:"delay,attr,omitempty"`
+	DelayExpr string `xml:"delayexpr,attr,omitempty"` // Named delays, resolved by the host
+}
+
+// SCXMLCancel cancels a pending <send> by its ID
+type SCXMLCancel struct {
+	SendID string `xml:"sendid,attr"`
+}
+
+// SCXMLInvoke represents an <invoke> element
+type SCXMLInvoke struct {
+	ID  string `xml:"id,attr"`
+	Src string `xml:"src,attr"`
+}
+
+// Document converts the machine configuration to SCXML structs
+func (e *SCXMLExporter[C]) Document() (*SCXMLDocument, error) {
+	doc := &SCXMLDocument{
+		Xmlns:   SCXMLNamespace,
+		Version: "1.0",
+		Name:    e.machine.ID,
+		Initial: string(e.machine.Initial),
+	}
+
+	for _, stateID := range rootStates(e.machine) {
+		doc.States = append(doc.States, e.buildNode(stateID))
+	}
+
+	return doc, nil
+}
+
+// Export returns the machine as an indented SCXML document
+func (e *SCXMLExporter[C]) Export() (string, error) {
+	doc, err := e.Document()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return xml.Header + string(data) + "\n", nil
+}
+
+// buildNode recursively builds the SCXML element for the given state
+func (e *SCXMLExporter[C]) buildNode(stateID ir.StateID) SCXMLNode {
+	state := e.machine.States[stateID]
+	node := SCXMLNode{ID: string(stateID)}
+	if state == nil {
+		node.XMLName.Local = "state"
+		return node
+	}
+
+	switch state.Type {
+	case ir.StateTypeParallel:
+		node.XMLName.Local = "parallel"
+	case ir.StateTypeFinal:
+		node.XMLName.Local = "final"
+	case ir.StateTypeHistory:
+		// History states only carry their type and default transition
+		node.XMLName.Local = "history"
+		node.Type = state.HistoryType.String()
+		if state.HistoryDefault != "" {
+			node.Transitions = append(node.Transitions, SCXMLTransition{Target: string(state.HistoryDefault)})
+		}
+		return node
+	default:
+		node.XMLName.Local = "state"
+		if len(state.Children) > 0 {
+			node.Initial = string(state.Initial)
+		}
+	}
+
+	entry := &SCXMLExecutable{Actions: logActions(state.Entry)}
+	exit := &SCXMLExecutable{Actions: logActions(state.Exit)}
+
+	delayed := 0
+	for _, trans := range state.Transitions {
+		transition := SCXMLTransition{
+			Event:   string(trans.Event),
+			Cond:    scxmlCond(trans.Guard),
+			Target:  string(trans.Target),
+			Actions: logActions(trans.Actions),
+		}
+		if trans.Internal {
+			transition.Type = "internal"
+		}
+
+		// Delayed transitions wait for an event sent on entry and canceled on exit
+		if trans.IsDelayed() {
+			delayed++
+			event := fmt.Sprintf("statekit.after.%s.%d", stateID, delayed)
+			send := SCXMLSend{ID: event, Event: event}
+			if trans.DelayName != "" {
+				send.DelayExpr = string(trans.DelayName)
+			} else {
+				send.Delay = scxmlDelay(trans.Delay)
+			}
+			entry.Sends = append(entry.Sends, send)
+			exit.Cancels = append(exit.Cancels, SCXMLCancel{SendID: event})
+			transition.Event = event
+		}
+
+		node.Transitions = append(node.Transitions, transition)
+	}
+
+	if !entry.empty() {
+		node.OnEntry = entry
+	}
+	if !exit.empty() {
+		node.OnExit = exit
+	}
+
+	for _, service := range state.Invoke {
+		node.Invoke = append(node.Invoke, SCXMLInvoke{
+			ID:  string(service),
+			Src: string(service),
+		})
+	}
+
+	for _, childID := range state.Children {
+		node.States = append(node.States, e.buildNode(childID))
+	}
+
+	return node
+}
+
+// empty reports whether the block has no executable content
+func (x *SCXMLExecutable) empty() bool {
+	return len(x.Actions) == 0 && len(x.Sends) == 0 && len(x.Cancels) == 0
+}
+
+// logActions converts action names to <log> placeholders
+func logActions(actions []ir.ActionType) []SCXMLLog {
+	var logs []SCXMLLog
+	for _, action := range actions {
+		logs = append(logs, SCXMLLog{Label: string(action)})
+	}
+	return logs
+}
+
+// scxmlDelay formats a delay as a CSS2 time value: in milliseconds if it is a
+// whole number of them, and otherwise in seconds with as many decimals as
+// needed, so no precision is lost
+func scxmlDelay(delay time.Duration) string {
+	if delay%time.Millisecond == 0 {
+		return fmt.Sprintf("%dms", delay.Milliseconds())
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%09d", delay%time.Second), "0")
+	return fmt.Sprintf("%d.%ss", delay/time.Second, fraction)
+}
+
+// scxmlCond converts a guard to a cond expression, using the SCXML In()
+// predicate for in-state guards
+func scxmlCond(guard ir.GuardType) string {
+	if id, ok := guard.InState(); ok {
+		return fmt.Sprintf("In('%s')", id)
+	}
+	return string(guard)
+}
@@ -0,0 +1,229 @@
+package export
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit"
+)
+
+func TestSCXMLExporter_WellFormed(t *testing.T) {
+	// A machine using every construct the SCXML exporter maps
+	noop := func(ctx *struct{}, e statekit.Event) {}
+	machine, err := statekit.NewMachine[struct{}]("player").
+		WithInitial("active").
+		WithAction("log", noop).
+		WithAction("notify", noop).
+		WithGuard("canStop", func(ctx struct{}, e statekit.Event) bool { return true }).
+		State("active").
+		WithInitial("playing").
+		OnEntry("log").
+		On("STOP").Target("stopped").Guard("canStop").Do("notify").End().
+		History("hist").Deep().Default("playing").End().
+		State("playing").On("PAUSE").Target("paused").End().End().
+		State("paused").
+		After(5 * time.Second).Target("stopped").
+		End().
+		Done().
+		State("stopped").
+		OnExit("log").
+		On("RESUME").Target("hist").
+		On("START").Target("running").
+		Done().
+		State("running").Parallel().
+		Region("audio").
+		WithInitial("muted").
+		State("muted").On("UNMUTE").Target("loud").Guard(statekit.In("shown")).EndState().
+		State("loud").EndState().
+		EndRegion().
+		Region("video").
+		WithInitial("shown").
+		State("shown").EndState().
+		EndRegion().
+		On("EJECT").Target("ejected").
+		Done().
+		State("ejected").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewSCXMLExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	if !strings.HasPrefix(result, xml.Header) {
+		t.Errorf("expected XML header, got:\n%s", result)
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(result))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("output is not well-formed XML: %v\n%s", err, result)
+		}
+	}
+
+	for _, want := range []string{
+		`<scxml xmlns="http://www.w3.org/2005/07/scxml" version="1.0" name="player" initial="active">`,
+		`<state id="active" initial="playing">`,
+		`<history id="hist" type="deep">`,
+		`<transition event="STOP" cond="canStop" target="stopped">`,
+		`<log label="notify"></log>`,
+		`<parallel id="running">`,
+		`<transition event="UNMUTE" cond="In(&#39;shown&#39;)" target="loud"></transition>`,
+		`<send id="statekit.after.paused.1" event="statekit.after.paused.1" delay="5000ms"></send>`,
+		`<cancel sendid="statekit.after.paused.1"></cancel>`,
+		`<transition event="statekit.after.paused.1" target="stopped"></transition>`,
+		`<final id="ejected"></final>`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestSCXMLExporter_RoundTrip(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("player").
+		WithInitial("active").
+		WithAction("log", func(ctx *struct{}, e statekit.Event) {}).
+		State("active").
+		WithInitial("playing").
+		OnEntry("log").
+		On("START").Target("running").End().
+		History("hist").Deep().Default("playing").End().
+		State("playing").End().
+		Done().
+		State("running").Parallel().
+		Region("audio").
+		WithInitial("muted").
+		State("muted").EndState().
+		EndRegion().
+		On("EJECT").Target("ejected").
+		Done().
+		State("ejected").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+	exporter := NewSCXMLExporter(machine)
+
+	result, err := exporter.Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	var decoded SCXMLDocument
+	if err := xml.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	// Marshaling the decoded document again must yield the same structure
+	data, err := xml.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var again SCXMLDocument
+	if err := xml.Unmarshal(data, &again); err != nil {
+		t.Fatalf("failed to unmarshal again: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, again) {
+		t.Errorf("round trip changed the document:\n%+v\n%+v", decoded, again)
+	}
+
+	// The decoded document matches what the exporter produced
+	doc, err := exporter.Document()
+	if err != nil {
+		t.Fatalf("failed to build document: %v", err)
+	}
+	if len(decoded.States) != len(doc.States) {
+		t.Fatalf("expected %d root states, got %d", len(doc.States), len(decoded.States))
+	}
+
+	kinds := make(map[string]string)
+	var walk func(nodes []SCXMLNode)
+	walk = func(nodes []SCXMLNode) {
+		for _, node := range nodes {
+			kinds[node.ID] = node.XMLName.Local
+			walk(node.States)
+		}
+	}
+	walk(decoded.States)
+
+	for id, kind := range map[string]string{
+		"active":  "state",
+		"hist":    "history",
+		"playing": "state",
+		"running": "parallel",
+		"audio":   "state",
+		"ejected": "final",
+	} {
+		if kinds[id] != kind {
+			t.Errorf("expected %q to be <%s>, got <%s>", id, kind, kinds[id])
+		}
+	}
+
+	active := decoded.States[0]
+	if active.OnEntry == nil || len(active.OnEntry.Actions) != 1 || active.OnEntry.Actions[0].Label != "log" {
+		t.Errorf("expected onentry log action on active, got %+v", active.OnEntry)
+	}
+	if hist := active.States[0]; hist.Type != "deep" || len(hist.Transitions) != 1 || hist.Transitions[0].Target != "playing" {
+		t.Errorf("unexpected history node: %+v", hist)
+	}
+}
+
+func TestSCXMLExporter_NamedDelayAndInvoke(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("fetch").
+		WithInitial("loading").
+		WithDelay("timeout", func(ctx struct{}, e statekit.Event) time.Duration { return time.Second }).
+		WithService("fetchUser", func(ctx context.Context, c struct{}, e statekit.Event) (any, error) {
+			return nil, nil
+		}).
+		State("loading").
+		Invoke("fetchUser").
+		AfterDelay("timeout").Target("failed").
+		Done().
+		State("failed").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	result, err := NewSCXMLExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	for _, want := range []string{
+		`<send id="statekit.after.loading.1" event="statekit.after.loading.1" delayexpr="timeout"></send>`,
+		`<invoke id="fetchUser" src="fetchUser"></invoke>`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestSCXMLDelay(t *testing.T) {
+	for delay, want := range map[time.Duration]string{
+		5 * time.Second:                        "5000ms",
+		250 * time.Millisecond:                 "250ms",
+		1500 * time.Microsecond:                "0.0015s",
+		250 * time.Nanosecond:                  "0.00000025s",
+		2*time.Second + 3*time.Microsecond:     "2.000003s",
+		time.Minute + 500*time.Millisecond + 1: "60.500000001s",
+	} {
+		if got := scxmlDelay(delay); got != want {
+			t.Errorf("scxmlDelay(%v) = %q, want %q", delay, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// rootStates returns all states that don't have a parent, in declaration
+// order when the machine records one and sorted by ID otherwise, so that
+// exporters produce deterministic output
+func rootStates[C any](machine *ir.MachineConfig[C]) []ir.StateID {
+	var roots []ir.StateID
+	for _, id := range machine.StateIDs() {
+		if machine.States[id].Parent == "" {
+			roots = append(roots, id)
+		}
+	}
+	return roots
+}
+
+// walkStates returns all state IDs in depth-first declaration order
+func walkStates[C any](machine *ir.MachineConfig[C]) []ir.StateID {
+	var ids []ir.StateID
+	machine.Walk(func(state *ir.StateConfig, depth int) bool {
+		ids = append(ids, state.ID)
+		return true
+	})
+	return ids
+}
+
+// scopedTransitions formats every transition with format and groups the lines
+// by the scope they must be declared in (see transitionScope), so that
+// transitions crossing compound boundaries are declared at the LCA
+func scopedTransitions[C any](machine *ir.MachineConfig[C], format func(source ir.StateID, trans *ir.TransitionConfig) string) map[ir.StateID][]string {
+	scoped := make(map[ir.StateID][]string)
+	for _, stateID := range walkStates(machine) {
+		for _, trans := range machine.States[stateID].Transitions {
+			scope := transitionScope(machine, stateID, trans.Target)
+			scoped[scope] = append(scoped[scope], format(stateID, trans))
+		}
+	}
+	return scoped
+}
+
+// transitionScope returns the innermost state that strictly contains both the
+// source and target of a transition, or "" when that is the machine root.
+// Diagram exporters declare the transition inside this scope.
+func transitionScope[C any](machine *ir.MachineConfig[C], source, target ir.StateID) ir.StateID {
+	sourceAncestors := machine.GetAncestors(source)
+	for _, ancestor := range sourceAncestors {
+		if ancestor != target && machine.IsDescendantOf(target, ancestor) {
+			return ancestor
+		}
+	}
+	return ""
+}
+
+// triggerLabel formats what triggers a transition as "EVENT [guard]",
+// using "always" for eventless and "after Nms" (or "after <name>" for named
+// delays) for delayed transitions
+func triggerLabel(trans *ir.TransitionConfig) string {
+	var label string
+	switch {
+	case trans.IsAlways():
+		label = "always"
+	case trans.DelayName != "":
+		label = fmt.Sprintf("after %s", trans.DelayName)
+	case trans.IsDelayed():
+		label = fmt.Sprintf("after %dms", trans.Delay.Milliseconds())
+	default:
+		label = string(trans.Event)
+	}
+	if trans.Guard != "" {
+		label += fmt.Sprintf(" [%s]", trans.Guard)
+	}
+	return label
+}
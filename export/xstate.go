@@ -4,6 +4,7 @@ package export
 
 import (
 	"encoding/json"
+	"maps"
 	"strconv"
 
 	"github.com/felixgeelhaar/statekit/internal/ir"
@@ -23,6 +24,15 @@ func NewXStateExporter[C any](machine *ir.MachineConfig[C]) *XStateExporter[C] {
 	return &XStateExporter[C]{machine: machine}
 }
 
+// Validate validates the machine configuration, returning an
+// *ir.ValidationError listing its issues if it is invalid
+func (e *XStateExporter[C]) Validate() error {
+	if errs := ir.Validate(e.machine); errs != nil {
+		return errs
+	}
+	return nil
+}
+
 // XStateMachine represents an XState machine configuration
 type XStateMachine struct {
 	ID      string                `json:"id"`
@@ -30,6 +40,12 @@ type XStateMachine struct {
 	States  map[string]XStateNode `json:"states"`
 }
 
+// Export returns the machine itself, so that a machine read from XState JSON
+// can be used as a MachineExporter
+func (m *XStateMachine) Export() (*XStateMachine, error) {
+	return m, nil
+}
+
 // XStateNode represents a single state in XState format
 type XStateNode struct {
 	Type    string                      `json:"type,omitempty"`    // "final", "compound", "atomic", "history"
@@ -44,14 +60,34 @@ type XStateNode struct {
 	Target  string `json:"target,omitempty"`  // Default target for history states
 
 	// Delayed transition fields (v2.0)
-	After map[string]XStateTransition `json:"after,omitempty"` // Key is delay in milliseconds
+	After map[string]XStateTransition `json:"after,omitempty"` // Key is delay in milliseconds or a named delay
+
+	// Eventless transitions, evaluated in order
+	Always []XStateTransition `json:"always,omitempty"`
+
+	// Invoked services, started on entry
+	Invoke []XStateInvoke `json:"invoke,omitempty"`
+
+	// Metadata attached to the state
+	Meta map[string]any `json:"meta,omitempty"`
+
+	// Active is set by ExportWithActive on the states of the current
+	// configuration. It is not part of the XState format.
+	Active bool `json:"_active,omitempty"`
+}
+
+// XStateInvoke represents an invoked service in XState format
+type XStateInvoke struct {
+	ID  string `json:"id"`
+	Src string `json:"src"`
 }
 
 // XStateTransition represents a transition in XState format
 type XStateTransition struct {
-	Target  string   `json:"target,omitempty"`
-	Actions []string `json:"actions,omitempty"`
-	Guard   string   `json:"guard,omitempty"` // XState v5 uses "guard", v4 uses "cond"
+	Target   string   `json:"target,omitempty"`
+	Actions  []string `json:"actions,omitempty"`
+	Guard    string   `json:"guard,omitempty"`    // XState v5 uses "guard", v4 uses "cond"
+	Internal bool     `json:"internal,omitempty"` // Internal transitions do not re-enter the source
 }
 
 // Export converts the machine configuration to XState JSON format
@@ -62,17 +98,51 @@ func (e *XStateExporter[C]) Export() (*XStateMachine, error) {
 		States:  make(map[string]XStateNode),
 	}
 
-	// Find root-level states (states without parents)
-	rootStates := e.findRootStates()
-
-	// Build state tree for each root state
-	for _, stateID := range rootStates {
+	// Build state tree for each root-level state (states without parents)
+	for _, stateID := range rootStates(e.machine) {
 		machine.States[string(stateID)] = e.buildStateNode(stateID)
 	}
 
 	return machine, nil
 }
 
+// ActiveStateSource reports the active states of a running machine: the active
+// leaves, their ancestors, and the active states of every parallel region.
+// *statekit.Interpreter implements it.
+type ActiveStateSource interface {
+	ActiveStates() []ir.StateID
+}
+
+// ExportWithActive is like Export, but marks the states that are currently
+// active in source with a non-standard "_active": true field, so that a
+// debugger can highlight them
+func (e *XStateExporter[C]) ExportWithActive(source ActiveStateSource) (*XStateMachine, error) {
+	machine, err := e.Export()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[ir.StateID]bool)
+	for _, id := range source.ActiveStates() {
+		active[id] = true
+	}
+	markActive(machine.States, active)
+
+	return machine, nil
+}
+
+// markActive sets Active on the active nodes, descending only into active nodes
+func markActive(nodes map[string]XStateNode, active map[ir.StateID]bool) {
+	for id, node := range nodes {
+		if !active[ir.StateID(id)] {
+			continue
+		}
+		node.Active = true
+		markActive(node.States, active)
+		nodes[id] = node
+	}
+}
+
 // ExportJSON returns the machine configuration as a JSON string
 func (e *XStateExporter[C]) ExportJSON() (string, error) {
 	machine, err := e.Export()
@@ -103,17 +173,6 @@ func (e *XStateExporter[C]) ExportJSONIndent(prefix, indent string) (string, err
 	return string(data), nil
 }
 
-// findRootStates returns all states that don't have a parent
-func (e *XStateExporter[C]) findRootStates() []ir.StateID {
-	var roots []ir.StateID
-	for id, state := range e.machine.States {
-		if state.Parent == "" {
-			roots = append(roots, id)
-		}
-	}
-	return roots
-}
-
 // buildStateNode recursively builds an XState node for the given state
 func (e *XStateExporter[C]) buildStateNode(stateID ir.StateID) XStateNode {
 	state := e.machine.States[stateID]
@@ -175,6 +234,17 @@ func (e *XStateExporter[C]) buildStateNode(stateID ir.StateID) XStateNode {
 		}
 	}
 
+	// Metadata
+	node.Meta = maps.Clone(state.Meta)
+
+	// Invoked services
+	for _, service := range state.Invoke {
+		node.Invoke = append(node.Invoke, XStateInvoke{
+			ID:  string(service),
+			Src: string(service),
+		})
+	}
+
 	// Transitions (separate event-based and delayed)
 	if len(state.Transitions) > 0 {
 		for _, trans := range state.Transitions {
@@ -193,14 +263,21 @@ func (e *XStateExporter[C]) buildStateNode(stateID ir.StateID) XStateNode {
 				transition.Guard = string(trans.Guard)
 			}
 
-			// Delayed transitions go in "after", event-based go in "on"
-			if trans.IsDelayed() {
+			transition.Internal = trans.IsInternal()
+
+			// Eventless transitions go in "always", delayed in "after", event-based in "on"
+			if trans.IsAlways() {
+				node.Always = append(node.Always, transition)
+			} else if trans.IsDelayed() {
 				if node.After == nil {
 					node.After = make(map[string]XStateTransition)
 				}
-				// Convert duration to milliseconds string
-				delayMs := strconv.FormatInt(trans.Delay.Milliseconds(), 10)
-				node.After[delayMs] = transition
+				// Named delays use their name as the key, fixed delays milliseconds
+				delayKey := string(trans.DelayName)
+				if delayKey == "" {
+					delayKey = strconv.FormatInt(trans.Delay.Milliseconds(), 10)
+				}
+				node.After[delayKey] = transition
 			} else {
 				if node.On == nil {
 					node.On = make(map[string]XStateTransition)
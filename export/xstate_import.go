@@ -0,0 +1,260 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// Registry resolves the action, guard, service, and delay names referenced by
+// an imported machine. *statekit.ActionRegistry satisfies this interface.
+type Registry[C any] interface {
+	Action(name ir.ActionType) (ir.Action[C], bool)
+	Guard(name ir.GuardType) (ir.Guard[C], bool)
+	Service(name ir.ServiceType) (ir.Service[C], bool)
+	Delay(name ir.DelayType) (ir.DelayResolver[C], bool)
+}
+
+// ImportXState parses XState JSON into a MachineConfig.
+// It is the inverse of XStateExporter: action, guard, and service names
+// are bound to implementations from the registry, and a missing
+// implementation is an error.
+//
+// State keys are used as state IDs, so they must be unique across the
+// whole machine. Targets may optionally use the XState "#id" form.
+func ImportXState[C any](data []byte, registry Registry[C]) (*ir.MachineConfig[C], error) {
+	var machine XStateMachine
+	if err := json.Unmarshal(data, &machine); err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+	return ImportXStateMachine(&machine, registry)
+}
+
+// ImportXStateMachine converts an already parsed XStateMachine into a MachineConfig
+func ImportXStateMachine[C any](machine *XStateMachine, registry Registry[C]) (*ir.MachineConfig[C], error) {
+	if registry == nil {
+		registry = emptyRegistry[C]{}
+	}
+
+	var ctx C
+	im := &xstateImporter[C]{
+		registry: registry,
+		machine:  ir.NewMachineConfig[C](machine.ID, importTarget(machine.Initial), ctx),
+	}
+
+	for _, key := range sortedKeys(machine.States) {
+		if err := im.importState(key, machine.States[key], ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ir.Validate(im.machine); err != nil {
+		return nil, fmt.Errorf("import: validation failed: %w", err)
+	}
+
+	return im.machine, nil
+}
+
+// xstateImporter holds the state of a single import
+type xstateImporter[C any] struct {
+	registry Registry[C]
+	machine  *ir.MachineConfig[C]
+}
+
+// importState recursively converts an XState node and its children
+func (im *xstateImporter[C]) importState(key string, node XStateNode, parentID ir.StateID) error {
+	stateID := ir.StateID(key)
+	if _, exists := im.machine.States[stateID]; exists {
+		return fmt.Errorf("import: duplicate state ID %q", key)
+	}
+
+	var stateType ir.StateType
+	switch node.Type {
+	case "final":
+		stateType = ir.StateTypeFinal
+	case "parallel":
+		stateType = ir.StateTypeParallel
+	case "history":
+		stateType = ir.StateTypeHistory
+	case "", "atomic", "compound":
+		if len(node.States) > 0 {
+			stateType = ir.StateTypeCompound
+		} else {
+			stateType = ir.StateTypeAtomic
+		}
+	default:
+		return fmt.Errorf("import: state %q has unknown type %q", key, node.Type)
+	}
+
+	state := ir.NewStateConfig(stateID, stateType)
+	state.Parent = parentID
+	state.Initial = importTarget(node.Initial)
+
+	if stateType == ir.StateTypeHistory {
+		switch node.History {
+		case "", "shallow":
+			state.HistoryType = ir.HistoryTypeShallow
+		case "deep":
+			state.HistoryType = ir.HistoryTypeDeep
+		default:
+			return fmt.Errorf("import: history state %q has unknown history %q", key, node.History)
+		}
+		state.HistoryDefault = importTarget(node.Target)
+	}
+
+	var err error
+	if state.Entry, err = im.bindActions(node.Entry); err != nil {
+		return err
+	}
+	if state.Exit, err = im.bindActions(node.Exit); err != nil {
+		return err
+	}
+
+	for _, invoke := range node.Invoke {
+		name := ir.ServiceType(invoke.Src)
+		service, ok := im.registry.Service(name)
+		if !ok {
+			return fmt.Errorf("import: service %q not found in registry", name)
+		}
+		im.machine.Services[name] = service
+		state.Invoke = append(state.Invoke, name)
+	}
+
+	// Event transitions, sorted by event for a stable order
+	for _, event := range sortedKeys(node.On) {
+		trans, err := im.importTransition(node.On[event])
+		if err != nil {
+			return err
+		}
+		trans.Event = ir.EventType(event)
+		state.Transitions = append(state.Transitions, trans)
+	}
+
+	// Delayed transitions, sorted by delay, then named delays sorted by name
+	var delays []time.Duration
+	var named []string
+	delayed := make(map[time.Duration]XStateTransition, len(node.After))
+	for ms, t := range node.After {
+		n, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			named = append(named, ms)
+			continue
+		}
+		delay := time.Duration(n) * time.Millisecond
+		delays = append(delays, delay)
+		delayed[delay] = t
+	}
+	sort.Slice(delays, func(a, b int) bool { return delays[a] < delays[b] })
+	for _, delay := range delays {
+		trans, err := im.importTransition(delayed[delay])
+		if err != nil {
+			return err
+		}
+		trans.Delay = delay
+		state.Transitions = append(state.Transitions, trans)
+	}
+	sort.Strings(named)
+	for _, delayKey := range named {
+		name := ir.DelayType(delayKey)
+		resolver, ok := im.registry.Delay(name)
+		if !ok {
+			return fmt.Errorf("import: delay %q not found in registry", name)
+		}
+		im.machine.Delays[name] = resolver
+
+		trans, err := im.importTransition(node.After[delayKey])
+		if err != nil {
+			return err
+		}
+		trans.DelayName = name
+		state.Transitions = append(state.Transitions, trans)
+	}
+
+	// Eventless transitions, in declaration order
+	for _, always := range node.Always {
+		trans, err := im.importTransition(always)
+		if err != nil {
+			return err
+		}
+		trans.Always = true
+		state.Transitions = append(state.Transitions, trans)
+	}
+
+	im.machine.States[stateID] = state
+
+	for _, childKey := range sortedKeys(node.States) {
+		if err := im.importState(childKey, node.States[childKey], stateID); err != nil {
+			return err
+		}
+		state.Children = append(state.Children, ir.StateID(childKey))
+	}
+
+	return nil
+}
+
+// importTransition converts a transition and binds its guard and actions
+func (im *xstateImporter[C]) importTransition(t XStateTransition) (*ir.TransitionConfig, error) {
+	trans := ir.NewTransitionConfig("", importTarget(t.Target))
+	trans.Internal = t.Internal
+
+	if t.Guard != "" {
+		name := ir.GuardType(t.Guard)
+		if guard, ok := im.registry.Guard(name); ok {
+			im.machine.Guards[name] = guard
+		} else if _, inState := name.InState(); !inState {
+			return nil, fmt.Errorf("import: guard %q not found in registry", name)
+		}
+		trans.Guard = name
+	}
+
+	actions, err := im.bindActions(t.Actions)
+	if err != nil {
+		return nil, err
+	}
+	trans.Actions = actions
+
+	return trans, nil
+}
+
+// bindActions resolves action names against the registry
+func (im *xstateImporter[C]) bindActions(names []string) ([]ir.ActionType, error) {
+	var actions []ir.ActionType
+	for _, n := range names {
+		name := ir.ActionType(n)
+		action, ok := im.registry.Action(name)
+		if !ok {
+			return nil, fmt.Errorf("import: action %q not found in registry", name)
+		}
+		im.machine.Actions[name] = action
+		actions = append(actions, name)
+	}
+	return actions, nil
+}
+
+// emptyRegistry is used when no registry is given; every lookup fails
+type emptyRegistry[C any] struct{}
+
+func (emptyRegistry[C]) Action(ir.ActionType) (ir.Action[C], bool)      { return nil, false }
+func (emptyRegistry[C]) Guard(ir.GuardType) (ir.Guard[C], bool)         { return nil, false }
+func (emptyRegistry[C]) Service(ir.ServiceType) (ir.Service[C], bool)   { return nil, false }
+func (emptyRegistry[C]) Delay(ir.DelayType) (ir.DelayResolver[C], bool) { return nil, false }
+
+// importTarget converts an XState target reference to a state ID
+func importTarget(target string) ir.StateID {
+	return ir.StateID(strings.TrimPrefix(target, "#"))
+}
+
+// sortedKeys returns the keys of a string-keyed map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,198 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit"
+)
+
+type importContext struct {
+	Log   []string
+	Count int
+}
+
+// importRegistry returns the implementations shared by the original and imported machines
+func importRegistry() *statekit.ActionRegistry[importContext] {
+	return statekit.NewActionRegistry[importContext]().
+		WithAction("logEnter", func(ctx *importContext, e statekit.Event) {
+			ctx.Log = append(ctx.Log, "enter:"+string(e.Type))
+		}).
+		WithAction("increment", func(ctx *importContext, e statekit.Event) {
+			ctx.Count++
+		}).
+		WithGuard("belowLimit", func(ctx importContext, e statekit.Event) bool {
+			return ctx.Count < 2
+		})
+}
+
+func TestImportXState_RoundTrip(t *testing.T) {
+	registry := importRegistry()
+	logEnter, _ := registry.Action("logEnter")
+	increment, _ := registry.Action("increment")
+	belowLimit, _ := registry.Guard("belowLimit")
+
+	original, err := statekit.NewMachine[importContext]("editor").
+		WithInitial("editing").
+		WithAction("logEnter", logEnter).
+		WithAction("increment", increment).
+		WithGuard("belowLimit", belowLimit).
+		State("editing").
+		WithInitial("draft").
+		OnEntry("logEnter").
+		On("PAUSE").Target("paused").End().
+		History("hist").Deep().Default("draft").End().
+		State("draft").
+		On("SAVE").Target("draft").Guard("belowLimit").Do("increment").End().
+		On("REVIEW").Target("review").End().
+		End().
+		State("review").End().
+		Done().
+		State("paused").
+		OnEntry("logEnter").
+		On("RESUME").Target("hist").
+		On("PUBLISH").Target("publishing").
+		After(5 * time.Second).Target("closed").
+		Done().
+		State("publishing").Parallel().
+		Region("upload").
+		WithInitial("uploading").
+		State("uploading").On("UPLOADED").Target("uploaded").EndState().
+		State("uploaded").EndState().
+		EndRegion().
+		Region("notify").
+		WithInitial("notifying").
+		State("notifying").On("NOTIFIED").Target("notified").EndState().
+		State("notified").EndState().
+		EndRegion().
+		On("CLOSE").Target("closed").
+		Done().
+		State("closed").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	data, err := NewXStateExporter(original).ExportJSON()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	imported, err := ImportXState([]byte(data), registry)
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	if delay := imported.States["paused"].GetDelayedTransitions(); len(delay) != 1 || delay[0].Delay != 5*time.Second {
+		t.Errorf("expected 5s delayed transition on 'paused', got %+v", delay)
+	}
+
+	want := statekit.NewInterpreter(original)
+	got := statekit.NewInterpreter(imported)
+	want.Start()
+	got.Start()
+	defer want.Stop()
+	defer got.Stop()
+
+	events := []statekit.EventType{
+		"SAVE", "SAVE", "SAVE", "REVIEW", "PAUSE", "RESUME",
+		"PAUSE", "PUBLISH", "UPLOADED", "NOTIFIED", "CLOSE",
+	}
+	for _, event := range events {
+		want.Send(statekit.Event{Type: event})
+		got.Send(statekit.Event{Type: event})
+
+		w, g := want.State(), got.State()
+		if w.Value != g.Value {
+			t.Fatalf("after %s: expected state %s, got %s", event, w.Value, g.Value)
+		}
+		for region, leaf := range w.ActiveInParallel {
+			if g.ActiveInParallel[region] != leaf {
+				t.Errorf("after %s: expected region %s in %s, got %s", event, region, leaf, g.ActiveInParallel[region])
+			}
+		}
+		if w.Context.Count != g.Context.Count || strings.Join(w.Context.Log, ",") != strings.Join(g.Context.Log, ",") {
+			t.Errorf("after %s: expected context %+v, got %+v", event, w.Context, g.Context)
+		}
+	}
+
+	if !got.Done() {
+		t.Errorf("expected imported machine to reach final state, got %s", got.State().Value)
+	}
+}
+
+func TestImportXState_MissingImplementations(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "action",
+			json: `{"id":"m","initial":"a","states":{"a":{"entry":["unknown"]}}}`,
+			want: `action "unknown"`,
+		},
+		{
+			name: "guard",
+			json: `{"id":"m","initial":"a","states":{"a":{"on":{"GO":{"target":"a","guard":"unknown"}}}}}`,
+			want: `guard "unknown"`,
+		},
+		{
+			name: "delay",
+			json: `{"id":"m","initial":"a","states":{"a":{"after":{"unknown":{"target":"a"}}}}}`,
+			want: `delay "unknown"`,
+		},
+		{
+			name: "transition action",
+			json: `{"id":"m","initial":"a","states":{"a":{"on":{"GO":{"target":"a","actions":["unknown"]}}}}}`,
+			want: `action "unknown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ImportXState([]byte(tt.json), importRegistry())
+			if err == nil {
+				t.Fatal("expected error for missing implementation")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error to mention %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestImportXState_InvalidMachine(t *testing.T) {
+	// Target does not exist
+	_, err := ImportXState([]byte(`{"id":"m","initial":"a","states":{"a":{"on":{"GO":{"target":"missing"}}}}}`), importRegistry())
+	if err == nil {
+		t.Error("expected validation error for invalid target")
+	}
+
+	// Malformed JSON
+	if _, err := ImportXState([]byte(`{`), importRegistry()); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+}
+
+func TestImportXState_NamedDelay(t *testing.T) {
+	registry := importRegistry().
+		WithDelay("timeout", func(ctx importContext, e statekit.Event) time.Duration { return time.Second })
+
+	machine, err := ImportXState([]byte(`{"id":"m","initial":"a","states":{"a":{"after":{"timeout":{"target":"b"},"500":{"target":"b"}}},"b":{}}}`), registry)
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	transitions := machine.States["a"].Transitions
+	if len(transitions) != 2 {
+		t.Fatalf("expected 2 delayed transitions, got %d", len(transitions))
+	}
+	if transitions[0].Delay != 500*time.Millisecond {
+		t.Errorf("expected fixed delay first, got %v", transitions[0].Delay)
+	}
+	if transitions[1].DelayName != "timeout" || machine.Delays["timeout"] == nil {
+		t.Errorf("expected named delay 'timeout' to be bound, got %q", transitions[1].DelayName)
+	}
+}
@@ -2,6 +2,8 @@ package export
 
 import (
 	"encoding/json"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/felixgeelhaar/statekit"
@@ -85,6 +87,27 @@ func TestXStateExporter_WithEntryExitActions(t *testing.T) {
 	}
 }
 
+func TestXStateExporter_Meta(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("test").
+		WithInitial("idle").
+		State("idle").
+		Meta("description", "Waiting").
+		Meta("tags", []string{"ui"}).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	jsonStr, err := NewXStateExporter(machine).ExportJSON()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if !strings.Contains(jsonStr, `"meta":{"description":"Waiting","tags":["ui"]}`) {
+		t.Errorf("expected metadata in export, got %s", jsonStr)
+	}
+}
+
 func TestXStateExporter_WithTransitionActions(t *testing.T) {
 	machine, err := statekit.NewMachine[struct{}]("test").
 		WithInitial("idle").
@@ -371,3 +394,139 @@ func TestXStateExporter_ComplexMachine(t *testing.T) {
 
 	t.Logf("Exported XState JSON:\n%s", jsonStr)
 }
+
+func TestXStateExporter_ExportWithActive(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("player").
+		WithInitial("library").
+		State("library").
+		WithInitial("browsing").
+		State("browsing").On("SEARCH").Target("searching").End().End().
+		State("searching").
+		WithInitial("typing").
+		State("typing").On("SUBMIT").Target("results").End().End().
+		State("results").End().
+		End().
+		On("PLAY").Target("playing").
+		Done().
+		State("playing").Parallel().
+		Region("audio").
+		WithInitial("loud").
+		State("loud").On("MUTE").Target("muted").EndState().
+		State("muted").EndState().
+		EndRegion().
+		Region("video").
+		WithInitial("windowed").
+		State("windowed").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	interp := statekit.NewInterpreter(machine)
+	interp.Start()
+	interp.Send(statekit.Event{Type: "SEARCH"})
+	interp.Send(statekit.Event{Type: "SUBMIT"})
+
+	exporter := NewXStateExporter(machine)
+	exported, err := exporter.ExportWithActive(interp)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	library := exported.States["library"]
+	searching := library.States["searching"]
+	if !library.Active || !searching.Active || !searching.States["results"].Active {
+		t.Error("expected the leaf 'results' and its ancestors to be active")
+	}
+	if library.States["browsing"].Active || searching.States["typing"].Active || exported.States["playing"].Active {
+		t.Error("expected inactive states not to be marked")
+	}
+
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"_active":true`) {
+		t.Errorf("expected _active field in JSON, got %s", data)
+	}
+
+	// Parallel regions have one active leaf each
+	interp.Send(statekit.Event{Type: "PLAY"})
+	interp.Send(statekit.Event{Type: "MUTE"})
+	exported, err = exporter.ExportWithActive(interp)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	playing := exported.States["playing"]
+	audio, video := playing.States["audio"], playing.States["video"]
+	if !playing.Active || !audio.Active || !video.Active {
+		t.Error("expected the parallel state and both regions to be active")
+	}
+	if !audio.States["muted"].Active || audio.States["loud"].Active || !video.States["windowed"].Active {
+		t.Errorf("expected 'muted' and 'windowed' to be the active leaves, got %+v", playing)
+	}
+	if exported.States["library"].Active {
+		t.Error("expected 'library' to be inactive")
+	}
+
+	// The plain export carries no annotations
+	plain, err := exporter.ExportJSON()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if strings.Contains(plain, "_active") {
+		t.Errorf("expected no _active field in plain export, got %s", plain)
+	}
+}
+
+// TestExporters_StableOutput tests that repeated exports are byte-identical
+// and that diagram exporters list states in declaration order
+func TestExporters_StableOutput(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("stable").
+		WithInitial("zulu").
+		State("zulu").On("NEXT").Target("mike").Done().
+		State("mike").On("NEXT").Target("alpha").Done().
+		State("alpha").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	if want := []ir.StateID{"zulu", "mike", "alpha"}; !slices.Equal(machine.Order, want) {
+		t.Errorf("expected order %v, got %v", want, machine.Order)
+	}
+
+	exporters := map[string]func() (string, error){
+		"xstate":  func() (string, error) { return NewXStateExporter(machine).ExportJSONIndent("", "  ") },
+		"dot":     NewDOTExporter(machine).Export,
+		"mermaid": NewMermaidExporter(machine).Export,
+		"scxml":   NewSCXMLExporter(machine).Export,
+	}
+	for name, export := range exporters {
+		first, err := export()
+		if err != nil {
+			t.Fatalf("%s: failed to export: %v", name, err)
+		}
+		for range 20 {
+			again, err := export()
+			if err != nil {
+				t.Fatalf("%s: failed to export: %v", name, err)
+			}
+			if again != first {
+				t.Fatalf("%s: expected identical output, got\n%s\nthen\n%s", name, first, again)
+			}
+		}
+	}
+
+	dot, err := NewDOTExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	zulu, mike, alpha := strings.Index(dot, `  "zulu"`), strings.Index(dot, `  "mike"`), strings.Index(dot, `  "alpha"`)
+	if zulu < 0 || !(zulu < mike && mike < alpha) {
+		t.Errorf("expected states in declaration order, got:\n%s", dot)
+	}
+}
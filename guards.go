@@ -0,0 +1,192 @@
+package statekit
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// guardOp is the logical operator of a combined guard
+type guardOp int
+
+const (
+	guardAnd guardOp = iota
+	guardOr
+	guardNot
+)
+
+// guardComposite is a named guard combined from other named guards
+type guardComposite struct {
+	op     guardOp
+	guards []GuardType
+}
+
+// compose builds the combined guard from its resolved component guards
+func compose[C any](op guardOp, components []Guard[C]) Guard[C] {
+	switch op {
+	case guardOr:
+		return func(ctx C, e Event) bool {
+			for _, g := range components {
+				if g(ctx, e) {
+					return true
+				}
+			}
+			return false
+		}
+	case guardNot:
+		return func(ctx C, e Event) bool {
+			return !components[0](ctx, e)
+		}
+	default:
+		return func(ctx C, e Event) bool {
+			for _, g := range components {
+				if !g(ctx, e) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+}
+
+// combinedGuardName returns the generated name of a guard requiring all of the
+// given guards, "g1 && g2", or any of them, "g1 || g2"
+func combinedGuardName(op guardOp, guards []GuardType) GuardType {
+	names := make([]string, len(guards))
+	for i, g := range guards {
+		names[i] = string(g)
+	}
+	if op == guardOr {
+		return GuardType(strings.Join(names, " || "))
+	}
+	return GuardType(strings.Join(names, " && "))
+}
+
+// resolveGuard returns the named guard, combining it from its component guards
+// if it is a composite. visiting tracks composites being resolved to detect cycles.
+func resolveGuard[C any](name GuardType, guards map[GuardType]Guard[C], stateGuards map[GuardType]StateGuard[C], composites map[GuardType]guardComposite, visiting map[GuardType]bool) (Guard[C], *ir.ValidationIssue) {
+	if guard, ok := guards[name]; ok {
+		return guard, nil
+	}
+	if _, ok := stateGuards[name]; ok {
+		return nil, &ir.ValidationIssue{
+			Code:    ir.ErrCodeMissingGuard,
+			Message: fmt.Sprintf("state guard '%s' cannot be combined", name),
+		}
+	}
+	composite, ok := composites[name]
+	if _, inState := name.InState(); !ok && inState {
+		return nil, &ir.ValidationIssue{
+			Code:    ir.ErrCodeMissingGuard,
+			Message: fmt.Sprintf("in-state guard '%s' cannot be combined", name),
+		}
+	}
+	if !ok {
+		return nil, &ir.ValidationIssue{
+			Code:    ir.ErrCodeMissingGuard,
+			Message: fmt.Sprintf("guard '%s' is not defined", name),
+		}
+	}
+	if visiting[name] {
+		return nil, &ir.ValidationIssue{
+			Code:    ir.ErrCodeGuardCycle,
+			Message: fmt.Sprintf("guard '%s' is combined from itself", name),
+		}
+	}
+
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	components := make([]Guard[C], len(composite.guards))
+	for i, component := range composite.guards {
+		guard, issue := resolveGuard(component, guards, stateGuards, composites, visiting)
+		if issue != nil {
+			return nil, issue
+		}
+		components[i] = guard
+	}
+	return compose(composite.op, components), nil
+}
+
+// composeGuards adds every combined guard to the machine, reporting combined
+// guards whose components are missing or cyclic
+func composeGuards[C any](machine *ir.MachineConfig[C], composites map[GuardType]guardComposite, errs *ir.ValidationError) {
+	names := make([]GuardType, 0, len(composites))
+	for name := range composites {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	guards := make(map[GuardType]Guard[C], len(machine.Guards))
+	for name, guard := range machine.Guards {
+		guards[name] = guard
+	}
+
+	for _, name := range names {
+		if machine.HasGuard(name) {
+			continue // Explicitly registered guards take precedence
+		}
+		guard, issue := resolveGuard(name, guards, machine.StateGuards, composites, map[GuardType]bool{})
+		if issue != nil {
+			errs.AddIssue(issue.Code,
+				fmt.Sprintf("combined guard '%s': %s", name, issue.Message),
+				"guards", string(name))
+			continue
+		}
+		machine.Guards[name] = guard
+	}
+}
+
+// guardReferences returns the sorted, distinct guards referenced by the
+// machine's transitions and by combined guards
+func guardReferences[C any](machine *ir.MachineConfig[C], composites map[GuardType]guardComposite) []GuardType {
+	var names []GuardType
+	for _, state := range machine.States {
+		for _, trans := range state.Transitions {
+			if trans.Guard != "" {
+				names = append(names, trans.Guard)
+			}
+			for _, conditional := range trans.ConditionalActions {
+				names = append(names, conditional.Guard)
+			}
+		}
+		for _, choice := range state.InitialChoices {
+			names = append(names, choice.Guard)
+		}
+	}
+	for _, composite := range composites {
+		names = append(names, composite.guards...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// instantiateGuards adds a guard for each guard call, such as "hasAtLeast(3)",
+// whose factory is registered. Explicitly registered guards take precedence;
+// calls of unregistered factories are left for validation to report.
+func instantiateGuards[C any](machine *ir.MachineConfig[C], factories map[GuardType]GuardFactory[C], names []GuardType, errs *ir.ValidationError) {
+	for _, name := range names {
+		if _, ok := machine.Guards[name]; ok {
+			continue
+		}
+		factoryName, args, ok := name.Call()
+		if !ok {
+			continue
+		}
+		factory, ok := factories[factoryName]
+		if !ok {
+			continue
+		}
+		guard := factory(args)
+		if guard == nil {
+			errs.AddIssue(ir.ErrCodeInvalidGuardArgs,
+				fmt.Sprintf("guard factory '%s' rejected the arguments of '%s'", factoryName, name),
+				"guards", string(name))
+			// Register a guard that never passes so the call is not also reported missing
+			guard = func(C, Event) bool { return false }
+		}
+		machine.Guards[name] = guard
+	}
+}
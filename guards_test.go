@@ -0,0 +1,625 @@
+package statekit
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+type accessContext struct {
+	Admin  bool
+	Active bool
+}
+
+// TestCombinedGuards tests and/or/not combinations against every component result
+func TestCombinedGuards(t *testing.T) {
+	machine, err := NewMachine[accessContext]("combined").
+		WithInitial("idle").
+		WithGuard("isAdmin", func(ctx accessContext, e Event) bool { return ctx.Admin }).
+		WithGuard("isActive", func(ctx accessContext, e Event) bool { return ctx.Active }).
+		WithGuardAnd("isAdminAndActive", "isAdmin", "isActive").
+		WithGuardOr("isAdminOrActive", "isAdmin", "isActive").
+		WithGuardNot("isNotAdmin", "isAdmin").
+		// Declared before its component combined guard
+		WithGuardNot("notBoth", "both").
+		WithGuardAnd("both", "isAdmin", "isActive").
+		State("idle").
+		On("AND").Target("running").Guard("isAdminAndActive").
+		On("OR").Target("running").Guard("isAdminOrActive").
+		On("NOT").Target("running").Guard("isNotAdmin").
+		On("GUARDS").Target("running").Guards("isAdmin", "isActive").
+		On("NESTED").Target("running").Guard("notBoth").
+		Done().
+		State("running").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tests := []struct {
+		event    EventType
+		ctx      accessContext
+		expected bool
+	}{
+		{"AND", accessContext{Admin: true, Active: true}, true},
+		{"AND", accessContext{Admin: true, Active: false}, false},
+		{"AND", accessContext{Admin: false, Active: true}, false},
+		{"OR", accessContext{Admin: false, Active: true}, true},
+		{"OR", accessContext{Admin: false, Active: false}, false},
+		{"NOT", accessContext{Admin: false}, true},
+		{"NOT", accessContext{Admin: true}, false},
+		{"GUARDS", accessContext{Admin: true, Active: true}, true},
+		{"GUARDS", accessContext{Admin: true, Active: false}, false},
+		{"NESTED", accessContext{Admin: true, Active: true}, false},
+		{"NESTED", accessContext{Admin: true, Active: false}, true},
+	}
+
+	for _, tt := range tests {
+		interp := NewInterpreter(machine)
+		interp.Start()
+		interp.UpdateContext(func(ctx *accessContext) { *ctx = tt.ctx })
+		interp.Send(Event{Type: tt.event})
+
+		if got := interp.Matches("running"); got != tt.expected {
+			t.Errorf("%s with %+v: expected transition %v, got %v", tt.event, tt.ctx, tt.expected, got)
+		}
+	}
+}
+
+// TestCombinedGuards_GuardsName tests the generated name of an all-must-pass guard
+func TestCombinedGuards_GuardsName(t *testing.T) {
+	machine, err := NewMachine[accessContext]("combined").
+		WithInitial("idle").
+		WithGuard("isAdmin", func(ctx accessContext, e Event) bool { return ctx.Admin }).
+		WithGuard("isActive", func(ctx accessContext, e Event) bool { return ctx.Active }).
+		State("idle").On("GO").Target("running").Guards("isAdmin", "isActive").Done().
+		State("running").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	trans := machine.States["idle"].Transitions[0]
+	if trans.Guard != "isAdmin && isActive" {
+		t.Errorf("Expected guard 'isAdmin && isActive', got '%s'", trans.Guard)
+	}
+	if machine.GetGuard(trans.Guard) == nil {
+		t.Error("Expected combined guard to be registered")
+	}
+}
+
+// TestCombinedGuards_Validation tests that missing and cyclic components are rejected
+func TestCombinedGuards_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *MachineBuilder[accessContext]
+		code    string
+	}{
+		{
+			name: "missing component",
+			builder: NewMachine[accessContext]("missing").
+				WithGuard("isAdmin", func(ctx accessContext, e Event) bool { return ctx.Admin }).
+				WithGuardAnd("isAdminAndActive", "isAdmin", "isActive"),
+			code: ir.ErrCodeMissingGuard,
+		},
+		{
+			name: "cycle",
+			builder: NewMachine[accessContext]("cycle").
+				WithGuardAnd("a", "b").
+				WithGuardOr("b", "a"),
+			code: ir.ErrCodeGuardCycle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.
+				WithInitial("idle").
+				State("idle").Done().
+				Build()
+			if err == nil {
+				t.Fatal("Expected validation error")
+			}
+
+			var validationErr *ir.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ir.ValidationError, got %T", err)
+			}
+			found := false
+			for _, issue := range validationErr.Issues {
+				if issue.Code == tt.code {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected %s issue, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+// TestCombinedGuards_Registry tests combined guards in the reflection DSL
+func TestCombinedGuards_Registry(t *testing.T) {
+	type Machine struct {
+		MachineDef `id:"combined" initial:"idle"`
+		Idle       StateNode `on:"GO->running:isAdminAndActive"`
+		Running    StateNode
+	}
+
+	registry := NewActionRegistry[accessContext]().
+		WithGuard("isAdmin", func(ctx accessContext, e Event) bool { return ctx.Admin }).
+		WithGuard("isActive", func(ctx accessContext, e Event) bool { return ctx.Active }).
+		WithGuardAnd("isAdminAndActive", "isAdmin", "isActive")
+
+	if _, ok := registry.Guard("isAdminAndActive"); !ok {
+		t.Error("Expected registry to resolve combined guard")
+	}
+
+	machine, err := FromStructWithContext[Machine](registry, accessContext{Admin: true})
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	// Active is false, so the combined guard blocks the transition
+	interp.Send(Event{Type: "GO"})
+	if !interp.Matches("idle") {
+		t.Errorf("Expected to stay in 'idle', got %s", interp.State().Value)
+	}
+
+	interp.UpdateContext(func(ctx *accessContext) { ctx.Active = true })
+	interp.Send(Event{Type: "GO"})
+	if !interp.Matches("running") {
+		t.Errorf("Expected 'running', got %s", interp.State().Value)
+	}
+}
+
+// hasAtLeast is a guard factory passing when the cart holds at least args[0] items
+func hasAtLeast(args []string) Guard[cartContext] {
+	if len(args) != 1 {
+		return nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil
+	}
+	return func(ctx cartContext, e Event) bool { return ctx.Items >= n }
+}
+
+// TestGuardFactory tests two transitions using the same factory with different arguments
+func TestGuardFactory(t *testing.T) {
+	machine, err := NewMachine[cartContext]("shipping").
+		WithInitial("cart").
+		WithGuardFactory("hasAtLeast", hasAtLeast).
+		State("cart").
+		On("SHIP").Target("freight").Guard("hasAtLeast(10)").
+		On("SHIP").Target("parcel").Guard("hasAtLeast(1)").
+		Done().
+		State("freight").Done().
+		State("parcel").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tests := []struct {
+		items    int
+		expected StateID
+	}{
+		{0, "cart"},
+		{3, "parcel"},
+		{12, "freight"},
+	}
+
+	for _, tt := range tests {
+		interp := NewInterpreter(machine)
+		interp.Start()
+		interp.UpdateContext(func(ctx *cartContext) { ctx.Items = tt.items })
+		interp.Send(Event{Type: "SHIP"})
+
+		if interp.State().Value != tt.expected {
+			t.Errorf("With %d items expected state %s, got %s", tt.items, tt.expected, interp.State().Value)
+		}
+	}
+}
+
+// TestGuardFactory_Combined tests guard calls as components of combined guards
+func TestGuardFactory_Combined(t *testing.T) {
+	machine, err := NewMachine[cartContext]("shipping").
+		WithInitial("cart").
+		WithGuardFactory("hasAtLeast", hasAtLeast).
+		WithGuardNot("isEmpty", "hasAtLeast(1)").
+		State("cart").On("CLOSE").Target("closed").Guard("isEmpty").Done().
+		State("closed").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "CLOSE"})
+	if interp.State().Value != "closed" {
+		t.Errorf("Expected state 'closed', got %s", interp.State().Value)
+	}
+}
+
+// TestGuardFactory_NamedIn tests that a guard factory named "in" does not take over In guards
+func TestGuardFactory_NamedIn(t *testing.T) {
+	machine, err := NewMachine[cartContext]("shipping").
+		WithInitial("cart").
+		WithGuardFactory("in", func(args []string) Guard[cartContext] {
+			return func(cartContext, Event) bool { return false }
+		}).
+		State("cart").On("SHIP").Target("shipped").Guard(In("cart")).Done().
+		State("shipped").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "SHIP"})
+	if interp.State().Value != "shipped" {
+		t.Errorf("Expected the In guard to pass, got %s", interp.State().Value)
+	}
+}
+
+// TestGuardFactory_Validation tests that missing factories and rejected arguments are reported
+func TestGuardFactory_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		guard GuardType
+		code  string
+	}{
+		{"missing factory", "hasAtMost(3)", ir.ErrCodeMissingGuard},
+		{"invalid argument", "hasAtLeast(three)", ir.ErrCodeInvalidGuardArgs},
+		{"wrong argument count", "hasAtLeast(1, 2)", ir.ErrCodeInvalidGuardArgs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMachine[cartContext]("shipping").
+				WithInitial("cart").
+				WithGuardFactory("hasAtLeast", hasAtLeast).
+				State("cart").On("SHIP").Target("shipped").Guard(tt.guard).Done().
+				State("shipped").Done().
+				Build()
+			if err == nil {
+				t.Fatal("Expected validation error")
+			}
+
+			var validationErr *ir.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected ValidationError, got %T", err)
+			}
+			if len(validationErr.Issues) != 1 || validationErr.Issues[0].Code != tt.code {
+				t.Errorf("Expected a single %s issue, got %v", tt.code, validationErr)
+			}
+		})
+	}
+}
+
+// TestTransition_DoWhen tests that a conditional action runs only if its guard
+// passes, and that the transition completes either way
+func TestTransition_DoWhen(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     accessContext
+		actions []ActionType
+	}{
+		{"guard passes", accessContext{Admin: true}, []ActionType{"log", "audit"}},
+		{"guard fails", accessContext{}, []ActionType{"log"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []ActionType
+			machine, err := NewMachine[accessContext]("conditional").
+				WithInitial("idle").
+				WithContext(tt.ctx).
+				WithGuard("isAdmin", func(ctx accessContext, e Event) bool { return ctx.Admin }).
+				WithAction("log", func(ctx *accessContext, e Event) { ran = append(ran, "log") }).
+				WithAction("audit", func(ctx *accessContext, e Event) { ran = append(ran, "audit") }).
+				State("idle").
+				On("GO").Target("running").DoWhen("isAdmin", "audit").Do("log").
+				Done().
+				State("running").Done().
+				Build()
+			if err != nil {
+				t.Fatalf("Failed to build machine: %v", err)
+			}
+
+			interp := NewInterpreter(machine)
+			interp.Start()
+			result := interp.SendResult(Event{Type: "GO"})
+
+			if !interp.Matches("running") {
+				t.Errorf("Expected 'running', got %s", interp.State().Value)
+			}
+			if !slices.Equal(ran, tt.actions) || !slices.Equal(result.Actions, tt.actions) {
+				t.Errorf("Expected actions %v, got %v (result %v)", tt.actions, ran, result.Actions)
+			}
+		})
+	}
+}
+
+// TestTransition_DoWhen_Validation tests that conditional actions with an
+// undefined guard or action are reported
+func TestTransition_DoWhen_Validation(t *testing.T) {
+	_, err := NewMachine[accessContext]("conditional").
+		WithInitial("idle").
+		State("idle").On("GO").Target("running").DoWhen("isAdmin", "audit").Done().
+		State("running").Done().
+		Build()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %T", err)
+	}
+	if !containsIssueCode(validationErr, ir.ErrCodeMissingGuard) || !containsIssueCode(validationErr, ir.ErrCodeMissingAction) {
+		t.Errorf("Expected MISSING_GUARD and MISSING_ACTION issues, got %v", validationErr)
+	}
+}
+
+// TestStateGuard_OtherRegion tests a state guard that checks the state of another parallel region
+func TestStateGuard_OtherRegion(t *testing.T) {
+	// PLAY requires the network region to be online, unless the track is downloaded
+	var snapshots []StateID
+	machine, err := NewMachine[accessContext]("player").
+		WithInitial("player").
+		WithStateGuard("canPlay", func(ctx accessContext, e Event, snap StateSnapshot) bool {
+			snapshots = append(snapshots, snap.Value())
+			return snap.Matches("online") || ctx.Active
+		}).
+		State("player").Parallel().
+		Region("playback").
+		WithInitial("paused").
+		State("paused").On("PLAY").Target("playing").Guard("canPlay").EndState().
+		State("playing").On("PAUSE").Target("paused").EndState().
+		EndRegion().
+		Region("network").
+		WithInitial("offline").
+		State("offline").On("CONNECT").Target("online").EndState().
+		State("online").On("DISCONNECT").Target("offline").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "PLAY"})
+	if !interp.Matches("paused") {
+		t.Fatalf("Expected PLAY to be blocked while offline, got %v", interp.ActiveStates())
+	}
+
+	interp.Send(Event{Type: "CONNECT"})
+	interp.Send(Event{Type: "PLAY"})
+	if !interp.MatchesAll("playing", "online") {
+		t.Errorf("Expected PLAY to pass while online, got %v", interp.ActiveStates())
+	}
+
+	// The guard still sees the context
+	interp.Send(Event{Type: "PAUSE"})
+	interp.Send(Event{Type: "DISCONNECT"})
+	interp.UpdateContext(func(ctx *accessContext) { ctx.Active = true })
+	interp.Send(Event{Type: "PLAY"})
+	if !interp.MatchesAll("playing", "offline") {
+		t.Errorf("Expected PLAY to pass with a downloaded track, got %v", interp.ActiveStates())
+	}
+
+	if !interp.Can("PAUSE") || interp.Can("PLAY") {
+		t.Errorf("Expected only PAUSE to be possible, got %v", interp.NextEvents())
+	}
+	expected := []StateID{"player", "player", "player"}
+	if !slices.Equal(snapshots, expected) {
+		t.Errorf("Expected the guard to see the parallel state %v, got %v", expected, snapshots)
+	}
+}
+
+// TestStateGuard_Registry tests state guards registered with an ActionRegistry,
+// in the reflection DSL and when linking a serialized machine
+func TestStateGuard_Registry(t *testing.T) {
+	machine, err := NewMachine[accessContext]("player").
+		WithInitial("player").
+		WithStateGuard("canPlay", func(ctx accessContext, e Event, snap StateSnapshot) bool {
+			return snap.Matches("online")
+		}).
+		State("player").Parallel().
+		Region("playback").
+		WithInitial("paused").
+		State("paused").On("PLAY").Target("playing").Guard("canPlay").EndState().
+		State("playing").EndState().
+		EndRegion().
+		Region("network").
+		WithInitial("offline").
+		State("offline").On("CONNECT").Target("online").EndState().
+		State("online").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	data, err := MarshalConfig(machine)
+	if err != nil {
+		t.Fatalf("Failed to marshal machine: %v", err)
+	}
+
+	registry := NewActionRegistry[accessContext]().
+		WithStateGuard("canPlay", func(ctx accessContext, e Event, snap StateSnapshot) bool {
+			return snap.Matches("online")
+		})
+	if _, ok := registry.Guard("canPlay"); ok {
+		t.Error("Expected Guard not to return a state guard")
+	}
+
+	restored, err := UnmarshalConfig(data, registry)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal machine: %v", err)
+	}
+	interp := NewInterpreter(restored)
+	interp.Start()
+	interp.SendAll(Event{Type: "CONNECT"}, Event{Type: "PLAY"})
+	if !interp.Matches("playing") {
+		t.Errorf("Expected the linked state guard to pass while online, got %v", interp.ActiveStates())
+	}
+
+	type Machine struct {
+		MachineDef `id:"gate" initial:"idle"`
+		Idle       StateNode `on:"GO->running:notIdle"`
+		Running    StateNode
+	}
+	gated, err := FromStruct[Machine](NewActionRegistry[accessContext]().
+		WithStateGuard("notIdle", func(ctx accessContext, e Event, snap StateSnapshot) bool {
+			return !snap.Matches("idle")
+		}))
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	interp = NewInterpreter(gated)
+	interp.Start()
+	interp.Send(Event{Type: "GO"})
+	if !interp.Matches("idle") {
+		t.Errorf("Expected the state guard to block GO, got %s", interp.State().Value)
+	}
+}
+
+// TestStateGuard_Combined tests that state guards cannot be combined
+func TestStateGuard_Combined(t *testing.T) {
+	_, err := NewMachine[accessContext]("combined").
+		WithInitial("idle").
+		WithGuard("isAdmin", func(ctx accessContext, e Event) bool { return ctx.Admin }).
+		WithStateGuard("isIdle", func(ctx accessContext, e Event, snap StateSnapshot) bool {
+			return snap.Matches("idle")
+		}).
+		State("idle").On("GO").Target("running").Guards("isAdmin", "isIdle").Done().
+		State("running").Done().
+		Build()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ir.ValidationError, got %T", err)
+	}
+	if !containsIssueCode(validationErr, ir.ErrCodeMissingGuard) || !strings.Contains(err.Error(), "state guard 'isIdle' cannot be combined") {
+		t.Errorf("Expected %s issue for the state guard, got %v", ir.ErrCodeMissingGuard, err)
+	}
+}
+
+type memoContext struct {
+	Ready bool
+}
+
+// TestGuardMemoization tests that a guard referenced by several candidate
+// transitions is evaluated once per dispatch, and again after an action runs
+func TestGuardMemoization(t *testing.T) {
+	// The GO candidates in "child" and its parent all reference the counted "ready" guard
+	var calls int
+	machine, err := NewMachine[memoContext]("memo").
+		WithInitial("parent").
+		WithGuard("ready", func(ctx memoContext, e Event) bool {
+			calls++
+			return ctx.Ready
+		}).
+		WithAction("prepare", func(ctx *memoContext, e Event) { ctx.Ready = true }).
+		State("parent").
+		WithInitial("child").
+		On("GO").Target("done").Guard("ready").End().
+		On("GO").Target("waiting").End().
+		State("child").
+		On("GO").Target("done").Guard("ready").End().
+		On("GO").Target("done").Guard("ready").End().
+		End().
+		Done().
+		State("waiting").
+		OnEntry("prepare").
+		Always().Target("done").Guard("ready").End().
+		Done().
+		State("done").
+		On("GO").Target("parent").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "GO"})
+
+	// Once for the three GO candidates, once for the eventless transition
+	// after "prepare" changed the context
+	if calls != 2 {
+		t.Errorf("Expected the guard to be called twice, got %d", calls)
+	}
+	if !interp.Matches("done") {
+		t.Errorf("Expected state 'done', got %s", interp.State().Value)
+	}
+
+	// Results are not kept across events
+	interp.Send(Event{Type: "GO"})
+	interp.Send(Event{Type: "GO"})
+	if calls != 3 || !interp.Matches("done") {
+		t.Errorf("Expected a fresh evaluation for the next GO, got %d calls in %s", calls, interp.State().Value)
+	}
+}
+
+// TestGuardMemoization_Tracer tests that reused guard results are still
+// reported to the tracer
+func TestGuardMemoization_Tracer(t *testing.T) {
+	var calls int
+	machine, err := NewMachine[memoContext]("memo").
+		WithInitial("parent").
+		WithGuard("ready", func(ctx memoContext, e Event) bool {
+			calls++
+			return ctx.Ready
+		}).
+		WithAction("prepare", func(ctx *memoContext, e Event) { ctx.Ready = true }).
+		State("parent").
+		WithInitial("child").
+		On("GO").Target("done").Guard("ready").End().
+		On("GO").Target("waiting").End().
+		State("child").
+		On("GO").Target("done").Guard("ready").End().
+		On("GO").Target("done").Guard("ready").End().
+		End().
+		Done().
+		State("waiting").
+		OnEntry("prepare").
+		Always().Target("done").Guard("ready").End().
+		Done().
+		State("done").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tracer := &parallelTracer{}
+	interp := NewInterpreter(machine, WithTracer(tracer))
+	interp.Start()
+
+	interp.Send(Event{Type: "GO"})
+
+	// Three GO candidates and the eventless transition, from two calls
+	if calls != 2 || tracer.guards != 4 {
+		t.Errorf("Expected 2 calls reported 4 times, got %d calls and %d reports", calls, tracer.guards)
+	}
+}
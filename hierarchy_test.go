@@ -1,6 +1,7 @@
 package statekit
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -144,6 +145,89 @@ func TestHierarchical_MatchesAncestors(t *testing.T) {
 	}
 }
 
+// TestHierarchical_MatchesPath tests matching XState-style dotted paths
+func TestHierarchical_MatchesPath(t *testing.T) {
+	machine, err := NewMachine[struct{}]("test").
+		WithInitial("active").
+		State("active").
+		WithInitial("working").
+		State("working").
+		WithInitial("loading").
+		State("loading").End().
+		State("processing").End().
+		End().
+		Done().
+		State("idle").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	if interp.MatchesPath("active") {
+		t.Error("expected no match before Start")
+	}
+	interp.Start()
+
+	for path, expected := range map[string]bool{
+		"active.working.loading":    true,  // exact, from the root
+		"active.working":            true,  // partial, ending at an ancestor
+		"working.loading":           true,  // partial, ending at the leaf
+		"loading":                   true,  // single state, like Matches
+		"active.working.processing": false, // inactive sibling
+		"active.loading":            false, // skips a level
+		"loading.working":           false, // wrong order
+		"root.active.working":       false, // longer than the full path
+		"idle":                      false,
+		"":                          false,
+		"active.":                   false,
+	} {
+		if got := interp.MatchesPath(path); got != expected {
+			t.Errorf("MatchesPath(%q) = %v, expected %v", path, got, expected)
+		}
+	}
+}
+
+// TestHierarchical_ActiveStates tests that ActiveStates() includes the leaf and all ancestors
+func TestHierarchical_ActiveStates(t *testing.T) {
+	machine, err := NewMachine[struct{}]("test").
+		WithInitial("active").
+		State("active").
+		WithInitial("working").
+		State("working").
+		WithInitial("loading").
+		State("loading").
+		On("LOADED").Target("processing").
+		End().
+		End().
+		State("processing").End().
+		End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	if got := interp.ActiveStates(); got != nil {
+		t.Errorf("expected no active states before start, got %v", got)
+	}
+
+	interp.Start()
+
+	expected := []StateID{"active", "loading", "working"}
+	if got := interp.ActiveStates(); !slices.Equal(got, expected) {
+		t.Errorf("expected active states %v, got %v", expected, got)
+	}
+
+	interp.Send(Event{Type: "LOADED"})
+
+	expected = []StateID{"active", "processing", "working"}
+	if got := interp.ActiveStates(); !slices.Equal(got, expected) {
+		t.Errorf("expected active states %v after LOADED, got %v", expected, got)
+	}
+}
+
 // TestHierarchical_TransitionWithinCompound tests transitions between siblings
 func TestHierarchical_TransitionWithinCompound(t *testing.T) {
 	machine, err := NewMachine[struct{}]("test").
@@ -409,6 +493,52 @@ func TestHierarchical_ChildTransitionTakesPriority(t *testing.T) {
 	}
 }
 
+// TestHierarchical_BubbleDefersToParent tests that a bubbling child transition lets the parent handle the event
+func TestHierarchical_BubbleDefersToParent(t *testing.T) {
+	handled := ""
+	parentAllowed := true
+
+	machine, err := NewMachine[struct{}]("test").
+		WithInitial("parent").
+		WithAction("parentHandled", func(ctx *struct{}, e Event) {
+			handled = "parent"
+		}).
+		WithAction("childHandled", func(ctx *struct{}, e Event) {
+			handled = "child"
+		}).
+		WithGuard("parentAllowed", func(ctx struct{}, e Event) bool {
+			return parentAllowed
+		}).
+		State("parent").
+		WithInitial("child").
+		On("EVENT").Target("parent").Guard("parentAllowed").Do("parentHandled").End().
+		State("child").
+		On("EVENT").Target("child").Do("childHandled").Bubble().
+		End().
+		End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	// Parent's transition should take priority
+	interp.Send(Event{Type: "EVENT"})
+	if handled != "parent" {
+		t.Errorf("expected parent to handle event, got %s", handled)
+	}
+
+	// The child handles the event when the parent's guard fails
+	parentAllowed = false
+	interp.Send(Event{Type: "EVENT"})
+	if handled != "child" {
+		t.Errorf("expected child to handle event, got %s", handled)
+	}
+}
+
 // TestHierarchical_TransitionToSibling tests transitions between siblings in a compound state
 func TestHierarchical_TransitionToSibling(t *testing.T) {
 	machine, err := NewMachine[orderContext]("test").
@@ -483,3 +613,245 @@ func TestHierarchical_TransitionToSibling(t *testing.T) {
 		}
 	}
 }
+
+// TestHierarchical_CrossHierarchyTransitions tests exit/entry sequences for
+// transitions that cross compound boundaries
+func TestHierarchical_CrossHierarchyTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    EventType
+		expected []string
+		state    StateID
+	}{
+		{
+			// Targeting an ancestor exits and re-enters it, then enters its initial leaf
+			name:     "child targets grandparent",
+			event:    "RESET",
+			expected: []string{"exit:detail", "exit:main", "exit:app", "enter:app", "enter:main", "enter:list"},
+			state:    "list",
+		},
+		{
+			name:     "child targets parent",
+			event:    "UP",
+			expected: []string{"exit:detail", "exit:main", "enter:main", "enter:list"},
+			state:    "list",
+		},
+		{
+			// The common ancestor "app" is neither exited nor re-entered
+			name:     "child targets sibling of ancestor",
+			event:    "SETTINGS",
+			expected: []string{"exit:detail", "exit:main", "enter:settings"},
+			state:    "settings",
+		},
+	}
+
+	// Every state logs its entry and exit:
+	//
+	//	app (compound)
+	//	├── main (compound)
+	//	│   ├── list (OPEN → detail)
+	//	│   └── detail (RESET → app, UP → main, SETTINGS → settings)
+	//	└── settings
+	builder := NewMachine[orderContext]("cross").WithInitial("app")
+	for _, name := range []string{"app", "main", "list", "detail", "settings"} {
+		builder.
+			WithAction(ActionType("enter_"+name), func(ctx *orderContext, e Event) {
+				ctx.Actions = append(ctx.Actions, "enter:"+name)
+			}).
+			WithAction(ActionType("exit_"+name), func(ctx *orderContext, e Event) {
+				ctx.Actions = append(ctx.Actions, "exit:"+name)
+			})
+	}
+	machine, err := builder.
+		State("app").
+		WithInitial("main").
+		OnEntry("enter_app").
+		OnExit("exit_app").
+		State("main").
+		WithInitial("list").
+		OnEntry("enter_main").
+		OnExit("exit_main").
+		State("list").
+		OnEntry("enter_list").
+		OnExit("exit_list").
+		On("OPEN").Target("detail").End().
+		End().
+		State("detail").
+		OnEntry("enter_detail").
+		OnExit("exit_detail").
+		On("RESET").Target("app").
+		On("UP").Target("main").
+		On("SETTINGS").Target("settings").End().
+		End().
+		End().
+		State("settings").
+		OnEntry("enter_settings").
+		OnExit("exit_settings").
+		End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interp := NewInterpreter(machine)
+			interp.Start()
+			interp.Send(Event{Type: "OPEN"})
+			if interp.State().Value != "detail" {
+				t.Fatalf("expected state 'detail', got %s", interp.State().Value)
+			}
+
+			interp.UpdateContext(func(c *orderContext) {
+				c.Actions = nil
+			})
+			interp.Send(Event{Type: tt.event})
+
+			ctx := interp.State().Context
+			if len(ctx.Actions) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, ctx.Actions)
+			}
+			for i, exp := range tt.expected {
+				if ctx.Actions[i] != exp {
+					t.Errorf("expected action[%d] = %s, got %s", i, exp, ctx.Actions[i])
+				}
+			}
+			if interp.State().Value != tt.state {
+				t.Errorf("expected state %s, got %s", tt.state, interp.State().Value)
+			}
+		})
+	}
+}
+
+// TestHierarchical_TransitionToDescendant tests that a compound state targeting
+// its own descendant does not exit and re-enter itself
+func TestHierarchical_TransitionToDescendant(t *testing.T) {
+	builder := NewMachine[orderContext]("descendant").WithInitial("main")
+	for _, name := range []string{"main", "list", "detail"} {
+		builder.
+			WithAction(ActionType("enter_"+name), func(ctx *orderContext, e Event) {
+				ctx.Actions = append(ctx.Actions, "enter:"+name)
+			}).
+			WithAction(ActionType("exit_"+name), func(ctx *orderContext, e Event) {
+				ctx.Actions = append(ctx.Actions, "exit:"+name)
+			})
+	}
+	machine, err := builder.
+		State("main").
+		WithInitial("list").
+		OnEntry("enter_main").
+		OnExit("exit_main").
+		On("DEEP").Target("detail").End().
+		State("list").OnEntry("enter_list").OnExit("exit_list").End().
+		State("detail").OnEntry("enter_detail").OnExit("exit_detail").End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.UpdateContext(func(c *orderContext) {
+		c.Actions = nil
+	})
+	interp.Send(Event{Type: "DEEP"}) // Handled by "main" while in "list"
+
+	ctx := interp.State().Context
+	expected := []string{"exit:list", "enter:detail"}
+	if len(ctx.Actions) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, ctx.Actions)
+	}
+	for i, exp := range expected {
+		if ctx.Actions[i] != exp {
+			t.Errorf("expected action[%d] = %s, got %s", i, exp, ctx.Actions[i])
+		}
+	}
+	if interp.State().Value != "detail" {
+		t.Errorf("expected state 'detail', got %s", interp.State().Value)
+	}
+}
+
+type resumeContext struct {
+	HasDraft  bool
+	Submitted bool
+}
+
+// TestHierarchical_InitialGuarded tests that the context chooses the child entered on start
+func TestHierarchical_InitialGuarded(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      resumeContext
+		expected StateID
+	}{
+		{"fallback", resumeContext{}, "blank"},
+		{"draft", resumeContext{HasDraft: true}, "typing"},
+		{"first passing guard wins", resumeContext{HasDraft: true, Submitted: true}, "review"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// "editor" resumes at the child that matches the context
+			machine, err := NewMachine[resumeContext]("resume").
+				WithInitial("editor").
+				WithContext(tt.ctx).
+				WithGuard("isSubmitted", func(ctx resumeContext, e Event) bool { return ctx.Submitted }).
+				WithGuard("hasDraft", func(ctx resumeContext, e Event) bool { return ctx.HasDraft }).
+				State("editor").
+				WithInitial("blank").
+				WithInitialGuarded("isSubmitted", "review").
+				WithInitialGuarded("hasDraft", "drafting").
+				State("blank").End().
+				State("drafting").
+				WithInitial("typing").
+				State("typing").End().
+				End().
+				State("review").End().
+				Done().
+				Build()
+			if err != nil {
+				t.Fatalf("Failed to build machine: %v", err)
+			}
+
+			interp := NewInterpreter(machine)
+			interp.Start()
+			if interp.State().Value != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, interp.State().Value)
+			}
+		})
+	}
+}
+
+// TestHierarchical_InitialGuardedOnReentry tests that guarded initial children are chosen each time the state is entered
+func TestHierarchical_InitialGuardedOnReentry(t *testing.T) {
+	machine, err := NewMachine[resumeContext]("resume").
+		WithInitial("editor").
+		WithGuard("hasDraft", func(ctx resumeContext, e Event) bool { return ctx.HasDraft }).
+		State("editor").
+		WithInitial("blank").
+		WithInitialGuarded("hasDraft", "drafting").
+		On("CLOSE").Target("closed").End().
+		State("blank").End().
+		State("drafting").
+		WithInitial("typing").
+		State("typing").End().
+		End().
+		Done().
+		State("closed").On("OPEN").Target("editor").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "CLOSE"})
+	interp.UpdateContext(func(ctx *resumeContext) { ctx.HasDraft = true })
+	interp.Send(Event{Type: "OPEN"})
+	if interp.State().Value != "typing" {
+		t.Errorf("Expected to resume at 'typing', got %s", interp.State().Value)
+	}
+}
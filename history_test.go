@@ -471,3 +471,139 @@ func TestHistoryState_ShallowVsDeep(t *testing.T) {
 		}
 	})
 }
+
+// TestHistoryState_InParallelRegion tests that history inside a region survives
+// exiting and re-entering the parallel state
+func TestHistoryState_InParallelRegion(t *testing.T) {
+	// The 'document' region contains a compound state with a deep history state
+	machine, err := NewMachine[struct{}]("editor_app").
+		WithInitial("editor").
+		State("editor").Parallel().
+		Region("document").
+		WithInitial("start").
+		State("start").On("CONTINUE").Target("hist").EndState().
+		State("editing").
+		WithInitial("draft").
+		History("hist").Deep().Default("draft").End().
+		State("draft").On("NEXT").Target("review").End().End().
+		State("review").
+		WithInitial("reading").
+		State("reading").On("COMMENT").Target("commenting").End().End().
+		State("commenting").End().
+		End().
+		EndState().
+		EndRegion().
+		Region("sidebar").
+		WithInitial("closed").
+		State("closed").On("TOGGLE").Target("open").EndState().
+		State("open").On("TOGGLE").Target("closed").EndState().
+		EndRegion().
+		On("SAVE").Target("saved").
+		Done().
+		State("saved").
+		On("REOPEN").Target("editor").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	// Without recorded history, the default is used
+	interp.Send(Event{Type: "CONTINUE"})
+	if got := interp.State().ActiveInParallel["document"]; got != "draft" {
+		t.Fatalf("Expected 'draft' from history default, got %s", got)
+	}
+
+	interp.Send(Event{Type: "NEXT"})
+	interp.Send(Event{Type: "COMMENT"})
+	interp.Send(Event{Type: "TOGGLE"})
+	if got := interp.State().ActiveInParallel["document"]; got != "commenting" {
+		t.Fatalf("Expected 'commenting', got %s", got)
+	}
+
+	// Exit the parallel state, then re-enter it: regions start at their initial states
+	interp.Send(Event{Type: "SAVE"})
+	if interp.State().Value != "saved" {
+		t.Fatalf("Expected 'saved', got %s", interp.State().Value)
+	}
+	interp.Send(Event{Type: "REOPEN"})
+	state := interp.State()
+	if state.ActiveInParallel["document"] != "start" || state.ActiveInParallel["sidebar"] != "closed" {
+		t.Fatalf("Expected regions at 'start' and 'closed', got %v", state.ActiveInParallel)
+	}
+
+	// Targeting the history state from within the region restores the deep leaf
+	interp.Send(Event{Type: "CONTINUE"})
+	if got := interp.State().ActiveInParallel["document"]; got != "commenting" {
+		t.Errorf("Expected 'commenting' via deep history, got %s", got)
+	}
+	if !interp.Matches("review") {
+		t.Error("Expected 'review' to be active")
+	}
+}
+
+// TestHistoryState_InParallelRegionFromOutside tests that targeting a history
+// state inside a region from outside enters the parallel state at the recorded
+// leaf, with the other regions at their initial states
+func TestHistoryState_InParallelRegionFromOutside(t *testing.T) {
+	machine, err := NewMachine[struct{}]("editor_app").
+		WithInitial("editor").
+		State("editor").Parallel().
+		Region("document").
+		WithInitial("start").
+		State("start").On("CONTINUE").Target("hist").EndState().
+		State("editing").
+		WithInitial("draft").
+		History("hist").Deep().Default("draft").End().
+		State("draft").On("NEXT").Target("review").End().End().
+		State("review").
+		WithInitial("reading").
+		State("reading").On("COMMENT").Target("commenting").End().End().
+		State("commenting").End().
+		End().
+		EndState().
+		EndRegion().
+		Region("sidebar").
+		WithInitial("closed").
+		State("closed").On("TOGGLE").Target("open").EndState().
+		State("open").On("TOGGLE").Target("closed").EndState().
+		EndRegion().
+		On("SAVE").Target("saved").
+		Done().
+		State("saved").
+		On("RESTORE").Target("hist").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "CONTINUE"})
+	interp.Send(Event{Type: "NEXT"})
+	interp.Send(Event{Type: "TOGGLE"})
+	interp.Send(Event{Type: "SAVE"})
+
+	interp.Send(Event{Type: "RESTORE"})
+	state := interp.State()
+	if state.Value != "editor" {
+		t.Fatalf("Expected parallel state 'editor', got %s", state.Value)
+	}
+	if state.ActiveInParallel["document"] != "reading" {
+		t.Errorf("Expected 'reading' via deep history, got %s", state.ActiveInParallel["document"])
+	}
+	if state.ActiveInParallel["sidebar"] != "closed" {
+		t.Errorf("Expected sidebar at its initial state, got %s", state.ActiveInParallel["sidebar"])
+	}
+
+	// The restored region keeps processing events
+	interp.Send(Event{Type: "COMMENT"})
+	if got := interp.State().ActiveInParallel["document"]; got != "commenting" {
+		t.Errorf("Expected 'commenting', got %s", got)
+	}
+}
@@ -0,0 +1,241 @@
+// Package inspect streams a running interpreter to the Stately inspector and
+// other tools that speak the XState inspection protocol.
+package inspect
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/felixgeelhaar/statekit"
+	"github.com/felixgeelhaar/statekit/export"
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// UpdateEvent is the event type reported with snapshots after transitions.
+// Subscribers are not told which event caused a transition, so every update
+// carries this type.
+const UpdateEvent = "statekit.update"
+
+// queueSize is the number of updates buffered for a client; a client that
+// falls further behind is disconnected so that it cannot block the interpreter
+const queueSize = 64
+
+// Serve listens on addr and streams the interpreter to every client that
+// connects over a WebSocket, like http.ListenAndServe with Handler. It
+// returns when the listener fails.
+func Serve[C any](interp *statekit.Interpreter[C], addr string) error {
+	return http.ListenAndServe(addr, Handler(interp))
+}
+
+// Handler returns an http.Handler that upgrades requests to WebSocket
+// connections and streams the interpreter in the XState inspection protocol:
+//
+//   - an "@xstate.actor" message with the machine definition, exported as
+//     XState JSON, and the current snapshot when the client connects
+//   - an "@xstate.snapshot" message after every transition, with an event of
+//     type UpdateEvent
+//
+// Snapshots have the XState shape {"status", "value", "context", "output"}:
+// the status is "active", "done" once a top-level final state is entered, or
+// "stopped", and the value nests the active states as XState does, e.g.
+// {"active": "dont_walk"}. The context is encoded with encoding/json.
+// Messages from clients are ignored.
+func Handler[C any](interp *statekit.Interpreter[C]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s := &session[C]{interp: interp, id: interp.Machine().ID}
+		updates := make(chan []byte, queueSize)
+		overflow := make(chan struct{})
+		var once sync.Once
+		unsubscribe := interp.Subscribe(func(state statekit.State[C]) {
+			// Read the status and output while the listener is notified
+			output, _ := interp.Output()
+			message := s.snapshotMessage(state, interp.IsStarted(), output)
+			select {
+			case updates <- message:
+			default:
+				once.Do(func() { close(overflow) })
+			}
+		})
+		defer unsubscribe()
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			conn.readLoop()
+		}()
+
+		actor, err := s.actorMessage()
+		if err != nil || conn.writeText(actor) != nil {
+			return
+		}
+		for {
+			select {
+			case message := <-updates:
+				if conn.writeText(message) != nil {
+					return
+				}
+			case <-overflow:
+				conn.writeClose(closePolicyViolated)
+				return
+			case <-closed:
+				return
+			}
+		}
+	})
+}
+
+// message is a message of the XState inspection protocol
+type message struct {
+	Type       string        `json:"type"`
+	ID         string        `json:"id"`
+	SessionID  string        `json:"sessionId"`
+	RootID     string        `json:"rootId"`
+	CreatedAt  string        `json:"createdAt"`
+	Definition string        `json:"definition,omitempty"`
+	Event      *messageEvent `json:"event,omitempty"`
+	Snapshot   actorSnapshot `json:"snapshot"`
+}
+
+// messageEvent is the event reported with a snapshot
+type messageEvent struct {
+	Type string `json:"type"`
+}
+
+// actorSnapshot is a snapshot in the XState shape
+type actorSnapshot struct {
+	Status  string          `json:"status"`
+	Value   any             `json:"value"`
+	Context json.RawMessage `json:"context"`
+	Output  any             `json:"output,omitempty"`
+}
+
+// session builds the messages sent to one client
+type session[C any] struct {
+	interp *statekit.Interpreter[C]
+	id     string
+	seq    atomic.Uint64
+}
+
+// actorMessage returns the message announcing the machine and its current state
+func (s *session[C]) actorMessage() ([]byte, error) {
+	definition, err := export.NewXStateExporter(s.interp.Machine()).ExportJSON()
+	if err != nil {
+		return nil, err
+	}
+	output, _ := s.interp.Output()
+	m := s.newMessage("@xstate.actor", s.interp.State(), s.interp.IsStarted(), output)
+	m.Definition = definition
+	return json.Marshal(m)
+}
+
+// snapshotMessage returns the message reporting a state after a transition
+func (s *session[C]) snapshotMessage(state statekit.State[C], started bool, output any) []byte {
+	m := s.newMessage("@xstate.snapshot", state, started, output)
+	m.Event = &messageEvent{Type: UpdateEvent}
+	data, err := json.Marshal(m)
+	if err != nil {
+		// Outputs that cannot be encoded are left out
+		m.Snapshot.Output = nil
+		data, _ = json.Marshal(m)
+	}
+	return data
+}
+
+// newMessage returns a message of the given type carrying a snapshot of state
+func (s *session[C]) newMessage(typ string, state statekit.State[C], started bool, output any) *message {
+	return &message{
+		Type:      typ,
+		ID:        s.id + ":" + strconv.FormatUint(s.seq.Add(1), 10),
+		SessionID: s.id,
+		RootID:    s.id,
+		CreatedAt: strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Snapshot:  s.snapshot(state, started, output),
+	}
+}
+
+// snapshot converts a state to the XState snapshot shape, given whether the
+// interpreter was started and its output when the state was reported
+func (s *session[C]) snapshot(state statekit.State[C], started bool, output any) actorSnapshot {
+	machine := s.interp.Machine()
+	snapshot := actorSnapshot{
+		Status:  "active",
+		Value:   stateValue(machine, state),
+		Context: json.RawMessage("null"),
+	}
+	if context, err := json.Marshal(state.Context); err == nil {
+		snapshot.Context = context
+	}
+
+	switch current := machine.GetState(state.Value); {
+	case current == nil || !started:
+		snapshot.Status = "stopped"
+	case current.IsFinal() && current.Parent == "":
+		snapshot.Status = "done"
+		snapshot.Output = output
+	}
+	return snapshot
+}
+
+// stateValue returns the XState value of a state: the ID of an atomic
+// top-level state, or an object mapping each active compound state to the
+// value of its active child and each parallel state to the values of its
+// regions. It returns nil for a state that is not in the machine.
+func stateValue[C any](machine *ir.MachineConfig[C], state statekit.State[C]) any {
+	active := make(map[statekit.StateID]bool)
+	for _, id := range machine.GetPath(state.Value) {
+		active[id] = true
+	}
+	for _, leaf := range state.ActiveInParallel {
+		for _, id := range machine.GetPath(leaf) {
+			active[id] = true
+		}
+	}
+
+	path := machine.GetPath(state.Value)
+	if machine.GetState(path[0]) == nil {
+		return nil
+	}
+	return childValue(machine, active, path[0])
+}
+
+// childValue returns the value of an active state as it appears in its
+// parent's value: its ID if it has no children, and otherwise an object
+func childValue[C any](machine *ir.MachineConfig[C], active map[statekit.StateID]bool, id statekit.StateID) any {
+	state := machine.GetState(id)
+	if len(state.Children) == 0 {
+		return string(id)
+	}
+	return map[string]any{string(id): nestedValue(machine, active, state)}
+}
+
+// nestedValue returns the value of the active children of a state
+func nestedValue[C any](machine *ir.MachineConfig[C], active map[statekit.StateID]bool, state *ir.StateConfig) any {
+	if state.IsParallel() {
+		regions := make(map[string]any, len(state.Children))
+		for _, regionID := range state.Children {
+			region := machine.GetState(regionID)
+			if len(region.Children) == 0 {
+				regions[string(regionID)] = map[string]any{}
+				continue
+			}
+			regions[string(regionID)] = nestedValue(machine, active, region)
+		}
+		return regions
+	}
+	for _, childID := range state.Children {
+		if active[childID] {
+			return childValue(machine, active, childID)
+		}
+	}
+	return map[string]any{}
+}
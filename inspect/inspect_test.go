@@ -0,0 +1,260 @@
+package inspect
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit"
+	"github.com/felixgeelhaar/statekit/export"
+)
+
+type lightContext struct {
+	Cycles int `json:"cycles"`
+}
+
+// dial connects a WebSocket client to the test server
+func dial(t *testing.T, server *httptest.Server) *wsConn {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: inspector\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", key)
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	resp, err := http.ReadResponse(rw.Reader, nil)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101 Switching Protocols, got %s", resp.Status)
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", accept)
+	}
+	return &wsConn{conn: conn, rw: rw}
+}
+
+// readMessage reads the next text message sent by the server
+func readMessage(t *testing.T, conn *wsConn) map[string]any {
+	t.Helper()
+	opcode, payload, masked, err := conn.readFrame()
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if opcode != opText || masked {
+		t.Fatalf("expected an unmasked text frame, got opcode %d masked %v", opcode, masked)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(payload, &m); err != nil {
+		t.Fatalf("invalid message %s: %v", payload, err)
+	}
+	return m
+}
+
+// TestHandler tests that a client receives the definition on connecting and
+// a snapshot after every transition
+func TestHandler(t *testing.T) {
+	// green -> yellow -> red -> green, where red is a compound state waiting
+	// for pedestrians, and a final "off" state
+	machine, err := statekit.NewMachine[lightContext]("light").
+		WithInitial("green").
+		WithAction("count", func(ctx *lightContext, e statekit.Event) { ctx.Cycles++ }).
+		State("green").On("TIMER").Target("yellow").On("OFF").Target("off").Done().
+		State("yellow").On("TIMER").Target("red").Done().
+		State("red").
+		WithInitial("walk").
+		On("TIMER").Target("green").Do("count").End().
+		State("walk").On("COUNTDOWN").Target("wait").End().End().
+		State("wait").End().
+		Done().
+		State("off").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+
+	interp := statekit.NewInterpreter(machine)
+	interp.Start()
+
+	server := httptest.NewServer(Handler(interp))
+	defer server.Close()
+	conn := dial(t, server)
+
+	actor := readMessage(t, conn)
+	if actor["type"] != "@xstate.actor" || actor["sessionId"] != "light" {
+		t.Fatalf("expected an @xstate.actor message for 'light', got %v", actor)
+	}
+	expected, _ := export.NewXStateExporter(machine).ExportJSON()
+	if actor["definition"] != expected {
+		t.Errorf("expected the XState definition %s, got %v", expected, actor["definition"])
+	}
+	snapshot := actor["snapshot"].(map[string]any)
+	if snapshot["status"] != "active" || snapshot["value"] != "green" {
+		t.Errorf("expected active 'green', got %v", snapshot)
+	}
+
+	interp.Send(statekit.Event{Type: "TIMER"})
+	interp.Send(statekit.Event{Type: "TIMER"})
+	interp.Send(statekit.Event{Type: "TIMER"})
+	interp.Send(statekit.Event{Type: "OFF"})
+
+	values := []any{"yellow", map[string]any{"red": "walk"}, "green", "off"}
+	for _, want := range values {
+		m := readMessage(t, conn)
+		if m["type"] != "@xstate.snapshot" || m["event"].(map[string]any)["type"] != UpdateEvent {
+			t.Fatalf("expected an @xstate.snapshot message, got %v", m)
+		}
+		snapshot = m["snapshot"].(map[string]any)
+		if value := snapshot["value"]; !reflect.DeepEqual(value, want) {
+			t.Errorf("expected value %v, got %v", want, value)
+		}
+	}
+	if snapshot["status"] != "done" || !reflect.DeepEqual(snapshot["context"], map[string]any{"cycles": 1.0}) {
+		t.Errorf("expected a done snapshot after one cycle, got %v", snapshot)
+	}
+}
+
+// TestHandler_NotWebSocket tests that plain HTTP requests are rejected
+func TestHandler_NotWebSocket(t *testing.T) {
+	machine, err := statekit.NewMachine[lightContext]("light").
+		WithInitial("green").
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+	interp := statekit.NewInterpreter(machine)
+	server := httptest.NewServer(Handler(interp))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 Bad Request, got %s", resp.Status)
+	}
+}
+
+// TestStateValue tests the XState values of nested and parallel states
+func TestStateValue(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("player").
+		WithInitial("on").
+		State("on").Parallel().
+		Region("playback").
+		WithInitial("paused").
+		State("paused").EndState().
+		State("playing").EndState().
+		EndRegion().
+		Region("volume").
+		WithInitial("normal").
+		State("normal").EndState().
+		EndRegion().
+		Done().
+		State("off").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	state := statekit.State[struct{}]{
+		Value:            "on",
+		ActiveInParallel: map[statekit.StateID]statekit.StateID{"playback": "playing", "volume": "normal"},
+	}
+	expected := map[string]any{"on": map[string]any{"playback": "playing", "volume": "normal"}}
+	if value := stateValue(machine, state); !reflect.DeepEqual(value, expected) {
+		t.Errorf("expected %v, got %v", expected, value)
+	}
+
+	if value := stateValue(machine, statekit.State[struct{}]{Value: "off"}); value != "off" {
+		t.Errorf("expected 'off', got %v", value)
+	}
+	if value := stateValue(machine, statekit.State[struct{}]{}); value != nil {
+		t.Errorf("expected no value before start, got %v", value)
+	}
+}
+
+// TestSnapshot_Status tests that the status and output of a snapshot are the
+// ones read when the state was reported, not when the snapshot is encoded
+func TestSnapshot_Status(t *testing.T) {
+	machine, err := statekit.NewMachine[struct{}]("job").
+		WithInitial("running").
+		State("running").On("FINISH").Target("finished").Done().
+		State("finished").Final().Output(func(struct{}, statekit.Event) any { return 42 }).Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	interp := statekit.NewInterpreter(machine)
+	interp.Start()
+
+	var notified statekit.State[struct{}]
+	var output any
+	interp.Subscribe(func(state statekit.State[struct{}]) {
+		notified = state
+		output, _ = interp.Output()
+	})
+	interp.Send(statekit.Event{Type: "FINISH"})
+	interp.Stop()
+
+	s := &session[struct{}]{interp: interp, id: "job"}
+	if snapshot := s.snapshot(notified, true, output); snapshot.Status != "done" || snapshot.Output != 42 {
+		t.Errorf("expected the notified state to be done with output 42, got %+v", snapshot)
+	}
+	if snapshot := s.snapshot(interp.State(), interp.IsStarted(), nil); snapshot.Status != "stopped" {
+		t.Errorf("expected the stopped interpreter to report 'stopped', got %+v", snapshot)
+	}
+}
+
+// TestHandler_Close tests that the server answers pings and echoes the
+// client's close frame
+func TestHandler_Close(t *testing.T) {
+	machine, err := statekit.NewMachine[lightContext]("light").
+		WithInitial("green").
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build machine: %v", err)
+	}
+	interp := statekit.NewInterpreter(machine)
+	interp.Start()
+	server := httptest.NewServer(Handler(interp))
+	defer server.Close()
+	conn := dial(t, server)
+	readMessage(t, conn)
+
+	// Client frames are masked; a zero mask leaves the payload as is
+	conn.rw.Write([]byte{0x80 | opPing, 0x80 | 4, 0, 0, 0, 0, 'p', 'i', 'n', 'g'})
+	conn.rw.Write([]byte{0x80 | opClose, 0x80 | 2, 0, 0, 0, 0, 0x03, 0xE8})
+	conn.rw.Flush()
+
+	for _, want := range []struct {
+		opcode  byte
+		payload string
+	}{{opPong, "ping"}, {opClose, "\x03\xe8"}} {
+		opcode, payload, _, err := conn.readFrame()
+		if err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		if opcode != want.opcode || string(payload) != want.payload {
+			t.Errorf("expected opcode %d with %q, got %d with %q", want.opcode, want.payload, opcode, payload)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Package clock defines the time source used to schedule delayed transitions.
+package clock
+
+import "time"
+
+// Clock schedules functions to run after a delay
+type Clock interface {
+	// AfterFunc waits for the duration to elapse and then calls f.
+	// The returned Timer can be used to cancel the call.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// TimeSource is implemented by clocks that also report the current time
+type TimeSource interface {
+	// Now returns the current time
+	Now() time.Time
+}
+
+// Now returns the current time on c if it is a TimeSource, and the time of
+// the time package otherwise
+func Now(c Clock) time.Time {
+	if source, ok := c.(TimeSource); ok {
+		return source.Now()
+	}
+	return time.Now()
+}
+
+// Timer is a pending call scheduled by a Clock
+type Timer interface {
+	// Stop prevents the call from running. It returns false if the call has
+	// already run or been stopped.
+	Stop() bool
+}
+
+// Real is a Clock backed by the time package
+type Real struct{}
+
+// AfterFunc calls time.AfterFunc
+func (Real) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Now calls time.Now
+func (Real) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,117 @@
+package ir
+
+import (
+	"slices"
+	"time"
+)
+
+// Edge is a transition as declared on its source state. Transitions
+// inherited from ancestors are not repeated on their descendants, and
+// targets are not resolved to leaf states.
+type Edge struct {
+	From     StateID
+	To       StateID
+	Event    EventType // Empty for eventless and delayed transitions
+	Guard    GuardType
+	Delay    time.Duration
+	Internal bool
+
+	// DelayName is the named delay of the transition, if any
+	DelayName DelayType
+}
+
+// Transitions returns every declared transition of the machine as a flat
+// list of edges, for analysis tools. States are visited in declaration order
+// (see Order) and the transitions of each state in the order they were added.
+func (m *MachineConfig[C]) Transitions() []Edge {
+	var edges []Edge
+	for _, id := range m.StateIDs() {
+		for _, trans := range m.States[id].Transitions {
+			edges = append(edges, NewEdge(id, trans))
+		}
+	}
+	return edges
+}
+
+// NewEdge returns the edge of a transition declared on the given state
+func NewEdge(from StateID, trans *TransitionConfig) Edge {
+	return Edge{
+		From:      from,
+		To:        trans.Target,
+		Event:     trans.Event,
+		Guard:     trans.Guard,
+		Delay:     trans.Delay,
+		Internal:  trans.Internal,
+		DelayName: trans.DelayName,
+	}
+}
+
+// StateIDs returns the IDs of all states in declaration order. States missing
+// from Order follow the others, sorted by ID.
+func (m *MachineConfig[C]) StateIDs() []StateID {
+	ids := make([]StateID, 0, len(m.States))
+	seen := make(map[StateID]bool, len(m.States))
+	for _, id := range m.Order {
+		if _, ok := m.States[id]; ok && !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+
+	var rest []StateID
+	for id := range m.States {
+		if !seen[id] {
+			rest = append(rest, id)
+		}
+	}
+	slices.Sort(rest)
+	return append(ids, rest...)
+}
+
+// ReferencedActions returns the sorted, distinct actions referenced by the
+// states and transitions of the machine: entry and exit actions, transition
+// actions, fallible actions, and conditional actions. Compare it with the
+// registered actions to find missing or unused registrations.
+func (m *MachineConfig[C]) ReferencedActions() []ActionType {
+	var names []ActionType
+	for _, state := range m.States {
+		names = append(names, state.Entry...)
+		names = append(names, state.Exit...)
+		for _, trans := range state.Transitions {
+			names = append(names, trans.Actions...)
+			names = append(names, trans.FallibleActions...)
+			for _, conditional := range trans.ConditionalActions {
+				names = append(names, conditional.Action)
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// ReferencedGuards returns the sorted, distinct guards referenced by the
+// transitions, conditional actions, and guarded initial children of the
+// machine. Guards are listed as referenced, so combined guards and guard calls
+// such as "hasAtLeast(3)" appear under their own names. In-state guards are
+// left out, as they are evaluated by the interpreter and never registered.
+func (m *MachineConfig[C]) ReferencedGuards() []GuardType {
+	var names []GuardType
+	add := func(name GuardType) {
+		if _, inState := name.InState(); name != "" && !inState {
+			names = append(names, name)
+		}
+	}
+	for _, state := range m.States {
+		for _, trans := range state.Transitions {
+			add(trans.Guard)
+			for _, conditional := range trans.ConditionalActions {
+				add(conditional.Guard)
+			}
+		}
+		for _, choice := range state.InitialChoices {
+			add(choice.Guard)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
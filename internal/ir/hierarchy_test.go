@@ -1,6 +1,10 @@
 package ir
 
-import "testing"
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
 
 // Helper to create a hierarchical test machine:
 //
@@ -227,3 +231,28 @@ func TestMachineConfig_FindLCA(t *testing.T) {
 		t.Errorf("expected LCA of loading/loading to be 'loading', got %s", lca)
 	}
 }
+
+func TestMachineConfig_Walk(t *testing.T) {
+	m := createHierarchicalMachine()
+
+	var visited []string
+	m.Walk(func(state *StateConfig, depth int) bool {
+		visited = append(visited, fmt.Sprintf("%s:%d", state.ID, depth))
+		return true
+	})
+	expected := []string{"active:0", "idle:1", "working:1", "loading:2", "processing:2", "done:0"}
+	if !slices.Equal(visited, expected) {
+		t.Errorf("expected %v, got %v", expected, visited)
+	}
+
+	// Returning false prunes the subtree
+	visited = nil
+	m.Walk(func(state *StateConfig, depth int) bool {
+		visited = append(visited, string(state.ID))
+		return state.ID != "working"
+	})
+	expected = []string{"active", "idle", "working", "done"}
+	if !slices.Equal(visited, expected) {
+		t.Errorf("expected %v, got %v", expected, visited)
+	}
+}
@@ -1,6 +1,11 @@
 package ir
 
-import "time"
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
 
 // MachineConfig is the immutable internal representation of a statechart
 type MachineConfig[C any] struct {
@@ -10,6 +15,38 @@ type MachineConfig[C any] struct {
 	States  map[StateID]*StateConfig
 	Actions map[ActionType]Action[C]
 	Guards  map[GuardType]Guard[C]
+
+	// Guards that also inspect the active states
+	StateGuards map[GuardType]StateGuard[C]
+
+	// IDs of the states in States in the order they were declared, used by
+	// exporters for stable output. Nil when the order is unknown.
+	Order []StateID
+
+	// Transition actions that may veto their transition
+	FallibleActions map[ActionType]FallibleAction[C]
+
+	// Actions that can also raise events
+	SchedulerActions map[ActionType]SchedulerAction[C]
+
+	// Invoked services, started on state entry
+	Services map[ServiceType]Service[C]
+
+	// Named delays, resolved when the state of a delayed transition is entered
+	Delays map[DelayType]DelayResolver[C]
+
+	// Outputs of top-level final states, keyed by state
+	Outputs map[StateID]Output[C]
+
+	// Expected payload types of events, checked by interpreters in strict mode
+	EventSchemas map[EventType]reflect.Type
+
+	// Optional check of the context, run by the interpreter before entering
+	// the initial state
+	ContextValidator func(C) error
+
+	// State entered when a fallible action vetoes a transition (none if empty)
+	ErrorTarget StateID
 }
 
 // StateConfig represents a single state node
@@ -26,6 +63,32 @@ type StateConfig struct {
 	// History state fields (v2.0)
 	HistoryType    HistoryType // Shallow or Deep (only for StateTypeHistory)
 	HistoryDefault StateID     // Default target if no history recorded
+
+	// Invoked services, started on entry and canceled on exit
+	Invoke []ServiceType
+
+	// Deferred events are held while the state is active and no transition
+	// handles them, and re-delivered after the next transition
+	Deferred []EventType
+
+	// Meta holds arbitrary metadata, such as descriptions or UI hints
+	Meta map[string]any
+
+	// InitialChoices are guarded initial children of a compound state, tried in
+	// order when it is entered. Initial is entered if no guard passes.
+	InitialChoices []InitialChoice
+}
+
+// InitialChoice is a guarded initial child of a compound state
+type InitialChoice struct {
+	Guard  GuardType `json:"guard"`
+	Target StateID   `json:"target"`
+}
+
+// ConditionalAction is a transition action that runs only if its guard passes
+type ConditionalAction struct {
+	Guard  GuardType  `json:"guard"`
+	Action ActionType `json:"action"`
 }
 
 // TransitionConfig represents a single transition
@@ -35,25 +98,74 @@ type TransitionConfig struct {
 	Guard   GuardType // Optional, empty string means no guard
 	Actions []ActionType
 
+	// FallibleActions run before any exit action; an error vetoes the transition
+	FallibleActions []ActionType
+
+	// ConditionalActions run after Actions, each only if its guard passes.
+	// Their guards do not affect whether the transition is taken.
+	ConditionalActions []ConditionalAction
+
 	// Delayed transition fields (v2.0)
-	// When Delay > 0, this is a delayed (after) transition
-	Delay time.Duration
+	// When Delay > 0 or DelayName is set, this is a delayed (after) transition
+	Delay     time.Duration
+	DelayName DelayType // Named delay resolved from the machine's Delays
+
+	// Repeat re-arms a delayed transition that is not taken when its timer
+	// fires, e.g. because its guard fails, for as long as its state is active
+	Repeat bool
+
+	// Eventless transition field
+	// When Always is true, the transition has no event and is taken as soon
+	// as its source state is active and its guard passes
+	Always bool
+
+	// Internal transitions do not exit and re-enter their source state
+	// when targeting it; only the transition actions run
+	Internal bool
+
+	// Bubble defers the transition to an ancestor: if an ancestor of the source
+	// state has a transition for the same event whose guard passes, that
+	// transition is taken instead
+	Bubble bool
 }
 
 // IsDelayed returns true if this is a delayed transition
 func (t *TransitionConfig) IsDelayed() bool {
-	return t.Delay > 0
+	return t.Delay > 0 || t.DelayName != ""
+}
+
+// IsInternal returns true if this is an internal transition
+func (t *TransitionConfig) IsInternal() bool {
+	return t.Internal
+}
+
+// IsWildcard returns true if this transition matches any event
+func (t *TransitionConfig) IsWildcard() bool {
+	return t.Event == WildcardEvent
+}
+
+// IsAlways returns true if this is an eventless (always) transition
+func (t *TransitionConfig) IsAlways() bool {
+	return t.Always
 }
 
 // NewMachineConfig creates a new MachineConfig with initialized maps
 func NewMachineConfig[C any](id string, initial StateID, ctx C) *MachineConfig[C] {
 	return &MachineConfig[C]{
-		ID:      id,
-		Initial: initial,
-		Context: ctx,
-		States:  make(map[StateID]*StateConfig),
-		Actions: make(map[ActionType]Action[C]),
-		Guards:  make(map[GuardType]Guard[C]),
+		ID:       id,
+		Initial:  initial,
+		Context:  ctx,
+		States:   make(map[StateID]*StateConfig),
+		Actions:  make(map[ActionType]Action[C]),
+		Guards:   make(map[GuardType]Guard[C]),
+		Services: make(map[ServiceType]Service[C]),
+		Delays:   make(map[DelayType]DelayResolver[C]),
+		Outputs:  make(map[StateID]Output[C]),
+
+		FallibleActions:  make(map[ActionType]FallibleAction[C]),
+		SchedulerActions: make(map[ActionType]SchedulerAction[C]),
+		StateGuards:      make(map[GuardType]StateGuard[C]),
+		EventSchemas:     make(map[EventType]reflect.Type),
 	}
 }
 
@@ -81,6 +193,61 @@ func NewTransitionConfig(event EventType, target StateID) *TransitionConfig {
 	}
 }
 
+// Clone returns a copy of the machine that can be modified without affecting
+// m: states, transitions, and the maps of actions, guards, and other functions
+// are copied, while the functions themselves are shared. The context is copied
+// by value, so slices, maps, and pointers in it are shared with m; use
+// CloneWithContext to give the copy a fresh context.
+func (m *MachineConfig[C]) Clone() *MachineConfig[C] {
+	return m.CloneWithContext(m.Context)
+}
+
+// CloneWithContext is like Clone, but sets the context of the copy to ctx.
+// Interpreters of machines cloned with contexts that share no memory cannot
+// affect each other through their initial context.
+func (m *MachineConfig[C]) CloneWithContext(ctx C) *MachineConfig[C] {
+	clone := *m
+	clone.Context = ctx
+	clone.States = make(map[StateID]*StateConfig, len(m.States))
+	for id, state := range m.States {
+		clone.States[id] = state.clone()
+	}
+	clone.Order = slices.Clone(m.Order)
+	clone.Actions = maps.Clone(m.Actions)
+	clone.Guards = maps.Clone(m.Guards)
+	clone.StateGuards = maps.Clone(m.StateGuards)
+	clone.FallibleActions = maps.Clone(m.FallibleActions)
+	clone.SchedulerActions = maps.Clone(m.SchedulerActions)
+	clone.Services = maps.Clone(m.Services)
+	clone.Delays = maps.Clone(m.Delays)
+	clone.Outputs = maps.Clone(m.Outputs)
+	clone.EventSchemas = maps.Clone(m.EventSchemas)
+	return &clone
+}
+
+// clone returns a copy of the state and its transitions. Meta values are shared.
+func (s *StateConfig) clone() *StateConfig {
+	clone := *s
+	clone.Children = slices.Clone(s.Children)
+	clone.Entry = slices.Clone(s.Entry)
+	clone.Exit = slices.Clone(s.Exit)
+	clone.Invoke = slices.Clone(s.Invoke)
+	clone.Deferred = slices.Clone(s.Deferred)
+	clone.Meta = maps.Clone(s.Meta)
+	clone.InitialChoices = slices.Clone(s.InitialChoices)
+	if s.Transitions != nil {
+		clone.Transitions = make([]*TransitionConfig, len(s.Transitions))
+		for i, trans := range s.Transitions {
+			transClone := *trans
+			transClone.Actions = slices.Clone(trans.Actions)
+			transClone.FallibleActions = slices.Clone(trans.FallibleActions)
+			transClone.ConditionalActions = slices.Clone(trans.ConditionalActions)
+			clone.Transitions[i] = &transClone
+		}
+	}
+	return &clone
+}
+
 // GetState returns the state config for the given ID, or nil if not found
 func (m *MachineConfig[C]) GetState(id StateID) *StateConfig {
 	return m.States[id]
@@ -91,11 +258,55 @@ func (m *MachineConfig[C]) GetAction(t ActionType) Action[C] {
 	return m.Actions[t]
 }
 
+// GetSchedulerAction returns the scheduler action for the given type, or nil if not found
+func (m *MachineConfig[C]) GetSchedulerAction(t ActionType) SchedulerAction[C] {
+	return m.SchedulerActions[t]
+}
+
+// HasAction reports whether an action or scheduler action is registered under the name
+func (m *MachineConfig[C]) HasAction(t ActionType) bool {
+	_, ok := m.Actions[t]
+	_, isScheduler := m.SchedulerActions[t]
+	return ok || isScheduler
+}
+
+// GetFallibleAction returns the fallible action for the given type, or nil if not found
+func (m *MachineConfig[C]) GetFallibleAction(t ActionType) FallibleAction[C] {
+	return m.FallibleActions[t]
+}
+
 // GetGuard returns the guard for the given type, or nil if not found
 func (m *MachineConfig[C]) GetGuard(t GuardType) Guard[C] {
 	return m.Guards[t]
 }
 
+// GetStateGuard returns the state guard for the given type, or nil if not found
+func (m *MachineConfig[C]) GetStateGuard(t GuardType) StateGuard[C] {
+	return m.StateGuards[t]
+}
+
+// HasGuard reports whether a guard or state guard is registered under the name
+func (m *MachineConfig[C]) HasGuard(t GuardType) bool {
+	_, ok := m.Guards[t]
+	_, isState := m.StateGuards[t]
+	return ok || isState
+}
+
+// GetService returns the service for the given type, or nil if not found
+func (m *MachineConfig[C]) GetService(t ServiceType) Service[C] {
+	return m.Services[t]
+}
+
+// GetDelay returns the resolver for the given delay, or nil if not found
+func (m *MachineConfig[C]) GetDelay(t DelayType) DelayResolver[C] {
+	return m.Delays[t]
+}
+
+// GetOutput returns the output of the given state, or nil if it has none
+func (m *MachineConfig[C]) GetOutput(id StateID) Output[C] {
+	return m.Outputs[id]
+}
+
 // FindTransition finds the first matching transition for the given event
 // Returns nil if no matching transition is found
 func (s *StateConfig) FindTransition(event EventType) *TransitionConfig {
@@ -143,11 +354,24 @@ func (s *StateConfig) GetDelayedTransitions() []*TransitionConfig {
 	return delayed
 }
 
-// GetAncestors returns all ancestor state IDs from immediate parent to root
+// GetAlwaysTransitions returns all eventless transitions for this state
+func (s *StateConfig) GetAlwaysTransitions() []*TransitionConfig {
+	var always []*TransitionConfig
+	for _, t := range s.Transitions {
+		if t.IsAlways() {
+			always = append(always, t)
+		}
+	}
+	return always
+}
+
+// GetAncestors returns all ancestor state IDs from immediate parent to root.
+// If the parent links loop, which Validate reports as CYCLIC_HIERARCHY, the
+// walk stops after as many ancestors as there are states.
 func (m *MachineConfig[C]) GetAncestors(stateID StateID) []StateID {
 	var ancestors []StateID
 	current := m.GetState(stateID)
-	for current != nil && current.Parent != "" {
+	for current != nil && current.Parent != "" && len(ancestors) < len(m.States) {
 		ancestors = append(ancestors, current.Parent)
 		current = m.GetState(current.Parent)
 	}
@@ -166,16 +390,45 @@ func (m *MachineConfig[C]) GetPath(stateID StateID) []StateID {
 	return path
 }
 
+// Walk visits the states of the machine depth-first: each root state in
+// declaration order (see StateIDs), then its children in order before the next
+// root. depth is 0 for root states and grows by one per level. If visit
+// returns false, the state's descendants are skipped. Each state is visited at
+// most once, even in a malformed machine whose children loop.
+func (m *MachineConfig[C]) Walk(visit func(state *StateConfig, depth int) bool) {
+	seen := make(map[StateID]bool, len(m.States))
+	var walk func(id StateID, depth int)
+	walk = func(id StateID, depth int) {
+		state := m.States[id]
+		if state == nil || seen[id] {
+			return
+		}
+		seen[id] = true
+		if !visit(state, depth) {
+			return
+		}
+		for _, childID := range state.Children {
+			walk(childID, depth+1)
+		}
+	}
+	for _, id := range m.StateIDs() {
+		if m.States[id].Parent == "" {
+			walk(id, 0)
+		}
+	}
+}
+
 // GetInitialLeaf resolves the initial state to its deepest leaf
 // For atomic states, returns the state itself
-// For compound states, recursively follows initial children
+// For compound states, follows initial children, at most once per state so
+// that a malformed machine whose initial children loop cannot hang
 func (m *MachineConfig[C]) GetInitialLeaf(stateID StateID) StateID {
-	state := m.GetState(stateID)
-	if state == nil {
-		return stateID
-	}
-	if state.IsCompound() && state.Initial != "" {
-		return m.GetInitialLeaf(state.Initial)
+	for range len(m.States) {
+		state := m.GetState(stateID)
+		if state == nil || !state.IsCompound() || state.Initial == "" {
+			break
+		}
+		stateID = state.Initial
 	}
 	return stateID
 }
@@ -146,6 +146,44 @@ func TestStateConfig_FindTransition(t *testing.T) {
 	}
 }
 
+func TestMachineConfig_Clone(t *testing.T) {
+	machine := NewMachineConfig("test", "green", testContext{Count: 1})
+	green := NewStateConfig("green", StateTypeAtomic)
+	green.Entry = []ActionType{"log"}
+	green.Meta = map[string]any{"color": "green"}
+	green.Transitions = []*TransitionConfig{NewTransitionConfig("TIMER", "yellow")}
+	green.Transitions[0].Actions = []ActionType{"count"}
+	machine.States["green"] = green
+	machine.Actions["log"] = func(ctx *testContext, e Event) {}
+
+	clone := machine.Clone()
+	if clone.Context.Count != 1 || clone.GetState("green").Transitions[0].Target != "yellow" {
+		t.Fatalf("expected the clone to match the machine, got %+v", clone)
+	}
+
+	cloneGreen := clone.GetState("green")
+	cloneGreen.Entry[0] = "changed"
+	cloneGreen.Meta["color"] = "changed"
+	cloneGreen.Transitions[0].Target = "red"
+	cloneGreen.Transitions[0].Actions[0] = "changed"
+	clone.States["yellow"] = NewStateConfig("yellow", StateTypeAtomic)
+	delete(clone.Actions, "log")
+
+	if green.Entry[0] != "log" || green.Meta["color"] != "green" {
+		t.Error("expected the state to be unchanged")
+	}
+	if green.Transitions[0].Target != "yellow" || green.Transitions[0].Actions[0] != "count" {
+		t.Error("expected the transition to be unchanged")
+	}
+	if machine.GetState("yellow") != nil || machine.GetAction("log") == nil {
+		t.Error("expected the machine's maps to be unchanged")
+	}
+
+	if ctxClone := machine.CloneWithContext(testContext{Count: 5}); ctxClone.Context.Count != 5 || machine.Context.Count != 1 {
+		t.Errorf("expected only the clone to get the new context, got %d and %d", ctxClone.Context.Count, machine.Context.Count)
+	}
+}
+
 func TestStateType_String(t *testing.T) {
 	tests := []struct {
 		st   StateType
@@ -0,0 +1,326 @@
+package ir
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Registry holds the function implementations that UnmarshalConfig links
+// to the names stored in a serialized machine
+type Registry[C any] struct {
+	Actions          map[ActionType]Action[C]
+	FallibleActions  map[ActionType]FallibleAction[C]
+	SchedulerActions map[ActionType]SchedulerAction[C]
+	Guards           map[GuardType]Guard[C]
+	StateGuards      map[GuardType]StateGuard[C]
+	Services         map[ServiceType]Service[C]
+	Delays           map[DelayType]DelayResolver[C]
+
+	// GuardFactories instantiate guard calls such as "hasAtLeast(3)" that are
+	// not in Guards
+	GuardFactories map[GuardType]GuardFactory[C]
+
+	Outputs map[StateID]Output[C]
+
+	// EventSchemas are set on the restored machine
+	EventSchemas map[EventType]reflect.Type
+
+	// ContextValidator is set on the restored machine
+	ContextValidator func(C) error
+}
+
+// machineJSON is the serialized form of a MachineConfig.
+// Functions are stored by name only.
+type machineJSON struct {
+	ID              string                `json:"id"`
+	Initial         StateID               `json:"initial"`
+	Context         json.RawMessage       `json:"context,omitempty"`
+	States          map[StateID]stateJSON `json:"states"`
+	Order           []StateID             `json:"order,omitempty"`
+	Actions         []ActionType          `json:"actions,omitempty"`
+	FallibleActions []ActionType          `json:"fallibleActions,omitempty"`
+	Guards          []GuardType           `json:"guards,omitempty"`
+	Services        []ServiceType         `json:"services,omitempty"`
+	Delays          []DelayType           `json:"delays,omitempty"`
+	Outputs         []StateID             `json:"outputs,omitempty"`
+	ErrorTarget     StateID               `json:"errorTarget,omitempty"`
+}
+
+// stateJSON is the serialized form of a StateConfig
+type stateJSON struct {
+	Type           string           `json:"type"`
+	Parent         StateID          `json:"parent,omitempty"`
+	Initial        StateID          `json:"initial,omitempty"`
+	Children       []StateID        `json:"children,omitempty"`
+	Entry          []ActionType     `json:"entry,omitempty"`
+	Exit           []ActionType     `json:"exit,omitempty"`
+	Transitions    []transitionJSON `json:"transitions,omitempty"`
+	History        string           `json:"history,omitempty"` // "shallow" or "deep" (only for history states)
+	HistoryDefault StateID          `json:"historyDefault,omitempty"`
+	Invoke         []ServiceType    `json:"invoke,omitempty"`
+	Deferred       []EventType      `json:"deferred,omitempty"`
+	Meta           map[string]any   `json:"meta,omitempty"`
+	InitialChoices []InitialChoice  `json:"initialChoices,omitempty"`
+}
+
+// transitionJSON is the serialized form of a TransitionConfig
+type transitionJSON struct {
+	Event              EventType           `json:"event,omitempty"`
+	Target             StateID             `json:"target,omitempty"`
+	Guard              GuardType           `json:"guard,omitempty"`
+	Actions            []ActionType        `json:"actions,omitempty"`
+	FallibleActions    []ActionType        `json:"fallibleActions,omitempty"`
+	ConditionalActions []ConditionalAction `json:"conditionalActions,omitempty"`
+	Delay              string              `json:"delay,omitempty"` // time.Duration string, e.g. "1m30s"
+	DelayName          DelayType           `json:"delayName,omitempty"`
+	Always             bool                `json:"always,omitempty"`
+	Internal           bool                `json:"internal,omitempty"`
+	Bubble             bool                `json:"bubble,omitempty"`
+	Repeat             bool                `json:"repeat,omitempty"`
+}
+
+// MarshalConfig serializes the structure of a machine as JSON: states,
+// transitions, and the names of its actions, guards, services, and delays,
+// and the states that have an output.
+// Function values are not serialized; UnmarshalConfig links them again by name.
+// The context is included and must be JSON-marshalable.
+func MarshalConfig[C any](m *MachineConfig[C]) ([]byte, error) {
+	ctx, err := json.Marshal(m.Context)
+	if err != nil {
+		return nil, fmt.Errorf("marshal context: %w", err)
+	}
+
+	// Scheduler actions and state guards are listed with the other actions and
+	// guards and linked by name
+	actions := slices.AppendSeq(slices.Collect(maps.Keys(m.Actions)), maps.Keys(m.SchedulerActions))
+	slices.Sort(actions)
+	guards := slices.AppendSeq(slices.Collect(maps.Keys(m.Guards)), maps.Keys(m.StateGuards))
+	slices.Sort(guards)
+
+	doc := machineJSON{
+		ID:              m.ID,
+		Initial:         m.Initial,
+		Context:         ctx,
+		States:          make(map[StateID]stateJSON, len(m.States)),
+		Order:           m.Order,
+		Actions:         actions,
+		FallibleActions: slices.Sorted(maps.Keys(m.FallibleActions)),
+		Guards:          guards,
+		Services:        slices.Sorted(maps.Keys(m.Services)),
+		Delays:          slices.Sorted(maps.Keys(m.Delays)),
+		Outputs:         slices.Sorted(maps.Keys(m.Outputs)),
+		ErrorTarget:     m.ErrorTarget,
+	}
+
+	for id, state := range m.States {
+		s := stateJSON{
+			Type:           state.Type.String(),
+			Parent:         state.Parent,
+			Initial:        state.Initial,
+			Children:       state.Children,
+			Entry:          state.Entry,
+			Exit:           state.Exit,
+			HistoryDefault: state.HistoryDefault,
+			Invoke:         state.Invoke,
+			Deferred:       state.Deferred,
+			Meta:           state.Meta,
+			InitialChoices: state.InitialChoices,
+		}
+		if state.Type == StateTypeHistory {
+			s.History = state.HistoryType.String()
+		}
+		for _, trans := range state.Transitions {
+			t := transitionJSON{
+				Event:              trans.Event,
+				Target:             trans.Target,
+				Guard:              trans.Guard,
+				Actions:            trans.Actions,
+				FallibleActions:    trans.FallibleActions,
+				ConditionalActions: trans.ConditionalActions,
+				DelayName:          trans.DelayName,
+				Always:             trans.Always,
+				Internal:           trans.Internal,
+				Bubble:             trans.Bubble,
+				Repeat:             trans.Repeat,
+			}
+			if trans.Delay != 0 {
+				t.Delay = trans.Delay.String()
+			}
+			s.Transitions = append(s.Transitions, t)
+		}
+		doc.States[id] = s
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// UnmarshalConfig rebuilds a machine serialized by MarshalConfig, linking each
+// named action, guard, service, and delay to its implementation in the registry.
+// Guard calls missing from the registry's guards are instantiated by their factory.
+// The result is validated; names missing from the registry are reported as a
+// *ValidationError.
+func UnmarshalConfig[C any](data []byte, registry Registry[C]) (*MachineConfig[C], error) {
+	var doc machineJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	var ctx C
+	if len(doc.Context) > 0 {
+		if err := json.Unmarshal(doc.Context, &ctx); err != nil {
+			return nil, fmt.Errorf("unmarshal context: %w", err)
+		}
+	}
+
+	m := NewMachineConfig(doc.ID, doc.Initial, ctx)
+	m.Order = doc.Order
+	m.ErrorTarget = doc.ErrorTarget
+	m.ContextValidator = registry.ContextValidator
+	maps.Copy(m.EventSchemas, registry.EventSchemas)
+	errs := &ValidationError{}
+
+	for _, name := range doc.Actions {
+		if action, ok := registry.Actions[name]; ok {
+			m.Actions[name] = action
+		} else if action, ok := registry.SchedulerActions[name]; ok {
+			m.SchedulerActions[name] = action
+		} else {
+			errs.AddIssue(ErrCodeMissingAction,
+				fmt.Sprintf("action '%s' is not in the registry", name),
+				"actions", string(name))
+		}
+	}
+	for _, name := range doc.FallibleActions {
+		if action, ok := registry.FallibleActions[name]; ok {
+			m.FallibleActions[name] = action
+		} else {
+			errs.AddIssue(ErrCodeMissingAction,
+				fmt.Sprintf("fallible action '%s' is not in the registry", name),
+				"fallibleActions", string(name))
+		}
+	}
+	for _, name := range doc.Guards {
+		if guard, ok := registry.Guards[name]; ok {
+			m.Guards[name] = guard
+			continue
+		}
+		if guard, ok := registry.StateGuards[name]; ok {
+			m.StateGuards[name] = guard
+			continue
+		}
+		if factoryName, args, ok := name.Call(); ok && registry.GuardFactories[factoryName] != nil {
+			if guard := registry.GuardFactories[factoryName](args); guard != nil {
+				m.Guards[name] = guard
+			} else {
+				errs.AddIssue(ErrCodeInvalidGuardArgs,
+					fmt.Sprintf("guard factory '%s' rejected the arguments of '%s'", factoryName, name),
+					"guards", string(name))
+			}
+			continue
+		}
+		errs.AddIssue(ErrCodeMissingGuard,
+			fmt.Sprintf("guard '%s' is not in the registry", name),
+			"guards", string(name))
+	}
+	for _, name := range doc.Services {
+		if service, ok := registry.Services[name]; ok {
+			m.Services[name] = service
+		} else {
+			errs.AddIssue(ErrCodeMissingService,
+				fmt.Sprintf("service '%s' is not in the registry", name),
+				"services", string(name))
+		}
+	}
+	for _, name := range doc.Delays {
+		if resolver, ok := registry.Delays[name]; ok {
+			m.Delays[name] = resolver
+		} else {
+			errs.AddIssue(ErrCodeMissingDelay,
+				fmt.Sprintf("delay '%s' is not in the registry", name),
+				"delays", string(name))
+		}
+	}
+	for _, id := range doc.Outputs {
+		if output, ok := registry.Outputs[id]; ok {
+			m.Outputs[id] = output
+		} else {
+			errs.AddIssue(ErrCodeInvalidOutput,
+				fmt.Sprintf("output of '%s' is not in the registry", id),
+				"outputs", string(id))
+		}
+	}
+
+	for id, s := range doc.States {
+		stateType, ok := parseStateType(s.Type)
+		if !ok {
+			return nil, fmt.Errorf("unmarshal config: state %q has unknown type %q", id, s.Type)
+		}
+
+		state := NewStateConfig(id, stateType)
+		state.Parent = s.Parent
+		state.Initial = s.Initial
+		state.Children = s.Children
+		state.Entry = s.Entry
+		state.Exit = s.Exit
+		state.HistoryDefault = s.HistoryDefault
+		state.Invoke = s.Invoke
+		state.Deferred = s.Deferred
+		state.Meta = s.Meta
+		state.InitialChoices = s.InitialChoices
+
+		switch s.History {
+		case "", "shallow":
+			state.HistoryType = HistoryTypeShallow
+		case "deep":
+			state.HistoryType = HistoryTypeDeep
+		default:
+			return nil, fmt.Errorf("unmarshal config: state %q has unknown history type %q", id, s.History)
+		}
+
+		for j, t := range s.Transitions {
+			trans := NewTransitionConfig(t.Event, t.Target)
+			trans.Guard = t.Guard
+			trans.Actions = t.Actions
+			trans.FallibleActions = t.FallibleActions
+			trans.ConditionalActions = t.ConditionalActions
+			trans.DelayName = t.DelayName
+			trans.Always = t.Always
+			trans.Internal = t.Internal
+			trans.Bubble = t.Bubble
+			trans.Repeat = t.Repeat
+			if t.Delay != "" {
+				delay, err := time.ParseDuration(t.Delay)
+				if err != nil {
+					return nil, fmt.Errorf("unmarshal config: state %q transition %d: %w", id, j, err)
+				}
+				trans.Delay = delay
+			}
+			state.Transitions = append(state.Transitions, trans)
+		}
+
+		m.States[id] = state
+	}
+
+	if err := Validate(m); err != nil {
+		errs.Issues = append(errs.Issues, err.Issues...)
+	}
+	if errs.HasIssues() {
+		return nil, errs
+	}
+
+	return m, nil
+}
+
+// parseStateType is the inverse of StateType.String
+func parseStateType(s string) (StateType, bool) {
+	for _, t := range []StateType{StateTypeAtomic, StateTypeCompound, StateTypeFinal, StateTypeHistory, StateTypeParallel} {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
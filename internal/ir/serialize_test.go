@@ -0,0 +1,99 @@
+package ir
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalConfig_DelayAndHistory(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "parent", testCtx{})
+	parent := NewStateConfig("parent", StateTypeCompound)
+	parent.Initial = "idle"
+	parent.Children = []StateID{"hist", "idle"}
+	machine.States["parent"] = parent
+
+	hist := NewStateConfig("hist", StateTypeHistory)
+	hist.Parent = "parent"
+	hist.HistoryType = HistoryTypeDeep
+	hist.HistoryDefault = "idle"
+	machine.States["hist"] = hist
+
+	idle := NewStateConfig("idle", StateTypeAtomic)
+	idle.Parent = "parent"
+	delayed := NewTransitionConfig("", "hist")
+	delayed.Delay = 90 * time.Second
+	idle.Transitions = []*TransitionConfig{delayed}
+	machine.States["idle"] = idle
+
+	data, err := MarshalConfig(machine)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	for _, want := range []string{`"delay": "1m30s"`, `"history": "deep"`, `"type": "compound"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, data)
+		}
+	}
+
+	restored, err := UnmarshalConfig(data, Registry[testCtx]{})
+	if err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if restored.States["idle"].Transitions[0].Delay != 90*time.Second {
+		t.Errorf("expected delay 1m30s, got %v", restored.States["idle"].Transitions[0].Delay)
+	}
+	if restored.States["hist"].HistoryType != HistoryTypeDeep {
+		t.Errorf("expected deep history, got %v", restored.States["hist"].HistoryType)
+	}
+}
+
+func TestUnmarshalConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"malformed", `{"id":`, "unmarshal config"},
+		{"unknown type", `{"id":"m","initial":"a","states":{"a":{"type":"weird"}}}`, `unknown type "weird"`},
+		{"unknown history", `{"id":"m","initial":"a","states":{"a":{"type":"atomic","history":"wide"}}}`, `unknown history type "wide"`},
+		{"bad delay", `{"id":"m","initial":"a","states":{"a":{"type":"atomic","transitions":[{"target":"a","delay":"soon"}]}}}`, `invalid duration`},
+		{"missing action", `{"id":"m","initial":"a","states":{"a":{"type":"atomic"}},"actions":["log"]}`, "action 'log' is not in the registry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalConfig([]byte(tt.data), Registry[testCtx]{})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalConfig_GuardFactory(t *testing.T) {
+	data := `{"id":"m","initial":"a","states":{"a":{"type":"atomic","transitions":[{"event":"GO","target":"a","guard":"atLeast(2)"}]}},"guards":["atLeast(2)"]}`
+	var calls [][]string
+	registry := Registry[testCtx]{
+		GuardFactories: map[GuardType]GuardFactory[testCtx]{
+			"atLeast": func(args []string) Guard[testCtx] {
+				calls = append(calls, args)
+				return func(testCtx, Event) bool { return true }
+			},
+		},
+	}
+
+	m, err := UnmarshalConfig([]byte(data), registry)
+	if err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if m.GetGuard("atLeast(2)") == nil {
+		t.Error("expected guard call to be instantiated")
+	}
+	if len(calls) != 1 || len(calls[0]) != 1 || calls[0][0] != "2" {
+		t.Errorf("expected factory called with [2], got %v", calls)
+	}
+}
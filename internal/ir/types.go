@@ -1,5 +1,11 @@
 package ir
 
+import (
+	"context"
+	"strings"
+	"time"
+)
+
 // StateType represents the kind of state node
 type StateType int
 
@@ -59,6 +65,9 @@ func (h HistoryType) String() string {
 // EventType is a named event identifier
 type EventType string
 
+// WildcardEvent matches any event not handled by a specific transition in the same state
+const WildcardEvent EventType = "*"
+
 // StateID uniquely identifies a state within a machine
 type StateID string
 
@@ -68,14 +77,150 @@ type ActionType string
 // GuardType identifies a named guard
 type GuardType string
 
-// Event represents a runtime event with optional payload
+// inStatePrefix starts the name of an in-state guard. It cannot be parsed as a
+// guard call, so a guard factory named "in" does not take over In guards.
+const inStatePrefix = "in:"
+
+// InStateGuard returns the guard that passes while the given state is active,
+// named "in:<id>". In-state guards are not registered on the machine; the
+// interpreter evaluates them against its active states.
+func InStateGuard(id StateID) GuardType {
+	return GuardType(inStatePrefix + string(id))
+}
+
+// InState returns the state checked by an in-state guard
+func (g GuardType) InState() (StateID, bool) {
+	id, ok := strings.CutPrefix(string(g), inStatePrefix)
+	if !ok || id == "" {
+		return "", false
+	}
+	return StateID(id), true
+}
+
+// GuardCall returns the guard that instantiates the named guard factory with
+// the given arguments, e.g. GuardCall("hasAtLeast", "3") is "hasAtLeast(3)"
+func GuardCall(factory GuardType, args ...string) GuardType {
+	return GuardType(string(factory) + "(" + strings.Join(args, ", ") + ")")
+}
+
+// Call returns the factory name and arguments of a guard call such as
+// "hasAtLeast(3)". Arguments are separated by commas and trimmed.
+func (g GuardType) Call() (GuardType, []string, bool) {
+	s := string(g)
+	open := strings.Index(s, "(")
+	if open <= 0 || !strings.HasSuffix(s, ")") {
+		return "", nil, false
+	}
+	name := s[:open]
+	for _, r := range name {
+		if !isGuardNameRune(r) {
+			return "", nil, false
+		}
+	}
+	inner := s[open+1 : len(s)-1]
+	if strings.ContainsAny(inner, "()") {
+		return "", nil, false
+	}
+	var args []string
+	if strings.TrimSpace(inner) != "" {
+		for _, arg := range strings.Split(inner, ",") {
+			arg = strings.TrimSpace(arg)
+			if arg == "" {
+				return "", nil, false
+			}
+			args = append(args, arg)
+		}
+	}
+	return GuardType(name), args, true
+}
+
+// isGuardNameRune reports whether r may appear in the name of a guard factory
+func isGuardNameRune(r rune) bool {
+	return r == '_' || r == '.' || r == '-' ||
+		(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// ServiceType identifies a named invoked service
+type ServiceType string
+
+// DelayType identifies a named delay
+type DelayType string
+
+// Event represents a runtime event with optional payload and metadata
 type Event struct {
 	Type    EventType
 	Payload any
+
+	// Optional metadata for tracing and auditing, not interpreted by the
+	// interpreter. Events raised by the interpreter itself have none.
+	ID            string    // Unique ID of the event
+	CorrelationID string    // ID shared by related events, e.g. a request ID
+	Time          time.Time // When the event occurred
+}
+
+// WithPayload returns a copy of the event with the given payload
+func (e Event) WithPayload(payload any) Event {
+	e.Payload = payload
+	return e
+}
+
+// WithID returns a copy of the event with the given ID
+func (e Event) WithID(id string) Event {
+	e.ID = id
+	return e
+}
+
+// WithCorrelationID returns a copy of the event with the given correlation ID
+func (e Event) WithCorrelationID(id string) Event {
+	e.CorrelationID = id
+	return e
+}
+
+// WithTime returns a copy of the event with the given time
+func (e Event) WithTime(t time.Time) Event {
+	e.Time = t
+	return e
 }
 
 // Action is a side-effect function executed during transitions
 type Action[C any] func(ctx *C, event Event)
 
+// Scheduler lets an action schedule work on its interpreter
+type Scheduler interface {
+	// Raise queues an event, processed after the current event completes
+	Raise(event Event)
+}
+
+// SchedulerAction is an action that can also schedule events
+type SchedulerAction[C any] func(ctx *C, event Event, sched Scheduler)
+
+// FallibleAction is a transition action that can veto the transition by returning an error
+type FallibleAction[C any] func(ctx *C, event Event) error
+
 // Guard is a predicate that determines if a transition should occur
 type Guard[C any] func(ctx C, event Event) bool
+
+// StateSnapshot is a read-only view of the active states of an interpreter
+type StateSnapshot interface {
+	// Value returns the active leaf state, or the parallel state while one is active
+	Value() StateID
+	// Matches reports whether the state or one of its descendants is active
+	Matches(id StateID) bool
+}
+
+// StateGuard is a guard that also inspects the active states of the interpreter
+type StateGuard[C any] func(ctx C, event Event, snap StateSnapshot) bool
+
+// GuardFactory creates a guard from the arguments of a guard call such as
+// "hasAtLeast(3)". It returns nil if the arguments are invalid.
+type GuardFactory[C any] func(args []string) Guard[C]
+
+// DelayResolver computes the duration of a named delay when its state is entered
+type DelayResolver[C any] func(ctx C, event Event) time.Duration
+
+// Output computes the result of a machine when a top-level final state is entered
+type Output[C any] func(ctx C, event Event) any
+
+// Service is a long-running function invoked while a state is active.
+// The context is canceled when the invoking state is exited.
+type Service[C any] func(ctx context.Context, machineCtx C, event Event) (any, error)
@@ -64,12 +64,19 @@ const (
 	ErrCodeInvalidTarget          = "INVALID_TARGET"
 	ErrCodeMissingAction          = "MISSING_ACTION"
 	ErrCodeMissingGuard           = "MISSING_GUARD"
+	ErrCodeGuardCycle             = "GUARD_CYCLE"
+	ErrCodeInvalidGuardArgs       = "INVALID_GUARD_ARGS"
+	ErrCodeInvalidInState         = "INVALID_IN_STATE"
+	ErrCodeMissingService         = "MISSING_SERVICE"
+	ErrCodeMissingDelay           = "MISSING_DELAY"
 	ErrCodeNoStates               = "NO_STATES"
 	ErrCodeDuplicateState         = "DUPLICATE_STATE"
 	ErrCodeCompoundMissingInitial = "COMPOUND_MISSING_INITIAL"
 	ErrCodeCompoundInvalidInitial = "COMPOUND_INVALID_INITIAL"
 	ErrCodeInvalidParent          = "INVALID_PARENT"
 	ErrCodeInvalidChild           = "INVALID_CHILD"
+	ErrCodeInvalidOutput          = "INVALID_OUTPUT"
+	ErrCodeCyclicHierarchy        = "CYCLIC_HIERARCHY"
 
 	// History state errors (v2.0)
 	ErrCodeHistoryNotInCompound     = "HISTORY_NOT_IN_COMPOUND"
@@ -77,12 +84,27 @@ const (
 	ErrCodeHistoryInvalidDefault    = "HISTORY_INVALID_DEFAULT"
 	ErrCodeHistoryDefaultNotSibling = "HISTORY_DEFAULT_NOT_SIBLING"
 
+	// Final state errors
+	ErrCodeFinalHasTransition = "FINAL_HAS_TRANSITION"
+
 	// Delayed transition errors (v2.0)
-	ErrCodeDelayNegative = "DELAY_NEGATIVE"
+	ErrCodeDelayNegative      = "DELAY_NEGATIVE"
+	ErrCodeInvalidRepeat      = "INVALID_REPEAT"
+	ErrCodeInvalidRepeatDelay = "INVALID_REPEAT_DELAY"
+
+	// Eventless transition errors
+	ErrCodePotentialInfiniteLoop = "POTENTIAL_INFINITE_LOOP"
 
 	// Parallel state errors (v2.0)
 	ErrCodeParallelNoRegions       = "PARALLEL_NO_REGIONS"
 	ErrCodeParallelRegionNoInitial = "PARALLEL_REGION_NO_INITIAL"
+
+	// Reachability error codes (reported by ValidateStrict only)
+	ErrCodeUnreachableState = "UNREACHABLE_STATE"
+
+	// Lint error codes (reported by ValidateLint only)
+	ErrCodeUselessTransition  = "USELESS_TRANSITION"
+	ErrCodeShadowedTransition = "SHADOWED_TRANSITION"
 )
 
 // Validate checks the machine configuration for errors
@@ -107,6 +129,15 @@ func Validate[C any](m *MachineConfig[C]) *ValidationError {
 		}
 	}
 
+	// Check if the error target exists
+	if m.ErrorTarget != "" {
+		if _, ok := m.States[m.ErrorTarget]; !ok {
+			errs.AddIssue(ErrCodeInvalidTarget,
+				fmt.Sprintf("error target '%s' not found", m.ErrorTarget),
+				"errorTarget")
+		}
+	}
+
 	// Validate each state
 	for stateID, state := range m.States {
 		statePath := []string{"states", string(stateID)}
@@ -134,6 +165,21 @@ func Validate[C any](m *MachineConfig[C]) *ValidationError {
 				}
 			}
 
+			// Guarded initial children must be children with defined guards
+			for i, choice := range state.InitialChoices {
+				choicePath := append(slices.Clone(statePath), "initialChoices", fmt.Sprintf("%d", i))
+				if !slices.Contains(state.Children, choice.Target) {
+					errs.AddIssue(ErrCodeCompoundInvalidInitial,
+						fmt.Sprintf("guarded initial state '%s' must be a child of compound state '%s'", choice.Target, stateID),
+						choicePath...)
+				}
+				if _, ok := choice.Guard.InState(); !ok && !m.HasGuard(choice.Guard) {
+					errs.AddIssue(ErrCodeMissingGuard,
+						fmt.Sprintf("guard '%s' is not defined", choice.Guard),
+						choicePath...)
+				}
+			}
+
 			// Validate all children exist
 			for i, childID := range state.Children {
 				child, ok := m.States[childID]
@@ -227,9 +273,17 @@ func Validate[C any](m *MachineConfig[C]) *ValidationError {
 			}
 		}
 
+		// Final states are terminal: no outgoing transitions, including delayed
+		// and eventless ones
+		if state.IsFinal() && len(state.Transitions) > 0 {
+			errs.AddIssue(ErrCodeFinalHasTransition,
+				fmt.Sprintf("final state '%s' must not have transitions", stateID),
+				append(statePath, "transitions")...)
+		}
+
 		// Validate entry actions exist
 		for i, actionName := range state.Entry {
-			if _, ok := m.Actions[actionName]; !ok {
+			if !m.HasAction(actionName) {
 				errs.AddIssue(ErrCodeMissingAction,
 					fmt.Sprintf("entry action '%s' is not defined", actionName),
 					append(statePath, "entry", fmt.Sprintf("%d", i))...)
@@ -238,13 +292,22 @@ func Validate[C any](m *MachineConfig[C]) *ValidationError {
 
 		// Validate exit actions exist
 		for i, actionName := range state.Exit {
-			if _, ok := m.Actions[actionName]; !ok {
+			if !m.HasAction(actionName) {
 				errs.AddIssue(ErrCodeMissingAction,
 					fmt.Sprintf("exit action '%s' is not defined", actionName),
 					append(statePath, "exit", fmt.Sprintf("%d", i))...)
 			}
 		}
 
+		// Validate invoked services exist
+		for i, serviceName := range state.Invoke {
+			if _, ok := m.Services[serviceName]; !ok {
+				errs.AddIssue(ErrCodeMissingService,
+					fmt.Sprintf("invoked service '%s' is not defined", serviceName),
+					append(statePath, "invoke", fmt.Sprintf("%d", i))...)
+			}
+		}
+
 		// Validate transitions
 		for i, trans := range state.Transitions {
 			transPath := slices.Concat(statePath, []string{"transitions", fmt.Sprintf("%d", i)})
@@ -256,9 +319,15 @@ func Validate[C any](m *MachineConfig[C]) *ValidationError {
 					transPath...)
 			}
 
-			// Check guard exists if specified
-			if trans.Guard != "" {
-				if _, ok := m.Guards[trans.Guard]; !ok {
+			// Check guard exists if specified; in-state guards must reference a state
+			if id, ok := trans.Guard.InState(); ok && !m.HasGuard(trans.Guard) {
+				if _, exists := m.States[id]; !exists {
+					errs.AddIssue(ErrCodeInvalidInState,
+						fmt.Sprintf("in-state guard references unknown state '%s'", id),
+						transPath...)
+				}
+			} else if trans.Guard != "" {
+				if !m.HasGuard(trans.Guard) {
 					errs.AddIssue(ErrCodeMissingGuard,
 						fmt.Sprintf("guard '%s' is not defined", trans.Guard),
 						transPath...)
@@ -267,24 +336,384 @@ func Validate[C any](m *MachineConfig[C]) *ValidationError {
 
 			// Check transition actions exist
 			for j, actionName := range trans.Actions {
-				if _, ok := m.Actions[actionName]; !ok {
+				if !m.HasAction(actionName) {
 					errs.AddIssue(ErrCodeMissingAction,
 						fmt.Sprintf("transition action '%s' is not defined", actionName),
 						append(transPath, "actions", fmt.Sprintf("%d", j))...)
 				}
 			}
 
+			// Check conditional actions and their guards exist
+			for j, conditional := range trans.ConditionalActions {
+				conditionalPath := append(slices.Clone(transPath), "conditionalActions", fmt.Sprintf("%d", j))
+				if !m.HasAction(conditional.Action) {
+					errs.AddIssue(ErrCodeMissingAction,
+						fmt.Sprintf("conditional action '%s' is not defined", conditional.Action),
+						conditionalPath...)
+				}
+				if _, ok := conditional.Guard.InState(); !ok && !m.HasGuard(conditional.Guard) {
+					errs.AddIssue(ErrCodeMissingGuard,
+						fmt.Sprintf("guard '%s' is not defined", conditional.Guard),
+						conditionalPath...)
+				}
+			}
+
+			// Check fallible transition actions exist
+			for j, actionName := range trans.FallibleActions {
+				if _, ok := m.FallibleActions[actionName]; !ok {
+					errs.AddIssue(ErrCodeMissingAction,
+						fmt.Sprintf("fallible action '%s' is not defined", actionName),
+						append(transPath, "fallibleActions", fmt.Sprintf("%d", j))...)
+				}
+			}
+
 			// Validate delayed transition (v2.0)
 			if trans.Delay < 0 {
 				errs.AddIssue(ErrCodeDelayNegative,
 					"delay cannot be negative",
 					transPath...)
 			}
+			switch {
+			case trans.Repeat && trans.Event == "" && !trans.Always && trans.DelayName == "" && trans.Delay <= 0:
+				// A repeating timer without a delay would be re-armed immediately, forever
+				errs.AddIssue(ErrCodeInvalidRepeatDelay,
+					fmt.Sprintf("repeating delayed transition needs a positive delay, got %s", trans.Delay),
+					transPath...)
+			case trans.Repeat && !trans.IsDelayed():
+				errs.AddIssue(ErrCodeInvalidRepeat,
+					"only delayed transitions can repeat",
+					transPath...)
+			}
+			if trans.DelayName != "" {
+				if _, ok := m.Delays[trans.DelayName]; !ok {
+					errs.AddIssue(ErrCodeMissingDelay,
+						fmt.Sprintf("delay '%s' is not defined", trans.DelayName),
+						transPath...)
+				}
+			}
+		}
+	}
+
+	// Parent links must form a tree
+	for _, cycle := range parentCycles(m) {
+		path := make([]string, len(cycle))
+		for i, id := range cycle {
+			path[i] = string(id)
+		}
+		errs.AddIssue(ErrCodeCyclicHierarchy,
+			fmt.Sprintf("state '%s' is its own ancestor: %s -> %s", cycle[0], strings.Join(path, " -> "), cycle[0]),
+			"states", string(cycle[0]))
+	}
+
+	// Outputs are computed on entering a top-level final state
+	for stateID := range m.Outputs {
+		state, ok := m.States[stateID]
+		if ok && state.IsFinal() && state.Parent == "" {
+			continue
+		}
+		errs.AddIssue(ErrCodeInvalidOutput,
+			fmt.Sprintf("output of '%s' requires a top-level final state", stateID),
+			"states", string(stateID), "output")
+	}
+
+	// Only look for eventless loops in a structurally valid machine, since the
+	// analysis follows initial children and parents
+	if !errs.HasIssues() {
+		for _, cycle := range alwaysCycles(m) {
+			path := make([]string, len(cycle))
+			for i, id := range cycle {
+				path[i] = string(id)
+			}
+			errs.AddIssue(ErrCodePotentialInfiniteLoop,
+				fmt.Sprintf("unguarded eventless transitions loop forever: %s -> %s", strings.Join(path, " -> "), cycle[0]),
+				"states", string(cycle[0]))
+		}
+	}
+
+	if errs.HasIssues() {
+		return errs
+	}
+	return nil
+}
+
+// parentCycles returns the loops formed by parent links, each once, starting
+// from its smallest state ID and listed from child to ancestor
+func parentCycles[C any](m *MachineConfig[C]) [][]StateID {
+	var cycles [][]StateID
+	for _, id := range m.StateIDs() {
+		cycle := []StateID{id}
+		for parent := m.States[id].Parent; parent != "" && len(cycle) <= len(m.States); {
+			if parent == id {
+				if slices.Min(cycle) == id {
+					cycles = append(cycles, cycle)
+				}
+				break
+			}
+			state, ok := m.States[parent]
+			if !ok {
+				break
+			}
+			cycle = append(cycle, parent)
+			parent = state.Parent
+		}
+	}
+	return cycles
+}
+
+// alwaysCycles returns the loops formed by unguarded eventless transitions.
+// From each leaf state, the next step is the first eventless transition found
+// walking up from the leaf, as the interpreter does; it only counts when that
+// transition is unguarded, so every loop found is taken unconditionally. Each
+// loop is returned once, starting from its smallest state ID.
+func alwaysCycles[C any](m *MachineConfig[C]) [][]StateID {
+	// next returns the leaf entered by the unconditional eventless step from leaf
+	next := func(leaf StateID) (StateID, bool) {
+		path := m.GetPath(leaf)
+		for i := len(path) - 1; i >= 0; i-- {
+			state := m.GetState(path[i])
+			if state == nil {
+				return "", false
+			}
+			for _, t := range state.Transitions {
+				if !t.IsAlways() {
+					continue
+				}
+				if t.Guard != "" {
+					return "", false
+				}
+				if t.Internal && t.Target == state.ID {
+					return leaf, true
+				}
+				return entryLeaf(m, t.Target), true
+			}
+		}
+		return "", false
+	}
+
+	var leaves []StateID
+	for id, state := range m.States {
+		if len(state.Children) == 0 && state.Type != StateTypeHistory {
+			leaves = append(leaves, id)
+		}
+	}
+	slices.Sort(leaves)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	status := make(map[StateID]int)
+	var cycles [][]StateID
+
+	for _, start := range leaves {
+		var trail []StateID
+		current := start
+		stuck := false
+		for status[current] == unvisited {
+			status[current] = visiting
+			trail = append(trail, current)
+			n, ok := next(current)
+			if !ok {
+				stuck = true
+				break
+			}
+			current = n
+		}
+
+		// Reaching a leaf on the current trail closes a new loop
+		if !stuck && status[current] == visiting {
+			idx := slices.Index(trail, current)
+			cycle := slices.Clone(trail[idx:])
+			minIdx := 0
+			for i, id := range cycle {
+				if id < cycle[minIdx] {
+					minIdx = i
+				}
+			}
+			cycles = append(cycles, append(cycle[minIdx:], cycle[:minIdx]...))
+		}
+		for _, id := range trail {
+			status[id] = done
+		}
+	}
+
+	return cycles
+}
+
+// entryLeaf returns the leaf entered when targeting a state, following
+// initial children and history defaults
+func entryLeaf[C any](m *MachineConfig[C], target StateID) StateID {
+	state := m.GetState(target)
+	if state != nil && state.Type == StateTypeHistory && state.HistoryDefault != "" {
+		return entryLeaf(m, state.HistoryDefault)
+	}
+	return m.GetInitialLeaf(target)
+}
+
+// ValidateStrict runs Validate and additionally reports states that can never
+// be entered from the machine's initial state or error target. Reachability is
+// kept out of Validate because intentionally unused states are common while a
+// machine is being developed.
+func ValidateStrict[C any](m *MachineConfig[C]) *ValidationError {
+	errs := Validate(m)
+	if errs == nil {
+		errs = &ValidationError{}
+	}
+
+	reachable := reachableStates(m)
+	var unreachable []StateID
+	for stateID := range m.States {
+		if !reachable[stateID] {
+			unreachable = append(unreachable, stateID)
 		}
 	}
+	slices.Sort(unreachable)
+	for _, stateID := range unreachable {
+		errs.AddIssue(ErrCodeUnreachableState,
+			fmt.Sprintf("state '%s' is not reachable from the initial state", stateID),
+			"states", string(stateID))
+	}
 
 	if errs.HasIssues() {
 		return errs
 	}
 	return nil
 }
+
+// ValidateLint runs Validate and additionally reports transitions that have no
+// effect: unguarded self-transitions without actions whose state has nothing
+// to run on re-entry, and transitions that follow an unguarded transition for
+// the same event and so can never fire. Such transitions are usually left over
+// from editing, but they are legal, so the checks are kept out of Validate.
+func ValidateLint[C any](m *MachineConfig[C]) *ValidationError {
+	errs := Validate(m)
+	if errs == nil {
+		errs = &ValidationError{}
+	}
+
+	for _, stateID := range m.StateIDs() {
+		state := m.States[stateID]
+		shadowing := make(map[EventType]int)
+		for i, trans := range state.Transitions {
+			if isUselessTransition(m, state, trans) {
+				errs.AddIssue(ErrCodeUselessTransition,
+					fmt.Sprintf("self-transition of '%s' has no guard, no actions, and no effect", stateID),
+					"states", string(stateID), "transitions", fmt.Sprintf("%d", i))
+			}
+
+			// Transitions after an unguarded transition for the same event can never fire
+			if trans.IsDelayed() {
+				continue
+			}
+			key := trans.Event
+			if trans.IsAlways() {
+				key = ""
+			}
+			if first, ok := shadowing[key]; ok {
+				errs.AddIssue(ErrCodeShadowedTransition,
+					fmt.Sprintf("transition %d of state '%s' is shadowed by unguarded transition %d for the same event", i, stateID, first),
+					"states", string(stateID), "transitions", fmt.Sprintf("%d", i))
+			} else if trans.Guard == "" {
+				shadowing[key] = i
+			}
+		}
+	}
+
+	if errs.HasIssues() {
+		return errs
+	}
+	return nil
+}
+
+// isUselessTransition reports whether an unguarded self-transition changes
+// nothing. An external self-transition still has an effect when the state runs
+// entry or exit actions, restarts timers or services, or resets its children
+// on re-entry, and a transition for an event that an ancestor also handles
+// keeps the event from reaching the ancestor.
+func isUselessTransition[C any](m *MachineConfig[C], state *StateConfig, trans *TransitionConfig) bool {
+	if trans.Target != state.ID || trans.Guard != "" || trans.IsAlways() || trans.IsWildcard() {
+		return false
+	}
+	if len(trans.Actions) > 0 || len(trans.FallibleActions) > 0 || len(trans.ConditionalActions) > 0 {
+		return false
+	}
+	if !trans.IsInternal() {
+		if len(state.Entry) > 0 || len(state.Exit) > 0 || len(state.Invoke) > 0 ||
+			len(state.Children) > 0 || len(state.GetDelayedTransitions()) > 0 {
+			return false
+		}
+	}
+	if trans.IsDelayed() {
+		return true
+	}
+	for _, ancestorID := range m.GetAncestors(state.ID) {
+		ancestor := m.States[ancestorID]
+		if ancestor != nil && (ancestor.FindTransition(trans.Event) != nil || ancestor.FindTransition(WildcardEvent) != nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// reachableStates returns the set of states that can be entered starting from
+// the initial state or the error target, following transitions, compound and
+// parallel entry, and history defaults. Entering a state also makes all of its
+// ancestors reachable.
+func reachableStates[C any](m *MachineConfig[C]) map[StateID]bool {
+	reachable := make(map[StateID]bool)
+	var queue []StateID
+
+	var enter func(id StateID)
+	enter = func(id StateID) {
+		state := m.GetState(id)
+		if state == nil || reachable[id] {
+			return
+		}
+		reachable[id] = true
+		queue = append(queue, id)
+
+		switch state.Type {
+		case StateTypeCompound:
+			enter(state.Initial)
+			for _, choice := range state.InitialChoices {
+				enter(choice.Target)
+			}
+		case StateTypeParallel:
+			for _, regionID := range state.Children {
+				enter(regionID)
+			}
+		case StateTypeHistory:
+			enter(state.HistoryDefault)
+		}
+
+		// Ancestors are active without entering their initial child, except
+		// parallel ancestors, which enter every region
+		for _, ancestorID := range m.GetAncestors(id) {
+			if reachable[ancestorID] {
+				break
+			}
+			reachable[ancestorID] = true
+			queue = append(queue, ancestorID)
+			if ancestor := m.GetState(ancestorID); ancestor != nil && ancestor.IsParallel() {
+				for _, regionID := range ancestor.Children {
+					enter(regionID)
+				}
+			}
+		}
+	}
+
+	enter(m.Initial)
+	if m.ErrorTarget != "" {
+		enter(m.ErrorTarget)
+	}
+	for len(queue) > 0 {
+		state := m.GetState(queue[0])
+		queue = queue[1:]
+		for _, trans := range state.Transitions {
+			enter(trans.Target)
+		}
+	}
+
+	return reachable
+}
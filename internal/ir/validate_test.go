@@ -98,6 +98,185 @@ func TestValidate_MissingGuard(t *testing.T) {
 	}
 }
 
+func TestValidate_InStateGuard(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+	machine.States["idle"] = NewStateConfig("idle", StateTypeAtomic)
+	machine.States["running"] = NewStateConfig("running", StateTypeAtomic)
+
+	trans := NewTransitionConfig("GO", "running")
+	trans.Guard = InStateGuard("running")
+	machine.States["idle"].Transitions = []*TransitionConfig{trans}
+
+	if err := Validate(machine); err != nil {
+		t.Fatalf("expected unregistered in-state guard to be valid, got: %v", err)
+	}
+
+	trans.Guard = InStateGuard("missing")
+	err := Validate(machine)
+	if err == nil {
+		t.Fatal("expected error for in-state guard referencing an unknown state")
+	}
+	if !containsCode(err, ErrCodeInvalidInState) {
+		t.Errorf("expected INVALID_IN_STATE error, got: %v", err)
+	}
+}
+
+func TestValidate_AlwaysCycle(t *testing.T) {
+	always := func(target StateID, guard GuardType) *TransitionConfig {
+		trans := NewTransitionConfig("", target)
+		trans.Always = true
+		trans.Guard = guard
+		return trans
+	}
+	newMachine := func() *MachineConfig[testCtx] {
+		machine := NewMachineConfig[testCtx]("test", "a", testCtx{})
+		machine.Guards["ok"] = func(ctx testCtx, e Event) bool { return true }
+		for _, id := range []StateID{"a", "b", "c"} {
+			machine.States[id] = NewStateConfig(id, StateTypeAtomic)
+		}
+		return machine
+	}
+
+	t.Run("unguarded cycle", func(t *testing.T) {
+		machine := newMachine()
+		machine.States["a"].Transitions = []*TransitionConfig{always("b", "")}
+		machine.States["b"].Transitions = []*TransitionConfig{always("a", "")}
+
+		err := Validate(machine)
+		if err == nil {
+			t.Fatal("expected error for unguarded eventless cycle")
+		}
+		if len(err.Issues) != 1 || err.Issues[0].Code != ErrCodePotentialInfiniteLoop {
+			t.Fatalf("expected a single POTENTIAL_INFINITE_LOOP error, got: %v", err)
+		}
+		if !strings.Contains(err.Issues[0].Message, "a -> b -> a") {
+			t.Errorf("expected message to describe the loop, got %q", err.Issues[0].Message)
+		}
+	})
+
+	t.Run("guarded cycle", func(t *testing.T) {
+		machine := newMachine()
+		machine.States["a"].Transitions = []*TransitionConfig{always("b", "ok")}
+		machine.States["b"].Transitions = []*TransitionConfig{always("a", "")}
+
+		if err := Validate(machine); err != nil {
+			t.Errorf("expected guarded cycle to be left to the runtime cap, got: %v", err)
+		}
+	})
+
+	t.Run("chain without cycle", func(t *testing.T) {
+		machine := newMachine()
+		machine.States["a"].Transitions = []*TransitionConfig{always("b", "")}
+		machine.States["b"].Transitions = []*TransitionConfig{always("c", "")}
+
+		if err := Validate(machine); err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("parent re-entered from child", func(t *testing.T) {
+		machine := newMachine()
+		parent := NewStateConfig("parent", StateTypeCompound)
+		parent.Initial = "child"
+		parent.Children = []StateID{"child"}
+		parent.Transitions = []*TransitionConfig{always("parent", "")}
+		machine.States["parent"] = parent
+		child := NewStateConfig("child", StateTypeAtomic)
+		child.Parent = "parent"
+		machine.States["child"] = child
+
+		err := Validate(machine)
+		if err == nil || !containsCode(err, ErrCodePotentialInfiniteLoop) {
+			t.Fatalf("expected POTENTIAL_INFINITE_LOOP error, got: %v", err)
+		}
+
+		// A guarded eventless transition on the child is checked first
+		child.Transitions = []*TransitionConfig{always("a", "ok")}
+		if err := Validate(machine); err != nil {
+			t.Errorf("expected guarded child transition to break the loop, got: %v", err)
+		}
+	})
+}
+
+func TestValidate_CyclicHierarchy(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+	machine.States["idle"] = NewStateConfig("idle", StateTypeAtomic)
+	for _, id := range []StateID{"a", "b", "c"} {
+		state := NewStateConfig(id, StateTypeCompound)
+		machine.States[id] = state
+	}
+	// a -> b -> c -> a, each the initial child of the next
+	machine.States["a"].Parent, machine.States["a"].Initial = "c", "c"
+	machine.States["b"].Parent, machine.States["b"].Initial = "a", "a"
+	machine.States["c"].Parent, machine.States["c"].Initial = "b", "b"
+	machine.States["a"].Children = []StateID{"b"}
+	machine.States["b"].Children = []StateID{"c"}
+	machine.States["c"].Children = []StateID{"a"}
+	// A state below the loop is not reported separately
+	leaf := NewStateConfig("leaf", StateTypeAtomic)
+	leaf.Parent = "a"
+	machine.States["leaf"] = leaf
+
+	// Traversals terminate despite the loop
+	if ancestors := machine.GetAncestors("leaf"); len(ancestors) != len(machine.States) {
+		t.Errorf("expected the ancestor walk to stop after %d states, got %v", len(machine.States), ancestors)
+	}
+	_ = machine.GetPath("a")
+	_ = machine.GetInitialLeaf("a")
+	_ = machine.IsDescendantOf("leaf", "missing")
+
+	err := Validate(machine)
+	if err == nil {
+		t.Fatal("expected error for cyclic hierarchy")
+	}
+	var cycles []ValidationIssue
+	for _, issue := range err.Issues {
+		if issue.Code == ErrCodeCyclicHierarchy {
+			cycles = append(cycles, issue)
+		}
+	}
+	if len(cycles) != 1 {
+		t.Fatalf("expected a single CYCLIC_HIERARCHY error, got: %v", err)
+	}
+	if !strings.Contains(cycles[0].Message, "a -> c -> b -> a") {
+		t.Errorf("expected message to describe the loop, got %q", cycles[0].Message)
+	}
+
+	t.Run("self parent", func(t *testing.T) {
+		machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+		idle := NewStateConfig("idle", StateTypeCompound)
+		idle.Parent, idle.Initial, idle.Children = "idle", "idle", []StateID{"idle"}
+		machine.States["idle"] = idle
+
+		if leaf := machine.GetInitialLeaf("idle"); leaf != "idle" {
+			t.Errorf("expected initial leaf 'idle', got %s", leaf)
+		}
+		err := Validate(machine)
+		if err == nil || !containsCode(err, ErrCodeCyclicHierarchy) {
+			t.Fatalf("expected CYCLIC_HIERARCHY error, got: %v", err)
+		}
+	})
+}
+
+func TestGuardType_InState(t *testing.T) {
+	tests := []struct {
+		guard GuardType
+		id    StateID
+		ok    bool
+	}{
+		{InStateGuard("r2_ready"), "r2_ready", true},
+		{"in:", "", false},
+		{"isReady", "", false},
+		{"in(r2_ready)", "", false}, // A guard call, not an in-state guard
+	}
+	for _, tt := range tests {
+		id, ok := tt.guard.InState()
+		if id != tt.id || ok != tt.ok {
+			t.Errorf("%q.InState() = (%q, %v), expected (%q, %v)", tt.guard, id, ok, tt.id, tt.ok)
+		}
+	}
+}
+
 func TestValidate_MissingEntryAction(t *testing.T) {
 	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
 	state := NewStateConfig("idle", StateTypeAtomic)
@@ -210,6 +389,114 @@ func TestValidationError_String(t *testing.T) {
 	}
 }
 
+func TestValidateStrict_OrphanedState(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+	machine.States["idle"] = NewStateConfig("idle", StateTypeAtomic)
+	machine.States["running"] = NewStateConfig("running", StateTypeAtomic)
+	machine.States["orphan"] = NewStateConfig("orphan", StateTypeAtomic)
+	machine.States["idle"].Transitions = []*TransitionConfig{NewTransitionConfig("START", "running")}
+
+	// Plain validation does not report reachability
+	if err := Validate(machine); err != nil {
+		t.Fatalf("expected no error from Validate, got: %v", err)
+	}
+
+	err := ValidateStrict(machine)
+	if err == nil {
+		t.Fatal("expected error for unreachable state")
+	}
+	if len(err.Issues) != 1 || err.Issues[0].Code != ErrCodeUnreachableState {
+		t.Fatalf("expected single UNREACHABLE_STATE issue, got: %v", err)
+	}
+	if got := strings.Join(err.Issues[0].Path, "."); got != "states.orphan" {
+		t.Errorf("expected path 'states.orphan', got %q", got)
+	}
+}
+
+func TestValidateStrict_ValidMachine(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+	machine.States["idle"] = NewStateConfig("idle", StateTypeAtomic)
+	machine.States["running"] = NewStateConfig("running", StateTypeAtomic)
+	machine.States["idle"].Transitions = []*TransitionConfig{NewTransitionConfig("START", "running")}
+	machine.States["running"].Transitions = []*TransitionConfig{NewTransitionConfig("STOP", "idle")}
+
+	if err := ValidateStrict(machine); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidateStrict_HierarchyAndHistory(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+	machine.States["idle"] = NewStateConfig("idle", StateTypeAtomic)
+	machine.States["idle"].Transitions = []*TransitionConfig{
+		NewTransitionConfig("DIRECT", "second"),
+		NewTransitionConfig("RESUME", "hist"),
+	}
+
+	// Compound state only entered through a direct child target and its history
+	parent := NewStateConfig("parent", StateTypeCompound)
+	parent.Initial = "first"
+	parent.Children = []StateID{"first", "second", "third", "hist"}
+	machine.States["parent"] = parent
+	for _, id := range []StateID{"first", "second", "third"} {
+		child := NewStateConfig(id, StateTypeAtomic)
+		child.Parent = "parent"
+		machine.States[id] = child
+	}
+	hist := NewStateConfig("hist", StateTypeHistory)
+	hist.Parent = "parent"
+	hist.HistoryDefault = "third"
+	machine.States["hist"] = hist
+
+	err := ValidateStrict(machine)
+	if err == nil {
+		t.Fatal("expected error for unreachable initial child")
+	}
+	if len(err.Issues) != 1 || !strings.Contains(err.Issues[0].Message, "'first'") {
+		t.Errorf("expected only 'first' to be unreachable, got: %v", err)
+	}
+}
+
+func TestValidateStrict_ParallelRegions(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+	machine.States["idle"] = NewStateConfig("idle", StateTypeAtomic)
+	machine.States["idle"].Transitions = []*TransitionConfig{NewTransitionConfig("GO", "left_b")}
+
+	par := NewStateConfig("par", StateTypeParallel)
+	par.Children = []StateID{"left", "right"}
+	machine.States["par"] = par
+	for region, children := range map[StateID][]StateID{
+		"left":  {"left_a", "left_b"},
+		"right": {"right_a", "right_b"},
+	} {
+		r := NewStateConfig(region, StateTypeCompound)
+		r.Parent = "par"
+		r.Initial = children[0]
+		r.Children = children
+		machine.States[region] = r
+		for _, id := range children {
+			child := NewStateConfig(id, StateTypeAtomic)
+			child.Parent = region
+			machine.States[id] = child
+		}
+	}
+
+	err := ValidateStrict(machine)
+	if err == nil {
+		t.Fatal("expected unreachable states")
+	}
+
+	// Other regions enter their initial state; unentered initials and
+	// states without incoming transitions are unreachable
+	var got []string
+	for _, issue := range err.Issues {
+		got = append(got, issue.Path[1])
+	}
+	if want := "left_a,right_b"; strings.Join(got, ",") != want {
+		t.Errorf("expected unreachable %s, got %v", want, got)
+	}
+}
+
 func containsCode(err *ValidationError, code string) bool {
 	for _, issue := range err.Issues {
 		if issue.Code == code {
@@ -218,3 +505,61 @@ func containsCode(err *ValidationError, code string) bool {
 	}
 	return false
 }
+
+func TestValidateLint_UselessTransition(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "idle", testCtx{})
+	machine.Guards["isReady"] = func(ctx testCtx, e Event) bool { return true }
+	machine.Actions["log"] = func(ctx *testCtx, e Event) {}
+
+	useless := NewTransitionConfig("NOOP", "idle")
+	guarded := NewTransitionConfig("CHECK", "idle")
+	guarded.Guard = "isReady"
+	logged := NewTransitionConfig("LOG", "idle")
+	logged.Actions = []ActionType{"log"}
+
+	machine.States["idle"] = NewStateConfig("idle", StateTypeAtomic)
+	machine.States["idle"].Transitions = []*TransitionConfig{useless, guarded, logged}
+
+	// Plain validation does not lint
+	if err := Validate(machine); err != nil {
+		t.Fatalf("expected no error from Validate, got: %v", err)
+	}
+
+	err := ValidateLint(machine)
+	if err == nil {
+		t.Fatal("expected error for useless transition")
+	}
+	if len(err.Issues) != 1 || err.Issues[0].Code != ErrCodeUselessTransition {
+		t.Fatalf("expected single USELESS_TRANSITION issue, got: %v", err)
+	}
+	if got := strings.Join(err.Issues[0].Path, "."); got != "states.idle.transitions.0" {
+		t.Errorf("expected path 'states.idle.transitions.0', got %q", got)
+	}
+}
+
+func TestValidateLint_SelfTransitionsWithEffect(t *testing.T) {
+	machine := NewMachineConfig[testCtx]("test", "parent", testCtx{})
+	machine.Actions["log"] = func(ctx *testCtx, e Event) {}
+
+	parent := NewStateConfig("parent", StateTypeCompound)
+	parent.Initial = "child"
+	parent.Children = []StateID{"child", "entered"}
+	parent.Transitions = []*TransitionConfig{NewTransitionConfig("RESET", "child")}
+
+	// Blocks RESET from reaching the parent
+	child := NewStateConfig("child", StateTypeAtomic)
+	child.Parent = "parent"
+	child.Transitions = []*TransitionConfig{NewTransitionConfig("RESET", "child"), NewTransitionConfig("GO", "entered")}
+
+	// Re-entry runs the entry action
+	entered := NewStateConfig("entered", StateTypeAtomic)
+	entered.Parent = "parent"
+	entered.Entry = []ActionType{"log"}
+	entered.Transitions = []*TransitionConfig{NewTransitionConfig("AGAIN", "entered")}
+
+	machine.States["parent"], machine.States["child"], machine.States["entered"] = parent, child, entered
+
+	if err := ValidateLint(machine); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
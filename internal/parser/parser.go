@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // StateSchemaType represents the type of a parsed state.
@@ -14,6 +15,8 @@ const (
 	StateSchemaAtomic StateSchemaType = iota
 	StateSchemaCompound
 	StateSchemaFinal
+	StateSchemaParallel
+	StateSchemaHistory
 )
 
 // TransitionSchema represents a parsed transition definition.
@@ -22,6 +25,27 @@ type TransitionSchema struct {
 	Target  string
 	Guard   string
 	Actions []string
+	Delay   time.Duration // Only set for delayed transitions
+
+	// Internal is set by a leading '.' on the target, e.g. "TICK->.counting"
+	Internal bool
+
+	// GuardArgs holds the arguments of a guard call such as "hasAtLeast(3)".
+	// It is nil when the guard is a plain name and empty for "name()".
+	GuardArgs []string
+
+	// Guards holds the operands of a guard expression such as
+	// "isAdmin&isActive", which pass when all operands pass, or when any
+	// passes if GuardOr is set ("isAdmin|isOwner"). It is nil for a single
+	// guard, held by Guard and GuardArgs.
+	Guards  []GuardSchema
+	GuardOr bool
+}
+
+// GuardSchema represents an operand of a guard expression.
+type GuardSchema struct {
+	Name string
+	Args []string // As TransitionSchema.GuardArgs
 }
 
 // StateSchema represents a parsed state definition.
@@ -33,6 +57,16 @@ type StateSchema struct {
 	Exit        []string
 	Transitions []TransitionSchema
 	Children    []*StateSchema
+
+	// Meta holds the key=value pairs of the meta tag
+	Meta map[string]string
+
+	// DelayedTransitions are taken automatically after their delay elapses
+	DelayedTransitions []TransitionSchema
+
+	// History state fields
+	HistoryDeep    bool
+	HistoryDefault string
 }
 
 // MachineSchema represents the complete parsed machine definition.
@@ -48,6 +82,8 @@ const (
 	MarkerState             = "StateNode"
 	MarkerCompoundState     = "CompoundNode"
 	MarkerFinalState        = "FinalNode"
+	MarkerParallelState     = "ParallelNode"
+	MarkerHistoryState      = "HistoryNode"
 )
 
 // ParseMachineStruct parses a struct type into a MachineSchema.
@@ -122,6 +158,12 @@ func parseStateField(field reflect.StructField) (*StateSchema, error) {
 		if isMarkerType(fieldType, MarkerFinalState) {
 			return parseFinalState(field.Name, field.Tag)
 		}
+		if isMarkerType(fieldType, MarkerParallelState) {
+			return parseParallelState(field.Name, field.Tag)
+		}
+		if isMarkerType(fieldType, MarkerHistoryState) {
+			return parseHistoryState(field.Name, field.Tag)
+		}
 	}
 
 	return nil, nil // Not a state field
@@ -134,7 +176,7 @@ func findEmbeddedMarker(t reflect.Type) (string, bool) {
 		if !field.Anonymous {
 			continue
 		}
-		for _, marker := range []string{MarkerState, MarkerCompoundState, MarkerFinalState} {
+		for _, marker := range []string{MarkerState, MarkerCompoundState, MarkerFinalState, MarkerParallelState, MarkerHistoryState} {
 			if isMarkerType(field.Type, marker) {
 				return marker, true
 			}
@@ -169,28 +211,47 @@ func parseStateStruct(name string, t reflect.Type, markerType string, parentTag
 		if err != nil {
 			return nil, err
 		}
-		// Parse child states from non-marker fields
-		for i := 0; i < t.NumField(); i++ {
-			field := t.Field(i)
-			if field.Anonymous {
-				continue // Skip embedded marker
-			}
-			child, err := parseStateField(field)
-			if err != nil {
-				return nil, fmt.Errorf("child %s: %w", field.Name, err)
-			}
-			if child != nil {
-				state.Children = append(state.Children, child)
-			}
+		if err := parseChildStates(t, state); err != nil {
+			return nil, err
+		}
+		return state, nil
+	case MarkerParallelState:
+		state, err := parseParallelState(name, tag)
+		if err != nil {
+			return nil, err
+		}
+		// Regions are the child states, each a compound state
+		if err := parseChildStates(t, state); err != nil {
+			return nil, err
 		}
 		return state, nil
 	case MarkerFinalState:
 		return parseFinalState(name, tag)
+	case MarkerHistoryState:
+		return parseHistoryState(name, tag)
 	}
 
 	return nil, fmt.Errorf("unknown marker type: %s", markerType)
 }
 
+// parseChildStates parses child states from the non-marker fields of a struct.
+func parseChildStates(t reflect.Type, state *StateSchema) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			continue // Skip embedded marker
+		}
+		child, err := parseStateField(field)
+		if err != nil {
+			return fmt.Errorf("child %s: %w", field.Name, err)
+		}
+		if child != nil {
+			state.Children = append(state.Children, child)
+		}
+	}
+	return nil
+}
+
 // parseAtomicState parses an atomic state from a tag.
 func parseAtomicState(name string, tag reflect.StructTag) (*StateSchema, error) {
 	state := &StateSchema{
@@ -219,6 +280,20 @@ func parseCompoundState(name string, tag reflect.StructTag) (*StateSchema, error
 	return state, nil
 }
 
+// parseParallelState parses a parallel state from a tag.
+func parseParallelState(name string, tag reflect.StructTag) (*StateSchema, error) {
+	state := &StateSchema{
+		Name: toSnakeCase(name),
+		Type: StateSchemaParallel,
+	}
+
+	if err := parseStateTag(tag, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
 // parseFinalState parses a final state from a tag.
 func parseFinalState(name string, tag reflect.StructTag) (*StateSchema, error) {
 	state := &StateSchema{
@@ -234,6 +309,26 @@ func parseFinalState(name string, tag reflect.StructTag) (*StateSchema, error) {
 	return state, nil
 }
 
+// parseHistoryState parses a history state from a tag.
+// Format: `history:"shallow|deep" default:"sibling"`
+func parseHistoryState(name string, tag reflect.StructTag) (*StateSchema, error) {
+	state := &StateSchema{
+		Name:           toSnakeCase(name),
+		Type:           StateSchemaHistory,
+		HistoryDefault: tag.Get("default"),
+	}
+
+	switch history := tag.Get("history"); history {
+	case "", "shallow":
+	case "deep":
+		state.HistoryDeep = true
+	default:
+		return nil, fmt.Errorf("invalid 'history' tag: %q (expected \"shallow\" or \"deep\")", history)
+	}
+
+	return state, nil
+}
+
 // parseMachineTag parses the machine definition tag.
 // Format: `id:"machineId" initial:"stateName"`
 func parseMachineTag(tag reflect.StructTag, schema *MachineSchema) error {
@@ -251,7 +346,7 @@ func parseMachineTag(tag reflect.StructTag, schema *MachineSchema) error {
 }
 
 // parseStateTag parses state-level tags.
-// Format: `on:"EVENT->target:guard,EVENT2->target2" entry:"action1,action2" exit:"action3" initial:"child"`
+// Format: `on:"EVENT->target:guard,EVENT2->target2" after:"5s->target" entry:"action1,action2" exit:"action3" initial:"child" meta:"key=value"`
 func parseStateTag(tag reflect.StructTag, state *StateSchema) error {
 	// Parse initial (for compound states)
 	if initial := tag.Get("initial"); initial != "" {
@@ -268,6 +363,15 @@ func parseStateTag(tag reflect.StructTag, state *StateSchema) error {
 		state.Exit = splitTrim(exit, ",")
 	}
 
+	// Parse metadata
+	if meta := tag.Get("meta"); meta != "" {
+		parsed, err := parseMeta(meta)
+		if err != nil {
+			return fmt.Errorf("invalid 'meta' tag: %w", err)
+		}
+		state.Meta = parsed
+	}
+
 	// Parse transitions
 	if on := tag.Get("on"); on != "" {
 		transitions, err := parseTransitions(on)
@@ -277,15 +381,62 @@ func parseStateTag(tag reflect.StructTag, state *StateSchema) error {
 		state.Transitions = transitions
 	}
 
+	// Parse delayed transitions
+	if after := tag.Get("after"); after != "" {
+		transitions, err := parseDelayedTransitions(after)
+		if err != nil {
+			return fmt.Errorf("invalid 'after' tag: %w", err)
+		}
+		state.DelayedTransitions = transitions
+	}
+
 	return nil
 }
 
+// parseMeta parses the metadata string.
+// Format: "key1=value1,key2=value2"
+func parseMeta(s string) (map[string]string, error) {
+	meta := make(map[string]string)
+	for _, pair := range splitTrim(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		meta[key] = strings.TrimSpace(value)
+	}
+	return meta, nil
+}
+
+// parseDelayedTransitions parses the delayed transition string.
+// Format: "5s->target,1m->target2:guard" where the left side is a time.ParseDuration string
+func parseDelayedTransitions(s string) ([]TransitionSchema, error) {
+	transitions, err := parseTransitions(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range transitions {
+		delay, err := time.ParseDuration(transitions[i].Event)
+		if err != nil {
+			return nil, fmt.Errorf("transition %d: invalid duration %q", i+1, transitions[i].Event)
+		}
+		transitions[i].Delay = delay
+		transitions[i].Event = ""
+	}
+
+	return transitions, nil
+}
+
 // parseTransitions parses the transition string.
 // Format: "EVENT->target:guard,EVENT2->target2" or "EVENT->target/action1,action2"
 func parseTransitions(s string) ([]TransitionSchema, error) {
 	var transitions []TransitionSchema
 
-	parts := splitTrim(s, ",")
+	parts, err := splitTopLevel(s)
+	if err != nil {
+		return nil, err
+	}
 	for i, part := range parts {
 		trans, err := parseTransition(part)
 		if err != nil {
@@ -298,7 +449,10 @@ func parseTransitions(s string) ([]TransitionSchema, error) {
 }
 
 // parseTransition parses a single transition.
-// Format: "EVENT->target" or "EVENT->target:guard" or "EVENT->target/action1;action2:guard"
+// Format: "EVENT->target" or "EVENT->target:guard" or "EVENT->target/action1;action2:guard".
+// The guard may be a guard call with arguments: "EVENT->target:guard(arg1, arg2)",
+// or guards joined by '&' or '|': "EVENT->target:guard1&guard2".
+// A leading '.' on the target marks the transition as internal: "EVENT->.target".
 func parseTransition(s string) (TransitionSchema, error) {
 	trans := TransitionSchema{}
 
@@ -317,8 +471,11 @@ func parseTransition(s string) (TransitionSchema, error) {
 
 	// Parse target, guard, and actions
 	// Format: target:guard or target/actions:guard
+	// Arguments of a guard call may not contain ':', so the last one separates the guard
 	if colonIdx := strings.LastIndex(rest, ":"); colonIdx != -1 {
-		trans.Guard = strings.TrimSpace(rest[colonIdx+1:])
+		if err := parseGuardExpr(strings.TrimSpace(rest[colonIdx+1:]), &trans); err != nil {
+			return trans, fmt.Errorf("%w in transition: %s", err, s)
+		}
 		rest = rest[:colonIdx]
 	}
 
@@ -330,6 +487,9 @@ func parseTransition(s string) (TransitionSchema, error) {
 		trans.Target = strings.TrimSpace(rest)
 	}
 
+	if target, ok := strings.CutPrefix(trans.Target, "."); ok {
+		trans.Target, trans.Internal = strings.TrimSpace(target), true
+	}
 	if trans.Target == "" {
 		return trans, fmt.Errorf("empty target in transition: %s", s)
 	}
@@ -337,6 +497,131 @@ func parseTransition(s string) (TransitionSchema, error) {
 	return trans, nil
 }
 
+// parseGuardExpr parses the guard of a transition: a single guard, or guards
+// joined by '&' or '|'. The operators cannot be mixed in one expression; a
+// combined guard registered with WithGuardAnd or WithGuardOr can be used as an
+// operand instead.
+func parseGuardExpr(s string, trans *TransitionSchema) error {
+	var operands []string
+	var op rune
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '&', '|':
+			if depth != 0 {
+				continue
+			}
+			if op != 0 && r != op {
+				return fmt.Errorf("mixed '&' and '|' in guard %q", s)
+			}
+			op = r
+			operands = append(operands, s[start:i])
+			start = i + 1
+		}
+	}
+
+	if op == 0 {
+		guard, args, err := parseGuard(s)
+		if err != nil {
+			return err
+		}
+		trans.Guard, trans.GuardArgs = guard, args
+		return nil
+	}
+
+	operands = append(operands, s[start:])
+	trans.Guards = make([]GuardSchema, len(operands))
+	for i, operand := range operands {
+		operand = strings.TrimSpace(operand)
+		if operand == "" {
+			return fmt.Errorf("empty operand in guard %q", s)
+		}
+		name, args, err := parseGuard(operand)
+		if err != nil {
+			return err
+		}
+		trans.Guards[i] = GuardSchema{Name: name, Args: args}
+	}
+	trans.GuardOr = op == '|'
+	return nil
+}
+
+// parseGuard splits a guard into its name and, for a guard call such as
+// "hasAtLeast(3)", its comma-separated arguments.
+func parseGuard(s string) (string, []string, error) {
+	open := strings.Index(s, "(")
+	if open == -1 {
+		if strings.Contains(s, ")") {
+			return "", nil, fmt.Errorf("unbalanced ')' in guard %q", s)
+		}
+		return s, nil, nil
+	}
+
+	name := strings.TrimSpace(s[:open])
+	if name == "" {
+		return "", nil, fmt.Errorf("missing guard name in %q", s)
+	}
+	if !strings.HasSuffix(s, ")") {
+		return "", nil, fmt.Errorf("unclosed '(' in guard %q", s)
+	}
+	inner := s[open+1 : len(s)-1]
+	if strings.ContainsAny(inner, "()") {
+		return "", nil, fmt.Errorf("nested parentheses in guard %q", s)
+	}
+
+	args := []string{}
+	if strings.TrimSpace(inner) == "" {
+		return name, args, nil
+	}
+	for _, arg := range strings.Split(inner, ",") {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			return "", nil, fmt.Errorf("empty argument in guard %q", s)
+		}
+		args = append(args, arg)
+	}
+	return name, args, nil
+}
+
+// splitTopLevel splits a transition list on commas that are not inside
+// the parentheses of a guard call.
+func splitTopLevel(s string) ([]string, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced ')' in %q", s)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unclosed '(' in %q", s)
+	}
+	parts = append(parts, s[start:])
+
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if trimmed := strings.TrimSpace(p); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result, nil
+}
+
 // isMarkerType checks if a type matches a marker type name.
 func isMarkerType(t reflect.Type, markerName string) bool {
 	if t.Kind() == reflect.Ptr {
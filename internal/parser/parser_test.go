@@ -2,7 +2,10 @@ package parser
 
 import (
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Mock marker types for testing (must match the constant names in parser.go)
@@ -11,6 +14,8 @@ type (
 	StateNode    struct{}
 	CompoundNode struct{}
 	FinalNode    struct{}
+	ParallelNode struct{}
+	HistoryNode  struct{}
 )
 
 func TestParseMachineStruct_Simple(t *testing.T) {
@@ -83,6 +88,35 @@ func TestParseMachineStruct_WithActions(t *testing.T) {
 	}
 }
 
+func TestParseMachineStruct_WithMeta(t *testing.T) {
+	type MetaMachine struct {
+		MachineDef `id:"meta" initial:"idle"`
+		Idle       StateNode `on:"START->running" meta:"color=red, label = Idle state"`
+		Running    StateNode `meta:"flag="`
+	}
+
+	schema, err := ParseMachineStruct(reflect.TypeOf(MetaMachine{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"color": "red", "label": "Idle state"}
+	if !reflect.DeepEqual(schema.States[0].Meta, expected) {
+		t.Errorf("expected meta %v, got %v", expected, schema.States[0].Meta)
+	}
+	if !reflect.DeepEqual(schema.States[1].Meta, map[string]string{"flag": ""}) {
+		t.Errorf("expected empty flag value, got %v", schema.States[1].Meta)
+	}
+
+	type InvalidMetaMachine struct {
+		MachineDef `id:"meta" initial:"idle"`
+		Idle       StateNode `meta:"color"`
+	}
+	if _, err := ParseMachineStruct(reflect.TypeOf(InvalidMetaMachine{})); err == nil {
+		t.Error("expected error for meta without '='")
+	}
+}
+
 func TestParseMachineStruct_WithGuards(t *testing.T) {
 	type GuardMachine struct {
 		MachineDef `id:"guards" initial:"idle"`
@@ -155,6 +189,36 @@ func TestParseMachineStruct_MultipleTransitions(t *testing.T) {
 	}
 }
 
+func TestParseMachineStruct_SameEventMultipleTargets(t *testing.T) {
+	type RoutingMachine struct {
+		MachineDef `id:"routing" initial:"review"`
+		Review     StateNode `on:"SUBMIT->approved:isValid,SUBMIT->escalated/notify:needsReview,SUBMIT->rejected"`
+		Approved   FinalNode
+		Escalated  FinalNode
+		Rejected   FinalNode
+	}
+
+	schema, err := ParseMachineStruct(reflect.TypeOf(RoutingMachine{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	review := schema.States[0]
+	expected := []TransitionSchema{
+		{Event: "SUBMIT", Target: "approved", Guard: "isValid"},
+		{Event: "SUBMIT", Target: "escalated", Guard: "needsReview", Actions: []string{"notify"}},
+		{Event: "SUBMIT", Target: "rejected"},
+	}
+	if len(review.Transitions) != len(expected) {
+		t.Fatalf("expected %d transitions, got %d", len(expected), len(review.Transitions))
+	}
+	for i, want := range expected {
+		if !reflect.DeepEqual(review.Transitions[i], want) {
+			t.Errorf("transition %d: expected %+v, got %+v", i, want, review.Transitions[i])
+		}
+	}
+}
+
 func TestParseMachineStruct_FinalState(t *testing.T) {
 	type FinalMachine struct {
 		MachineDef `id:"final" initial:"active"`
@@ -234,6 +298,193 @@ func TestParseMachineStruct_Hierarchical(t *testing.T) {
 	}
 }
 
+func TestParseMachineStruct_Parallel(t *testing.T) {
+	type UploadRegion struct {
+		CompoundNode `initial:"uploading"`
+		Uploading    StateNode `on:"UPLOAD_DONE->uploaded"`
+		Uploaded     FinalNode
+	}
+	type DownloadRegion struct {
+		CompoundNode `initial:"downloading"`
+		Downloading  StateNode `on:"DOWNLOAD_DONE->downloaded"`
+		Downloaded   FinalNode
+	}
+	type TransferState struct {
+		ParallelNode `on:"CANCEL->idle"`
+		Upload       UploadRegion
+		Download     DownloadRegion
+	}
+	type ParallelMachine struct {
+		MachineDef `id:"parallel" initial:"transfer"`
+		Transfer   TransferState
+		Idle       StateNode
+	}
+
+	schema, err := ParseMachineStruct(reflect.TypeOf(ParallelMachine{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(schema.States) != 2 {
+		t.Fatalf("expected 2 root states, got %d", len(schema.States))
+	}
+
+	transfer := schema.States[0]
+	if transfer.Name != "transfer" {
+		t.Errorf("expected state name 'transfer', got %q", transfer.Name)
+	}
+	if transfer.Type != StateSchemaParallel {
+		t.Errorf("expected StateSchemaParallel, got %v", transfer.Type)
+	}
+	if len(transfer.Transitions) != 1 || transfer.Transitions[0].Event != "CANCEL" {
+		t.Errorf("expected CANCEL transition on transfer, got %+v", transfer.Transitions)
+	}
+
+	if len(transfer.Children) != 2 {
+		t.Fatalf("expected 2 regions, got %d", len(transfer.Children))
+	}
+
+	upload := transfer.Children[0]
+	if upload.Name != "upload" {
+		t.Errorf("expected region name 'upload', got %q", upload.Name)
+	}
+	if upload.Type != StateSchemaCompound {
+		t.Errorf("expected region to be StateSchemaCompound, got %v", upload.Type)
+	}
+	if upload.Initial != "uploading" {
+		t.Errorf("expected initial 'uploading', got %q", upload.Initial)
+	}
+	if len(upload.Children) != 2 {
+		t.Fatalf("expected 2 children in upload region, got %d", len(upload.Children))
+	}
+	if upload.Children[1].Type != StateSchemaFinal {
+		t.Errorf("expected 'uploaded' to be StateSchemaFinal, got %v", upload.Children[1].Type)
+	}
+
+	download := transfer.Children[1]
+	if download.Name != "download" {
+		t.Errorf("expected region name 'download', got %q", download.Name)
+	}
+	if len(download.Children) != 2 || download.Children[0].Transitions[0].Target != "downloaded" {
+		t.Errorf("unexpected download region children: %+v", download.Children)
+	}
+}
+
+func TestParseMachineStruct_History(t *testing.T) {
+	type ActiveState struct {
+		CompoundNode `initial:"idle"`
+		Hist         HistoryNode `history:"deep" default:"idle"`
+		Recent       HistoryNode `default:"idle"`
+		Idle         StateNode   `on:"START->working"`
+		Working      StateNode
+	}
+	type HistoryMachine struct {
+		MachineDef `id:"history" initial:"active"`
+		Active     ActiveState
+	}
+
+	schema, err := ParseMachineStruct(reflect.TypeOf(HistoryMachine{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	active := schema.States[0]
+	if len(active.Children) != 4 {
+		t.Fatalf("expected 4 children, got %d", len(active.Children))
+	}
+
+	hist := active.Children[0]
+	if hist.Name != "hist" {
+		t.Errorf("expected state name 'hist', got %q", hist.Name)
+	}
+	if hist.Type != StateSchemaHistory {
+		t.Errorf("expected StateSchemaHistory, got %v", hist.Type)
+	}
+	if !hist.HistoryDeep {
+		t.Error("expected deep history")
+	}
+	if hist.HistoryDefault != "idle" {
+		t.Errorf("expected default 'idle', got %q", hist.HistoryDefault)
+	}
+
+	recent := active.Children[1]
+	if recent.Type != StateSchemaHistory || recent.HistoryDeep {
+		t.Errorf("expected shallow history by default, got %+v", recent)
+	}
+}
+
+func TestParseMachineStruct_InvalidHistoryType(t *testing.T) {
+	type ActiveState struct {
+		CompoundNode `initial:"idle"`
+		Hist         HistoryNode `history:"sideways" default:"idle"`
+		Idle         StateNode
+	}
+	type HistoryMachine struct {
+		MachineDef `id:"history" initial:"active"`
+		Active     ActiveState
+	}
+
+	_, err := ParseMachineStruct(reflect.TypeOf(HistoryMachine{}))
+	if err == nil {
+		t.Fatal("expected error for invalid history type")
+	}
+}
+
+func TestParseMachineStruct_DelayedTransitions(t *testing.T) {
+	type DelayedMachine struct {
+		MachineDef `id:"delayed" initial:"waiting"`
+		Waiting    StateNode `on:"CANCEL->idle" after:"5s->timeout,1m->error/logError:canFail"`
+		Timeout    StateNode
+		Error      StateNode
+		Idle       StateNode
+	}
+
+	schema, err := ParseMachineStruct(reflect.TypeOf(DelayedMachine{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waiting := schema.States[0]
+	if len(waiting.Transitions) != 1 || waiting.Transitions[0].Event != "CANCEL" {
+		t.Errorf("expected CANCEL transition, got %+v", waiting.Transitions)
+	}
+	if len(waiting.DelayedTransitions) != 2 {
+		t.Fatalf("expected 2 delayed transitions, got %d", len(waiting.DelayedTransitions))
+	}
+
+	first := waiting.DelayedTransitions[0]
+	if first.Delay != 5*time.Second || first.Target != "timeout" {
+		t.Errorf("unexpected first delayed transition: %+v", first)
+	}
+	if first.Event != "" {
+		t.Errorf("expected empty event on delayed transition, got %q", first.Event)
+	}
+
+	second := waiting.DelayedTransitions[1]
+	if second.Delay != time.Minute || second.Target != "error" {
+		t.Errorf("unexpected second delayed transition: %+v", second)
+	}
+	if second.Guard != "canFail" || len(second.Actions) != 1 || second.Actions[0] != "logError" {
+		t.Errorf("expected guard and action on second delayed transition, got %+v", second)
+	}
+}
+
+func TestParseMachineStruct_InvalidDelay(t *testing.T) {
+	type DelayedMachine struct {
+		MachineDef `id:"delayed" initial:"waiting"`
+		Waiting    StateNode `after:"soon->timeout"`
+		Timeout    StateNode
+	}
+
+	_, err := ParseMachineStruct(reflect.TypeOf(DelayedMachine{}))
+	if err == nil {
+		t.Fatal("expected error for invalid duration")
+	}
+	if !strings.Contains(err.Error(), "Waiting") || !strings.Contains(err.Error(), "soon") {
+		t.Errorf("expected error to mention field and duration, got %q", err.Error())
+	}
+}
+
 func TestParseMachineStruct_MissingMachineDef(t *testing.T) {
 	type InvalidMachine struct {
 		Idle    StateNode `on:"START->running"`
@@ -290,6 +541,46 @@ func TestParseTransition_InvalidFormat(t *testing.T) {
 	}
 }
 
+func TestParseTransition_Internal(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   string
+		actions  []string
+		guard    string
+		internal bool
+	}{
+		{"TICK->.counting", "counting", nil, "", true},
+		{"TICK->.counting/increment", "counting", []string{"increment"}, "", true},
+		{"TICK->.counting/increment:isRunning", "counting", []string{"increment"}, "isRunning", true},
+		{"TICK->counting/increment", "counting", []string{"increment"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			trans, err := parseTransition(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if trans.Target != tt.target {
+				t.Errorf("expected target %q, got %q", tt.target, trans.Target)
+			}
+			if trans.Internal != tt.internal {
+				t.Errorf("expected internal %v, got %v", tt.internal, trans.Internal)
+			}
+			if trans.Guard != tt.guard {
+				t.Errorf("expected guard %q, got %q", tt.guard, trans.Guard)
+			}
+			if !slices.Equal(trans.Actions, tt.actions) {
+				t.Errorf("expected actions %v, got %v", tt.actions, trans.Actions)
+			}
+		})
+	}
+
+	if _, err := parseTransition("TICK->."); err == nil {
+		t.Error("expected error for an internal transition without a target")
+	}
+}
+
 func TestToSnakeCase(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -518,3 +809,100 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestParseTransition_GuardCall(t *testing.T) {
+	tests := []struct {
+		input string
+		guard string
+		args  []string
+	}{
+		{"SUBMIT->ok:hasAtLeast(3)", "hasAtLeast", []string{"3"}},
+		{"SUBMIT->ok/log:between( 1 , 5 )", "between", []string{"1", "5"}},
+		{"SUBMIT->ok:ready()", "ready", []string{}},
+		{"SUBMIT->ok:ready", "ready", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			trans, err := parseTransition(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if trans.Guard != tt.guard {
+				t.Errorf("expected guard %q, got %q", tt.guard, trans.Guard)
+			}
+			if !reflect.DeepEqual(trans.GuardArgs, tt.args) {
+				t.Errorf("expected args %#v, got %#v", tt.args, trans.GuardArgs)
+			}
+		})
+	}
+}
+
+func TestParseTransitions_GuardCallArguments(t *testing.T) {
+	transitions, err := parseTransitions("SUBMIT->ok:between(1, 5),SUBMIT->rejected")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(transitions) != 2 {
+		t.Fatalf("expected 2 transitions, got %d", len(transitions))
+	}
+	if !reflect.DeepEqual(transitions[0].GuardArgs, []string{"1", "5"}) {
+		t.Errorf("expected args [1 5], got %v", transitions[0].GuardArgs)
+	}
+
+	for _, input := range []string{
+		"SUBMIT->ok:hasAtLeast(3",
+		"SUBMIT->ok:hasAtLeast3)",
+		"SUBMIT->ok:(3)",
+		"SUBMIT->ok:between(1,,5)",
+		"SUBMIT->ok:outer(inner(1))",
+	} {
+		if _, err := parseTransitions(input); err == nil {
+			t.Errorf("expected error for input %q", input)
+		}
+	}
+}
+
+func TestParseTransition_GuardExpression(t *testing.T) {
+	tests := []struct {
+		input  string
+		guard  string
+		guards []GuardSchema
+		or     bool
+	}{
+		{"GO->x:isAdmin", "isAdmin", nil, false},
+		{"GO->x:isAdmin&isActive", "", []GuardSchema{{Name: "isAdmin"}, {Name: "isActive"}}, false},
+		{"GO->x/log:isAdmin | isOwner | isActive", "", []GuardSchema{{Name: "isAdmin"}, {Name: "isOwner"}, {Name: "isActive"}}, true},
+		{"GO->x:hasAtLeast(1, 2)&isActive", "", []GuardSchema{{Name: "hasAtLeast", Args: []string{"1", "2"}}, {Name: "isActive"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			trans, err := parseTransition(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if trans.Guard != tt.guard {
+				t.Errorf("expected guard %q, got %q", tt.guard, trans.Guard)
+			}
+			if !reflect.DeepEqual(trans.Guards, tt.guards) {
+				t.Errorf("expected guards %#v, got %#v", tt.guards, trans.Guards)
+			}
+			if trans.GuardOr != tt.or {
+				t.Errorf("expected GuardOr %v, got %v", tt.or, trans.GuardOr)
+			}
+		})
+	}
+
+	for _, input := range []string{
+		"GO->x:isAdmin&isActive|isOwner",
+		"GO->x:isAdmin&",
+		"GO->x:|isAdmin",
+		"GO->x:isAdmin&&isActive",
+		"GO->x:isAdmin&hasAtLeast(3",
+	} {
+		if _, err := parseTransition(input); err == nil {
+			t.Errorf("expected error for input %q", input)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package statekit
+
+import (
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/export"
+)
+
+type lifecycleCounts struct {
+	Entries int
+	Exits   int
+	Ticks   int
+}
+
+// TestInternalTransition_SkipsEntryExit tests that internal self-transitions only run actions
+func TestInternalTransition_SkipsEntryExit(t *testing.T) {
+	machine, err := NewMachine[lifecycleCounts]("tick").
+		WithInitial("running").
+		WithAction("enter", func(ctx *lifecycleCounts, e Event) { ctx.Entries++ }).
+		WithAction("exit", func(ctx *lifecycleCounts, e Event) { ctx.Exits++ }).
+		WithAction("tick", func(ctx *lifecycleCounts, e Event) { ctx.Ticks++ }).
+		State("running").
+		OnEntry("enter").
+		OnExit("exit").
+		On("TICK").Target("running").Internal().Do("tick").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "TICK"})
+	interp.Send(Event{Type: "TICK"})
+
+	ctx := interp.State().Context
+	if ctx.Entries != 1 {
+		t.Errorf("Expected 1 entry (from Start), got %d", ctx.Entries)
+	}
+	if ctx.Exits != 0 {
+		t.Errorf("Expected 0 exits, got %d", ctx.Exits)
+	}
+	if ctx.Ticks != 2 {
+		t.Errorf("Expected 2 ticks, got %d", ctx.Ticks)
+	}
+}
+
+// TestInternalTransition_ExternalDefault tests that self-transitions are external by default
+func TestInternalTransition_ExternalDefault(t *testing.T) {
+	machine, err := NewMachine[lifecycleCounts]("tick").
+		WithInitial("running").
+		WithAction("enter", func(ctx *lifecycleCounts, e Event) { ctx.Entries++ }).
+		WithAction("exit", func(ctx *lifecycleCounts, e Event) { ctx.Exits++ }).
+		WithAction("tick", func(ctx *lifecycleCounts, e Event) { ctx.Ticks++ }).
+		State("running").
+		OnEntry("enter").
+		OnExit("exit").
+		On("TICK").Target("running").Do("tick").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "TICK"})
+
+	ctx := interp.State().Context
+	if ctx.Entries != 2 || ctx.Exits != 1 || ctx.Ticks != 1 {
+		t.Errorf("Expected entries=2 exits=1 ticks=1, got %+v", ctx)
+	}
+}
+
+// TestInternalTransition_CompoundKeepsChild tests that an internal transition on a
+// compound state does not reset its active child
+func TestInternalTransition_CompoundKeepsChild(t *testing.T) {
+	machine, err := NewMachine[lifecycleCounts]("compound_internal").
+		WithInitial("active").
+		WithAction("enter", func(ctx *lifecycleCounts, e Event) { ctx.Entries++ }).
+		WithAction("tick", func(ctx *lifecycleCounts, e Event) { ctx.Ticks++ }).
+		State("active").
+		WithInitial("idle").
+		OnEntry("enter").
+		On("TICK").Target("active").Internal().Do("tick").End().
+		State("idle").On("START").Target("working").End().End().
+		State("working").End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "START"})
+	interp.Send(Event{Type: "TICK"})
+
+	if interp.State().Value != "working" {
+		t.Errorf("Expected to stay in 'working', got %s", interp.State().Value)
+	}
+	if interp.State().Context.Entries != 1 || interp.State().Context.Ticks != 1 {
+		t.Errorf("Expected entries=1 ticks=1, got %+v", interp.State().Context)
+	}
+}
+
+// TestInternalTransition_ParallelRegion tests internal self-transitions inside a region
+func TestInternalTransition_ParallelRegion(t *testing.T) {
+	machine, err := NewMachine[lifecycleCounts]("parallel_internal").
+		WithInitial("active").
+		WithAction("enter", func(ctx *lifecycleCounts, e Event) { ctx.Entries++ }).
+		WithAction("tick", func(ctx *lifecycleCounts, e Event) { ctx.Ticks++ }).
+		State("active").Parallel().
+		Region("left").
+		WithInitial("l1").
+		State("l1").OnEntry("enter").On("TICK").Target("l1").Internal().Do("tick").EndState().
+		EndRegion().
+		Region("right").
+		WithInitial("r1").
+		State("r1").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "TICK"})
+
+	if interp.State().Context.Entries != 1 || interp.State().Context.Ticks != 1 {
+		t.Errorf("Expected entries=1 ticks=1, got %+v", interp.State().Context)
+	}
+
+	interp.Stop()
+}
+
+// TestInternalTransition_XStateExport tests that internal transitions export with internal: true
+func TestInternalTransition_XStateExport(t *testing.T) {
+	machine, err := NewMachine[lifecycleCounts]("tick").
+		WithInitial("running").
+		WithAction("tick", func(ctx *lifecycleCounts, e Event) { ctx.Ticks++ }).
+		State("running").
+		On("TICK").Target("running").Internal().Do("tick").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	exported, err := export.NewXStateExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	tick := exported.States["running"].On["TICK"]
+	if !tick.Internal {
+		t.Error("Expected TICK transition to be internal")
+	}
+}
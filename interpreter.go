@@ -1,10 +1,16 @@
 package statekit
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/felixgeelhaar/statekit/internal/clock"
 	"github.com/felixgeelhaar/statekit/internal/ir"
 )
 
@@ -25,13 +31,180 @@ type Interpreter[C any] struct {
 
 	// Timer management for delayed transitions (v2.0)
 	// Maps timer key (stateID:index) to active timer
-	timers   map[string]*time.Timer
+	timers   map[string]*scheduledTimer
 	timersMu sync.Mutex
 
 	// Parallel state tracking (v2.0)
 	// When inside a parallel state, this holds the parallel state ID
 	// The actual region states are tracked in state.ActiveInParallel
 	currentParallel ir.StateID
+
+	// Invoked services for active states
+	// Maps invocation key (stateID:service) to the running invocation
+	invocations map[string]*invocation
+
+	// Subscriptions: listeners notified after each transition
+	listeners      []listener[C]
+	nextListenerID uint64
+	listenersMu    sync.Mutex
+
+	// Run-to-completion queue: each step runs under mu and reports whether
+	// listeners should be notified. Guarded by queueMu, not mu, so that
+	// actions and listeners can enqueue events while a step is running.
+	queue      []queuedStep
+	queueSeq   uint64 // sequence number of the next queued step
+	processing bool
+	queueMu    sync.Mutex
+	running    uint64 // one more than the seq of the step being run, or 0
+
+	// Events held by deferring states until the next transition (guarded by mu)
+	deferred []Event
+
+	// Result being recorded by SendResult, nil otherwise (guarded by mu)
+	result *TransitionResult
+
+	// Activity counters reported by Stats (guarded by mu)
+	stats Stats
+
+	// States entered and transitions taken, nil unless WithCoverage is set
+	// (guarded by mu)
+	coverage *coverage
+
+	// Most recent transitions, nil unless WithHistoryLog is set (guarded by mu)
+	transitionLog *transitionLog
+
+	// Result of the top-level final state, reported by Output (guarded by mu)
+	output    any
+	hasOutput bool
+
+	// Results of context guards evaluated while an event is dispatched,
+	// cleared before every action (guarded by mu)
+	guardCache    map[ir.GuardType]bool
+	cachingGuards bool
+
+	// Set while Can or NextEvents evaluates guards, which are then neither
+	// reported to the tracer nor counted in Stats (guarded by mu)
+	querying bool
+
+	// Options configured at construction
+	opts interpreterOptions
+
+	// Optional context cloner set with WithContextCloner
+	cloner func(C) C
+
+	// Inbox of an interpreter created with NewInterpreterAsync, nil otherwise
+	inbox *asyncInbox
+
+	// Set while the initial states are entered without entry actions (guarded by mu)
+	skipEntryActions bool
+}
+
+// DefaultMaxAlwaysIterations is the default limit on consecutive eventless
+// transitions taken while settling after a single event
+const DefaultMaxAlwaysIterations = 100
+
+// InterpreterOption configures optional interpreter behavior
+type InterpreterOption func(*interpreterOptions)
+
+// interpreterOptions holds the settings applied by InterpreterOption values
+type interpreterOptions struct {
+	maxAlwaysIterations int
+	clock               Clock
+	tracer              Tracer
+	contextCloner       any // func(C) C, checked in NewInterpreter
+	strictPayloads      bool
+	strictEvents        bool
+	unhandledEvent      func(Event, error)
+	coverage            bool
+	historyLog          int
+	recoverPanics       bool
+	panicHandler        func(any, ActionType)
+	abortOnPanic        bool
+	initialState        StateID
+	skipInitialEntry    bool
+}
+
+// WithMaxAlwaysIterations limits how many eventless (always) transitions are
+// taken in a row before the interpreter stops evaluating them. This guards
+// against infinite loops between states whose always guards keep passing.
+// Values less than 1 are ignored.
+func WithMaxAlwaysIterations(n int) InterpreterOption {
+	return func(o *interpreterOptions) {
+		if n > 0 {
+			o.maxAlwaysIterations = n
+		}
+	}
+}
+
+// WithInitialState makes Start and Reset enter the given state instead of the
+// machine's initial state, e.g. to test a single state or resume a workflow
+// without a snapshot. The state's ancestors are entered first, root to leaf,
+// and a compound, parallel, or history state is resolved like a transition
+// target. Entry actions receive an event of type InitEvent, unless
+// WithoutInitialEntryActions is also set. If the state is not in the machine,
+// StartErr returns an error and the interpreter stays stopped.
+func WithInitialState(id StateID) InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.initialState = id
+	}
+}
+
+// WithoutInitialEntryActions makes Start and Reset enter the initial states
+// without running their entry actions, as if the interpreter had been restored
+// in them: delayed transitions are scheduled and services are started.
+// Eventless transitions are taken as usual.
+func WithoutInitialEntryActions() InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.skipInitialEntry = true
+	}
+}
+
+// WithClock sets the clock used to schedule delayed transitions.
+// A nil clock is ignored.
+func WithClock(c Clock) InterpreterOption {
+	return func(o *interpreterOptions) {
+		if c != nil {
+			o.clock = c
+		}
+	}
+}
+
+// WithTracer reports the interpreter's transitions, action executions, guard
+// evaluations, and ignored events to the given tracer. No tracer is set by
+// default, so tracing has no overhead unless enabled.
+func WithTracer(t Tracer) InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.tracer = t
+	}
+}
+
+// WithContextCloner sets a function used to copy the context whenever it leaves
+// the interpreter: from State(), Snapshot(), and listener notifications.
+//
+// By default the context is copied by value, so slices, maps, and pointers in
+// the returned context share memory with the interpreter's context; mutating
+// them changes interpreter state. Use DeepCopy for a reflection-based cloner.
+// The cloner also saves the context a transition aborted by WithAbortOnPanic
+// rolls back to.
+//
+// NewInterpreter panics if the cloner's type does not match the machine's context type.
+func WithContextCloner[C any](fn func(C) C) InterpreterOption {
+	return func(o *interpreterOptions) {
+		if fn != nil {
+			o.contextCloner = fn
+		}
+	}
+}
+
+// listener is a registered state change callback
+type listener[C any] struct {
+	id uint64
+	fn func(State[C])
+}
+
+// invocation tracks a running invoked service
+type invocation struct {
+	cancel context.CancelFunc
 }
 
 // transitionSource holds the state that owns the transition and the transition itself
@@ -41,7 +214,34 @@ type transitionSource[C any] struct {
 }
 
 // NewInterpreter creates a new interpreter for the given machine configuration
-func NewInterpreter[C any](machine *ir.MachineConfig[C]) *Interpreter[C] {
+func NewInterpreter[C any](machine *ir.MachineConfig[C], opts ...InterpreterOption) *Interpreter[C] {
+	options := interpreterOptions{
+		maxAlwaysIterations: DefaultMaxAlwaysIterations,
+		clock:               clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var cloner func(C) C
+	if options.contextCloner != nil {
+		var ok bool
+		cloner, ok = options.contextCloner.(func(C) C)
+		if !ok {
+			var zero C
+			panic(fmt.Sprintf("statekit: context cloner %T does not match context type %T", options.contextCloner, zero))
+		}
+	}
+
+	var cov *coverage
+	if options.coverage {
+		cov = newCoverage()
+	}
+	var log *transitionLog
+	if options.historyLog > 0 {
+		log = newTransitionLog(options.historyLog)
+	}
+
 	return &Interpreter[C]{
 		machine: machine,
 		state: State[C]{
@@ -52,43 +252,226 @@ func NewInterpreter[C any](machine *ir.MachineConfig[C]) *Interpreter[C] {
 		started:         false,
 		shallowHistory:  make(map[ir.StateID]ir.StateID),
 		deepHistory:     make(map[ir.StateID]ir.StateID),
-		timers:          make(map[string]*time.Timer),
+		timers:          make(map[string]*scheduledTimer),
+		invocations:     make(map[string]*invocation),
 		currentParallel: "",
+		opts:            options,
+		cloner:          cloner,
+		coverage:        cov,
+		transitionLog:   log,
 	}
 }
 
-// Start initializes the interpreter and enters the initial state
+// ErrNotStarted is reported in SendResult().Err for an event sent to an
+// interpreter that is not started
+var ErrNotStarted = errors.New("interpreter not started")
+
+// Start initializes the interpreter and enters the initial state.
+// If the machine has a context validator that rejects the context, the
+// interpreter is not started; use StartErr to observe the error.
 func (i *Interpreter[C]) Start() {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+	_ = i.StartErr()
+}
 
-	if i.started {
-		return
+// StartErr is like Start, but returns the error of the machine's context
+// validator, or of an initial state set with WithInitialState that is not in
+// the machine. On error the interpreter stays stopped, and StartErr may be
+// called again after fixing the context with UpdateContext.
+// Calling StartErr on a started interpreter does nothing and returns nil.
+func (i *Interpreter[C]) StartErr() error {
+	var err error
+	i.process(func() bool {
+		if i.started {
+			return false
+		}
+		err = i.startUnlocked()
+		return err == nil
+	})
+	return err
+}
+
+// startUnlocked validates the context and enters the initial state (caller must hold mu)
+func (i *Interpreter[C]) startUnlocked() error {
+	if validate := i.machine.ContextValidator; validate != nil {
+		if err := validate(i.state.Context); err != nil {
+			return fmt.Errorf("start: invalid context: %w", err)
+		}
+	}
+	initial := i.opts.initialState
+	if initial != "" && i.machine.GetState(initial) == nil {
+		return fmt.Errorf("start: initial state %q not found", initial)
 	}
 	i.started = true
+	i.output, i.hasOutput = nil, false
+	i.deferred = nil
 
 	// Enter initial state, resolving to deepest leaf
-	i.enterStateHierarchy(i.machine.Initial)
+	initEvent := Event{Type: InitEvent}
+	i.enterInitialState(initial, initEvent)
+	i.processAlwaysTransitions(initEvent)
+	return nil
+}
+
+// enterInitialState enters the machine's initial state, or the state set with
+// WithInitialState and its ancestors (caller must hold mu)
+func (i *Interpreter[C]) enterInitialState(initial ir.StateID, event Event) {
+	i.skipEntryActions = i.opts.skipInitialEntry
+	defer func() { i.skipEntryActions = false }()
+
+	if initial == "" {
+		i.enterStateHierarchy(i.machine.Initial, event)
+		return
+	}
+	target := i.resolveTarget(initial, event)
+	i.enterStates(i.getStatesToEnter(target, ""), target, event)
+}
+
+// Reset returns the interpreter to the start of the machine, whether it is
+// running, done, or stopped. It cancels timers and invoked services, clears
+// history and parallel state tracking, restores the context configured on the
+// machine (copied with the context cloner, if one is set), and enters the
+// initial state, running its entry actions. Exit actions of the active states
+// are not run. Listeners are notified of the new state; Stats are kept.
+//
+// Like Start, Reset leaves the interpreter stopped if the context validator
+// rejects the context.
+func (i *Interpreter[C]) Reset() {
+	i.process(func() bool {
+		i.cancelAllTimers()
+		i.cancelAllInvocations()
+
+		ctx := i.machine.Context
+		if i.cloner != nil {
+			ctx = i.cloner(ctx)
+		}
+		i.state = State[C]{
+			Context:          ctx,
+			ActiveInParallel: make(map[ir.StateID]ir.StateID),
+		}
+		i.shallowHistory = make(map[ir.StateID]ir.StateID)
+		i.deepHistory = make(map[ir.StateID]ir.StateID)
+		i.currentParallel = ""
+		i.started = false
+
+		return i.startUnlocked() == nil
+	})
+}
+
+// IsStarted reports whether the interpreter is running: it has been started
+// with Start, StartErr, Reset, Replay, or Restore and not stopped since.
+func (i *Interpreter[C]) IsStarted() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.started
+}
+
+// Machine returns the machine configuration the interpreter runs. It is shared
+// with the interpreter and must not be modified.
+func (i *Interpreter[C]) Machine() *ir.MachineConfig[C] {
+	return i.machine
 }
 
 // State returns the current state of the interpreter
+// The returned ActiveInParallel map is a copy. The context is copied by value
+// unless a cloner was set with WithContextCloner. Before Start, Value is empty;
+// after Stop, State keeps reporting the last state.
 func (i *Interpreter[C]) State() State[C] {
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	return i.state
+	return i.copyStateUnlocked()
 }
 
 // Matches checks if the current state matches the given state ID
 // For hierarchical states, returns true if current state equals id or is a descendant of id
 // For parallel states, also checks all active region states
+// It returns false when the interpreter is not started.
 func (i *Interpreter[C]) Matches(id StateID) bool {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	return i.matchesUnlocked(id)
 }
 
+// Meta returns a copy of the metadata attached to the state, or nil if the state
+// has none or does not exist
+func (i *Interpreter[C]) Meta(id StateID) map[string]any {
+	stateConfig := i.machine.GetState(id)
+	if stateConfig == nil {
+		return nil
+	}
+	return maps.Clone(stateConfig.Meta)
+}
+
+// MatchesAny reports whether Matches is true for at least one of the given
+// state IDs. It returns false if no IDs are given.
+func (i *Interpreter[C]) MatchesAny(ids ...StateID) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return slices.ContainsFunc(ids, i.matchesUnlocked)
+}
+
+// MatchesAll reports whether Matches is true for every given state ID, e.g.
+// to check the states of several parallel regions at once. It returns true if
+// no IDs are given.
+func (i *Interpreter[C]) MatchesAll(ids ...StateID) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, id := range ids {
+		if !i.matchesUnlocked(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesPath reports whether the active states include a dotted path of
+// nested state IDs, like XState's state.matches("active.working.loading").
+// The path lists states from parent to child and need not start at the root:
+// it matches if its last state is active and the path ends with that state's
+// ancestors. While in active.working.loading, "active.working.loading",
+// "active.working", and "working.loading" all match, but "active.loading" does
+// not. It returns false when the interpreter is not started.
+func (i *Interpreter[C]) MatchesPath(path string) bool {
+	segments := strings.Split(path, ".")
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	last := StateID(segments[len(segments)-1])
+	if last == "" || !i.matchesUnlocked(last) {
+		return false
+	}
+	full := i.machine.GetPath(last)
+	if len(segments) > len(full) {
+		return false
+	}
+	for n, segment := range segments {
+		if full[len(full)-len(segments)+n] != StateID(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// ActiveStates returns the sorted IDs of every active state: the current leaf and
+// its ancestors, plus the active leaf of every parallel region and its ancestors.
+// It returns nil before the interpreter is started.
+func (i *Interpreter[C]) ActiveStates() []StateID {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.started {
+		return nil
+	}
+	active := i.activeStatesUnlocked()
+	slices.Sort(active)
+	return slices.Compact(active)
+}
+
 // matchesUnlocked is the internal version without locking (caller must hold mu)
 func (i *Interpreter[C]) matchesUnlocked(id StateID) bool {
+	if !i.started {
+		return false
+	}
 	if i.state.Value == id {
 		return true
 	}
@@ -105,7 +488,31 @@ func (i *Interpreter[C]) matchesUnlocked(id StateID) bool {
 	return false
 }
 
-// Done returns true if the machine is in a final state
+// Output returns the output computed when the machine entered its top-level
+// final state. It reports false until a final state with an output is entered.
+func (i *Interpreter[C]) Output() (any, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.output, i.hasOutput
+}
+
+// TypedOutput returns the output of the interpreter as a T, like Output. It
+// reports false until a final state with an output is entered, or if the
+// output is not a T. (Go methods cannot have type parameters, so this is a
+// function: statekit.TypedOutput[Receipt](interp).)
+func TypedOutput[T, C any](i *Interpreter[C]) (T, bool) {
+	output, ok := i.Output()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	typed, ok := output.(T)
+	return typed, ok
+}
+
+// Done returns true if the machine is in a final state. The final state may be
+// nested in a compound state; use DoneIn to check whether a given compound
+// state has completed. It returns false when the interpreter is not started.
 func (i *Interpreter[C]) Done() bool {
 	i.mu.Lock()
 	defer i.mu.Unlock()
@@ -120,35 +527,362 @@ func (i *Interpreter[C]) Done() bool {
 	return stateConfig.Type == ir.StateTypeFinal
 }
 
-// Send processes an event and potentially transitions to a new state
-func (i *Interpreter[C]) Send(event Event) {
+// DoneIn returns true if the given state has completed: a compound state whose
+// active child is one of its final states, at any level of the hierarchy, or an
+// active parallel state whose regions have all completed. This is the condition
+// under which the interpreter raises DoneStateEvent(stateID).
+func (i *Interpreter[C]) DoneIn(stateID StateID) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.started && i.isDoneUnlocked(stateID)
+}
+
+// Can reports whether sending the event would currently cause a transition,
+// taking guards, hierarchical bubbling, and parallel regions into account.
+// It does not change state. Guards are evaluated with an event that has no payload.
+// It returns false when the interpreter is not started.
+func (i *Interpreter[C]) Can(event EventType) bool {
 	i.mu.Lock()
 	defer i.mu.Unlock()
+	return i.canUnlocked(Event{Type: event})
+}
 
+// NextEvents returns the sorted event types that would currently cause a transition
+// It returns nil before the interpreter is started.
+func (i *Interpreter[C]) NextEvents() []EventType {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	seen := make(map[EventType]bool)
+	var events []EventType
+	for _, stateID := range i.activeStatesUnlocked() {
+		stateConfig := i.machine.GetState(stateID)
+		if stateConfig == nil {
+			continue
+		}
+		for _, t := range stateConfig.Transitions {
+			if t.IsAlways() || t.IsDelayed() || seen[t.Event] {
+				continue
+			}
+			seen[t.Event] = true
+			if i.canUnlocked(Event{Type: t.Event}) {
+				events = append(events, t.Event)
+			}
+		}
+	}
+	slices.Sort(events)
+	return events
+}
+
+// canUnlocked reports whether the event would cause a transition (caller must hold mu)
+func (i *Interpreter[C]) canUnlocked(event Event) bool {
 	if !i.started {
+		return false
+	}
+
+	i.querying = true
+	defer func() { i.querying = false }()
+
+	// Parallel states: the parallel state itself, then each region
+	if i.currentParallel != "" {
+		parallelState := i.machine.GetState(i.currentParallel)
+		if parallelState == nil {
+			return false
+		}
+		if i.findMatchingTransition(parallelState, event) != nil {
+			return true
+		}
+		for regionID, leafID := range i.state.ActiveInParallel {
+			regionState := i.machine.GetState(leafID)
+			if regionState != nil && i.findMatchingTransitionInRegion(regionState, regionID, event) != nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	currentState := i.machine.GetState(i.state.Value)
+	if currentState == nil {
+		return false
+	}
+	return i.findMatchingTransitionHierarchical(currentState, event) != nil
+}
+
+// Send processes an event and potentially transitions to a new state.
+//
+// Events are processed one at a time in FIFO order (run-to-completion): each
+// event's transition, actions, and eventless transitions finish before the next
+// event is processed. If Send is called while another event is being processed,
+// for example from an action or a listener, the event is queued and Send returns
+// immediately; otherwise Send processes the queue until it is empty.
+//
+// An interpreter created with NewInterpreterAsync only enqueues the event; it
+// is processed in the background. Events sent before Start or after Stop are
+// ignored and reported to the tracer's OnEventIgnored.
+func (i *Interpreter[C]) Send(event Event) {
+	if i.inbox != nil {
+		i.enqueue(asyncEvent{event: event})
+		return
+	}
+	i.SendResult(event)
+}
+
+// Replay starts the interpreter if it has not been started, sends each event in
+// order, and returns the state trace: the current state before the first event,
+// followed by the state after each event. States are reported like State().Value,
+// i.e. the active leaf, or the parallel state while one is active.
+//
+// Replay only advances the machine through the given events. Delayed transitions
+// are not fired unless their timers expire on the interpreter's clock during the
+// replay; use a statekittest.FakeClock to keep them from firing.
+func (i *Interpreter[C]) Replay(events []Event) []StateID {
+	i.Start()
+
+	trace := make([]StateID, 0, len(events)+1)
+	trace = append(trace, i.currentValue())
+	for _, event := range events {
+		i.SendSync(event)
+		trace = append(trace, i.currentValue())
+	}
+	return trace
+}
+
+// currentValue returns the current state value
+func (i *Interpreter[C]) currentValue() StateID {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.state.Value
+}
+
+// queuedStep is a step in the run-to-completion queue
+type queuedStep struct {
+	run func() bool
+	seq uint64 // order in which the step was queued
+	// parent is one more than the seq of the step that raised this step, or
+	// 0 if it was sent. Raised steps are discarded with an aborted step (see
+	// runStep).
+	parent uint64
+}
+
+// newStep numbers a step for the queue (caller must hold queueMu)
+func (i *Interpreter[C]) newStep(run func() bool) queuedStep {
+	step := queuedStep{run: run, seq: i.queueSeq}
+	i.queueSeq++
+	return step
+}
+
+// raise queues a step raised by the step being run, such as a done event or
+// an event raised by a scheduler action. Unlike steps queued by Send, it is
+// discarded if the running step is aborted.
+func (i *Interpreter[C]) raise(run func() bool) {
+	i.queueMu.Lock()
+	if !i.processing {
+		i.queueMu.Unlock()
+		i.process(run)
 		return
 	}
+	step := i.newStep(run)
+	step.parent = i.running
+	i.queue = append(i.queue, step)
+	i.queueMu.Unlock()
+}
+
+// process queues steps and, unless the queue is already being processed, runs
+// them in order. After each of the given steps, the steps it queued run until
+// the queue is empty, so every step runs to completion before the next one.
+// Each step runs under mu; listeners are notified outside the lock when a step
+// returns true. Returns false if the steps were only queued.
+func (i *Interpreter[C]) process(steps ...func() bool) bool {
+	i.queueMu.Lock()
+	if i.processing {
+		for _, step := range steps {
+			i.queue = append(i.queue, i.newStep(step))
+		}
+		i.queueMu.Unlock()
+		return false
+	}
+	i.processing = true
+
+	for _, step := range steps {
+		i.queue = append(i.queue, i.newStep(step))
+		for len(i.queue) > 0 {
+			next := i.queue[0]
+			i.queue = i.queue[1:]
+			i.running = next.seq + 1
+			i.queueMu.Unlock()
+
+			i.mu.Lock()
+			changed := i.runStep(next)
+			snapshot := i.copyStateUnlocked()
+			i.mu.Unlock()
+
+			i.queueMu.Lock()
+			i.running = 0
+			i.queueMu.Unlock()
+
+			if changed {
+				i.notify(snapshot)
+			}
+
+			i.queueMu.Lock()
+		}
+	}
+
+	i.processing = false
+	i.queueMu.Unlock()
+	return true
+}
+
+// sendUnlocked processes an event (caller must hold mu)
+// Returns true if a transition was taken
+func (i *Interpreter[C]) sendUnlocked(event Event) bool {
+	if !i.started {
+		if i.result != nil {
+			i.result.Err = ErrNotStarted
+		}
+		if i.opts.tracer != nil {
+			i.opts.tracer.OnEventIgnored(event.Type)
+		}
+		return false
+	}
+	i.stats.EventsReceived++
+	if tracer, ok := i.opts.tracer.(EventTracer); ok {
+		tracer.OnEvent(event)
+	}
+
+	// In strict mode, events with a mismatched payload are rejected
+	if i.opts.strictPayloads {
+		if err := i.checkPayload(event); err != nil {
+			if i.result != nil {
+				i.result.Err = err
+			}
+			i.recordIgnored(event)
+			return false
+		}
+	}
+
+	return i.deliverUnlocked(event)
+}
+
+// deliverUnlocked takes the transition for an event, or holds the event if an
+// active state defers it, and re-delivers deferred events after a transition
+// (caller must hold mu). Returns true if a transition was taken.
+func (i *Interpreter[C]) deliverUnlocked(event Event) bool {
+	if !i.started {
+		return false
+	}
+	i.cachingGuards = true
+	defer i.stopCachingGuards()
 
 	// Handle parallel states: broadcast event to all regions (v2.0)
 	if i.currentParallel != "" {
-		i.sendToParallelRegions(event)
-		return
+		if !i.sendToParallelRegions(event) {
+			if !i.deferUnlocked(event) {
+				i.recordIgnored(event)
+			}
+			return false
+		}
+		i.processAlwaysTransitions(event)
+		i.releaseDeferred()
+		return true
 	}
 
 	// Get current state config
 	currentState := i.machine.GetState(i.state.Value)
 	if currentState == nil {
-		return
+		return false
 	}
 
 	// Find matching transition, bubbling up through ancestors
 	source := i.findMatchingTransitionHierarchical(currentState, event)
 	if source == nil {
-		return // No matching transition in hierarchy
+		if !i.deferUnlocked(event) {
+			i.recordIgnored(event)
+		}
+		return false // No matching transition in hierarchy
 	}
 
-	// Execute the transition
+	// Execute the transition unless a fallible action vetoes it
+	if i.vetoed(source.transition, event) {
+		return false
+	}
 	i.executeTransitionHierarchical(source, event)
+	i.processAlwaysTransitions(event)
+	i.releaseDeferred()
+	return true
+}
+
+// recordIgnored counts an event that caused no transition and reports it to
+// the tracer, if one is set
+func (i *Interpreter[C]) recordIgnored(event Event) {
+	i.stats.EventsIgnored++
+	if i.opts.tracer != nil {
+		i.opts.tracer.OnEventIgnored(event.Type)
+	}
+}
+
+// Subscribe registers a listener that is called after every transition,
+// including delayed transitions fired by timers and parallel region updates.
+// The listener is also called once when Start() enters the initial state.
+//
+// Listeners are invoked synchronously in registration order, outside the
+// interpreter lock, so they may safely call State() or Send(). Each listener
+// receives a consistent snapshot of the state taken when the transition
+// completed; the snapshot's ActiveInParallel map is a copy and is safe to retain.
+//
+// The returned function removes the listener. It is safe to call more than once.
+func (i *Interpreter[C]) Subscribe(fn func(State[C])) (unsubscribe func()) {
+	i.listenersMu.Lock()
+	defer i.listenersMu.Unlock()
+
+	i.nextListenerID++
+	id := i.nextListenerID
+	i.listeners = append(i.listeners, listener[C]{id: id, fn: fn})
+
+	return func() {
+		i.listenersMu.Lock()
+		defer i.listenersMu.Unlock()
+		for idx, l := range i.listeners {
+			if l.id == id {
+				i.listeners = append(i.listeners[:idx:idx], i.listeners[idx+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notify calls all registered listeners with the given snapshot
+// Must be called without holding mu
+func (i *Interpreter[C]) notify(snapshot State[C]) {
+	i.listenersMu.Lock()
+	listeners := make([]listener[C], len(i.listeners))
+	copy(listeners, i.listeners)
+	i.listenersMu.Unlock()
+
+	for _, l := range listeners {
+		l.fn(snapshot)
+	}
+}
+
+// copyStateUnlocked returns a copy of the current state that does not share
+// the ActiveInParallel map with the interpreter (caller must hold mu)
+func (i *Interpreter[C]) copyStateUnlocked() State[C] {
+	snapshot := i.state
+	snapshot.ActiveInParallel = make(map[ir.StateID]ir.StateID, len(i.state.ActiveInParallel))
+	for regionID, leafID := range i.state.ActiveInParallel {
+		snapshot.ActiveInParallel[regionID] = leafID
+	}
+	snapshot.Context = i.copyContextUnlocked()
+	return snapshot
+}
+
+// copyContextUnlocked returns the context, cloned if a cloner is set (caller must hold mu)
+func (i *Interpreter[C]) copyContextUnlocked() C {
+	if i.cloner != nil {
+		return i.cloner(i.state.Context)
+	}
+	return i.state.Context
 }
 
 // UpdateContext allows updating the context with a function
@@ -158,19 +892,61 @@ func (i *Interpreter[C]) UpdateContext(fn func(ctx *C)) {
 	fn(&i.state.Context)
 }
 
+// Context returns a copy of the current context, like State().Context
+// (shallow unless a context cloner is set)
+func (i *Interpreter[C]) Context() C {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.copyContextUnlocked()
+}
+
+// SetContext replaces the context. The value is copied like the context
+// returned by Context, so later changes to it by the caller do not affect the
+// interpreter. Like UpdateContext, SetContext does not run any actions, notify
+// listeners, or check the context validator.
+func (i *Interpreter[C]) SetContext(ctx C) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.cloner != nil {
+		ctx = i.cloner(ctx)
+	}
+	i.state.Context = ctx
+}
+
+// scheduler is the Scheduler passed to scheduler actions
+type scheduler[C any] struct {
+	interp *Interpreter[C]
+}
+
+// Raise queues the event behind the event being processed, like Send from an
+// action, even on an interpreter created with NewInterpreterAsync
+func (s scheduler[C]) Raise(event Event) {
+	s.interp.raise(func() bool { return s.interp.sendUnlocked(event) })
+}
+
 // findMatchingTransition finds the first transition that matches the event and passes guards
 func (i *Interpreter[C]) findMatchingTransition(state *ir.StateConfig, event Event) *ir.TransitionConfig {
+	// Specific transitions take precedence over wildcard transitions in the same state
+	if t := i.findTransitionForEvent(state, event.Type, event); t != nil {
+		return t
+	}
+	return i.findTransitionForEvent(state, ir.WildcardEvent, event)
+}
+
+// findTransitionForEvent returns the first transition on the state registered for
+// eventType whose guard passes for the given event
+func (i *Interpreter[C]) findTransitionForEvent(state *ir.StateConfig, eventType ir.EventType, event Event) *ir.TransitionConfig {
 	for _, t := range state.Transitions {
-		if t.Event != event.Type {
+		if t.IsAlways() || t.IsDelayed() || t.Event != eventType {
 			continue
 		}
 
 		// Check guard if present
-		if t.Guard != "" {
-			guard := i.machine.GetGuard(t.Guard)
-			if guard != nil && !guard(i.state.Context, event) {
-				continue // Guard failed, try next transition
+		if t.Guard != "" && !i.evalGuard(t.Guard, event) {
+			if !i.querying {
+				i.stats.GuardRejections++
 			}
+			continue // Guard failed, try next transition
 		}
 
 		return t
@@ -178,6 +954,59 @@ func (i *Interpreter[C]) findMatchingTransition(state *ir.StateConfig, event Eve
 	return nil
 }
 
+// evalGuard evaluates the named guard against the current context
+// State guards also see the active states, and in-state guards check them;
+// any other guard missing from the machine passes. Results of context guards
+// are memoized while an event is dispatched (see Guard).
+func (i *Interpreter[C]) evalGuard(name ir.GuardType, event Event) bool {
+	var result bool
+	if guard := i.machine.GetGuard(name); guard != nil {
+		// Reused results are reported to the tracer like evaluated ones
+		cached, ok := i.guardCache[name]
+		result = cached
+		if !ok {
+			result = guard(i.state.Context, event)
+			if i.cachingGuards {
+				if i.guardCache == nil {
+					i.guardCache = make(map[ir.GuardType]bool)
+				}
+				i.guardCache[name] = result
+			}
+		}
+	} else if guard := i.machine.GetStateGuard(name); guard != nil {
+		result = guard(i.state.Context, event, stateView[C]{i})
+	} else if id, ok := name.InState(); ok {
+		result = i.matchesUnlocked(id)
+	} else {
+		return true
+	}
+	if i.opts.tracer != nil && !i.querying {
+		i.opts.tracer.OnGuardEval(name, result)
+	}
+	return result
+}
+
+// stopCachingGuards ends the memoization of guard results started when an
+// event is dispatched (caller must hold mu)
+func (i *Interpreter[C]) stopCachingGuards() {
+	i.cachingGuards = false
+	clear(i.guardCache)
+}
+
+// stateView is the StateSnapshot passed to state guards. Guards run while the
+// interpreter lock is held, so it reads the state without locking.
+type stateView[C any] struct {
+	interp *Interpreter[C]
+}
+
+func (v stateView[C]) Value() StateID {
+	return v.interp.state.Value
+}
+
+func (v stateView[C]) Matches(id StateID) bool {
+	return v.interp.matchesUnlocked(id)
+}
+
 // findMatchingTransitionHierarchical finds a matching transition starting from the given state
 // and bubbling up through ancestor states until a match is found
 func (i *Interpreter[C]) findMatchingTransitionHierarchical(state *ir.StateConfig, event Event) *transitionSource[C] {
@@ -186,6 +1015,12 @@ func (i *Interpreter[C]) findMatchingTransitionHierarchical(state *ir.StateConfi
 	for current != nil {
 		transition := i.findMatchingTransition(current, event)
 		if transition != nil {
+			// A bubbling transition defers to a matching transition of an ancestor
+			if transition.Bubble {
+				if source := i.findMatchingTransitionHierarchical(i.machine.GetState(current.Parent), event); source != nil {
+					return source
+				}
+			}
 			return &transitionSource[C]{
 				state:      current,
 				transition: transition,
@@ -208,86 +1043,106 @@ func (i *Interpreter[C]) executeTransitionHierarchical(source *transitionSource[
 	sourceStateID := source.state.ID
 	targetStateID := transition.Target
 
+	// Internal self-transitions only run transition actions
+	if isInternalSelfTransition(source) {
+		i.recordTransition(i.state.Value, i.state.Value, event)
+		i.executeTransitionActions(transition, event)
+		return
+	}
+
 	// Resolve target: handle history states or resolve to leaf state
-	resolvedTarget := i.resolveTarget(targetStateID)
+	resolvedTarget := i.resolveTarget(targetStateID, event)
 
 	// Get the current leaf state (what we're actually in)
 	currentLeaf := i.state.Value
+	i.recordTransition(currentLeaf, resolvedTarget, event)
 
-	// Find the Lowest Common Ancestor (LCA)
-	// The LCA determines which states to exit and enter
-	lca := i.machine.FindLCA(sourceStateID, resolvedTarget)
+	// Find the transition domain: the deepest state that is neither exited nor re-entered
+	domain := i.transitionDomain(sourceStateID, targetStateID)
 
-	// For self-transitions (or transitions within the same state hierarchy),
-	// we need to exit and re-enter the source state. This is "external" transition behavior.
-	// The LCA for external transitions should be the parent of the source state.
-	isSelfTransition := sourceStateID == targetStateID
-
-	var statesToExit []ir.StateID
-	var statesToEnter []ir.StateID
-
-	if isSelfTransition {
-		// Self-transition: exit and re-enter the state (and any descendants)
-		statesToExit = i.getStatesToExit(currentLeaf, source.state.Parent)
-		statesToEnter = i.getStatesToEnter(resolvedTarget, source.state.Parent)
-	} else {
-		// Calculate states to exit: from current leaf up to (but not including) LCA
-		statesToExit = i.getStatesToExit(currentLeaf, lca)
-
-		// Calculate states to enter: from below LCA down to target
-		statesToEnter = i.getStatesToEnter(resolvedTarget, lca)
-	}
+	// Exit from the current leaf up to the domain, then enter from below the domain down to the target
+	statesToExit := i.getStatesToExit(currentLeaf, domain)
+	statesToEnter := i.getStatesToEnter(resolvedTarget, domain)
 
 	// 1. Execute exit actions (leaf to root order), cancel timers, and record history
 	for _, stateID := range statesToExit {
 		stateConfig := i.machine.GetState(stateID)
 		if stateConfig != nil {
-			// Cancel any active delayed transitions (v2.0)
-			i.cancelDelayedTransitions(stateID)
-
-			i.executeActions(stateConfig.Exit, event)
-
-			// Record history for parent compound states when exiting
-			if stateConfig.Parent != "" {
-				parent := i.machine.GetState(stateConfig.Parent)
-				if parent != nil && parent.IsCompound() {
-					// Record shallow history: immediate child that was active
-					i.shallowHistory[parent.ID] = stateID
-					// Record deep history: the current leaf state
-					i.deepHistory[parent.ID] = currentLeaf
-				}
-			}
+			// Cancel timers and services, then run exit actions
+			i.exitState(stateConfig, event)
+			i.recordHistory(stateConfig, currentLeaf)
 		}
 	}
 
 	// 2. Execute transition actions
-	i.executeActions(transition.Actions, event)
+	i.executeTransitionActions(transition, event)
 
-	// 3. Check if target is a parallel state (v2.0)
-	targetConfig := i.machine.GetState(resolvedTarget)
-	if targetConfig != nil && targetConfig.IsParallel() {
-		// Enter the parallel state (handles all regions)
-		i.enterParallelState(resolvedTarget, event)
-		return
-	}
+	// 3. Execute entry actions (root to leaf order) and schedule delayed transitions
+	i.enterStates(statesToEnter, resolvedTarget, event)
+}
 
-	// 4. Execute entry actions (root to leaf order) and schedule delayed transitions
+// enterStates enters the given states in root-to-leaf order and makes target,
+// the resolved leaf, the current state
+func (i *Interpreter[C]) enterStates(statesToEnter []ir.StateID, target ir.StateID, event Event) {
 	for _, stateID := range statesToEnter {
 		stateConfig := i.machine.GetState(stateID)
 		if stateConfig != nil {
-			// Check if this is a parallel state within the entry path
+			// The outermost parallel state on the entry path enters all its regions,
+			// including nested parallel states. The target's region is entered at
+			// the target, e.g. a restored history leaf.
 			if stateConfig.IsParallel() {
-				i.enterParallelState(stateID, event)
+				i.enterParallelState(stateID, target, event)
 				return
 			}
-			i.executeActions(stateConfig.Entry, event)
-			// Schedule delayed transitions (v2.0)
-			i.scheduleDelayedTransitions(stateID)
+			i.enterState(stateConfig, event)
 		}
 	}
+	i.state.Value = target
+}
 
-	// 5. Update current state to the leaf
-	i.state.Value = resolvedTarget
+// recordHistory records the exited state as the last active child of its
+// compound parent (shallow history) and leaf as its last active leaf (deep history)
+func (i *Interpreter[C]) recordHistory(stateConfig *ir.StateConfig, leaf ir.StateID) {
+	if stateConfig.Parent == "" {
+		return
+	}
+	parent := i.machine.GetState(stateConfig.Parent)
+	if parent != nil && parent.IsCompound() {
+		i.shallowHistory[parent.ID] = stateConfig.ID
+		i.deepHistory[parent.ID] = leaf
+	}
+}
+
+// recordTransition counts a transition, adds it to the transition log, and
+// reports it to the tracer, if one is set
+func (i *Interpreter[C]) recordTransition(from, to ir.StateID, event Event) {
+	i.stats.Transitions++
+	if i.transitionLog != nil {
+		i.transitionLog.add(TransitionLogEntry{From: from, To: to, Event: event.Type, Timestamp: clock.Now(i.opts.clock)})
+	}
+	if i.opts.tracer != nil {
+		i.opts.tracer.OnTransition(from, to, event.Type)
+	}
+}
+
+// transitionDomain returns the state that contains a transition from source to target.
+// It is the lowest common ancestor of the two, except when the target is the source or
+// one of its ancestors: the target is then exited and re-entered, so the domain is its
+// parent. A target that is a descendant of the source does not re-enter the source.
+// An empty result means the transition crosses the root.
+func (i *Interpreter[C]) transitionDomain(source, target ir.StateID) ir.StateID {
+	domain := i.machine.FindLCA(source, target)
+	if domain == target {
+		if targetConfig := i.machine.GetState(target); targetConfig != nil {
+			return targetConfig.Parent
+		}
+	}
+	return domain
+}
+
+// isInternalSelfTransition returns true if the transition is internal and targets its own source state
+func isInternalSelfTransition[C any](source *transitionSource[C]) bool {
+	return source.transition.IsInternal() && source.transition.Target == source.state.ID
 }
 
 // getStatesToExit returns states to exit in leaf-to-root order
@@ -340,7 +1195,8 @@ func (i *Interpreter[C]) getStatesToEnter(target, lca ir.StateID) []ir.StateID {
 }
 
 // enterStateHierarchy enters a state and all its descendants to the initial leaf
-func (i *Interpreter[C]) enterStateHierarchy(stateID ir.StateID) {
+// Entry actions receive the given event
+func (i *Interpreter[C]) enterStateHierarchy(stateID ir.StateID, event Event) {
 	stateConfig := i.machine.GetState(stateID)
 	if stateConfig == nil {
 		return
@@ -348,12 +1204,12 @@ func (i *Interpreter[C]) enterStateHierarchy(stateID ir.StateID) {
 
 	// Handle parallel states (v2.0)
 	if stateConfig.IsParallel() {
-		i.enterParallelState(stateID, Event{})
+		i.enterParallelState(stateID, "", event)
 		return
 	}
 
 	// Get the path from this state to its initial leaf
-	leaf := i.machine.GetInitialLeaf(stateID)
+	leaf := i.initialLeaf(stateID, event)
 	path := i.getEntryPath(stateID, leaf)
 
 	// Check if any state in the path is a parallel state
@@ -365,11 +1221,10 @@ func (i *Interpreter[C]) enterStateHierarchy(stateID ir.StateID) {
 			for _, preID := range prePath[:len(prePath)-1] {
 				preConfig := i.machine.GetState(preID)
 				if preConfig != nil {
-					i.executeActions(preConfig.Entry, Event{})
-					i.scheduleDelayedTransitions(preID)
+					i.enterState(preConfig, event)
 				}
 			}
-			i.enterParallelState(id, Event{})
+			i.enterParallelState(id, "", event)
 			return
 		}
 	}
@@ -378,9 +1233,7 @@ func (i *Interpreter[C]) enterStateHierarchy(stateID ir.StateID) {
 	for _, id := range path {
 		stateConfig := i.machine.GetState(id)
 		if stateConfig != nil {
-			i.executeActions(stateConfig.Entry, Event{})
-			// Schedule delayed transitions (v2.0)
-			i.scheduleDelayedTransitions(id)
+			i.enterState(stateConfig, event)
 		}
 	}
 
@@ -404,26 +1257,153 @@ func (i *Interpreter[C]) getEntryPath(start, leaf ir.StateID) []ir.StateID {
 		if id == start {
 			foundStart = true
 		}
-		if foundStart {
-			result = append(result, id)
+		if foundStart {
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
+
+// enterState runs entry actions for a single state, schedules its delayed
+// transitions, and starts its invoked services
+func (i *Interpreter[C]) enterState(stateConfig *ir.StateConfig, event Event) {
+	i.coverage.visit(stateConfig.ID)
+	if !i.skipEntryActions {
+		i.executeActions(stateConfig.Entry, event)
+	}
+	i.scheduleDelayedTransitions(stateConfig.ID, event)
+	i.startInvocations(stateConfig, event)
+	if stateConfig.IsFinal() {
+		if output := i.machine.GetOutput(stateConfig.ID); output != nil && stateConfig.Parent == "" {
+			i.output, i.hasOutput = output(i.state.Context, event), true
+		}
+		i.raiseDoneEvents(stateConfig)
+	}
+}
+
+// exitState cancels the delayed transitions and invoked services of a single
+// state, then runs its exit actions
+func (i *Interpreter[C]) exitState(stateConfig *ir.StateConfig, event Event) {
+	i.cancelDelayedTransitions(stateConfig.ID)
+	i.cancelInvocations(stateConfig.ID)
+	i.executeActions(stateConfig.Exit, event)
+}
+
+// executeActions executes a list of actions
+func (i *Interpreter[C]) executeActions(actions []ir.ActionType, event Event) {
+	for _, actionName := range actions {
+		action := i.machine.GetAction(actionName)
+		if scheduled := i.machine.GetSchedulerAction(actionName); action == nil && scheduled != nil {
+			action = func(ctx *C, e Event) { scheduled(ctx, e, scheduler[C]{i}) }
+		}
+		if action != nil {
+			// The action may change the context guards are evaluated on
+			clear(i.guardCache)
+			if i.opts.tracer != nil {
+				i.opts.tracer.OnActionStart(actionName)
+			}
+			err := i.runAction(actionName, func() { action(&i.state.Context, event) })
+			if i.opts.tracer != nil {
+				i.opts.tracer.OnActionEnd(actionName)
+			}
+			if i.result != nil {
+				i.result.Actions = append(i.result.Actions, actionName)
+			}
+			i.abortOnPanic(err)
+		}
+	}
+}
+
+// executeTransitionActions executes a transition's actions, then each of its
+// conditional actions whose guard passes
+func (i *Interpreter[C]) executeTransitionActions(trans *ir.TransitionConfig, event Event) {
+	i.coverage.take(trans)
+	i.executeActions(trans.Actions, event)
+	for _, conditional := range trans.ConditionalActions {
+		if i.evalGuard(conditional.Guard, event) {
+			i.executeActions([]ir.ActionType{conditional.Action}, event)
+		}
+	}
+}
+
+// vetoed runs the transition's fallible actions on a copy of the context and
+// reports whether one of them vetoed the transition. On success the copy becomes
+// the context; on a veto it is discarded and the error is recorded in the result
+// being built by SendResult, if any, and the machine is routed to its error
+// target (caller must hold mu).
+func (i *Interpreter[C]) vetoed(trans *ir.TransitionConfig, event Event) bool {
+	if len(trans.FallibleActions) == 0 {
+		return false
+	}
+
+	ctx := i.copyContextUnlocked()
+	for _, actionName := range trans.FallibleActions {
+		action := i.machine.GetFallibleAction(actionName)
+		if action == nil {
+			continue
+		}
+		if i.opts.tracer != nil {
+			i.opts.tracer.OnActionStart(actionName)
+		}
+		var err error
+		if panicErr := i.runAction(actionName, func() { err = action(&ctx, event) }); panicErr != nil {
+			err = panicErr
+		}
+		if i.opts.tracer != nil {
+			i.opts.tracer.OnActionEnd(actionName)
+		}
+		if i.result != nil {
+			i.result.Actions = append(i.result.Actions, actionName)
+		}
+		if err != nil {
+			err = fmt.Errorf("action %q vetoed transition: %w", actionName, err)
+			if i.result != nil && i.result.Err == nil {
+				i.result.Err = err
+			}
+			i.routeToErrorTarget(err)
+			return true
 		}
 	}
 
-	return result
+	i.state.Context = ctx
+	clear(i.guardCache)
+	return false
 }
 
-// executeActions executes a list of actions
-func (i *Interpreter[C]) executeActions(actions []ir.ActionType, event Event) {
-	for _, actionName := range actions {
-		action := i.machine.GetAction(actionName)
-		if action != nil {
-			action(&i.state.Context, event)
-		}
+// routeToErrorTarget queues a transition to the machine's error target, if it
+// has one, taken like a transition of the active top-level state with an
+// ErrorEvent carrying err (caller must hold mu)
+func (i *Interpreter[C]) routeToErrorTarget(err error) {
+	target := i.machine.ErrorTarget
+	if target == "" {
+		return
 	}
+	i.raise(func() bool {
+		if !i.started {
+			return false
+		}
+		root := i.machine.GetState(i.machine.GetPath(i.state.Value)[0])
+		if root == nil {
+			return false
+		}
+
+		event := Event{Type: ErrorEvent, Payload: err}
+		if i.currentParallel != "" {
+			i.exitParallelRegions(event)
+		}
+		i.executeTransitionHierarchical(&transitionSource[C]{
+			state:      root,
+			transition: &ir.TransitionConfig{Target: target},
+		}, event)
+		i.processAlwaysTransitions(event)
+		i.releaseDeferred()
+		return true
+	})
 }
 
 // resolveTarget resolves the target state, handling history states, compound states, and parallel states
-func (i *Interpreter[C]) resolveTarget(targetID ir.StateID) ir.StateID {
+func (i *Interpreter[C]) resolveTarget(targetID ir.StateID, event Event) ir.StateID {
 	targetState := i.machine.GetState(targetID)
 	if targetState == nil {
 		return targetID
@@ -431,7 +1411,7 @@ func (i *Interpreter[C]) resolveTarget(targetID ir.StateID) ir.StateID {
 
 	// Handle history states
 	if targetState.IsHistory() {
-		return i.resolveHistoryTarget(targetState)
+		return i.resolveHistoryTarget(targetState, event)
 	}
 
 	// For parallel states, return the parallel state itself (don't resolve to leaf)
@@ -441,15 +1421,36 @@ func (i *Interpreter[C]) resolveTarget(targetID ir.StateID) ir.StateID {
 	}
 
 	// For compound states, resolve to initial leaf
-	return i.machine.GetInitialLeaf(targetID)
+	return i.initialLeaf(targetID, event)
+}
+
+// initialLeaf resolves a state to the leaf entered by default, following the
+// initial child of compound states. The first guarded initial child whose
+// guard passes is preferred over the initial child.
+func (i *Interpreter[C]) initialLeaf(stateID ir.StateID, event Event) ir.StateID {
+	state := i.machine.GetState(stateID)
+	if state == nil || !state.IsCompound() {
+		return stateID
+	}
+	initial := state.Initial
+	for _, choice := range state.InitialChoices {
+		if i.evalGuard(choice.Guard, event) {
+			initial = choice.Target
+			break
+		}
+	}
+	if initial == "" {
+		return stateID
+	}
+	return i.initialLeaf(initial, event)
 }
 
 // resolveHistoryTarget resolves a history state to the appropriate target
-func (i *Interpreter[C]) resolveHistoryTarget(historyState *ir.StateConfig) ir.StateID {
+func (i *Interpreter[C]) resolveHistoryTarget(historyState *ir.StateConfig, event Event) ir.StateID {
 	parentID := historyState.Parent
 	if parentID == "" {
 		// Fallback to default
-		return i.machine.GetInitialLeaf(historyState.HistoryDefault)
+		return i.initialLeaf(historyState.HistoryDefault, event)
 	}
 
 	// Check if we have recorded history for the parent
@@ -467,32 +1468,259 @@ func (i *Interpreter[C]) resolveHistoryTarget(historyState *ir.StateConfig) ir.S
 			return recordedHistory
 		}
 		// For shallow history, we need to resolve to the initial leaf of the recorded child
-		return i.machine.GetInitialLeaf(recordedHistory)
+		return i.initialLeaf(recordedHistory, event)
 	}
 
 	// No history recorded, use default
-	return i.machine.GetInitialLeaf(historyState.HistoryDefault)
+	return i.initialLeaf(historyState.HistoryDefault, event)
+}
+
+// --- Eventless (always) transitions ---
+
+// processAlwaysTransitions repeatedly takes eventless transitions until none
+// applies or the iteration limit is reached (caller must hold mu)
+func (i *Interpreter[C]) processAlwaysTransitions(event Event) {
+	for n := 0; n < i.opts.maxAlwaysIterations; n++ {
+		if !i.takeAlwaysTransition(event) {
+			return
+		}
+	}
+}
+
+// takeAlwaysTransition takes the first eventless transition whose guard passes
+// Returns true if a transition was taken
+func (i *Interpreter[C]) takeAlwaysTransition(event Event) bool {
+	if i.currentParallel != "" {
+		parallelState := i.machine.GetState(i.currentParallel)
+		if parallelState == nil {
+			return false
+		}
+
+		// Transitions on the parallel state itself exit all regions
+		if t := i.findAlwaysTransition(parallelState, event); t != nil {
+			if i.vetoed(t, event) {
+				return false
+			}
+			transSource := &transitionSource[C]{
+				state:      parallelState,
+				transition: t,
+			}
+			if !isInternalSelfTransition(transSource) {
+				i.exitParallelRegions(event)
+			}
+			i.executeTransitionHierarchical(transSource, event)
+			return true
+		}
+
+		return i.takeAlwaysTransitionInRegions(parallelState, event)
+	}
+
+	// Check the current leaf, then bubble up through ancestors
+	current := i.machine.GetState(i.state.Value)
+	for current != nil {
+		if t := i.findAlwaysTransition(current, event); t != nil {
+			if i.vetoed(t, event) {
+				return false
+			}
+			i.executeTransitionHierarchical(&transitionSource[C]{
+				state:      current,
+				transition: t,
+			}, event)
+			return true
+		}
+		if current.Parent == "" {
+			break
+		}
+		current = i.machine.GetState(current.Parent)
+	}
+	return false
+}
+
+// takeAlwaysTransitionInRegions takes the first eventless transition found in
+// the regions of a parallel state, checked in declaration order for
+// deterministic behavior. The regions of a nested parallel state are checked
+// after the nested state and its ancestors within the region.
+func (i *Interpreter[C]) takeAlwaysTransitionInRegions(parallelState *ir.StateConfig, event Event) bool {
+	for _, regionID := range parallelState.Children {
+		leafID, ok := i.state.ActiveInParallel[regionID]
+		if !ok {
+			continue
+		}
+		current := i.machine.GetState(leafID)
+		for current != nil {
+			if t := i.findAlwaysTransition(current, event); t != nil {
+				if i.vetoed(t, event) {
+					break
+				}
+				i.executeTransitionInRegion(regionID, &transitionSource[C]{
+					state:      current,
+					transition: t,
+				}, event)
+				return true
+			}
+			if current.ID == regionID || current.Parent == "" {
+				break
+			}
+			current = i.machine.GetState(current.Parent)
+		}
+		if leafConfig := i.machine.GetState(leafID); leafConfig != nil && leafConfig.IsParallel() &&
+			i.takeAlwaysTransitionInRegions(leafConfig, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// findAlwaysTransition finds the first eventless transition whose guard passes
+func (i *Interpreter[C]) findAlwaysTransition(state *ir.StateConfig, event Event) *ir.TransitionConfig {
+	for _, t := range state.Transitions {
+		if !t.IsAlways() {
+			continue
+		}
+		if t.Guard != "" && !i.evalGuard(t.Guard, event) {
+			continue
+		}
+		return t
+	}
+	return nil
+}
+
+// --- Done events ---
+
+// raiseDoneEvents queues done.state events for the parent of a final state that
+// was entered and, when the parent is a parallel region, for the parallel state.
+// The events are processed after the current step, and only if the state is
+// still done by then.
+func (i *Interpreter[C]) raiseDoneEvents(final *ir.StateConfig) {
+	parent := i.machine.GetState(final.Parent)
+	if parent == nil {
+		return
+	}
+	i.raiseDone(parent.ID)
+
+	if grandparent := i.machine.GetState(parent.Parent); grandparent != nil && grandparent.IsParallel() {
+		i.raiseDone(grandparent.ID)
+	}
+}
+
+// raiseDone queues a done.state event for the given state
+func (i *Interpreter[C]) raiseDone(stateID ir.StateID) {
+	i.raise(func() bool {
+		if !i.started || !i.isDoneUnlocked(stateID) {
+			return false
+		}
+		return i.sendUnlocked(Event{Type: DoneStateEvent(stateID)})
+	})
+}
+
+// isDoneUnlocked returns true if a compound state's active child is final, or
+// if every region of an active parallel state is done (caller must hold mu)
+func (i *Interpreter[C]) isDoneUnlocked(stateID ir.StateID) bool {
+	stateConfig := i.machine.GetState(stateID)
+	if stateConfig == nil {
+		return false
+	}
+
+	if stateConfig.IsParallel() {
+		if !i.matchesUnlocked(stateID) {
+			return false
+		}
+		for _, regionID := range stateConfig.Children {
+			if !i.isDoneUnlocked(regionID) {
+				return false
+			}
+		}
+		return true
+	}
+
+	leaf := i.activeLeafInUnlocked(stateID)
+	if leaf == "" {
+		return false
+	}
+	leafConfig := i.machine.GetState(leaf)
+	return leafConfig != nil && leafConfig.IsFinal() && leafConfig.Parent == stateID
+}
+
+// activeLeafInUnlocked returns the active leaf state within the given state,
+// or an empty ID if the state is not active (caller must hold mu)
+func (i *Interpreter[C]) activeLeafInUnlocked(stateID ir.StateID) ir.StateID {
+	if i.currentParallel == "" {
+		if i.state.Value == stateID || i.machine.IsDescendantOf(i.state.Value, stateID) {
+			return i.state.Value
+		}
+		return ""
+	}
+	for _, leafID := range i.state.ActiveInParallel {
+		if leafID == stateID || i.machine.IsDescendantOf(leafID, stateID) {
+			return leafID
+		}
+	}
+	return ""
 }
 
 // --- Timer management for delayed transitions (v2.0) ---
 
-// Stop cancels all active timers and stops the interpreter
+// Stop cancels all active timers and invoked services and stops the interpreter.
+// Exit actions are not run; use StopWithExit to exit the active states gracefully.
 func (i *Interpreter[C]) Stop() {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
+	i.cancelAllTimers()
+	i.cancelAllInvocations()
+	i.deferred = nil
+	i.started = false
+}
+
+// StopWithExit stops the interpreter after exiting every active state. Exit
+// actions run once per active state in leaf-to-root order, with parallel regions
+// exited in reverse declaration order before their parallel state. Exit actions receive
+// a synthetic event of type StopEvent.
+//
+// Like Send, if StopWithExit is called while an event is being processed (for
+// example from an action), the stop is queued and runs after that event.
+func (i *Interpreter[C]) StopWithExit() {
+	i.process(func() bool {
+		if !i.started {
+			return false
+		}
+
+		event := Event{Type: StopEvent}
+		i.exitParallelRegions(event)
+		for _, stateID := range i.getStatesToExit(i.state.Value, "") {
+			if stateConfig := i.machine.GetState(stateID); stateConfig != nil {
+				i.exitState(stateConfig, event)
+			}
+		}
+
+		i.cancelAllTimers()
+		i.cancelAllInvocations()
+		i.deferred = nil
+		i.started = false
+		return false
+	})
+}
+
+// cancelAllTimers stops and removes every active delayed transition timer
+func (i *Interpreter[C]) cancelAllTimers() {
 	i.timersMu.Lock()
-	for key, timer := range i.timers {
-		timer.Stop()
+	defer i.timersMu.Unlock()
+
+	for key, scheduled := range i.timers {
+		scheduled.timer.Stop()
 		delete(i.timers, key)
 	}
-	i.timersMu.Unlock()
-
-	i.started = false
 }
 
 // scheduleDelayedTransitions schedules timers for all delayed transitions in the given state
-func (i *Interpreter[C]) scheduleDelayedTransitions(stateID ir.StateID) {
+// Named delays are resolved with the event that entered the state
+func (i *Interpreter[C]) scheduleDelayedTransitions(stateID ir.StateID, event Event) {
+	i.scheduleDelayedTransitionsElapsed(stateID, event, 0)
+}
+
+// scheduleDelayedTransitionsElapsed schedules timers for all delayed transitions in the
+// given state, treating elapsed as time already spent in the state
+func (i *Interpreter[C]) scheduleDelayedTransitionsElapsed(stateID ir.StateID, event Event, elapsed time.Duration) {
 	stateConfig := i.machine.GetState(stateID)
 	if stateConfig == nil {
 		return
@@ -503,31 +1731,68 @@ func (i *Interpreter[C]) scheduleDelayedTransitions(stateID ir.StateID) {
 			continue
 		}
 
-		// Create timer key: stateID:transitionIndex
-		timerKey := fmt.Sprintf("%s:%d", stateID, idx)
+		// Fire immediately if the delay has already elapsed
+		remaining := max(i.resolveDelay(trans, event)-elapsed, 0)
+		i.scheduleDelayedTransition(stateConfig, idx, trans, remaining)
+	}
+}
 
-		// Capture transition for closure
-		capturedTrans := trans
+// scheduleDelayedTransition arms the timer of a delayed transition. A repeating
+// transition that is not taken when the timer fires is armed again.
+func (i *Interpreter[C]) scheduleDelayedTransition(stateConfig *ir.StateConfig, idx int, trans *ir.TransitionConfig, delay time.Duration) {
+	// Create timer key: stateID:transitionIndex
+	stateID := stateConfig.ID
+	timerKey := fmt.Sprintf("%s:%d", stateID, idx)
 
-		i.timersMu.Lock()
-		timer := time.AfterFunc(trans.Delay, func() {
-			// Acquire main mutex first to protect state access
-			i.mu.Lock()
-			defer i.mu.Unlock()
+	i.timersMu.Lock()
+	defer i.timersMu.Unlock()
 
+	scheduled := &scheduledTimer{
+		stateID:  stateID,
+		index:    idx,
+		trans:    trans,
+		deadline: clock.Now(i.opts.clock).Add(delay),
+	}
+	scheduled.timer = i.opts.clock.AfterFunc(delay, func() {
+		i.process(func() bool {
+			// Ignore timers canceled or replaced while this step was queued
 			i.timersMu.Lock()
-			// Remove timer from map before executing
-			delete(i.timers, timerKey)
+			current := i.timers[timerKey] == scheduled
+			if current {
+				delete(i.timers, timerKey)
+			}
 			i.timersMu.Unlock()
 
 			// Execute the delayed transition if still in the originating state
-			if i.started && i.matchesUnlocked(stateID) {
-				i.executeDelayedTransition(stateConfig, capturedTrans)
+			if !current || !i.started || !i.matchesUnlocked(stateID) {
+				return false
 			}
+			if !i.executeDelayedTransition(stateConfig, trans) {
+				// A named delay that resolves to no time ends the repetition
+				// instead of re-arming the timer immediately, forever
+				if delay := i.resolveDelay(trans, Event{}); trans.Repeat && delay > 0 {
+					i.scheduleDelayedTransition(stateConfig, idx, trans, delay)
+				}
+				return false
+			}
+			i.stats.DelayedFired++
+			i.processAlwaysTransitions(Event{})
+			i.releaseDeferred()
+			return true
 		})
-		i.timers[timerKey] = timer
-		i.timersMu.Unlock()
+	})
+	i.timers[timerKey] = scheduled
+}
+
+// resolveDelay returns the duration of a delayed transition, calling its named
+// delay resolver if it has one
+func (i *Interpreter[C]) resolveDelay(trans *ir.TransitionConfig, event Event) time.Duration {
+	if trans.DelayName != "" {
+		if resolver := i.machine.GetDelay(trans.DelayName); resolver != nil {
+			return resolver(i.state.Context, event)
+		}
 	}
+	return trans.Delay
 }
 
 // cancelDelayedTransitions cancels all timers for the given state
@@ -542,66 +1807,196 @@ func (i *Interpreter[C]) cancelDelayedTransitions(stateID ir.StateID) {
 
 	for idx := range stateConfig.Transitions {
 		timerKey := fmt.Sprintf("%s:%d", stateID, idx)
-		if timer, ok := i.timers[timerKey]; ok {
-			timer.Stop()
+		if scheduled, ok := i.timers[timerKey]; ok {
+			scheduled.timer.Stop()
 			delete(i.timers, timerKey)
 		}
 	}
 }
 
 // executeDelayedTransition executes a delayed transition
-func (i *Interpreter[C]) executeDelayedTransition(sourceState *ir.StateConfig, trans *ir.TransitionConfig) {
+// Returns true if the transition was taken
+func (i *Interpreter[C]) executeDelayedTransition(sourceState *ir.StateConfig, trans *ir.TransitionConfig) bool {
 	// Check guard if present
-	if trans.Guard != "" {
-		guard := i.machine.GetGuard(trans.Guard)
-		if guard != nil && !guard(i.state.Context, Event{}) {
-			return // Guard failed, don't execute
-		}
+	if trans.Guard != "" && !i.evalGuard(trans.Guard, Event{}) {
+		return false // Guard failed, don't execute
+	}
+
+	if i.vetoed(trans, Event{}) {
+		return false
 	}
 
 	source := &transitionSource[C]{
 		state:      sourceState,
 		transition: trans,
 	}
+
+	// Like events, transitions of region states stay within their region, and
+	// transitions of the parallel state or its ancestors exit every region
+	if regionID := i.regionOf(sourceState.ID); regionID != "" {
+		i.executeTransitionInRegion(regionID, source, Event{})
+		return true
+	}
+	if !isInternalSelfTransition(source) {
+		i.exitParallelRegions(Event{})
+	}
 	i.executeTransitionHierarchical(source, Event{})
+	return true
+}
+
+// regionOf returns the innermost active parallel region that contains the
+// given state or is the state itself, or "" if it is not in a region
+func (i *Interpreter[C]) regionOf(stateID ir.StateID) ir.StateID {
+	for state := i.machine.GetState(stateID); state != nil && state.Parent != ""; state = i.machine.GetState(state.Parent) {
+		if _, ok := i.state.ActiveInParallel[state.ID]; ok {
+			return state.ID
+		}
+	}
+	return ""
+}
+
+// --- Invoked services ---
+
+// startInvocations starts every service invoked by the given state
+func (i *Interpreter[C]) startInvocations(stateConfig *ir.StateConfig, event Event) {
+	for _, name := range stateConfig.Invoke {
+		service := i.machine.GetService(name)
+		if service == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s", stateConfig.ID, name)
+		ctx, cancel := context.WithCancel(context.Background())
+		inv := &invocation{cancel: cancel}
+		i.invocations[key] = inv
+
+		go i.runInvocation(ctx, key, inv, name, service, i.state.Context, event)
+	}
+}
+
+// runInvocation runs a service and delivers its result as a done or error event
+// The result is dropped if the invocation was canceled before it completed
+func (i *Interpreter[C]) runInvocation(ctx context.Context, key string, inv *invocation, name ir.ServiceType, service ir.Service[C], machineCtx C, event Event) {
+	result, err := service(ctx, machineCtx, event)
+
+	i.process(func() bool {
+		if i.invocations[key] != inv {
+			// State was exited (or re-entered) while the service was running
+			return false
+		}
+		delete(i.invocations, key)
+		inv.cancel()
+
+		if err != nil {
+			return i.sendUnlocked(Event{Type: ErrorInvokeEvent(name), Payload: err})
+		}
+		return i.sendUnlocked(Event{Type: DoneInvokeEvent(name), Payload: result})
+	})
+}
+
+// cancelInvocations cancels every running service invoked by the given state
+func (i *Interpreter[C]) cancelInvocations(stateID ir.StateID) {
+	stateConfig := i.machine.GetState(stateID)
+	if stateConfig == nil {
+		return
+	}
+
+	for _, name := range stateConfig.Invoke {
+		key := fmt.Sprintf("%s:%s", stateID, name)
+		if inv, ok := i.invocations[key]; ok {
+			inv.cancel()
+			delete(i.invocations, key)
+		}
+	}
+}
+
+// cancelAllInvocations cancels every running invoked service
+func (i *Interpreter[C]) cancelAllInvocations() {
+	for key, inv := range i.invocations {
+		inv.cancel()
+		delete(i.invocations, key)
+	}
 }
 
 // --- Parallel state management (v2.0) ---
 
 // sendToParallelRegions broadcasts an event to all active parallel regions
-func (i *Interpreter[C]) sendToParallelRegions(event Event) {
+// Returns true if any transition was taken
+func (i *Interpreter[C]) sendToParallelRegions(event Event) bool {
 	parallelState := i.machine.GetState(i.currentParallel)
 	if parallelState == nil {
-		return
+		return false
 	}
 
-	// Try to find a transition on the parallel state itself first (exits parallel)
+	// Try to find a transition on the parallel state itself first (exits
+	// parallel); it takes precedence over region transitions for the same event
 	source := i.findMatchingTransition(parallelState, event)
 	if source != nil {
-		// Transition exits the parallel state entirely
-		i.exitParallelState(event)
 		transSource := &transitionSource[C]{
 			state:      parallelState,
 			transition: source,
 		}
+		if i.vetoed(source, event) {
+			return false
+		}
+		// Transition exits the parallel state entirely (unless internal)
+		if !isInternalSelfTransition(transSource) {
+			i.exitParallelRegions(event)
+		}
 		i.executeTransitionHierarchical(transSource, event)
-		return
+		return true
 	}
 
-	// Broadcast event to each region independently
-	for regionID, leafID := range i.state.ActiveInParallel {
-		regionState := i.machine.GetState(leafID)
-		if regionState == nil {
-			continue
+	return i.broadcastToRegions(parallelState, event)
+}
+
+// broadcastToRegions sends an event to each region of a parallel state
+// independently, in declaration order. Returns true if any transition was taken.
+func (i *Interpreter[C]) broadcastToRegions(parallelState *ir.StateConfig, event Event) bool {
+	transitioned := false
+	for _, regionID := range parallelState.Children {
+		if i.sendToRegion(regionID, event) {
+			transitioned = true
 		}
+	}
+	return transitioned
+}
+
+// sendToRegion sends an event to the active state of a single region. When that
+// state is a nested parallel state, its own transitions are tried first and the
+// event is then broadcast to its regions. Returns true if a transition was taken.
+func (i *Interpreter[C]) sendToRegion(regionID ir.StateID, event Event) bool {
+	leafID, ok := i.state.ActiveInParallel[regionID]
+	if !ok {
+		return false
+	}
+	regionState := i.machine.GetState(leafID)
+	if regionState == nil {
+		return false
+	}
 
-		// Find matching transition in this region's hierarchy
-		transSource := i.findMatchingTransitionInRegion(regionState, regionID, event)
-		if transSource != nil {
-			// Execute transition within the region
-			i.executeTransitionInRegion(regionID, transSource, event)
+	// Find matching transition in this region's hierarchy
+	transSource := i.findMatchingTransitionInRegion(regionState, regionID, event)
+	if transSource == nil {
+		if regionState.IsParallel() {
+			return i.broadcastToRegions(regionState, event)
 		}
+		return false
+	}
+	if i.vetoed(transSource.transition, event) {
+		return false
+	}
+
+	// Execute transition within the region
+	i.executeTransitionInRegion(regionID, transSource, event)
+	if i.result != nil {
+		i.result.Regions = append(i.result.Regions, RegionTransition{
+			Region: regionID,
+			From:   leafID,
+			To:     i.state.ActiveInParallel[regionID],
+		})
 	}
+	return true
 }
 
 // findMatchingTransitionInRegion finds a transition bubbling up within a region
@@ -610,6 +2005,12 @@ func (i *Interpreter[C]) findMatchingTransitionInRegion(state *ir.StateConfig, r
 	for current != nil {
 		transition := i.findMatchingTransition(current, event)
 		if transition != nil {
+			// A bubbling transition defers to an ancestor within the region
+			if transition.Bubble && current.ID != regionID {
+				if source := i.findMatchingTransitionInRegion(i.machine.GetState(current.Parent), regionID, event); source != nil {
+					return source
+				}
+			}
 			return &transitionSource[C]{
 				state:      current,
 				transition: transition,
@@ -634,12 +2035,20 @@ func (i *Interpreter[C]) executeTransitionInRegion(regionID ir.StateID, source *
 	sourceStateID := source.state.ID
 	targetStateID := transition.Target
 
-	// Resolve target to leaf
-	resolvedTarget := i.resolveTarget(targetStateID)
-
 	// Get current leaf in this region
 	currentLeaf := i.state.ActiveInParallel[regionID]
 
+	// Internal self-transitions only run transition actions
+	if isInternalSelfTransition(source) {
+		i.recordTransition(currentLeaf, currentLeaf, event)
+		i.executeTransitionActions(transition, event)
+		return
+	}
+
+	// Resolve target to leaf
+	resolvedTarget := i.resolveTarget(targetStateID, event)
+	i.recordTransition(currentLeaf, resolvedTarget, event)
+
 	// Find LCA within the region
 	lca := i.machine.FindLCA(sourceStateID, resolvedTarget)
 
@@ -661,24 +2070,41 @@ func (i *Interpreter[C]) executeTransitionInRegion(regionID ir.StateID, source *
 		statesToEnter = i.getStatesToEnter(resolvedTarget, lca)
 	}
 
-	// Execute exit actions
+	// A nested parallel state exits its regions before itself
+	leafConfig := i.machine.GetState(currentLeaf)
+	if leafConfig != nil && leafConfig.IsParallel() {
+		i.exitRegions(leafConfig, event)
+	}
+
+	// Execute exit actions and record history within the region
 	for _, stateID := range statesToExit {
 		stateConfig := i.machine.GetState(stateID)
 		if stateConfig != nil {
-			i.cancelDelayedTransitions(stateID)
-			i.executeActions(stateConfig.Exit, event)
+			i.exitState(stateConfig, event)
+			i.recordHistory(stateConfig, currentLeaf)
 		}
 	}
 
 	// Execute transition actions
-	i.executeActions(transition.Actions, event)
+	i.executeTransitionActions(transition, event)
 
-	// Execute entry actions
+	// A transition into a nested parallel state that is not exited re-enters its regions
+	if leafConfig != nil && leafConfig.IsParallel() && !slices.Contains(statesToExit, currentLeaf) {
+		i.enterRegions(leafConfig, resolvedTarget, event)
+		return
+	}
+
+	// Execute entry actions, entering the regions of a nested parallel state
 	for _, stateID := range statesToEnter {
 		stateConfig := i.machine.GetState(stateID)
-		if stateConfig != nil {
-			i.executeActions(stateConfig.Entry, event)
-			i.scheduleDelayedTransitions(stateID)
+		if stateConfig == nil {
+			continue
+		}
+		i.enterState(stateConfig, event)
+		if stateConfig.IsParallel() {
+			i.state.ActiveInParallel[regionID] = stateID
+			i.enterRegions(stateConfig, resolvedTarget, event)
+			return
 		}
 	}
 
@@ -686,8 +2112,10 @@ func (i *Interpreter[C]) executeTransitionInRegion(regionID ir.StateID, source *
 	i.state.ActiveInParallel[regionID] = resolvedTarget
 }
 
-// enterParallelState enters a parallel state and all its regions
-func (i *Interpreter[C]) enterParallelState(parallelID ir.StateID, event Event) {
+// enterParallelState enters a parallel state and all its regions. If target is
+// a leaf inside one of the regions, that region is entered at target instead of
+// its initial state.
+func (i *Interpreter[C]) enterParallelState(parallelID, target ir.StateID, event Event) {
 	parallelState := i.machine.GetState(parallelID)
 	if parallelState == nil || !parallelState.IsParallel() {
 		return
@@ -698,39 +2126,41 @@ func (i *Interpreter[C]) enterParallelState(parallelID ir.StateID, event Event)
 	i.state.Value = parallelID
 
 	// Execute entry actions for parallel state
-	i.executeActions(parallelState.Entry, event)
-	i.scheduleDelayedTransitions(parallelID)
+	i.enterState(parallelState, event)
+	i.enterRegions(parallelState, target, event)
+}
 
-	// Enter each region (child of parallel state)
+// enterRegions enters each region of a parallel state at its initial leaf, or
+// at target for the region that contains it
+func (i *Interpreter[C]) enterRegions(parallelState *ir.StateConfig, target ir.StateID, event Event) {
 	for _, regionID := range parallelState.Children {
-		i.enterRegion(regionID, event)
+		leafID := i.initialLeaf(regionID, event)
+		if target != "" && i.machine.IsDescendantOf(target, regionID) {
+			leafID = target
+		}
+		i.enterRegion(regionID, leafID, event)
 	}
 }
 
-// enterRegion enters a single parallel region
-func (i *Interpreter[C]) enterRegion(regionID ir.StateID, event Event) {
-	regionState := i.machine.GetState(regionID)
-	if regionState == nil {
+// enterRegion enters a single parallel region down to the given leaf. A nested
+// parallel state on the way becomes the region's active state and its own
+// regions are entered.
+func (i *Interpreter[C]) enterRegion(regionID, leafID ir.StateID, event Event) {
+	if i.machine.GetState(regionID) == nil {
 		return
 	}
 
-	// Get the initial leaf for this region
-	var leafID ir.StateID
-	if regionState.IsCompound() {
-		leafID = i.machine.GetInitialLeaf(regionID)
-	} else {
-		leafID = regionID
-	}
-
-	// Get path from region to leaf
-	path := i.getEntryPath(regionID, leafID)
-
-	// Enter each state in the path
-	for _, stateID := range path {
+	// Enter each state in the path from region to leaf
+	for _, stateID := range i.getEntryPath(regionID, leafID) {
 		stateConfig := i.machine.GetState(stateID)
-		if stateConfig != nil {
-			i.executeActions(stateConfig.Entry, event)
-			i.scheduleDelayedTransitions(stateID)
+		if stateConfig == nil {
+			continue
+		}
+		i.enterState(stateConfig, event)
+		if stateConfig.IsParallel() && stateID != regionID {
+			i.state.ActiveInParallel[regionID] = stateID
+			i.enterRegions(stateConfig, leafID, event)
+			return
 		}
 	}
 
@@ -738,8 +2168,10 @@ func (i *Interpreter[C]) enterRegion(regionID ir.StateID, event Event) {
 	i.state.ActiveInParallel[regionID] = leafID
 }
 
-// exitParallelState exits a parallel state and all its regions
-func (i *Interpreter[C]) exitParallelState(event Event) {
+// exitParallelRegions exits all regions of the active parallel state and clears
+// parallel tracking. The parallel state itself stays the current state, so the
+// caller exits it along with its ancestors.
+func (i *Interpreter[C]) exitParallelRegions(event Event) {
 	if i.currentParallel == "" {
 		return
 	}
@@ -749,22 +2181,31 @@ func (i *Interpreter[C]) exitParallelState(event Event) {
 		return
 	}
 
-	// Exit each region
-	for regionID, leafID := range i.state.ActiveInParallel {
-		i.exitRegion(regionID, leafID, event)
-	}
-
-	// Execute exit actions for parallel state
-	i.cancelDelayedTransitions(i.currentParallel)
-	i.executeActions(parallelState.Exit, event)
+	i.exitRegions(parallelState, event)
 
 	// Clear parallel state tracking
 	i.currentParallel = ""
 	i.state.ActiveInParallel = make(map[ir.StateID]ir.StateID)
 }
 
-// exitRegion exits all states in a region from leaf up to region boundary
+// exitRegions exits each region of a parallel state in reverse declaration
+// order, mirroring entry, and stops tracking them
+func (i *Interpreter[C]) exitRegions(parallelState *ir.StateConfig, event Event) {
+	for _, regionID := range slices.Backward(parallelState.Children) {
+		if leafID, ok := i.state.ActiveInParallel[regionID]; ok {
+			i.exitRegion(regionID, leafID, event)
+			delete(i.state.ActiveInParallel, regionID)
+		}
+	}
+}
+
+// exitRegion exits all states in a region from leaf up to region boundary.
+// The regions of a nested parallel state are exited first.
 func (i *Interpreter[C]) exitRegion(regionID, leafID ir.StateID, event Event) {
+	if leafConfig := i.machine.GetState(leafID); leafConfig != nil && leafConfig.IsParallel() && leafID != regionID {
+		i.exitRegions(leafConfig, event)
+	}
+
 	// Get states to exit (leaf up to and including region)
 	statesToExit := i.getStatesToExit(leafID, "")
 
@@ -776,12 +2217,12 @@ func (i *Interpreter[C]) exitRegion(regionID, leafID ir.StateID, event Event) {
 		}
 	}
 
-	// Execute exit actions
+	// Execute exit actions and record history within the region
 	for _, stateID := range filtered {
 		stateConfig := i.machine.GetState(stateID)
 		if stateConfig != nil {
-			i.cancelDelayedTransitions(stateID)
-			i.executeActions(stateConfig.Exit, event)
+			i.exitState(stateConfig, event)
+			i.recordHistory(stateConfig, leafID)
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package statekit
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -19,6 +20,9 @@ func TestInterpreter_Start(t *testing.T) {
 	}
 
 	interp := NewInterpreter(machine)
+	if interp.Machine() != machine {
+		t.Error("expected Machine to return the interpreted machine")
+	}
 
 	// Before start, state should be empty
 	state := interp.State()
@@ -136,6 +140,64 @@ func TestInterpreter_Send_WithGuard(t *testing.T) {
 	}
 }
 
+// TestInterpreter_Send_FirstMatchWins tests that the first transition in declaration
+// order whose guard passes is taken when several share an event
+func TestInterpreter_Send_FirstMatchWins(t *testing.T) {
+	tests := []struct {
+		name     string
+		g1, g2   bool
+		expected StateID
+	}{
+		{name: "only second passes", g1: false, g2: true, expected: "b"},
+		{name: "both pass", g1: true, g2: true, expected: "a"},
+		{name: "only first passes", g1: true, g2: false, expected: "a"},
+		{name: "none pass", g1: false, g2: false, expected: "idle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var evaluated []GuardType
+			machine, err := NewMachine[counterContext]("test").
+				WithInitial("idle").
+				WithGuard("g1", func(ctx counterContext, e Event) bool {
+					evaluated = append(evaluated, "g1")
+					return tt.g1
+				}).
+				WithGuard("g2", func(ctx counterContext, e Event) bool {
+					evaluated = append(evaluated, "g2")
+					return tt.g2
+				}).
+				State("idle").
+				On("GO").Target("a").Guard("g1").
+				On("GO").Target("b").Guard("g2").
+				Done().
+				State("a").Done().
+				State("b").Done().
+				Build()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			interp := NewInterpreter(machine)
+			interp.Start()
+			interp.Send(Event{Type: "GO"})
+
+			if interp.State().Value != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, interp.State().Value)
+			}
+
+			// Guards after the first passing one are not evaluated
+			want := []GuardType{"g1", "g2"}
+			if tt.g1 {
+				want = want[:1]
+			}
+			if !slices.Equal(evaluated, want) {
+				t.Errorf("expected guards evaluated %v, got %v", want, evaluated)
+			}
+		})
+	}
+}
+
 func TestInterpreter_Send_WithActions(t *testing.T) {
 	var entryLog, exitLog, transitionLog []string
 
@@ -297,3 +359,65 @@ func TestInterpreter_MultipleTransitionsOnState(t *testing.T) {
 		t.Errorf("expected 'stateB', got %v", interp.State().Value)
 	}
 }
+
+func TestInterpreter_EntryActionEvent(t *testing.T) {
+	var received []EventType
+
+	machine, err := NewMachine[counterContext]("entry_event").
+		WithInitial("idle").
+		WithAction("record", func(ctx *counterContext, e Event) {
+			received = append(received, e.Type)
+			if e.Type == InitEvent {
+				ctx.Transitions = append(ctx.Transitions, "initial")
+			} else {
+				ctx.Transitions = append(ctx.Transitions, "reentry")
+			}
+		}).
+		State("idle").
+		OnEntry("record").
+		On("RESET").Target("idle").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "RESET"})
+
+	if len(received) != 2 || received[0] != InitEvent || received[1] != "RESET" {
+		t.Errorf("expected entry events [%s RESET], got %v", InitEvent, received)
+	}
+
+	transitions := interp.State().Context.Transitions
+	if len(transitions) != 2 || transitions[0] != "initial" || transitions[1] != "reentry" {
+		t.Errorf("expected [initial reentry], got %v", transitions)
+	}
+}
+
+func TestInterpreter_NestedEntryReceivesInitEvent(t *testing.T) {
+	var received []EventType
+
+	machine, err := NewMachine[counterContext]("nested_entry_event").
+		WithInitial("parent").
+		WithAction("record", func(ctx *counterContext, e Event) {
+			received = append(received, e.Type)
+		}).
+		State("parent").
+		WithInitial("child").
+		OnEntry("record").
+		State("child").OnEntry("record").End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	if len(received) != 2 || received[0] != InitEvent || received[1] != InitEvent {
+		t.Errorf("expected both entries to receive %s, got %v", InitEvent, received)
+	}
+}
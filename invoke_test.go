@@ -0,0 +1,262 @@
+package statekit
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/export"
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+type userContext struct {
+	UserID string
+	Name   string
+	Err    string
+}
+
+// waitForState waits until the interpreter reaches the expected state, failing
+// the test if it does not within a few seconds
+func waitForState[C any](t *testing.T, interp *Interpreter[C], expected StateID) {
+	t.Helper()
+	reached := make(chan struct{})
+	var once sync.Once
+	unsubscribe := interp.Subscribe(func(s State[C]) {
+		if s.Value == expected {
+			once.Do(func() { close(reached) })
+		}
+	})
+	defer unsubscribe()
+
+	if interp.State().Value == expected {
+		return
+	}
+	select {
+	case <-reached:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for state '%s', got '%s'", expected, interp.State().Value)
+	}
+}
+
+// processLate runs release in a queue step and waits for the result of the
+// service it releases to be queued, so that the result has been processed when
+// processLate returns. returned must be closed when the service returns.
+func processLate[C any](interp *Interpreter[C], release func(), returned <-chan struct{}) {
+	interp.process(func() bool {
+		release()
+		<-returned
+		for {
+			interp.queueMu.Lock()
+			queued := len(interp.queue)
+			interp.queueMu.Unlock()
+			if queued > 0 {
+				return false
+			}
+			runtime.Gosched()
+		}
+	})
+}
+
+// TestInvoke_Done tests that a successful service sends done.invoke.<name> with its result
+func TestInvoke_Done(t *testing.T) {
+	machine, err := NewMachine[userContext]("invoke_done").
+		WithInitial("loading").
+		WithContext(userContext{UserID: "42"}).
+		WithService("fetchUser", func(ctx context.Context, c userContext, e Event) (any, error) {
+			return "user-" + c.UserID, nil
+		}).
+		WithAction("storeUser", func(ctx *userContext, e Event) {
+			ctx.Name = e.Payload.(string)
+		}).
+		State("loading").
+		Invoke("fetchUser").
+		On("done.invoke.fetchUser").Target("ready").Do("storeUser").
+		On("error.invoke.fetchUser").Target("failed").
+		Done().
+		State("ready").Done().
+		State("failed").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	defer interp.Stop()
+
+	waitForState(t, interp, "ready")
+	if interp.State().Context.Name != "user-42" {
+		t.Errorf("Expected name 'user-42', got %q", interp.State().Context.Name)
+	}
+}
+
+// TestInvoke_Error tests that a failing service sends error.invoke.<name> with the error
+func TestInvoke_Error(t *testing.T) {
+	machine, err := NewMachine[userContext]("invoke_error").
+		WithInitial("loading").
+		WithService("fetchUser", func(ctx context.Context, c userContext, e Event) (any, error) {
+			return nil, errors.New("not found")
+		}).
+		WithAction("storeError", func(ctx *userContext, e Event) {
+			ctx.Err = e.Payload.(error).Error()
+		}).
+		State("loading").
+		Invoke("fetchUser").
+		On(DoneInvokeEvent("fetchUser")).Target("ready").
+		On(ErrorInvokeEvent("fetchUser")).Target("failed").Do("storeError").
+		Done().
+		State("ready").Done().
+		State("failed").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	defer interp.Stop()
+
+	waitForState(t, interp, "failed")
+	if interp.State().Context.Err != "not found" {
+		t.Errorf("Expected error 'not found', got %q", interp.State().Context.Err)
+	}
+}
+
+// TestInvoke_CancelOnExit tests that leaving the state cancels the service and drops its result
+func TestInvoke_CancelOnExit(t *testing.T) {
+	canceled := make(chan struct{})
+	release := make(chan struct{})
+	returned := make(chan struct{})
+
+	machine, err := NewMachine[userContext]("invoke_cancel").
+		WithInitial("loading").
+		WithService("slow", func(ctx context.Context, c userContext, e Event) (any, error) {
+			defer close(returned)
+			<-ctx.Done()
+			close(canceled)
+			<-release
+			return "late", nil
+		}).
+		State("loading").
+		Invoke("slow").
+		On("CANCEL").Target("idle").
+		On("done.invoke.slow").Target("ready").
+		Done().
+		State("idle").
+		On("done.invoke.slow").Target("ready").
+		Done().
+		State("ready").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	defer interp.Stop()
+
+	interp.Send(Event{Type: "CANCEL"})
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected service context to be canceled on exit")
+	}
+
+	processLate(interp, func() { close(release) }, returned)
+	if interp.State().Value != "idle" {
+		t.Errorf("Expected canceled result to be dropped, got state %s", interp.State().Value)
+	}
+}
+
+// TestInvoke_Stop tests that Stop cancels running services
+func TestInvoke_Stop(t *testing.T) {
+	done := make(chan struct{})
+	release := make(chan struct{})
+	returned := make(chan struct{})
+
+	machine, err := NewMachine[userContext]("invoke_stop").
+		WithInitial("loading").
+		WithService("slow", func(ctx context.Context, c userContext, e Event) (any, error) {
+			defer close(returned)
+			<-ctx.Done()
+			close(done)
+			<-release
+			return nil, ctx.Err()
+		}).
+		State("loading").
+		Invoke("slow").
+		On("error.invoke.slow").Target("failed").
+		Done().
+		State("failed").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to cancel the service")
+	}
+
+	processLate(interp, func() { close(release) }, returned)
+	if interp.State().Value != "loading" {
+		t.Errorf("Expected no transition after Stop, got %s", interp.State().Value)
+	}
+}
+
+// TestInvoke_Validation tests that invoking an unregistered service fails validation
+func TestInvoke_Validation(t *testing.T) {
+	_, err := NewMachine[userContext]("invoke_missing").
+		WithInitial("loading").
+		State("loading").
+		Invoke("missing").
+		Done().
+		Build()
+	if err == nil {
+		t.Fatal("Expected validation error for missing service")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %T", err)
+	}
+	if validationErr.Issues[0].Code != ir.ErrCodeMissingService {
+		t.Errorf("Expected MISSING_SERVICE, got %s", validationErr.Issues[0].Code)
+	}
+}
+
+// TestInvoke_XStateExport tests that invoked services export to the "invoke" key
+func TestInvoke_XStateExport(t *testing.T) {
+	machine, err := NewMachine[userContext]("invoke_export").
+		WithInitial("loading").
+		WithService("fetchUser", func(ctx context.Context, c userContext, e Event) (any, error) {
+			return nil, nil
+		}).
+		State("loading").
+		Invoke("fetchUser").
+		On("done.invoke.fetchUser").Target("ready").
+		Done().
+		State("ready").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	exported, err := export.NewXStateExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	loading := exported.States["loading"]
+	if len(loading.Invoke) != 1 || loading.Invoke[0].Src != "fetchUser" {
+		t.Errorf("Expected invoke of 'fetchUser', got %+v", loading.Invoke)
+	}
+}
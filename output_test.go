@@ -0,0 +1,271 @@
+package statekit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+type fulfillmentContext struct {
+	OrderID string
+	Items   int
+}
+
+type orderSummary struct {
+	OrderID  string
+	Items    int
+	Tracking string
+}
+
+// TestOutput_FinalState tests that the output is computed on entering the final state
+func TestOutput_FinalState(t *testing.T) {
+	machine, err := NewMachine[fulfillmentContext]("fulfillment").
+		WithInitial("packing").
+		WithContext(fulfillmentContext{OrderID: "A-100"}).
+		WithAction("addItem", func(ctx *fulfillmentContext, e Event) { ctx.Items++ }).
+		State("packing").
+		On("ADD").Target("packing").Internal().Do("addItem").
+		On("SHIP").Target("completed").
+		Done().
+		State("completed").
+		Final().
+		Output(func(ctx fulfillmentContext, e Event) any {
+			tracking, _ := e.Payload.(string)
+			return orderSummary{OrderID: ctx.OrderID, Items: ctx.Items, Tracking: tracking}
+		}).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "ADD"})
+	interp.Send(Event{Type: "ADD"})
+	if _, ok := interp.Output(); ok {
+		t.Fatal("Expected no output before reaching the final state")
+	}
+
+	interp.Send(Event{Type: "SHIP", Payload: "TRK-42"})
+	output, ok := interp.Output()
+	if !ok {
+		t.Fatal("Expected output after reaching 'completed'")
+	}
+	expected := orderSummary{OrderID: "A-100", Items: 2, Tracking: "TRK-42"}
+	if output != expected {
+		t.Errorf("Expected output %+v, got %+v", expected, output)
+	}
+
+	// Restarting clears the output
+	interp.Stop()
+	interp.Start()
+	if _, ok := interp.Output(); ok {
+		t.Error("Expected output to be cleared on restart")
+	}
+}
+
+// TestOutput_FinalStateWithoutOutput tests that final states without an output report none
+func TestOutput_FinalStateWithoutOutput(t *testing.T) {
+	machine, err := NewMachine[fulfillmentContext]("fulfillment").
+		WithInitial("packing").
+		State("packing").
+		On("SHIP").Target("completed").
+		On("CANCEL").Target("cancelled").
+		Done().
+		State("completed").Final().Output(func(ctx fulfillmentContext, e Event) any { return ctx.Items }).Done().
+		State("cancelled").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "CANCEL"})
+	if !interp.Done() {
+		t.Fatal("Expected machine to be done")
+	}
+	if output, ok := interp.Output(); ok {
+		t.Errorf("Expected no output, got %+v", output)
+	}
+}
+
+// TestOutput_Restore tests that the output survives a snapshot round trip and
+// is computed again for a snapshot without one
+func TestOutput_Restore(t *testing.T) {
+	machine, err := NewMachine[fulfillmentContext]("fulfillment").
+		WithInitial("packing").
+		WithContext(fulfillmentContext{OrderID: "A-100"}).
+		WithAction("addItem", func(ctx *fulfillmentContext, e Event) { ctx.Items++ }).
+		State("packing").
+		On("ADD").Target("packing").Internal().Do("addItem").
+		On("SHIP").Target("completed").
+		Done().
+		State("completed").
+		Final().
+		Output(func(ctx fulfillmentContext, e Event) any {
+			tracking, _ := e.Payload.(string)
+			return orderSummary{OrderID: ctx.OrderID, Items: ctx.Items, Tracking: tracking}
+		}).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "ADD"})
+	interp.Send(Event{Type: "SHIP", Payload: "TRK-42"})
+
+	snapshot := interp.Snapshot()
+	restored := NewInterpreter(machine)
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	expected := orderSummary{OrderID: "A-100", Items: 1, Tracking: "TRK-42"}
+	if output, ok := restored.Output(); !restored.Done() || !ok || output != expected {
+		t.Errorf("Expected the restored machine to be done with output %+v, got %+v %v", expected, output, ok)
+	}
+
+	// Without a stored output, it is computed from the context and an empty event
+	snapshot.Output = nil
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	expected.Tracking = ""
+	if output, ok := restored.Output(); !ok || output != expected {
+		t.Errorf("Expected the recomputed output %+v, got %+v %v", expected, output, ok)
+	}
+}
+
+// TestTypedOutput tests that the output is returned as its concrete type
+func TestTypedOutput(t *testing.T) {
+	machine, err := NewMachine[fulfillmentContext]("fulfillment").
+		WithInitial("packing").
+		WithContext(fulfillmentContext{OrderID: "A-100"}).
+		WithAction("addItem", func(ctx *fulfillmentContext, e Event) { ctx.Items++ }).
+		State("packing").
+		On("ADD").Target("packing").Internal().Do("addItem").
+		On("SHIP").Target("completed").
+		Done().
+		State("completed").
+		Final().
+		Output(func(ctx fulfillmentContext, e Event) any {
+			tracking, _ := e.Payload.(string)
+			return orderSummary{OrderID: ctx.OrderID, Items: ctx.Items, Tracking: tracking}
+		}).
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "ADD"})
+
+	if summary, ok := TypedOutput[orderSummary](interp); ok {
+		t.Fatalf("Expected no output before reaching the final state, got %+v", summary)
+	}
+
+	interp.Send(Event{Type: "SHIP", Payload: "TRK-7"})
+	summary, ok := TypedOutput[orderSummary](interp)
+	if !ok {
+		t.Fatal("Expected a typed output after reaching 'completed'")
+	}
+	if summary.OrderID != "A-100" || summary.Items != 1 || summary.Tracking != "TRK-7" {
+		t.Errorf("Unexpected output %+v", summary)
+	}
+
+	// An output of another type is reported as missing
+	if _, ok := TypedOutput[string](interp); ok {
+		t.Error("Expected false for an output that is not a string")
+	}
+}
+
+// TestOutput_Validation tests that outputs are only allowed on top-level final states
+func TestOutput_Validation(t *testing.T) {
+	summary := func(ctx fulfillmentContext, e Event) any { return ctx.Items }
+
+	tests := []struct {
+		name    string
+		builder *MachineBuilder[fulfillmentContext]
+	}{
+		{
+			name: "non-final state",
+			builder: NewMachine[fulfillmentContext]("m").
+				WithInitial("packing").
+				State("packing").Output(summary).On("SHIP").Target("done").Done().
+				State("done").Final().Done(),
+		},
+		{
+			name: "nested final state",
+			builder: NewMachine[fulfillmentContext]("m").
+				WithInitial("order").
+				State("order").
+				WithInitial("packing").
+				State("packing").On("SHIP").Target("shipped").End().End().
+				State("shipped").Final().Output(summary).End().
+				Done(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			var validationErr *ir.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected ValidationError, got %v", err)
+			}
+			if !containsIssueCode(validationErr, ir.ErrCodeInvalidOutput) {
+				t.Errorf("Expected %s issue, got %v", ir.ErrCodeInvalidOutput, validationErr)
+			}
+		})
+	}
+}
+
+// TestOutput_Serialization tests that outputs are linked again from the registry
+func TestOutput_Serialization(t *testing.T) {
+	original, err := NewMachine[fulfillmentContext]("fulfillment").
+		WithInitial("packing").
+		WithAction("addItem", func(ctx *fulfillmentContext, e Event) { ctx.Items++ }).
+		State("packing").
+		On("ADD").Target("packing").Internal().Do("addItem").
+		On("SHIP").Target("completed").
+		Done().
+		State("completed").Final().Output(func(ctx fulfillmentContext, e Event) any { return ctx.Items }).Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	data, err := MarshalConfig(original)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	registry := NewActionRegistry[fulfillmentContext]().
+		WithAction("addItem", func(ctx *fulfillmentContext, e Event) { ctx.Items++ })
+	if _, err := UnmarshalConfig(data, registry); err == nil {
+		t.Fatal("Expected error for missing output")
+	}
+
+	registry.WithOutput("completed", func(ctx fulfillmentContext, e Event) any { return ctx.Items })
+	machine, err := UnmarshalConfig(data, registry)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "ADD"})
+	interp.Send(Event{Type: "SHIP"})
+	if output, _ := interp.Output(); output != 1 {
+		t.Errorf("Expected output 1, got %v", output)
+	}
+}
@@ -0,0 +1,154 @@
+package statekit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrActionPanic is wrapped by the error reported for an action that panicked
+// while panics are recovered (see WithPanicHandler)
+var ErrActionPanic = errors.New("action panicked")
+
+// WithPanicHandler recovers panics in actions and calls fn with the recovered
+// value and the action's name; the interpreter then continues with the next
+// action. Panics in guards, listeners, and services are not recovered.
+func WithPanicHandler(fn func(recovered any, actionName ActionType)) InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.recoverPanics = true
+		o.panicHandler = fn
+	}
+}
+
+// WithAbortOnPanic recovers panics in actions like WithPanicHandler, but
+// aborts the transition and returns the interpreter to its state from before
+// the event, discarding the events the transition raised.
+func WithAbortOnPanic() InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.recoverPanics = true
+		o.abortOnPanic = true
+	}
+}
+
+// abortTransition is the panic value used to unwind a transition aborted by a
+// panicking action up to the queue step that started it
+type abortTransition struct{}
+
+// checkpoint is the interpreter state restored when a transition is aborted
+type checkpoint[C any] struct {
+	snapshot        Snapshot[C]
+	currentParallel StateID
+	started         bool
+	output          any
+	hasOutput       bool
+	deferred        []Event
+}
+
+// runAction runs an action, recovering a panic if panics are recovered. It
+// returns an error wrapping ErrActionPanic if the action panicked, after
+// calling the panic handler (caller must hold mu).
+func (i *Interpreter[C]) runAction(name ActionType, run func()) (err error) {
+	if !i.opts.recoverPanics {
+		run()
+		return nil
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: action %q: %v", ErrActionPanic, name, recovered)
+			if i.opts.panicHandler != nil {
+				i.opts.panicHandler(recovered, name)
+			}
+		}
+	}()
+	run()
+	return nil
+}
+
+// abortOnPanic aborts the transition being processed if err reports a panic
+// and WithAbortOnPanic is set, recording err in the result being built by
+// SendResult, if any (caller must hold mu)
+func (i *Interpreter[C]) abortOnPanic(err error) {
+	if err == nil || !i.opts.abortOnPanic {
+		return
+	}
+	if i.result != nil && i.result.Err == nil {
+		i.result.Err = err
+	}
+	panic(abortTransition{})
+}
+
+// runStep runs a queue step. With WithAbortOnPanic, a step aborted by a
+// panicking action is rolled back and reports no change (caller must hold mu).
+func (i *Interpreter[C]) runStep(step queuedStep) (changed bool) {
+	if !i.opts.abortOnPanic {
+		return step.run()
+	}
+
+	before := checkpoint[C]{
+		snapshot:        i.snapshotUnlocked(),
+		currentParallel: i.currentParallel,
+		started:         i.started,
+		output:          i.output,
+		hasOutput:       i.hasOutput,
+		deferred:        append([]Event(nil), i.deferred...),
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if _, ok := recovered.(abortTransition); !ok {
+				panic(recovered)
+			}
+			i.rollbackUnlocked(before, step.seq)
+			changed = false
+		}
+	}()
+	return step.run()
+}
+
+// rollbackUnlocked returns the interpreter to a checkpoint, discards the steps
+// raised by the aborted step seq, and completes the result being built by
+// SendResult, if any (caller must hold mu)
+func (i *Interpreter[C]) rollbackUnlocked(before checkpoint[C], seq uint64) {
+	if stateConfig := i.machine.GetState(before.snapshot.Value); before.started && stateConfig != nil {
+		i.restoreUnlocked(before.snapshot, stateConfig, 0)
+	} else {
+		i.cancelAllTimers()
+		i.cancelAllInvocations()
+		i.state = State[C]{
+			Value:            before.snapshot.Value,
+			Context:          before.snapshot.Context,
+			ActiveInParallel: before.snapshot.ActiveInParallel,
+		}
+		if i.state.ActiveInParallel == nil {
+			i.state.ActiveInParallel = make(map[StateID]StateID)
+		}
+		i.shallowHistory = before.snapshot.ShallowHistory
+		if i.shallowHistory == nil {
+			i.shallowHistory = make(map[StateID]StateID)
+		}
+		i.deepHistory = before.snapshot.DeepHistory
+		if i.deepHistory == nil {
+			i.deepHistory = make(map[StateID]StateID)
+		}
+		i.currentParallel = before.currentParallel
+		i.started = false
+	}
+	i.output, i.hasOutput = before.output, before.hasOutput
+	i.deferred = before.deferred
+
+	// Drop the events and routes raised by the aborted step; events sent in
+	// the meantime still run
+	i.queueMu.Lock()
+	i.queue = slices.DeleteFunc(i.queue, func(step queuedStep) bool {
+		return step.parent == seq+1
+	})
+	i.queueMu.Unlock()
+
+	if i.result != nil {
+		i.result.Handled = false
+		i.result.Deferred = false
+		i.result.Regions = nil
+		i.result.To = i.state.Value
+		i.result = nil
+	}
+}
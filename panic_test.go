@@ -0,0 +1,236 @@
+package statekit
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+type panicContext struct {
+	Log []string
+}
+
+// TestPanicHandler_EntryAction tests that a panicking entry action is recovered,
+// reported with its name, and the transition continues
+func TestPanicHandler_EntryAction(t *testing.T) {
+	// The entry action of 'running' panics before a second entry action runs
+	machine, err := NewMachine[panicContext]("panic").
+		WithInitial("idle").
+		WithAction("crash", func(ctx *panicContext, e Event) {
+			ctx.Log = append(ctx.Log, "crash")
+			panic("boom")
+		}).
+		WithAction("log", func(ctx *panicContext, e Event) {
+			ctx.Log = append(ctx.Log, "log")
+		}).
+		State("idle").On("START").Target("running").Do("log").Done().
+		State("running").OnEntry("crash").OnEntry("log").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	var recovered []any
+	var names []ActionType
+	interp := NewInterpreter(machine, WithPanicHandler(func(r any, name ActionType) {
+		recovered = append(recovered, r)
+		names = append(names, name)
+	}))
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "START"})
+
+	if !slices.Equal(names, []ActionType{"crash"}) || recovered[0] != "boom" {
+		t.Fatalf("Expected the handler to receive 'boom' from 'crash', got %v %v", recovered, names)
+	}
+	if !result.Handled || !interp.Matches("running") {
+		t.Errorf("Expected the transition to complete, got %+v", result)
+	}
+	expected := []string{"log", "crash", "log"}
+	if log := interp.State().Context.Log; !slices.Equal(log, expected) {
+		t.Errorf("Expected log %v, got %v", expected, log)
+	}
+}
+
+// TestPanicHandler_FallibleAction tests that a panicking fallible action vetoes its transition
+func TestPanicHandler_FallibleAction(t *testing.T) {
+	machine, err := NewMachine[panicContext]("panic_fallible").
+		WithInitial("idle").
+		WithFallibleAction("check", func(ctx *panicContext, e Event) error {
+			panic("check failed")
+		}).
+		State("idle").On("CHECK").Target("running").DoFallible("check").Done().
+		State("running").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	var names []ActionType
+	interp := NewInterpreter(machine, WithPanicHandler(func(r any, name ActionType) {
+		names = append(names, name)
+	}))
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "CHECK"})
+
+	if result.Handled || !errors.Is(result.Err, ErrActionPanic) {
+		t.Errorf("Expected a veto wrapping ErrActionPanic, got %+v", result)
+	}
+	if !interp.Matches("idle") || !slices.Equal(names, []ActionType{"check"}) {
+		t.Errorf("Expected to stay in 'idle' after 'check' panicked, got %s %v", interp.State().Value, names)
+	}
+}
+
+// TestAbortOnPanic tests that a panicking action aborts the transition and
+// restores the state and context from before the event
+func TestAbortOnPanic(t *testing.T) {
+	machine, err := NewMachine[panicContext]("panic").
+		WithInitial("idle").
+		WithAction("crash", func(ctx *panicContext, e Event) {
+			ctx.Log = append(ctx.Log, "crash")
+			panic("boom")
+		}).
+		WithAction("log", func(ctx *panicContext, e Event) {
+			ctx.Log = append(ctx.Log, "log")
+		}).
+		WithFallibleAction("check", func(ctx *panicContext, e Event) error {
+			panic("check failed")
+		}).
+		State("idle").
+		On("START").Target("running").Do("log").
+		On("CHECK").Target("running").DoFallible("check").
+		Done().
+		State("running").OnEntry("crash").OnEntry("log").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	var names []ActionType
+	interp := NewInterpreter(machine,
+		WithAbortOnPanic(),
+		WithPanicHandler(func(r any, name ActionType) { names = append(names, name) }),
+		WithContextCloner(DeepCopy[panicContext]),
+	)
+	interp.Start()
+
+	var notified int
+	interp.Subscribe(func(State[panicContext]) { notified++ })
+	result := interp.SendResult(Event{Type: "START"})
+
+	if result.Handled || result.To != "idle" || !errors.Is(result.Err, ErrActionPanic) {
+		t.Errorf("Expected an aborted transition wrapping ErrActionPanic, got %+v", result)
+	}
+	if !slices.Equal(result.Actions, []ActionType{"log", "crash"}) {
+		t.Errorf("Expected the actions run before the panic, got %v", result.Actions)
+	}
+	if !interp.Matches("idle") || len(interp.State().Context.Log) != 0 {
+		t.Errorf("Expected 'idle' with the context from before the event, got %+v", interp.State())
+	}
+	if notified != 0 || !slices.Equal(names, []ActionType{"crash"}) {
+		t.Errorf("Expected no notification and the handler called once, got %d %v", notified, names)
+	}
+
+	// The interpreter keeps processing events; fallible actions still only veto
+	result = interp.SendResult(Event{Type: "CHECK"})
+	if result.Handled || !errors.Is(result.Err, ErrActionPanic) || !interp.Matches("idle") {
+		t.Errorf("Expected CHECK to be vetoed after the abort, got %+v", result)
+	}
+}
+
+// TestAbortOnPanic_RaisedEvents tests that events raised by actions of an
+// aborted transition are discarded with it
+func TestAbortOnPanic_RaisedEvents(t *testing.T) {
+	machine, err := NewMachine[panicContext]("panic_queue").
+		WithInitial("idle").
+		WithSchedulerAction("raiseNext", func(ctx *panicContext, e Event, sched Scheduler) {
+			sched.Raise(Event{Type: "NEXT"})
+		}).
+		WithAction("crash", func(ctx *panicContext, e Event) {
+			panic("boom")
+		}).
+		State("idle").
+		On("GO").Target("busy").Do("raiseNext").Do("crash").
+		On("NEXT").Target("skipped").
+		Done().
+		State("busy").Done().
+		State("skipped").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithAbortOnPanic())
+	interp.Start()
+	result := interp.SendResult(Event{Type: "GO"})
+
+	if !errors.Is(result.Err, ErrActionPanic) {
+		t.Errorf("Expected an aborted transition, got %+v", result)
+	}
+	if !interp.Matches("idle") {
+		t.Errorf("Expected NEXT to be discarded with the aborted transition, got %s", interp.State().Value)
+	}
+	if stats := interp.Stats(); stats.EventsReceived != 1 {
+		t.Errorf("Expected only GO to be received, got %d events", stats.EventsReceived)
+	}
+}
+
+// TestAbortOnPanic_Start tests that a panicking initial entry action leaves the interpreter stopped
+func TestAbortOnPanic_Start(t *testing.T) {
+	machine, err := NewMachine[panicContext]("panic_start").
+		WithInitial("running").
+		WithAction("crash", func(ctx *panicContext, e Event) { panic("boom") }).
+		State("running").OnEntry("crash").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithAbortOnPanic())
+	interp.Start()
+
+	if interp.IsStarted() || interp.State().Value != "" {
+		t.Errorf("Expected the aborted start to leave the interpreter stopped, got %+v", interp.State())
+	}
+}
+
+// TestAbortOnPanic_ConcurrentSend tests that an event sent from another
+// goroutine while a transition is aborted is still processed
+func TestAbortOnPanic_ConcurrentSend(t *testing.T) {
+	sending := make(chan struct{})
+	sent := make(chan struct{})
+	machine, err := NewMachine[panicContext]("panic_concurrent").
+		WithInitial("idle").
+		WithAction("crash", func(ctx *panicContext, e Event) {
+			close(sending)
+			<-sent
+			panic("boom")
+		}).
+		State("idle").
+		On("GO").Target("busy").Do("crash").
+		On("PING").Target("pinged").
+		Done().
+		State("busy").Done().
+		State("pinged").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithAbortOnPanic())
+	interp.Start()
+	go func() {
+		<-sending
+		interp.Send(Event{Type: "PING"})
+		close(sent)
+	}()
+	result := interp.SendResult(Event{Type: "GO"})
+
+	if !errors.Is(result.Err, ErrActionPanic) {
+		t.Errorf("Expected an aborted transition, got %+v", result)
+	}
+	if !interp.Matches("pinged") {
+		t.Errorf("Expected PING from another goroutine to be processed, got %s", interp.State().Value)
+	}
+}
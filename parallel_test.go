@@ -2,6 +2,8 @@ package statekit
 
 import (
 	"encoding/json"
+	"maps"
+	"slices"
 	"testing"
 
 	"github.com/felixgeelhaar/statekit/export"
@@ -96,6 +98,107 @@ func TestParallelState_Matches(t *testing.T) {
 	interp.Stop()
 }
 
+// TestParallelState_MatchesAnyAll tests matching several region states at once
+func TestParallelState_MatchesAnyAll(t *testing.T) {
+	machine, err := NewMachine[struct{}]("parallel_matches_any_all").
+		WithInitial("transfer").
+		State("transfer").Parallel().
+		Region("upload").
+		WithInitial("uploading").
+		State("uploading").On("UPLOADED").Target("uploaded").EndState().
+		State("uploaded").EndState().
+		EndRegion().
+		Region("download").
+		WithInitial("downloading").
+		State("downloading").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	if !interp.MatchesAll("uploading", "downloading", "transfer") {
+		t.Error("Expected to match 'uploading' and 'downloading'")
+	}
+	if !interp.MatchesAny("uploaded", "downloading") {
+		t.Error("Expected to match 'uploaded' or 'downloading'")
+	}
+
+	interp.Send(Event{Type: "UPLOADED"})
+	if interp.MatchesAll("uploading", "downloading") {
+		t.Error("Should not match 'uploading' and 'downloading' after UPLOADED")
+	}
+	if !interp.MatchesAll("uploaded", "downloading") {
+		t.Error("Expected to match 'uploaded' and 'downloading'")
+	}
+	if interp.MatchesAny("uploading", "missing") {
+		t.Error("Should not match 'uploading' or 'missing'")
+	}
+
+	// Dotted paths match through regions
+	if !interp.MatchesPath("transfer.upload.uploaded") || !interp.MatchesPath("download.downloading") {
+		t.Error("Expected to match the dotted paths of both regions")
+	}
+	if interp.MatchesPath("transfer.upload.downloading") {
+		t.Error("Should not match a state through the wrong region")
+	}
+
+	// Vacuous cases
+	if interp.MatchesAny() || !interp.MatchesAll() {
+		t.Error("Expected MatchesAny() to be false and MatchesAll() to be true")
+	}
+}
+
+// TestParallelState_ActiveStates tests that ActiveStates() includes every region leaf and its ancestors
+func TestParallelState_ActiveStates(t *testing.T) {
+	machine, err := NewMachine[struct{}]("parallel_active").
+		WithInitial("app").
+		State("app").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("editor").
+		WithInitial("editing").
+		State("editing").
+		WithInitial("typing").
+		State("typing").End().
+		EndState().
+		EndRegion().
+		Region("sync").
+		WithInitial("synced").
+		State("synced").
+		On("CHANGE").Target("syncing").
+		EndState().
+		State("syncing").EndState().
+		EndRegion().
+		End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	expected := []StateID{"active", "app", "editing", "editor", "sync", "synced", "typing"}
+	if got := interp.ActiveStates(); !slices.Equal(got, expected) {
+		t.Errorf("Expected active states %v, got %v", expected, got)
+	}
+
+	interp.Send(Event{Type: "CHANGE"})
+
+	expected = []StateID{"active", "app", "editing", "editor", "sync", "syncing", "typing"}
+	if got := interp.ActiveStates(); !slices.Equal(got, expected) {
+		t.Errorf("Expected active states %v after CHANGE, got %v", expected, got)
+	}
+
+	interp.Stop()
+}
+
 // TestParallelState_EventBroadcast tests event broadcasting to regions
 func TestParallelState_EventBroadcast(t *testing.T) {
 	type Context struct {
@@ -263,7 +366,7 @@ func TestParallelState_ExitOnParentTransition(t *testing.T) {
 	interp.Start()
 
 	// Entry actions: parallel + r1_working + r2_working = 3
-	// (regions are compound states but only have their children's entry actions)
+	// (the regions have no entry actions of their own)
 	if interp.State().Context.EntryCount != 3 {
 		t.Errorf("Expected EntryCount 3, got %d", interp.State().Context.EntryCount)
 	}
@@ -276,11 +379,10 @@ func TestParallelState_ExitOnParentTransition(t *testing.T) {
 		t.Errorf("Expected state 'cancelled', got %s", interp.State().Value)
 	}
 
-	// Exit actions: r1_working + region1 + r2_working + region2 = 4, then parallel exit is not in the filtered list
-	// The exitRegion filters to states within the region, which includes region1/region2 themselves
-	// Total: r1_working + r2_working + parallel = 4 (regions don't have exit actions)
-	if interp.State().Context.ExitCount != 4 {
-		t.Errorf("Expected ExitCount 4, got %d", interp.State().Context.ExitCount)
+	// Exit actions: r2_working + r1_working + parallel = 3, each exactly once
+	// (the regions have no exit actions of their own)
+	if interp.State().Context.ExitCount != 3 {
+		t.Errorf("Expected ExitCount 3, got %d", interp.State().Context.ExitCount)
 	}
 
 	// Parallel tracking should be cleared
@@ -291,6 +393,93 @@ func TestParallelState_ExitOnParentTransition(t *testing.T) {
 	interp.Stop()
 }
 
+type cancelContext struct {
+	Confirmed bool
+	Log       []string
+}
+
+// TestParallelState_TransitionPrecedence tests that a transition on the
+// parallel state takes precedence over region transitions for the same event
+func TestParallelState_TransitionPrecedence(t *testing.T) {
+	// The parallel state and its upload region both handle CANCEL
+	machine, err := NewMachine[cancelContext]("parallel_cancel").
+		WithInitial("active").
+		WithContext(cancelContext{Confirmed: true}).
+		WithGuard("confirmed", func(ctx cancelContext, e Event) bool { return ctx.Confirmed }).
+		WithAction("cancelAll", func(ctx *cancelContext, e Event) { ctx.Log = append(ctx.Log, "cancelAll") }).
+		WithAction("cancelUpload", func(ctx *cancelContext, e Event) { ctx.Log = append(ctx.Log, "cancelUpload") }).
+		State("active").Parallel().
+		On("CANCEL").Target("cancelled").Guard("confirmed").Do("cancelAll").End().
+		Region("upload").
+		WithInitial("uploading").
+		State("uploading").On("CANCEL").Target("upload_cancelled").Do("cancelUpload").EndState().
+		State("upload_cancelled").EndState().
+		EndRegion().
+		Region("download").
+		WithInitial("downloading").
+		State("downloading").EndState().
+		EndRegion().
+		Done().
+		State("cancelled").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "CANCEL"})
+
+	if !result.Handled || result.To != "cancelled" || len(result.Regions) != 0 {
+		t.Errorf("Expected the parallel state to exit to 'cancelled', got %+v", result)
+	}
+	if log := interp.State().Context.Log; !slices.Equal(log, []string{"cancelAll"}) {
+		t.Errorf("Expected only the parallel state's action to run, got %v", log)
+	}
+}
+
+// TestParallelState_TransitionPrecedenceGuarded tests that the regions handle
+// the event when the parallel state's transition is not enabled
+func TestParallelState_TransitionPrecedenceGuarded(t *testing.T) {
+	// The parallel state and its upload region both handle CANCEL
+	machine, err := NewMachine[cancelContext]("parallel_cancel").
+		WithInitial("active").
+		WithGuard("confirmed", func(ctx cancelContext, e Event) bool { return ctx.Confirmed }).
+		WithAction("cancelAll", func(ctx *cancelContext, e Event) { ctx.Log = append(ctx.Log, "cancelAll") }).
+		WithAction("cancelUpload", func(ctx *cancelContext, e Event) { ctx.Log = append(ctx.Log, "cancelUpload") }).
+		State("active").Parallel().
+		On("CANCEL").Target("cancelled").Guard("confirmed").Do("cancelAll").End().
+		Region("upload").
+		WithInitial("uploading").
+		State("uploading").On("CANCEL").Target("upload_cancelled").Do("cancelUpload").EndState().
+		State("upload_cancelled").EndState().
+		EndRegion().
+		Region("download").
+		WithInitial("downloading").
+		State("downloading").EndState().
+		EndRegion().
+		Done().
+		State("cancelled").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "CANCEL"})
+
+	state := interp.State()
+	if state.Value != "active" || state.ActiveInParallel["upload"] != "upload_cancelled" {
+		t.Errorf("Expected the upload region to handle CANCEL, got %+v", state)
+	}
+	if !slices.Equal(state.Context.Log, []string{"cancelUpload"}) {
+		t.Errorf("Expected only the region's action to run, got %v", state.Context.Log)
+	}
+}
+
 // TestParallelState_EntryOrder tests entry action ordering
 func TestParallelState_EntryOrder(t *testing.T) {
 	type Context struct {
@@ -341,6 +530,117 @@ func TestParallelState_EntryOrder(t *testing.T) {
 	interp.Stop()
 }
 
+// TestParallelState_ExitOrder tests that regions exit in reverse declaration order on every run
+func TestParallelState_ExitOrder(t *testing.T) {
+	type Context struct {
+		Order []string
+	}
+
+	builder := NewMachine[Context]("parallel_exit_order").WithInitial("active")
+	for _, name := range []string{"active", "a_idle", "b_idle", "c_idle"} {
+		builder.WithAction(ActionType("exit_"+name), func(ctx *Context, e Event) {
+			ctx.Order = append(ctx.Order, name)
+		})
+	}
+
+	machine, err := builder.
+		State("active").Parallel().
+		OnExit("exit_active").
+		On("CANCEL").Target("cancelled").End().
+		Region("region_a").
+		WithInitial("a_idle").
+		State("a_idle").OnExit("exit_a_idle").EndState().
+		EndRegion().
+		Region("region_b").
+		WithInitial("b_idle").
+		State("b_idle").OnExit("exit_b_idle").EndState().
+		EndRegion().
+		Region("region_c").
+		WithInitial("c_idle").
+		State("c_idle").OnExit("exit_c_idle").EndState().
+		EndRegion().
+		Done().
+		State("cancelled").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	expected := []string{"c_idle", "b_idle", "a_idle", "active"}
+	for run := 0; run < 20; run++ {
+		interp := NewInterpreter(machine)
+		interp.Start()
+		interp.Send(Event{Type: "CANCEL"})
+
+		if got := interp.State().Context.Order; !slices.Equal(got, expected) {
+			t.Fatalf("Run %d: expected exit order %v, got %v", run, expected, got)
+		}
+	}
+}
+
+// TestParallelState_RegionEntryExit tests that region entry and exit actions run at the region boundary
+func TestParallelState_RegionEntryExit(t *testing.T) {
+	type Context struct {
+		Order []string
+	}
+
+	builder := NewMachine[Context]("parallel_region_actions").WithInitial("active")
+	for _, name := range []string{"enter_region1", "exit_region1", "enter_r1_idle", "exit_r1_idle", "enter_region2", "exit_region2", "enter_r2_idle", "exit_r2_idle"} {
+		builder.WithAction(ActionType(name), func(ctx *Context, e Event) {
+			ctx.Order = append(ctx.Order, name)
+		})
+	}
+
+	machine, err := builder.
+		State("active").Parallel().
+		On("CANCEL").Target("cancelled").End().
+		Region("region1").
+		OnEntry("enter_region1").
+		OnExit("exit_region1").
+		WithInitial("r1_idle").
+		State("r1_idle").
+		OnEntry("enter_r1_idle").
+		OnExit("exit_r1_idle").
+		On("NEXT").Target("r1_busy").
+		EndState().
+		State("r1_busy").EndState().
+		EndRegion().
+		Region("region2").
+		OnEntry("enter_region2").
+		OnExit("exit_region2").
+		WithInitial("r2_idle").
+		State("r2_idle").OnEntry("enter_r2_idle").OnExit("exit_r2_idle").EndState().
+		EndRegion().
+		Done().
+		State("cancelled").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	expected := []string{"enter_region1", "enter_r1_idle", "enter_region2", "enter_r2_idle"}
+	if got := interp.State().Context.Order; !slices.Equal(got, expected) {
+		t.Fatalf("Expected entry order %v, got %v", expected, got)
+	}
+
+	// Transitions within a region do not exit or re-enter the region
+	interp.UpdateContext(func(ctx *Context) { ctx.Order = nil })
+	interp.Send(Event{Type: "NEXT"})
+	if got := interp.State().Context.Order; !slices.Equal(got, []string{"exit_r1_idle"}) {
+		t.Errorf("Expected only the child to exit, got %v", got)
+	}
+
+	interp.UpdateContext(func(ctx *Context) { ctx.Order = nil })
+	interp.Send(Event{Type: "CANCEL"})
+	expected = []string{"exit_r2_idle", "exit_region2", "exit_region1"}
+	if got := interp.State().Context.Order; !slices.Equal(got, expected) {
+		t.Errorf("Expected exit order %v, got %v", expected, got)
+	}
+}
+
 // TestParallelState_XStateExport tests XState JSON export of parallel states
 func TestParallelState_XStateExport(t *testing.T) {
 	machine, err := NewMachine[struct{}]("export_parallel").
@@ -533,3 +833,221 @@ func TestParallelState_SimpleWithTransitions(t *testing.T) {
 
 	interp.Stop()
 }
+
+// TestParallelState_InStateGuard tests a region transition guarded by another region's state
+func TestParallelState_InStateGuard(t *testing.T) {
+	machine, err := NewMachine[struct{}]("coordinated").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("region1").
+		WithInitial("r1_waiting").
+		State("r1_waiting").
+		On("ADVANCE").Target("r1_done").Guard(In("r2_ready")).
+		EndState().
+		State("r1_done").EndState().
+		EndRegion().
+		Region("region2").
+		WithInitial("r2_loading").
+		State("r2_loading").
+		On("LOADED").Target("r2_ready").
+		EndState().
+		State("r2_ready").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	// region2 is not ready, so region1 cannot advance
+	if interp.Can("ADVANCE") {
+		t.Error("Expected ADVANCE to be blocked while region2 is loading")
+	}
+	interp.Send(Event{Type: "ADVANCE"})
+	if interp.State().ActiveInParallel["region1"] != "r1_waiting" {
+		t.Errorf("Expected region1 'r1_waiting', got %s", interp.State().ActiveInParallel["region1"])
+	}
+
+	interp.Send(Event{Type: "LOADED"})
+	interp.Send(Event{Type: "ADVANCE"})
+	if interp.State().ActiveInParallel["region1"] != "r1_done" {
+		t.Errorf("Expected region1 'r1_done' once region2 is ready, got %s", interp.State().ActiveInParallel["region1"])
+	}
+
+	interp.Stop()
+}
+
+// TestParallelState_InStateGuardValidation tests that In must reference an existing state
+func TestParallelState_InStateGuardValidation(t *testing.T) {
+	_, err := NewMachine[struct{}]("invalid_in").
+		WithInitial("idle").
+		State("idle").
+		On("GO").Target("running").Guard(In("missing")).
+		Done().
+		State("running").Done().
+		Build()
+	if err == nil {
+		t.Fatal("Expected validation error for unknown in-state guard target")
+	}
+}
+
+type nestedParallelContext struct {
+	Log []string
+}
+
+// TestParallelState_Nested tests entry, broadcast, and exit of a parallel state inside a region
+func TestParallelState_Nested(t *testing.T) {
+	logEntry := func(id string) func(ctx *nestedParallelContext, e Event) {
+		return func(ctx *nestedParallelContext, e Event) { ctx.Log = append(ctx.Log, "enter:"+id) }
+	}
+	logExit := func(id string) func(ctx *nestedParallelContext, e Event) {
+		return func(ctx *nestedParallelContext, e Event) { ctx.Log = append(ctx.Log, "exit:"+id) }
+	}
+
+	// The 'editor' region contains the parallel state 'open'
+	machine, err := NewMachine[nestedParallelContext]("nested_parallel").
+		WithInitial("app").
+		State("app").Parallel().
+		On("QUIT").Target("quit").End().
+		Region("editor").
+		WithInitial("closed").
+		State("closed").On("OPEN").Target("open").EndState().
+		State("open").Parallel().
+		OnEntryFunc(logEntry("open")).
+		OnExitFunc(logExit("open")).
+		Region("bold").
+		WithInitial("bold_off").
+		State("bold_off").OnEntryFunc(logEntry("bold_off")).OnExitFunc(logExit("bold_off")).
+		On("BOLD").Target("bold_on").EndState().
+		State("bold_on").OnEntryFunc(logEntry("bold_on")).OnExitFunc(logExit("bold_on")).
+		On("BOLD").Target("bold_off").EndState().
+		EndRegion().
+		Region("italic").
+		WithInitial("italic_off").
+		State("italic_off").OnEntryFunc(logEntry("italic_off")).OnExitFunc(logExit("italic_off")).
+		On("ITALIC").Target("italic_on").EndState().
+		State("italic_on").OnEntryFunc(logEntry("italic_on")).OnExitFunc(logExit("italic_on")).EndState().
+		EndRegion().
+		On("CLOSE").Target("closed").
+		EndState().
+		EndRegion().
+		Region("network").
+		WithInitial("online").
+		State("online").On("DISCONNECT").Target("offline").EndState().
+		State("offline").On("CONNECT").Target("online").EndState().
+		EndRegion().
+		Done().
+		State("quit").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "OPEN"})
+	expected := map[StateID]StateID{"editor": "open", "bold": "bold_off", "italic": "italic_off", "network": "online"}
+	if got := interp.State().ActiveInParallel; !maps.Equal(got, expected) {
+		t.Fatalf("Expected regions %v, got %v", expected, got)
+	}
+	if got := interp.State().Context.Log; !slices.Equal(got, []string{"enter:open", "enter:bold_off", "enter:italic_off"}) {
+		t.Errorf("Expected the nested parallel state to be entered before its regions, got %v", got)
+	}
+	if !interp.Matches("open") || !interp.Matches("bold_off") || !interp.Matches("editor") {
+		t.Error("Expected the nested parallel state and its regions to match")
+	}
+
+	// Events are broadcast to the nested regions and the outer regions
+	interp.Send(Event{Type: "BOLD"})
+	interp.Send(Event{Type: "ITALIC"})
+	interp.Send(Event{Type: "DISCONNECT"})
+	expected = map[StateID]StateID{"editor": "open", "bold": "bold_on", "italic": "italic_on", "network": "offline"}
+	if got := interp.State().ActiveInParallel; !maps.Equal(got, expected) {
+		t.Fatalf("Expected regions %v, got %v", expected, got)
+	}
+
+	active := interp.ActiveStates()
+	for _, id := range []StateID{"app", "editor", "open", "bold", "bold_on", "italic", "italic_on", "network", "offline"} {
+		if !slices.Contains(active, id) {
+			t.Errorf("Expected %s to be active, got %v", id, active)
+		}
+	}
+	if !interp.Can("BOLD") || !slices.Contains(interp.NextEvents(), "CLOSE") {
+		t.Errorf("Expected BOLD and CLOSE to be available, got %v", interp.NextEvents())
+	}
+
+	// A transition on the nested parallel state exits its regions first
+	interp.UpdateContext(func(ctx *nestedParallelContext) { ctx.Log = nil })
+	interp.Send(Event{Type: "CLOSE"})
+	if got := interp.State().Context.Log; !slices.Equal(got, []string{"exit:italic_on", "exit:bold_on", "exit:open"}) {
+		t.Errorf("Expected nested regions to exit in reverse order before 'open', got %v", got)
+	}
+	expected = map[StateID]StateID{"editor": "closed", "network": "offline"}
+	if got := interp.State().ActiveInParallel; !maps.Equal(got, expected) {
+		t.Errorf("Expected regions %v, got %v", expected, got)
+	}
+
+	// Exiting the outer parallel state exits the nested one as well
+	interp.Send(Event{Type: "OPEN"})
+	interp.UpdateContext(func(ctx *nestedParallelContext) { ctx.Log = nil })
+	interp.Send(Event{Type: "QUIT"})
+	if interp.State().Value != "quit" || len(interp.State().ActiveInParallel) != 0 {
+		t.Errorf("Expected 'quit' with no active regions, got %+v", interp.State())
+	}
+	if got := interp.State().Context.Log; !slices.Equal(got, []string{"exit:italic_off", "exit:bold_off", "exit:open"}) {
+		t.Errorf("Expected the nested parallel state to exit, got %v", got)
+	}
+}
+
+// TestParallelState_NestedTarget tests a transition targeting a state inside a nested parallel state
+func TestParallelState_NestedTarget(t *testing.T) {
+	logEntry := func(id string) func(ctx *nestedParallelContext, e Event) {
+		return func(ctx *nestedParallelContext, e Event) { ctx.Log = append(ctx.Log, "enter:"+id) }
+	}
+
+	// OPEN_BOLD targets a state in a region of the nested parallel state 'open'
+	machine, err := NewMachine[nestedParallelContext]("nested_parallel").
+		WithInitial("app").
+		State("app").Parallel().
+		Region("editor").
+		WithInitial("closed").
+		State("closed").On("OPEN_BOLD").Target("bold_on").EndState().
+		State("open").Parallel().
+		OnEntryFunc(logEntry("open")).
+		Region("bold").
+		WithInitial("bold_off").
+		State("bold_off").OnEntryFunc(logEntry("bold_off")).EndState().
+		State("bold_on").OnEntryFunc(logEntry("bold_on")).EndState().
+		EndRegion().
+		Region("italic").
+		WithInitial("italic_off").
+		State("italic_off").OnEntryFunc(logEntry("italic_off")).EndState().
+		EndRegion().
+		EndState().
+		EndRegion().
+		Region("network").
+		WithInitial("online").
+		State("online").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	interp.Send(Event{Type: "OPEN_BOLD"})
+	expected := map[StateID]StateID{"editor": "open", "bold": "bold_on", "italic": "italic_off", "network": "online"}
+	if got := interp.State().ActiveInParallel; !maps.Equal(got, expected) {
+		t.Fatalf("Expected regions %v, got %v", expected, got)
+	}
+	if got := interp.State().Context.Log; !slices.Equal(got, []string{"enter:open", "enter:bold_on", "enter:italic_off"}) {
+		t.Errorf("Expected 'bold' to be entered at the target, got %v", got)
+	}
+}
@@ -0,0 +1,214 @@
+package statekit
+
+import (
+	"slices"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// Path is a sequence of transitions that leads from the initial state of a
+// machine to State
+type Path struct {
+	// State is the state reached at the end of the path
+	State StateID
+	// Edges are the transitions taken, in order; empty for the initial state
+	Edges []Edge
+}
+
+// Events returns the events to send to follow the path. Eventless and delayed
+// transitions, and transitions on done.state events raised by the interpreter,
+// are taken without an event and are skipped.
+func (p Path) Events() []Event {
+	var events []Event
+	for _, edge := range p.Edges {
+		if edge.Event == "" || edge.Event == DoneStateEvent(edge.From) {
+			continue
+		}
+		events = append(events, Event{Type: edge.Event})
+	}
+	return events
+}
+
+// ShortestPaths returns, for each state reachable from the initial state, a
+// path with the fewest transitions that reaches it. The paths are computed
+// from the declared transitions alone: guards are assumed to pass, and every
+// guarded initial child is considered reachable. A compound state is reached
+// by the shortest path to any of its descendants. The regions of a parallel
+// state are explored independently, so a path to a state in one region does
+// not constrain the other regions.
+func ShortestPaths[C any](machine *ir.MachineConfig[C]) map[StateID]Path {
+	paths := make(map[StateID]Path)
+	graph := newPathGraph(machine)
+
+	var queue []Path
+	queued := make(map[StateID]bool)
+	reach := func(path Path) {
+		if queued[path.State] {
+			return
+		}
+		queued[path.State] = true
+		queue = append(queue, path)
+		for id := path.State; id != ""; id = machine.States[id].Parent {
+			if _, ok := paths[id]; !ok {
+				paths[id] = Path{State: id, Edges: path.Edges}
+			}
+		}
+	}
+
+	for _, id := range graph.target(machine.Initial) {
+		reach(Path{State: id})
+	}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		for _, step := range graph.steps(path.State) {
+			for _, id := range step.targets {
+				reach(Path{State: id, Edges: append(slices.Clip(path.Edges), step.edge)})
+			}
+		}
+	}
+	return paths
+}
+
+// SimplePaths returns every path from the initial state that does not visit a
+// state twice, in depth-first order. Paths end in the states that can be
+// active on their own: atomic, final, and parallel states, and the states of
+// parallel regions. Like ShortestPaths, it assumes that guards pass. The
+// number of simple paths grows quickly with the number of cycles, so
+// SimplePaths is meant for small machines.
+func SimplePaths[C any](machine *ir.MachineConfig[C]) []Path {
+	graph := newPathGraph(machine)
+	var paths []Path
+	visited := make(map[StateID]bool)
+
+	var visit func(path Path)
+	visit = func(path Path) {
+		paths = append(paths, path)
+		visited[path.State] = true
+		for _, step := range graph.steps(path.State) {
+			for _, id := range step.targets {
+				if !visited[id] {
+					visit(Path{State: id, Edges: append(slices.Clip(path.Edges), step.edge)})
+				}
+			}
+		}
+		visited[path.State] = false
+	}
+
+	for _, id := range graph.target(machine.Initial) {
+		visit(Path{State: id})
+	}
+	return paths
+}
+
+// pathGraph is the state graph explored by the path generators. Its nodes are
+// the states that can be active on their own; each step is a declared
+// transition and the nodes it may enter.
+type pathGraph[C any] struct {
+	machine *ir.MachineConfig[C]
+}
+
+// pathStep is a transition and the nodes it may enter
+type pathStep struct {
+	edge    Edge
+	targets []StateID
+}
+
+func newPathGraph[C any](machine *ir.MachineConfig[C]) *pathGraph[C] {
+	return &pathGraph[C]{machine: machine}
+}
+
+// steps returns the transitions available in a node: its own, then those of
+// its ancestors, in declaration order. Internal self-transitions, which do not
+// change the active states, are left out, and transitions on a done.state
+// event are only available in the final states that raise it.
+func (g *pathGraph[C]) steps(id StateID) []pathStep {
+	node := g.machine.States[id]
+	var steps []pathStep
+	for source := node; source != nil; source = g.machine.States[source.Parent] {
+		for _, trans := range source.Transitions {
+			if trans.Target == "" || (trans.Internal && trans.Target == source.ID) {
+				continue
+			}
+			if trans.Event == DoneStateEvent(source.ID) && !g.completes(node, source) {
+				continue
+			}
+			steps = append(steps, pathStep{
+				edge:    ir.NewEdge(source.ID, trans),
+				targets: g.target(trans.Target),
+			})
+		}
+	}
+	return steps
+}
+
+// completes reports whether the node is a final state that raises the done
+// event of the given state: a final child of a compound state, or a final state
+// in a region of a parallel state
+func (g *pathGraph[C]) completes(node, state *ir.StateConfig) bool {
+	if !node.IsFinal() {
+		return false
+	}
+	if state.IsParallel() {
+		return g.machine.IsDescendantOf(node.ID, state.ID)
+	}
+	return node.Parent == state.ID
+}
+
+// target returns the nodes that may become active when a transition targets
+// the given state. Targeting a state inside a parallel region also enters the
+// parallel state and its other regions.
+func (g *pathGraph[C]) target(id StateID) []StateID {
+	nodes := g.enter(id)
+	for child := g.machine.States[id]; child != nil && child.Parent != ""; child = g.machine.States[child.Parent] {
+		parent := g.machine.States[child.Parent]
+		if parent == nil || !parent.IsParallel() {
+			continue
+		}
+		nodes = append(nodes, parent.ID)
+		for _, regionID := range parent.Children {
+			if regionID != child.ID {
+				nodes = append(nodes, g.enter(regionID)...)
+			}
+		}
+	}
+	return nodes
+}
+
+// enter returns the nodes that may become active when the given state is
+// entered: the initial leaf and the targets of guarded initial children of a
+// compound state, a parallel state together with the nodes of its regions,
+// and the default target of a history state
+func (g *pathGraph[C]) enter(id StateID) []StateID {
+	state := g.machine.States[id]
+	if state == nil {
+		return nil
+	}
+
+	switch state.Type {
+	case ir.StateTypeHistory:
+		target := state.HistoryDefault
+		if target == "" && state.Parent != "" {
+			target = g.machine.States[state.Parent].Initial
+		}
+		return g.enter(target)
+	case ir.StateTypeParallel:
+		nodes := []StateID{id}
+		for _, regionID := range state.Children {
+			nodes = append(nodes, g.enter(regionID)...)
+		}
+		return nodes
+	case ir.StateTypeCompound:
+		nodes := g.enter(state.Initial)
+		for _, choice := range state.InitialChoices {
+			for _, node := range g.enter(choice.Target) {
+				if !slices.Contains(nodes, node) {
+					nodes = append(nodes, node)
+				}
+			}
+		}
+		return nodes
+	default:
+		return []StateID{id}
+	}
+}
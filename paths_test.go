@@ -0,0 +1,130 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+)
+
+type pathsContext struct {
+	Returning bool
+}
+
+// pathEvents returns the event types of a path
+func pathEvents(path Path) []EventType {
+	var events []EventType
+	for _, event := range path.Events() {
+		events = append(events, event.Type)
+	}
+	return events
+}
+
+// TestShortestPaths tests the shortest paths through hierarchy, guarded initial children, done events, and regions
+func TestShortestPaths(t *testing.T) {
+	machine, err := NewMachine[pathsContext]("paths").
+		WithInitial("idle").
+		WithGuard("isReturning", func(ctx pathsContext, e Event) bool { return ctx.Returning }).
+		State("idle").On("START").Target("setup").Done().
+		State("setup").
+		WithInitial("welcome").
+		WithInitialGuarded("isReturning", "profile").
+		State("welcome").On("NEXT").Target("profile").End().
+		End().
+		State("profile").On("SAVE").Target("ready").End().
+		End().
+		State("ready").Final().End().
+		OnDone().Target("player").
+		Done().
+		State("player").Parallel().
+		Region("audio").
+		WithInitial("muted").
+		State("muted").On("UNMUTE").Target("loud").EndState().
+		State("loud").EndState().
+		EndRegion().
+		Region("video").
+		WithInitial("windowed").
+		State("windowed").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	paths := ShortestPaths(machine)
+
+	tests := []struct {
+		state  StateID
+		edges  int
+		events []EventType
+	}{
+		{"idle", 0, nil},
+		{"setup", 1, []EventType{"START"}},
+		{"welcome", 1, []EventType{"START"}},
+		{"profile", 1, []EventType{"START"}}, // Guarded initial child
+		{"ready", 2, []EventType{"START", "SAVE"}},
+		{"player", 3, []EventType{"START", "SAVE"}}, // done.state.setup is raised
+		{"windowed", 3, []EventType{"START", "SAVE"}},
+		{"loud", 4, []EventType{"START", "SAVE", "UNMUTE"}},
+	}
+	for _, tt := range tests {
+		path, ok := paths[tt.state]
+		if !ok {
+			t.Errorf("Expected a path to %s", tt.state)
+			continue
+		}
+		if len(path.Edges) != tt.edges || !slices.Equal(pathEvents(path), tt.events) {
+			t.Errorf("Expected %d edges with events %v to %s, got %v", tt.edges, tt.events, tt.state, path.Edges)
+		}
+	}
+	if len(paths) != len(machine.States) {
+		t.Errorf("Expected paths to all %d states, got %d", len(machine.States), len(paths))
+	}
+
+	// Following the path to 'loud' reaches it once the guards on the path pass
+	machine.Context.Returning = true
+	interp := NewInterpreter(machine)
+	interp.Replay(paths["loud"].Events())
+	if !interp.MatchesAll("player", "loud", "windowed") {
+		t.Errorf("Expected 'loud' and 'windowed' to be active, got %v", interp.ActiveStates())
+	}
+}
+
+// TestSimplePaths tests that simple paths branch at guarded initial children and never revisit a state
+func TestSimplePaths(t *testing.T) {
+	machine, err := NewMachine[pathsContext]("paths").
+		WithInitial("idle").
+		WithGuard("isReturning", func(ctx pathsContext, e Event) bool { return ctx.Returning }).
+		State("idle").On("START").Target("setup").Done().
+		State("setup").
+		WithInitial("welcome").
+		WithInitialGuarded("isReturning", "profile").
+		State("welcome").On("NEXT").Target("profile").End().
+		End().
+		State("profile").On("SAVE").Target("ready").End().
+		End().
+		State("ready").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	paths := SimplePaths(machine)
+
+	var toReady [][]EventType
+	for _, path := range paths {
+		seen := make(map[StateID]bool)
+		for _, edge := range path.Edges {
+			if seen[edge.To] {
+				t.Errorf("Expected no state to be visited twice, got %v", path.Edges)
+			}
+			seen[edge.To] = true
+		}
+		if path.State == "ready" {
+			toReady = append(toReady, pathEvents(path))
+		}
+	}
+
+	// 'profile' is reached through 'welcome', or directly as the guarded initial child
+	expected := [][]EventType{{"START", "NEXT", "SAVE"}, {"START", "SAVE"}}
+	if !slices.EqualFunc(toReady, expected, slices.Equal) {
+		t.Errorf("Expected paths %v to 'ready', got %v", expected, toReady)
+	}
+}
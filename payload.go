@@ -0,0 +1,47 @@
+package statekit
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// PayloadError reports an event whose payload does not match the type
+// registered with WithEventSchema
+type PayloadError struct {
+	Event    EventType
+	Expected reflect.Type
+	Got      reflect.Type // nil for a nil payload
+}
+
+func (e *PayloadError) Error() string {
+	return fmt.Sprintf("event %q expects payload of type %v, got %v", e.Event, e.Expected, e.Got)
+}
+
+// WithStrictPayloads makes the interpreter check event payloads against the
+// types registered with WithEventSchema. An event with a mismatched payload is
+// ignored, and SendResult reports a *PayloadError in Err. Events without a
+// registered schema are not checked.
+func WithStrictPayloads() InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.strictPayloads = true
+	}
+}
+
+// checkPayload returns a *PayloadError if the event's payload does not match its
+// schema. A payload matches if it has exactly the registered type or, for an
+// interface type, implements it; a nil payload only matches an interface type.
+func (i *Interpreter[C]) checkPayload(event Event) error {
+	expected := i.machine.EventSchemas[event.Type]
+	if expected == nil {
+		return nil
+	}
+
+	got := reflect.TypeOf(event.Payload)
+	if got == expected {
+		return nil
+	}
+	if expected.Kind() == reflect.Interface && (got == nil || got.Implements(expected)) {
+		return nil
+	}
+	return &PayloadError{Event: event.Type, Expected: expected, Got: got}
+}
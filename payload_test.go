@@ -0,0 +1,121 @@
+package statekit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type billingContext struct {
+	Paid int
+}
+
+type PaymentInfo struct {
+	Amount int
+}
+
+// TestStrictPayloads_Matching tests that events with a matching payload are processed
+func TestStrictPayloads_Matching(t *testing.T) {
+	machine, err := NewMachine[billingContext]("billing").
+		WithInitial("unpaid").
+		WithEventSchema("PAID", reflect.TypeOf(PaymentInfo{})).
+		WithEventSchema("NOTE", reflect.TypeOf((*fmt.Stringer)(nil)).Elem()).
+		WithAction("record", func(ctx *billingContext, e Event) {
+			ctx.Paid += e.Payload.(PaymentInfo).Amount
+		}).
+		State("unpaid").
+		On("PAID").Target("paid").Do("record").
+		On("NOTE").Target("unpaid").Internal().
+		Done().
+		State("paid").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithStrictPayloads())
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "NOTE", Payload: time.Second})
+	if !result.Handled || result.Err != nil {
+		t.Errorf("Expected payload implementing the interface to match, got %+v", result)
+	}
+
+	result = interp.SendResult(Event{Type: "PAID", Payload: PaymentInfo{Amount: 40}})
+	if !result.Handled || result.Err != nil {
+		t.Fatalf("Expected PAID to be handled, got %+v", result)
+	}
+	if interp.State().Context.Paid != 40 {
+		t.Errorf("Expected 40 paid, got %d", interp.State().Context.Paid)
+	}
+}
+
+// TestStrictPayloads_Mismatch tests that events with a mismatched payload are rejected
+func TestStrictPayloads_Mismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload any
+	}{
+		{"wrong type", "40 EUR"},
+		{"pointer", &PaymentInfo{Amount: 40}},
+		{"nil", nil},
+	}
+
+	machine, err := NewMachine[billingContext]("billing").
+		WithInitial("unpaid").
+		WithEventSchema("PAID", reflect.TypeOf(PaymentInfo{})).
+		State("unpaid").On("PAID").Target("paid").Done().
+		State("paid").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interp := NewInterpreter(machine, WithStrictPayloads())
+			interp.Start()
+
+			result := interp.SendResult(Event{Type: "PAID", Payload: tt.payload})
+			if result.Handled {
+				t.Error("Expected event to be rejected")
+			}
+			var payloadErr *PayloadError
+			if !errors.As(result.Err, &payloadErr) {
+				t.Fatalf("Expected PayloadError, got %v", result.Err)
+			}
+			if payloadErr.Event != "PAID" || payloadErr.Expected != reflect.TypeOf(PaymentInfo{}) {
+				t.Errorf("Unexpected error details: %+v", payloadErr)
+			}
+			if interp.State().Value != "unpaid" {
+				t.Errorf("Expected state 'unpaid', got %s", interp.State().Value)
+			}
+			if interp.Stats().EventsIgnored != 1 {
+				t.Errorf("Expected rejected event to be counted as ignored, got %+v", interp.Stats())
+			}
+		})
+	}
+}
+
+// TestStrictPayloads_OffByDefault tests that payloads are not checked without WithStrictPayloads
+func TestStrictPayloads_OffByDefault(t *testing.T) {
+	machine, err := NewMachine[billingContext]("billing").
+		WithInitial("unpaid").
+		WithEventSchema("PAID", reflect.TypeOf(PaymentInfo{})).
+		State("unpaid").On("PAID").Target("paid").Done().
+		State("paid").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "PAID", Payload: "40 EUR"})
+	if !result.Handled || result.Err != nil {
+		t.Errorf("Expected event to be handled without strict mode, got %+v", result)
+	}
+}
@@ -273,3 +273,37 @@ func toSnakeCase(s string) string {
 	}
 	return string(result)
 }
+
+// BenchmarkInterpreter_Send_SharedGuard benchmarks an event whose candidate
+// transitions across hierarchy levels share one guard
+func BenchmarkInterpreter_Send_SharedGuard(b *testing.B) {
+	machine, _ := NewMachine[memoContext]("memo").
+		WithInitial("parent").
+		WithGuard("ready", func(ctx memoContext, e Event) bool { return ctx.Ready }).
+		WithAction("prepare", func(ctx *memoContext, e Event) { ctx.Ready = true }).
+		State("parent").
+		WithInitial("child").
+		On("GO").Target("done").Guard("ready").End().
+		On("GO").Target("waiting").End().
+		State("child").
+		On("GO").Target("done").Guard("ready").End().
+		On("GO").Target("done").Guard("ready").End().
+		End().
+		Done().
+		State("waiting").
+		OnEntry("prepare").
+		Always().Target("done").Guard("ready").End().
+		Done().
+		State("done").
+		On("GO").Target("parent").
+		Done().
+		Build()
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		interp.Send(Event{Type: "GO"})
+	}
+}
@@ -0,0 +1,199 @@
+package statekit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type raiseContext struct {
+	Log []string
+}
+
+// TestQueue_EntryActionRaisesEvent tests that an entry action can send an
+// event that causes a further transition without deadlocking
+func TestQueue_EntryActionRaisesEvent(t *testing.T) {
+	var interp *Interpreter[raiseContext]
+
+	machine, err := NewMachine[raiseContext]("raise").
+		WithInitial("idle").
+		WithAction("startLoading", func(ctx *raiseContext, e Event) {
+			ctx.Log = append(ctx.Log, "enter:loading")
+			interp.Send(Event{Type: "LOADED"})
+			// The raised event is processed after this transition completes
+			ctx.Log = append(ctx.Log, "raised")
+		}).
+		WithAction("enterReady", func(ctx *raiseContext, e Event) {
+			ctx.Log = append(ctx.Log, "enter:ready:"+string(e.Type))
+		}).
+		State("idle").
+		On("LOAD").Target("loading").
+		Done().
+		State("loading").
+		OnEntry("startLoading").
+		On("LOADED").Target("ready").
+		Done().
+		State("ready").
+		OnEntry("enterReady").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp = NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "LOAD"})
+
+	if interp.State().Value != "ready" {
+		t.Errorf("Expected state 'ready', got %s", interp.State().Value)
+	}
+
+	expected := []string{"enter:loading", "raised", "enter:ready:LOADED"}
+	log := interp.State().Context.Log
+	if len(log) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, log)
+	}
+	for idx, entry := range expected {
+		if log[idx] != entry {
+			t.Errorf("Log %d: expected %s, got %s", idx, entry, log[idx])
+		}
+	}
+}
+
+// TestQueue_FIFO tests that raised events are processed in the order they were sent
+func TestQueue_FIFO(t *testing.T) {
+	var interp *Interpreter[struct{}]
+
+	machine, err := NewMachine[struct{}]("fifo").
+		WithInitial("first").
+		WithAction("raiseBoth", func(ctx *struct{}, e Event) {
+			interp.Send(Event{Type: "A"})
+			interp.Send(Event{Type: "B"})
+		}).
+		State("first").
+		OnEntry("raiseBoth").
+		On("A").Target("second").
+		Done().
+		State("second").
+		On("B").Target("third").
+		Done().
+		State("third").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	// Events raised during Start are processed before Start returns
+	interp = NewInterpreter(machine)
+	interp.Start()
+
+	if interp.State().Value != "third" {
+		t.Errorf("Expected state 'third', got %s", interp.State().Value)
+	}
+}
+
+// TestQueue_ListenerSends tests that a listener can send events, which are
+// processed after the current notification
+func TestQueue_ListenerSends(t *testing.T) {
+	machine, err := NewMachine[struct{}]("listener_send").
+		WithInitial("a").
+		State("a").On("NEXT").Target("b").Done().
+		State("b").On("NEXT").Target("c").Done().
+		State("c").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	var seen []StateID
+	interp.Subscribe(func(s State[struct{}]) {
+		seen = append(seen, s.Value)
+		if s.Value == "b" {
+			interp.Send(Event{Type: "NEXT"})
+		}
+	})
+
+	interp.Start()
+	interp.Send(Event{Type: "NEXT"})
+
+	expected := []StateID{"a", "b", "c"}
+	if len(seen) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, seen)
+	}
+	for idx, id := range expected {
+		if seen[idx] != id {
+			t.Errorf("Notification %d: expected %s, got %s", idx, id, seen[idx])
+		}
+	}
+}
+
+// TestQueue_DelayedTransitionRaisesEvent tests raising events from actions run by timers
+func TestQueue_DelayedTransitionRaisesEvent(t *testing.T) {
+	var interp *Interpreter[struct{}]
+
+	machine, err := NewMachine[struct{}]("delayed_raise").
+		WithInitial("waiting").
+		WithAction("raiseDone", func(ctx *struct{}, e Event) {
+			interp.Send(Event{Type: "DONE"})
+		}).
+		State("waiting").
+		After(time.Second).Target("timedOut").
+		Done().
+		State("timedOut").
+		OnEntry("raiseDone").
+		On("DONE").Target("finished").
+		Done().
+		State("finished").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp = NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+	clock.Advance(time.Second)
+
+	if interp.State().Value != "finished" {
+		t.Errorf("Expected state 'finished', got %s", interp.State().Value)
+	}
+}
+
+// TestQueue_ConcurrentSend tests that concurrent senders do not lose events
+func TestQueue_ConcurrentSend(t *testing.T) {
+	machine, err := NewMachine[counterContext]("concurrent_queue").
+		WithInitial("counting").
+		WithAction("increment", func(ctx *counterContext, e Event) {
+			ctx.Count++
+		}).
+		State("counting").
+		On("INC").Target("counting").Do("increment").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			interp.Send(Event{Type: "INC"})
+		}()
+	}
+	wg.Wait()
+
+	if interp.State().Context.Count != 50 {
+		t.Errorf("Expected count 50, got %d", interp.State().Context.Count)
+	}
+}
@@ -2,6 +2,7 @@ package statekit
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
 
 	"github.com/felixgeelhaar/statekit/internal/ir"
@@ -31,8 +32,11 @@ type MachineDef struct{}
 //   - on:"EVENT->target:guard" - Transition with guard condition
 //   - on:"EVENT->target/action1;action2" - Transition with actions
 //   - on:"EVENT->target/action:guard" - Transition with action and guard
+//   - on:"EVENT->a:guard,EVENT->b" - Same event, tried in order (first passing guard wins)
+//   - after:"5s->target" - Delayed transition (durations use time.ParseDuration syntax)
 //   - entry:"action1,action2" - Entry actions
 //   - exit:"action1,action2" - Exit actions
+//   - meta:"key=value,key2=value2" - Metadata (string values)
 //
 // Example:
 //
@@ -58,6 +62,43 @@ type StateNode struct{}
 //	}
 type CompoundNode struct{}
 
+// ParallelNode is a marker type for defining parallel states, whose regions
+// are all active at the same time.
+//
+// Use struct tags to configure the parallel state:
+//   - on:"EVENT->target" - Transitions that exit all regions
+//   - entry:"action" - Entry actions
+//   - exit:"action" - Exit actions
+//
+// Regions are defined as fields within the struct that embeds ParallelNode.
+// Each region is a compound state (a struct embedding CompoundNode).
+//
+// Example:
+//
+//	type TransferState struct {
+//	    statekit.ParallelNode `on:"CANCEL->idle"`
+//	    Upload   UploadRegion   // embeds statekit.CompoundNode
+//	    Download DownloadRegion // embeds statekit.CompoundNode
+//	}
+type ParallelNode struct{}
+
+// HistoryNode is a marker type for defining history states inside a compound state.
+// Transitioning to a history state re-enters the last active child of its parent.
+//
+// Use struct tags to configure the history state:
+//   - history:"shallow" or history:"deep" - History type (default: shallow)
+//   - default:"sibling" - Required. State to enter when no history is recorded
+//
+// Example:
+//
+//	type ActiveState struct {
+//	    statekit.CompoundNode `initial:"idle"`
+//	    Hist    statekit.HistoryNode `history:"deep" default:"idle"`
+//	    Idle    statekit.StateNode   `on:"START->working"`
+//	    Working statekit.StateNode
+//	}
+type HistoryNode struct{}
+
 // FinalNode is a marker type for defining final states.
 //
 // Final states indicate the machine has completed. They typically
@@ -74,15 +115,35 @@ type FinalNode struct{}
 // ActionRegistry is not safe for concurrent use. It should be fully
 // configured before calling FromStruct or FromStructWithContext.
 type ActionRegistry[C any] struct {
-	actions map[ActionType]Action[C]
-	guards  map[GuardType]Guard[C]
+	actions          map[ActionType]Action[C]
+	fallibleActions  map[ActionType]FallibleAction[C]
+	schedulerActions map[ActionType]SchedulerAction[C]
+	guards           map[GuardType]Guard[C]
+	stateGuards      map[GuardType]StateGuard[C]
+	services         map[ServiceType]Service[C]
+	delays           map[DelayType]DelayResolver[C]
+	composites       map[GuardType]guardComposite
+	guardFactories   map[GuardType]GuardFactory[C]
+	outputs          map[StateID]Output[C]
+	eventSchemas     map[EventType]reflect.Type
+
+	contextValidator func(C) error
 }
 
 // NewActionRegistry creates a new empty action registry.
 func NewActionRegistry[C any]() *ActionRegistry[C] {
 	return &ActionRegistry[C]{
-		actions: make(map[ActionType]Action[C]),
-		guards:  make(map[GuardType]Guard[C]),
+		actions:          make(map[ActionType]Action[C]),
+		fallibleActions:  make(map[ActionType]FallibleAction[C]),
+		schedulerActions: make(map[ActionType]SchedulerAction[C]),
+		guards:           make(map[GuardType]Guard[C]),
+		stateGuards:      make(map[GuardType]StateGuard[C]),
+		services:         make(map[ServiceType]Service[C]),
+		delays:           make(map[DelayType]DelayResolver[C]),
+		composites:       make(map[GuardType]guardComposite),
+		guardFactories:   make(map[GuardType]GuardFactory[C]),
+		outputs:          make(map[StateID]Output[C]),
+		eventSchemas:     make(map[EventType]reflect.Type),
 	}
 }
 
@@ -93,6 +154,21 @@ func (r *ActionRegistry[C]) WithAction(name ActionType, action Action[C]) *Actio
 	return r
 }
 
+// WithFallibleAction registers a fallible action by name.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithFallibleAction(name ActionType, action FallibleAction[C]) *ActionRegistry[C] {
+	r.fallibleActions[name] = action
+	return r
+}
+
+// WithSchedulerAction registers an action that can also raise events by name
+// (see SchedulerAction).
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithSchedulerAction(name ActionType, action SchedulerAction[C]) *ActionRegistry[C] {
+	r.schedulerActions[name] = action
+	return r
+}
+
 // WithGuard registers a guard function by name.
 // Returns the registry for method chaining.
 func (r *ActionRegistry[C]) WithGuard(name GuardType, guard Guard[C]) *ActionRegistry[C] {
@@ -100,6 +176,105 @@ func (r *ActionRegistry[C]) WithGuard(name GuardType, guard Guard[C]) *ActionReg
 	return r
 }
 
+// WithStateGuard registers a guard that also inspects the active states of
+// the interpreter by name (see StateGuard).
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithStateGuard(name GuardType, guard StateGuard[C]) *ActionRegistry[C] {
+	r.stateGuards[name] = guard
+	return r
+}
+
+// WithGuardAnd registers a guard that passes when all of the given guards pass.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithGuardAnd(name GuardType, guards ...GuardType) *ActionRegistry[C] {
+	r.composites[name] = guardComposite{op: guardAnd, guards: guards}
+	return r
+}
+
+// WithGuardOr registers a guard that passes when any of the given guards passes.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithGuardOr(name GuardType, guards ...GuardType) *ActionRegistry[C] {
+	r.composites[name] = guardComposite{op: guardOr, guards: guards}
+	return r
+}
+
+// WithGuardNot registers a guard that passes when the given guard fails.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithGuardNot(name GuardType, guard GuardType) *ActionRegistry[C] {
+	r.composites[name] = guardComposite{op: guardNot, guards: []GuardType{guard}}
+	return r
+}
+
+// WithGuardFactory registers a parameterized guard by name.
+// Tags reference it with a guard call, e.g. `on:"SUBMIT->ok:hasAtLeast(3)"`.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithGuardFactory(name GuardType, factory GuardFactory[C]) *ActionRegistry[C] {
+	r.guardFactories[name] = factory
+	return r
+}
+
+// WithService registers an invoked service by name.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithService(name ServiceType, service Service[C]) *ActionRegistry[C] {
+	r.services[name] = service
+	return r
+}
+
+// WithOutput sets the output of a top-level final state by its ID.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithOutput(state StateID, output Output[C]) *ActionRegistry[C] {
+	r.outputs[state] = output
+	return r
+}
+
+// WithEventSchema registers the expected payload type of an event, checked by
+// interpreters created with WithStrictPayloads.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithEventSchema(event EventType, payload reflect.Type) *ActionRegistry[C] {
+	r.eventSchemas[event] = payload
+	return r
+}
+
+// WithContextValidator sets a function that checks the context before the
+// interpreter enters the initial state.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithContextValidator(validate func(C) error) *ActionRegistry[C] {
+	r.contextValidator = validate
+	return r
+}
+
+// WithDelay registers a named delay resolver by name.
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) WithDelay(name DelayType, resolver DelayResolver[C]) *ActionRegistry[C] {
+	r.delays[name] = resolver
+	return r
+}
+
+// Action returns the action registered under name, if any.
+func (r *ActionRegistry[C]) Action(name ActionType) (Action[C], bool) {
+	action, ok := r.actions[name]
+	return action, ok
+}
+
+// Guard returns the guard registered under name, if any.
+// Combined guards are returned if all of their components are registered.
+func (r *ActionRegistry[C]) Guard(name GuardType) (Guard[C], bool) {
+	guard, issue := resolveGuard(name, r.guards, r.stateGuards, r.composites, map[GuardType]bool{})
+	return guard, issue == nil
+}
+
+// Service returns the service registered under name, if any.
+func (r *ActionRegistry[C]) Service(name ServiceType) (Service[C], bool) {
+	service, ok := r.services[name]
+	return service, ok
+}
+
+// Delay returns the delay resolver registered under name, if any.
+func (r *ActionRegistry[C]) Delay(name DelayType) (DelayResolver[C], bool) {
+	resolver, ok := r.delays[name]
+	return resolver, ok
+}
+
 // FromStruct builds a MachineConfig from a struct definition using the reflection DSL.
 //
 // The struct M must embed MachineDef and define states using StateNode,
@@ -154,28 +329,67 @@ func buildMachineFromSchema[C any](schema *parser.MachineSchema, registry *Actio
 		for name, action := range registry.actions {
 			machine.Actions[name] = ir.Action[C](action)
 		}
+		for name, action := range registry.fallibleActions {
+			machine.FallibleActions[name] = action
+		}
+		for name, action := range registry.schedulerActions {
+			machine.SchedulerActions[name] = action
+		}
 		for name, guard := range registry.guards {
 			machine.Guards[name] = ir.Guard[C](guard)
 		}
+		for name, guard := range registry.stateGuards {
+			machine.StateGuards[name] = guard
+		}
+		for name, service := range registry.services {
+			machine.Services[name] = ir.Service[C](service)
+		}
+		for name, resolver := range registry.delays {
+			machine.Delays[name] = resolver
+		}
+		for state, output := range registry.outputs {
+			machine.Outputs[state] = output
+		}
+		for event, payload := range registry.eventSchemas {
+			machine.EventSchemas[event] = payload
+		}
+		machine.ContextValidator = registry.contextValidator
+	}
+
+	// Guard expressions in tags add combined guards to the registered ones
+	composites := make(map[GuardType]guardComposite)
+	var factories map[GuardType]GuardFactory[C]
+	if registry != nil {
+		maps.Copy(composites, registry.composites)
+		factories = registry.guardFactories
 	}
 
 	// Build states recursively
+	errs := &ir.ValidationError{}
 	for _, stateSchema := range schema.States {
-		if err := buildStateFromSchema(machine, stateSchema, ""); err != nil {
+		if err := buildStateFromSchema(machine, stateSchema, "", composites, errs); err != nil {
 			return nil, err
 		}
 	}
 
+	// Instantiate guard calls, then combine guards from their components
+	instantiateGuards(machine, factories, guardReferences(machine, composites), errs)
+	composeGuards(machine, composites, errs)
+
 	// Validate the machine
 	if err := ir.Validate(machine); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		errs.Issues = append(errs.Issues, err.Issues...)
+	}
+	if errs.HasIssues() {
+		return nil, fmt.Errorf("validation failed: %w", errs)
 	}
 
 	return machine, nil
 }
 
 // buildStateFromSchema recursively builds states from schema.
-func buildStateFromSchema[C any](machine *ir.MachineConfig[C], schema *parser.StateSchema, parentID ir.StateID) error {
+// Guard expressions are added to composites as combined guards.
+func buildStateFromSchema[C any](machine *ir.MachineConfig[C], schema *parser.StateSchema, parentID ir.StateID, composites map[GuardType]guardComposite, errs *ir.ValidationError) error {
 	stateID := ir.StateID(schema.Name)
 
 	// Determine state type
@@ -187,6 +401,10 @@ func buildStateFromSchema[C any](machine *ir.MachineConfig[C], schema *parser.St
 		stateType = ir.StateTypeCompound
 	case parser.StateSchemaFinal:
 		stateType = ir.StateTypeFinal
+	case parser.StateSchemaParallel:
+		stateType = ir.StateTypeParallel
+	case parser.StateSchemaHistory:
+		stateType = ir.StateTypeHistory
 	default:
 		return fmt.Errorf("unknown state schema type: %d", schema.Type)
 	}
@@ -195,6 +413,13 @@ func buildStateFromSchema[C any](machine *ir.MachineConfig[C], schema *parser.St
 	state := ir.NewStateConfig(stateID, stateType)
 	state.Parent = parentID
 	state.Initial = ir.StateID(schema.Initial)
+	if stateType == ir.StateTypeHistory {
+		state.HistoryType = ir.HistoryTypeShallow
+		if schema.HistoryDeep {
+			state.HistoryType = ir.HistoryTypeDeep
+		}
+		state.HistoryDefault = ir.StateID(schema.HistoryDefault)
+	}
 
 	// Add entry actions
 	for _, action := range schema.Entry {
@@ -206,13 +431,34 @@ func buildStateFromSchema[C any](machine *ir.MachineConfig[C], schema *parser.St
 		state.Exit = append(state.Exit, ir.ActionType(action))
 	}
 
+	// Add metadata
+	for key, value := range schema.Meta {
+		if state.Meta == nil {
+			state.Meta = make(map[string]any, len(schema.Meta))
+		}
+		state.Meta[key] = value
+	}
+
 	// Add transitions
 	for _, trans := range schema.Transitions {
 		transition := ir.NewTransitionConfig(
 			ir.EventType(trans.Event),
 			ir.StateID(trans.Target),
 		)
-		transition.Guard = ir.GuardType(trans.Guard)
+		transition.Guard = schemaGuard(trans, composites)
+		transition.Internal = trans.Internal
+		for _, action := range trans.Actions {
+			transition.Actions = append(transition.Actions, ir.ActionType(action))
+		}
+		state.Transitions = append(state.Transitions, transition)
+	}
+
+	// Add delayed transitions
+	for _, trans := range schema.DelayedTransitions {
+		transition := ir.NewTransitionConfig("", ir.StateID(trans.Target))
+		transition.Delay = trans.Delay
+		transition.Guard = schemaGuard(trans, composites)
+		transition.Internal = trans.Internal
 		for _, action := range trans.Actions {
 			transition.Actions = append(transition.Actions, ir.ActionType(action))
 		}
@@ -220,11 +466,13 @@ func buildStateFromSchema[C any](machine *ir.MachineConfig[C], schema *parser.St
 	}
 
 	// Register state
-	machine.States[stateID] = state
+	if !registerState(machine, state, errs) {
+		return nil
+	}
 
 	// Build children
 	for _, childSchema := range schema.Children {
-		if err := buildStateFromSchema(machine, childSchema, stateID); err != nil {
+		if err := buildStateFromSchema(machine, childSchema, stateID, composites, errs); err != nil {
 			return err
 		}
 		state.Children = append(state.Children, ir.StateID(childSchema.Name))
@@ -232,3 +480,32 @@ func buildStateFromSchema[C any](machine *ir.MachineConfig[C], schema *parser.St
 
 	return nil
 }
+
+// schemaGuard returns the guard of a parsed transition, as a guard call if the
+// tag passed arguments. A guard expression such as "isAdmin&isActive" is added
+// to composites and returned under its combined name, "isAdmin && isActive".
+func schemaGuard(trans parser.TransitionSchema, composites map[GuardType]guardComposite) ir.GuardType {
+	if trans.Guards == nil {
+		return schemaGuardName(trans.Guard, trans.GuardArgs)
+	}
+
+	op := guardAnd
+	if trans.GuardOr {
+		op = guardOr
+	}
+	guards := make([]GuardType, len(trans.Guards))
+	for i, guard := range trans.Guards {
+		guards[i] = schemaGuardName(guard.Name, guard.Args)
+	}
+	name := combinedGuardName(op, guards)
+	composites[name] = guardComposite{op: op, guards: guards}
+	return name
+}
+
+// schemaGuardName returns a parsed guard, as a guard call if it has arguments
+func schemaGuardName(name string, args []string) ir.GuardType {
+	if args != nil {
+		return ir.GuardCall(ir.GuardType(name), args...)
+	}
+	return ir.GuardType(name)
+}
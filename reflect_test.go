@@ -1,7 +1,14 @@
 package statekit
 
 import (
+	"errors"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+	"github.com/felixgeelhaar/statekit/statekittest"
 )
 
 // Test context for reflection tests
@@ -158,6 +165,108 @@ func TestFromStruct_WithGuards(t *testing.T) {
 	}
 }
 
+// Machine with guarded routing on a single event for testing
+type RoutingReflectMachine struct {
+	MachineDef `id:"routing" initial:"review"`
+	Review     StateNode `on:"SUBMIT->approved:isValid,SUBMIT->rejected:isComplete"`
+	Approved   FinalNode
+	Rejected   FinalNode
+}
+
+// Machine with guarded routing and an unguarded fallback for testing
+type FallbackReflectMachine struct {
+	MachineDef `id:"fallback" initial:"review"`
+	Review     StateNode `on:"SUBMIT->approved:isValid,SUBMIT->rejected"`
+	Approved   FinalNode
+	Rejected   FinalNode
+}
+
+func TestFromStruct_SameEventGuardedFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		valid    bool
+		expected StateID
+	}{
+		{name: "guard passes", valid: true, expected: "approved"},
+		{name: "falls through to unguarded", valid: false, expected: "rejected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewActionRegistry[ReflectTestContext]().
+				WithGuard("isValid", func(ctx ReflectTestContext, e Event) bool {
+					return tt.valid
+				})
+
+			machine, err := FromStruct[FallbackReflectMachine, ReflectTestContext](registry)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			interp := NewInterpreter(machine)
+			interp.Start()
+			interp.Send(Event{Type: "SUBMIT"})
+
+			if interp.State().Value != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, interp.State().Value)
+			}
+		})
+	}
+}
+
+func TestFromStruct_SameEventAllGuardsFail(t *testing.T) {
+	var evaluated []GuardType
+
+	registry := NewActionRegistry[ReflectTestContext]().
+		WithGuard("isValid", func(ctx ReflectTestContext, e Event) bool {
+			evaluated = append(evaluated, "isValid")
+			return false
+		}).
+		WithGuard("isComplete", func(ctx ReflectTestContext, e Event) bool {
+			evaluated = append(evaluated, "isComplete")
+			return false
+		})
+
+	machine, err := FromStruct[RoutingReflectMachine, ReflectTestContext](registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "SUBMIT"})
+
+	// Event is effectively ignored
+	if interp.State().Value != "review" {
+		t.Errorf("expected to stay in 'review', got %q", interp.State().Value)
+	}
+
+	// Guards are evaluated in declaration order
+	if len(evaluated) != 2 || evaluated[0] != "isValid" || evaluated[1] != "isComplete" {
+		t.Errorf("expected guards evaluated in order [isValid isComplete], got %v", evaluated)
+	}
+}
+
+func TestFromStruct_SameEventFirstMatchWins(t *testing.T) {
+	registry := NewActionRegistry[ReflectTestContext]().
+		WithGuard("isValid", func(ctx ReflectTestContext, e Event) bool { return true }).
+		WithGuard("isComplete", func(ctx ReflectTestContext, e Event) bool { return true })
+
+	machine, err := FromStruct[RoutingReflectMachine, ReflectTestContext](registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "SUBMIT"})
+
+	// Both guards pass, so the transition declared first in the tag wins
+	if interp.State().Value != "approved" {
+		t.Errorf("expected 'approved', got %q", interp.State().Value)
+	}
+}
+
 // Machine with final state for testing
 type FinalReflectMachine struct {
 	MachineDef `id:"final" initial:"active"`
@@ -209,6 +318,75 @@ type ParentState struct {
 	Sibling      SiblingState
 }
 
+// Machine with internal and external self-transitions for testing
+type InternalReflectMachine struct {
+	MachineDef `id:"internal" initial:"counting"`
+	Counting   StateNode `on:"TICK->.counting/increment,RESTART->counting/increment" entry:"onEnterCounting"`
+}
+
+func TestFromStruct_InternalTransition(t *testing.T) {
+	entries := 0
+	registry := NewActionRegistry[ReflectTestContext]().
+		WithAction("increment", func(ctx *ReflectTestContext, e Event) {
+			ctx.Count++
+		}).
+		WithAction("onEnterCounting", func(ctx *ReflectTestContext, e Event) {
+			entries++
+		})
+
+	machine, err := FromStruct[InternalReflectMachine, ReflectTestContext](registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	counting := machine.States["counting"]
+	if !counting.Transitions[0].Internal || counting.Transitions[1].Internal {
+		t.Errorf("expected only TICK to be internal, got %+v, %+v", counting.Transitions[0], counting.Transitions[1])
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	// Internal self-transitions run their actions without re-entering the state
+	interp.Send(Event{Type: "TICK"})
+	interp.Send(Event{Type: "TICK"})
+	if entries != 1 {
+		t.Errorf("expected 1 entry after internal transitions, got %d", entries)
+	}
+
+	// External self-transitions re-enter the state
+	interp.Send(Event{Type: "RESTART"})
+	if entries != 2 {
+		t.Errorf("expected 2 entries after an external transition, got %d", entries)
+	}
+	if interp.State().Context.Count != 3 {
+		t.Errorf("expected count 3, got %d", interp.State().Context.Count)
+	}
+}
+
+// Machine with state metadata for testing
+type MetaReflectMachine struct {
+	MachineDef `id:"meta" initial:"idle"`
+	Idle       StateNode `on:"START->running" meta:"color=red,label=Idle"`
+	Running    StateNode
+}
+
+func TestFromStruct_Meta(t *testing.T) {
+	machine, err := FromStruct[MetaReflectMachine, ReflectTestContext](NewActionRegistry[ReflectTestContext]())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	meta := interp.Meta("idle")
+	if meta["color"] != "red" || meta["label"] != "Idle" {
+		t.Errorf("expected color=red and label=Idle, got %v", meta)
+	}
+	if interp.Meta("running") != nil {
+		t.Errorf("expected no metadata on running, got %v", interp.Meta("running"))
+	}
+}
+
 type HierarchicalReflectMachine struct {
 	MachineDef `id:"hierarchical" initial:"parent"`
 	Parent     ParentState
@@ -411,6 +589,68 @@ func TestFromStruct_MissingAction(t *testing.T) {
 	}
 }
 
+// TestFromStruct_GuardExpression tests guards joined by '&' and '|' in tags
+func TestFromStruct_GuardExpression(t *testing.T) {
+	type AccessMachine struct {
+		MachineDef `id:"access" initial:"locked"`
+		Locked     StateNode `on:"OPEN->open:isAdmin&isActive,OPEN->peek:isAdmin|isOwner"`
+		Open       FinalNode
+		Peek       FinalNode
+	}
+
+	var admin, active, owner bool
+	registry := NewActionRegistry[ReflectTestContext]().
+		WithGuard("isAdmin", func(ctx ReflectTestContext, e Event) bool { return admin }).
+		WithGuard("isActive", func(ctx ReflectTestContext, e Event) bool { return active }).
+		WithGuard("isOwner", func(ctx ReflectTestContext, e Event) bool { return owner })
+
+	machine, err := FromStruct[AccessMachine, ReflectTestContext](registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := machine.GetState("locked").Transitions
+	if transitions[0].Guard != "isAdmin && isActive" || transitions[1].Guard != "isAdmin || isOwner" {
+		t.Errorf("expected combined guard names, got %q and %q", transitions[0].Guard, transitions[1].Guard)
+	}
+
+	tests := []struct {
+		admin, active, owner bool
+		expected             StateID
+	}{
+		{false, false, false, "locked"},
+		{false, true, false, "locked"},
+		{true, false, false, "peek"},
+		{false, false, true, "peek"},
+		{true, true, false, "open"},
+	}
+	for _, tt := range tests {
+		admin, active, owner = tt.admin, tt.active, tt.owner
+		interp := NewInterpreter(machine)
+		interp.Start()
+		interp.Send(Event{Type: "OPEN"})
+		if interp.State().Value != tt.expected {
+			t.Errorf("admin=%v active=%v owner=%v: expected %q, got %q",
+				tt.admin, tt.active, tt.owner, tt.expected, interp.State().Value)
+		}
+	}
+
+	// Each operand must be registered
+	type MissingOperandMachine struct {
+		MachineDef `id:"missing" initial:"locked"`
+		Locked     StateNode `on:"OPEN->open:isAdmin&isVerified"`
+		Open       FinalNode
+	}
+	_, err = FromStruct[MissingOperandMachine, ReflectTestContext](registry)
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) || !containsIssueCode(validationErr, ir.ErrCodeMissingGuard) {
+		t.Fatalf("expected MISSING_GUARD, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "guard 'isVerified' is not defined") {
+		t.Errorf("expected the missing operand to be reported, got %v", err)
+	}
+}
+
 // Test missing guard error
 type MissingGuardMachine struct {
 	MachineDef `id:"missing" initial:"idle"`
@@ -426,3 +666,341 @@ func TestFromStruct_MissingGuard(t *testing.T) {
 		t.Fatal("expected error for missing guard")
 	}
 }
+
+// TestFromStruct_ParallelParityWithBuilder tests that a reflected parallel machine behaves like a built one
+func TestFromStruct_ParallelParityWithBuilder(t *testing.T) {
+	builderMachine, err := NewMachine[ReflectTestContext]("transfer").
+		WithInitial("transfer").
+		State("transfer").Parallel().
+		On("CANCEL").Target("idle").End().
+		Region("upload").WithInitial("uploading").
+		State("uploading").On("UPLOAD_DONE").Target("uploaded").EndState().
+		State("uploaded").Final().EndState().
+		EndRegion().
+		Region("download").WithInitial("downloading").
+		State("downloading").On("DOWNLOAD_DONE").Target("downloaded").EndState().
+		State("downloaded").Final().EndState().
+		EndRegion().
+		Done().
+		State("idle").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("builder error: %v", err)
+	}
+
+	type UploadRegion struct {
+		CompoundNode `initial:"uploading"`
+		Uploading    StateNode `on:"UPLOAD_DONE->uploaded"`
+		Uploaded     FinalNode
+	}
+	type DownloadRegion struct {
+		CompoundNode `initial:"downloading"`
+		Downloading  StateNode `on:"DOWNLOAD_DONE->downloaded"`
+		Downloaded   FinalNode
+	}
+	type TransferState struct {
+		ParallelNode `on:"CANCEL->idle"`
+		Upload       UploadRegion
+		Download     DownloadRegion
+	}
+	type TransferMachine struct {
+		MachineDef `id:"transfer" initial:"transfer"`
+		Transfer   TransferState
+		Idle       StateNode
+	}
+
+	reflectMachine, err := FromStruct[TransferMachine, ReflectTestContext](NewActionRegistry[ReflectTestContext]())
+	if err != nil {
+		t.Fatalf("reflect error: %v", err)
+	}
+
+	if len(builderMachine.States) != len(reflectMachine.States) {
+		t.Errorf("States count mismatch: builder=%d, reflect=%d", len(builderMachine.States), len(reflectMachine.States))
+	}
+	if !reflectMachine.GetState("transfer").IsParallel() {
+		t.Error("expected 'transfer' to be a parallel state")
+	}
+
+	builderInterp := NewInterpreter(builderMachine)
+	reflectInterp := NewInterpreter(reflectMachine)
+
+	builderInterp.Start()
+	reflectInterp.Start()
+
+	for _, event := range []string{"", "UPLOAD_DONE", "DOWNLOAD_DONE", "CANCEL"} {
+		if event != "" {
+			builderInterp.Send(Event{Type: EventType(event)})
+			reflectInterp.Send(Event{Type: EventType(event)})
+		}
+
+		builderState := builderInterp.State()
+		reflectState := reflectInterp.State()
+		if builderState.Value != reflectState.Value {
+			t.Errorf("state mismatch after %q: builder=%q, reflect=%q", event, builderState.Value, reflectState.Value)
+		}
+		if len(builderState.ActiveInParallel) != len(reflectState.ActiveInParallel) {
+			t.Errorf("region count mismatch after %q: builder=%v, reflect=%v", event, builderState.ActiveInParallel, reflectState.ActiveInParallel)
+		}
+		for region, leaf := range builderState.ActiveInParallel {
+			if reflectState.ActiveInParallel[region] != leaf {
+				t.Errorf("region %q mismatch after %q: builder=%q, reflect=%q", region, event, leaf, reflectState.ActiveInParallel[region])
+			}
+		}
+	}
+}
+
+// TestFromStruct_HistoryParityWithBuilder tests that reflected history states resume like built ones
+func TestFromStruct_HistoryParityWithBuilder(t *testing.T) {
+	type SectionState struct {
+		CompoundNode `initial:"step1"`
+		Step1        StateNode `on:"NEXT->step2"`
+		Step2        StateNode
+	}
+	type ShallowActive struct {
+		CompoundNode `initial:"section" on:"PAUSE->paused"`
+		Hist         HistoryNode `default:"section"`
+		Section      SectionState
+		Other        StateNode
+	}
+	type ShallowMachine struct {
+		MachineDef `id:"history" initial:"active"`
+		Active     ShallowActive
+		Paused     StateNode `on:"RESUME->hist"`
+	}
+	type DeepActive struct {
+		CompoundNode `initial:"section" on:"PAUSE->paused"`
+		Hist         HistoryNode `history:"deep" default:"section"`
+		Section      SectionState
+		Other        StateNode
+	}
+	type DeepMachine struct {
+		MachineDef `id:"history" initial:"active"`
+		Active     DeepActive
+		Paused     StateNode `on:"RESUME->hist"`
+	}
+
+	buildWithBuilder := func(deep bool) *ir.MachineConfig[ReflectTestContext] {
+		active := NewMachine[ReflectTestContext]("history").
+			WithInitial("active").
+			State("active").
+			WithInitial("section").
+			On("PAUSE").Target("paused").End()
+		hist := active.History("hist").Default("section")
+		if deep {
+			hist = hist.Deep()
+		}
+		machine, err := hist.End().
+			State("section").
+			WithInitial("step1").
+			State("step1").On("NEXT").Target("step2").End().End().
+			State("step2").End().
+			End().
+			State("other").End().
+			Done().
+			State("paused").
+			On("RESUME").Target("hist").
+			Done().
+			Build()
+		if err != nil {
+			t.Fatalf("Failed to build machine: %v", err)
+		}
+		return machine
+	}
+
+	shallowMachine, err := FromStruct[ShallowMachine, ReflectTestContext](NewActionRegistry[ReflectTestContext]())
+	if err != nil {
+		t.Fatalf("reflect error: %v", err)
+	}
+	deepMachine, err := FromStruct[DeepMachine, ReflectTestContext](NewActionRegistry[ReflectTestContext]())
+	if err != nil {
+		t.Fatalf("reflect error: %v", err)
+	}
+
+	if got := deepMachine.GetState("hist").HistoryType; got != ir.HistoryTypeDeep {
+		t.Errorf("expected deep history type, got %v", got)
+	}
+
+	tests := []struct {
+		name    string
+		reflect *ir.MachineConfig[ReflectTestContext]
+		builder *ir.MachineConfig[ReflectTestContext]
+		resumed StateID
+	}{
+		{"shallow", shallowMachine, buildWithBuilder(false), "step1"},
+		{"deep", deepMachine, buildWithBuilder(true), "step2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builderInterp := NewInterpreter(tt.builder)
+			reflectInterp := NewInterpreter(tt.reflect)
+
+			builderInterp.Start()
+			reflectInterp.Start()
+
+			for _, event := range []string{"NEXT", "PAUSE", "RESUME"} {
+				builderInterp.Send(Event{Type: EventType(event)})
+				reflectInterp.Send(Event{Type: EventType(event)})
+
+				if builderInterp.State().Value != reflectInterp.State().Value {
+					t.Errorf("state mismatch after %s: builder=%q, reflect=%q",
+						event, builderInterp.State().Value, reflectInterp.State().Value)
+				}
+			}
+
+			if reflectInterp.State().Value != tt.resumed {
+				t.Errorf("expected to resume in %q, got %q", tt.resumed, reflectInterp.State().Value)
+			}
+		})
+	}
+}
+
+// TestFromStruct_HistoryDefaultNotSibling tests that a history default must be a sibling
+func TestFromStruct_HistoryDefaultNotSibling(t *testing.T) {
+	type ActiveState struct {
+		CompoundNode `initial:"idle"`
+		Hist         HistoryNode `default:"paused"`
+		Idle         StateNode
+	}
+	type InvalidHistoryMachine struct {
+		MachineDef `id:"history" initial:"active"`
+		Active     ActiveState
+		Paused     StateNode
+	}
+
+	_, err := FromStruct[InvalidHistoryMachine, ReflectTestContext](NewActionRegistry[ReflectTestContext]())
+	if err == nil {
+		t.Fatal("expected validation error for non-sibling history default")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T", err)
+	}
+	if validationErr.Issues[0].Code != ir.ErrCodeHistoryDefaultNotSibling {
+		t.Errorf("expected HISTORY_DEFAULT_NOT_SIBLING, got %s", validationErr.Issues[0].Code)
+	}
+}
+
+// TestFromStruct_DelayedTransitions tests that after tags produce delayed transitions
+func TestFromStruct_DelayedTransitions(t *testing.T) {
+	type DelayedMachine struct {
+		MachineDef `id:"delayed" initial:"waiting"`
+		Waiting    StateNode `on:"CANCEL->idle" after:"5s->timeout,1m->failed"`
+		Timeout    StateNode `on:"RETRY->waiting"`
+		Failed     FinalNode
+		Idle       StateNode `on:"RETRY->waiting"`
+	}
+
+	machine, err := FromStruct[DelayedMachine, ReflectTestContext](NewActionRegistry[ReflectTestContext]())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waiting := machine.GetState("waiting")
+	if len(waiting.Transitions) != 3 {
+		t.Fatalf("expected 3 transitions on waiting, got %d", len(waiting.Transitions))
+	}
+	if waiting.Transitions[1].Delay != 5*time.Second || waiting.Transitions[2].Delay != time.Minute {
+		t.Errorf("unexpected delays: %v, %v", waiting.Transitions[1].Delay, waiting.Transitions[2].Delay)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	// The first delay wins
+	clock.Advance(5 * time.Second)
+	if interp.State().Value != "timeout" {
+		t.Errorf("expected 'timeout' after 5s, got %q", interp.State().Value)
+	}
+
+	// A normal transition cancels the pending delays
+	interp.Send(Event{Type: "RETRY"})
+	interp.Send(Event{Type: "CANCEL"})
+	clock.Advance(time.Minute)
+	if interp.State().Value != "idle" {
+		t.Errorf("expected 'idle' after CANCEL, got %q", interp.State().Value)
+	}
+}
+
+// TestFromStruct_DuplicateState tests that states sharing an ID under different parents are rejected
+func TestFromStruct_DuplicateState(t *testing.T) {
+	type EditingState struct {
+		CompoundNode `initial:"idle"`
+		Idle         StateNode
+	}
+	type ReviewingState struct {
+		CompoundNode `initial:"idle"`
+		Idle         StateNode
+	}
+	type DuplicateMachine struct {
+		MachineDef `id:"duplicate" initial:"editing"`
+		Editing    EditingState
+		Reviewing  ReviewingState
+	}
+
+	_, err := FromStruct[DuplicateMachine, ReflectTestContext](NewActionRegistry[ReflectTestContext]())
+	if err == nil {
+		t.Fatal("expected validation error for duplicate state")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T", err)
+	}
+	if validationErr.Issues[0].Code != ir.ErrCodeDuplicateState {
+		t.Errorf("expected DUPLICATE_STATE, got %s", validationErr.Issues[0].Code)
+	}
+}
+
+// TestFromStruct_GuardFactory tests guard calls in tags instantiated from one factory
+func TestFromStruct_GuardFactory(t *testing.T) {
+	type ReviewMachine struct {
+		MachineDef `id:"review" initial:"draft"`
+		Draft      StateNode `on:"SUBMIT->approved:countAtLeast(5),SUBMIT->pending:countAtLeast(1),SUBMIT->rejected"`
+		Approved   FinalNode
+		Pending    FinalNode
+		Rejected   FinalNode
+	}
+
+	registry := NewActionRegistry[ReflectTestContext]().
+		WithGuardFactory("countAtLeast", func(args []string) Guard[ReflectTestContext] {
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return nil
+			}
+			return func(ctx ReflectTestContext, e Event) bool { return ctx.Count >= n }
+		})
+
+	machine, err := FromStruct[ReviewMachine, ReflectTestContext](registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if guard := machine.GetState("draft").Transitions[0].Guard; guard != "countAtLeast(5)" {
+		t.Errorf("expected guard 'countAtLeast(5)', got %q", guard)
+	}
+
+	for count, expected := range map[int]StateID{0: "rejected", 2: "pending", 7: "approved"} {
+		interp := NewInterpreter(machine)
+		interp.Start()
+		interp.UpdateContext(func(ctx *ReflectTestContext) { ctx.Count = count })
+		interp.Send(Event{Type: "SUBMIT"})
+		if interp.State().Value != expected {
+			t.Errorf("with count %d expected %q, got %q", count, expected, interp.State().Value)
+		}
+	}
+
+	// Arguments the factory rejects are reported at build time
+	type BadMachine struct {
+		MachineDef `id:"bad" initial:"draft"`
+		Draft      StateNode `on:"SUBMIT->done:countAtLeast(many)"`
+		Done       FinalNode
+	}
+	_, err = FromStruct[BadMachine, ReflectTestContext](registry)
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) || !containsIssueCode(validationErr, ir.ErrCodeInvalidGuardArgs) {
+		t.Errorf("expected INVALID_GUARD_ARGS, got %v", err)
+	}
+}
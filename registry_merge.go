@@ -0,0 +1,147 @@
+package statekit
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MergePolicy decides what ActionRegistry.MergeWith does when both registries
+// define the same name
+type MergePolicy int
+
+const (
+	// MergeLastWins replaces an existing entry with the one being merged in
+	MergeLastWins MergePolicy = iota
+	// MergeErrorOnConflict rejects the merge with a *RegistryConflictError
+	MergeErrorOnConflict
+)
+
+// RegistryConflictError reports the names defined by both registries in a
+// merge with MergeErrorOnConflict
+type RegistryConflictError struct {
+	// Conflicts lists each conflicting entry as "<kind> <name>", e.g.
+	// "guard isValid", sorted
+	Conflicts []string
+}
+
+func (e *RegistryConflictError) Error() string {
+	return fmt.Sprintf("registry merge conflict: %s", strings.Join(e.Conflicts, ", "))
+}
+
+// Merge copies every entry of other into the registry, replacing entries with
+// the same name (last wins), so that a library of common actions and guards can
+// be shared between machines:
+//
+//	registry := statekit.NewActionRegistry[Order]().
+//	    Merge(common).
+//	    Merge(payments)
+//
+// Returns the registry for method chaining.
+func (r *ActionRegistry[C]) Merge(other *ActionRegistry[C]) *ActionRegistry[C] {
+	_ = r.MergeWith(other, MergeLastWins)
+	return r
+}
+
+// MergeWith copies every entry of other into the registry, resolving names
+// defined by both registries with the given policy. Plain and scheduler
+// actions share one namespace, as do plain, state, combined, and
+// parameterized guards, and two context validators conflict. With MergeErrorOnConflict, a conflict returns a
+// *RegistryConflictError and leaves the registry unchanged.
+func (r *ActionRegistry[C]) MergeWith(other *ActionRegistry[C], policy MergePolicy) error {
+	if policy == MergeErrorOnConflict {
+		if conflicts := r.conflicts(other); len(conflicts) > 0 {
+			return &RegistryConflictError{Conflicts: conflicts}
+		}
+	}
+
+	// An action name resolves to a single kind of action, so a merged action
+	// replaces an existing action of either kind
+	for name := range other.actionNames() {
+		delete(r.actions, name)
+		delete(r.schedulerActions, name)
+	}
+	maps.Copy(r.actions, other.actions)
+	maps.Copy(r.schedulerActions, other.schedulerActions)
+	maps.Copy(r.fallibleActions, other.fallibleActions)
+	maps.Copy(r.services, other.services)
+	maps.Copy(r.delays, other.delays)
+	maps.Copy(r.outputs, other.outputs)
+	maps.Copy(r.eventSchemas, other.eventSchemas)
+
+	// A guard name resolves to a single kind of guard, so a merged guard
+	// replaces an existing guard of any kind
+	for name := range other.guardNames() {
+		delete(r.guards, name)
+		delete(r.stateGuards, name)
+		delete(r.composites, name)
+		delete(r.guardFactories, name)
+	}
+	maps.Copy(r.guards, other.guards)
+	maps.Copy(r.stateGuards, other.stateGuards)
+	maps.Copy(r.composites, other.composites)
+	maps.Copy(r.guardFactories, other.guardFactories)
+
+	if other.contextValidator != nil {
+		r.contextValidator = other.contextValidator
+	}
+	return nil
+}
+
+// conflicts returns the entries defined by both registries as "<kind> <name>", sorted
+func (r *ActionRegistry[C]) conflicts(other *ActionRegistry[C]) []string {
+	var conflicts []string
+	conflicts = appendConflicts(conflicts, "action", r.actionNames(), other.actionNames())
+	conflicts = appendConflicts(conflicts, "fallible action", r.fallibleActions, other.fallibleActions)
+	conflicts = appendConflicts(conflicts, "guard", r.guardNames(), other.guardNames())
+	conflicts = appendConflicts(conflicts, "service", r.services, other.services)
+	conflicts = appendConflicts(conflicts, "delay", r.delays, other.delays)
+	conflicts = appendConflicts(conflicts, "output", r.outputs, other.outputs)
+	conflicts = appendConflicts(conflicts, "event schema", r.eventSchemas, other.eventSchemas)
+	if r.contextValidator != nil && other.contextValidator != nil {
+		conflicts = append(conflicts, "context validator")
+	}
+	slices.Sort(conflicts)
+	return conflicts
+}
+
+// actionNames returns the names of all plain and scheduler actions
+func (r *ActionRegistry[C]) actionNames() map[ActionType]bool {
+	names := make(map[ActionType]bool, len(r.actions)+len(r.schedulerActions))
+	for name := range r.actions {
+		names[name] = true
+	}
+	for name := range r.schedulerActions {
+		names[name] = true
+	}
+	return names
+}
+
+// guardNames returns the names of all plain, state, combined, and parameterized guards
+func (r *ActionRegistry[C]) guardNames() map[GuardType]bool {
+	names := make(map[GuardType]bool, len(r.guards)+len(r.stateGuards)+len(r.composites)+len(r.guardFactories))
+	for name := range r.guards {
+		names[name] = true
+	}
+	for name := range r.stateGuards {
+		names[name] = true
+	}
+	for name := range r.composites {
+		names[name] = true
+	}
+	for name := range r.guardFactories {
+		names[name] = true
+	}
+	return names
+}
+
+// appendConflicts appends "<kind> <name>" for each name present in both maps
+func appendConflicts[K ~string, V, W any](conflicts []string, kind string, existing map[K]V, merged map[K]W) []string {
+	for name := range merged {
+		if _, ok := existing[name]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s %s", kind, name))
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,79 @@
+package statekit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestActionRegistry_Merge tests building a machine from two merged registries
+func TestActionRegistry_Merge(t *testing.T) {
+	var calls []string
+	common := NewActionRegistry[ReflectTestContext]().
+		WithAction("onEnterIdle", func(ctx *ReflectTestContext, e Event) { calls = append(calls, "common") }).
+		WithAction("onExitIdle", func(ctx *ReflectTestContext, e Event) { calls = append(calls, "exitIdle") })
+	local := NewActionRegistry[ReflectTestContext]().
+		WithAction("onEnterIdle", func(ctx *ReflectTestContext, e Event) { calls = append(calls, "local") }).
+		WithAction("onEnterRunning", func(ctx *ReflectTestContext, e Event) { calls = append(calls, "enterRunning") })
+
+	registry := NewActionRegistry[ReflectTestContext]().Merge(common).Merge(local)
+	machine, err := FromStruct[ActionReflectMachine, ReflectTestContext](registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "START"})
+
+	// The later registry wins the conflicting name
+	expected := []string{"local", "exitIdle", "enterRunning"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+
+	// The merged registries are not modified
+	if _, ok := common.Action("onEnterRunning"); ok {
+		t.Error("expected the source registry to be unchanged")
+	}
+}
+
+// TestActionRegistry_MergeConflict tests that conflicting names are reported under the error policy
+func TestActionRegistry_MergeConflict(t *testing.T) {
+	pass := func(ctx ReflectTestContext, e Event) bool { return true }
+	registry := NewActionRegistry[ReflectTestContext]().
+		WithAction("log", func(ctx *ReflectTestContext, e Event) {}).
+		WithGuard("canStart", pass).
+		WithGuard("isReady", pass)
+	other := NewActionRegistry[ReflectTestContext]().
+		WithAction("log", func(ctx *ReflectTestContext, e Event) {}).
+		WithAction("notify", func(ctx *ReflectTestContext, e Event) {}).
+		WithGuardNot("canStart", "isReady")
+
+	err := registry.MergeWith(other, MergeErrorOnConflict)
+	var conflictErr *RegistryConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected RegistryConflictError, got %v", err)
+	}
+	expected := []string{"action log", "guard canStart"}
+	if !reflect.DeepEqual(conflictErr.Conflicts, expected) {
+		t.Errorf("expected conflicts %v, got %v", expected, conflictErr.Conflicts)
+	}
+
+	// A rejected merge leaves the registry unchanged
+	if _, ok := registry.Action("notify"); ok {
+		t.Error("expected no entries merged after a conflict")
+	}
+
+	// Under last-wins, the combined guard replaces the plain guard
+	if err := registry.MergeWith(other, MergeLastWins); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	guard, ok := registry.Guard("canStart")
+	if !ok || guard(ReflectTestContext{}, Event{}) {
+		t.Error("expected canStart to resolve to the merged not(isReady) guard")
+	}
+	if _, ok := registry.Action("notify"); !ok {
+		t.Error("expected notify to be merged")
+	}
+}
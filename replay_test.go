@@ -0,0 +1,75 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type replayContext struct {
+	Cars int
+}
+
+// TestReplay_TrafficLight tests replaying the traffic light sequence from a fresh interpreter
+func TestReplay_TrafficLight(t *testing.T) {
+	machine, err := NewMachine[replayContext]("traffic").
+		WithInitial("green").
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").On("TIMER").Target("red").Done().
+		State("red").On("TIMER").Target("green").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	trace := interp.Replay([]Event{{Type: "TIMER"}, {Type: "TIMER"}, {Type: "TIMER"}})
+
+	expected := []StateID{"green", "yellow", "red", "green"}
+	if !slices.Equal(trace, expected) {
+		t.Errorf("Expected trace %v, got %v", expected, trace)
+	}
+}
+
+// TestReplay_GuardsAndDelays tests that guards are respected and delayed transitions are not fired
+func TestReplay_GuardsAndDelays(t *testing.T) {
+	machine, err := NewMachine[replayContext]("crossing").
+		WithInitial("waiting").
+		WithAction("arrive", func(ctx *replayContext, e Event) { ctx.Cars++ }).
+		WithGuard("hasCars", func(ctx replayContext, e Event) bool { return ctx.Cars > 0 }).
+		State("waiting").
+		On("CAR").Target("waiting").Internal().Do("arrive").
+		On("OPEN").Target("open").Guard("hasCars").
+		Done().
+		State("open").
+		After(10 * time.Second).Target("closed").
+		On("CLOSE").Target("closed").
+		Done().
+		State("closed").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+	defer interp.Stop()
+
+	trace := interp.Replay([]Event{{Type: "OPEN"}, {Type: "CAR"}, {Type: "OPEN"}, {Type: "UNKNOWN"}})
+
+	// The first OPEN is blocked by the guard, and the delay out of 'open' never fires
+	expected := []StateID{"waiting", "waiting", "waiting", "open", "open"}
+	if !slices.Equal(trace, expected) {
+		t.Errorf("Expected trace %v, got %v", expected, trace)
+	}
+
+	// Replay continues from the current state of a started interpreter
+	trace = interp.Replay([]Event{{Type: "CLOSE"}})
+	expected = []StateID{"open", "closed"}
+	if !slices.Equal(trace, expected) {
+		t.Errorf("Expected trace %v, got %v", expected, trace)
+	}
+}
@@ -0,0 +1,126 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type resetContext struct {
+	Visits []StateID
+}
+
+// TestReset_FromFinalState tests that Reset re-enters the initial leaf with a fresh context
+func TestReset_FromFinalState(t *testing.T) {
+	machine, err := NewMachine[resetContext]("reset").
+		WithInitial("draft").
+		WithAction("visitDraft", func(ctx *resetContext, e Event) { ctx.Visits = append(ctx.Visits, "draft") }).
+		State("draft").OnEntry("visitDraft").On("NEXT").Target("review").Done().
+		State("review").On("FINISH").Target("finished").Done().
+		State("finished").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithContextCloner(DeepCopy[resetContext]))
+	interp.Start()
+
+	var notified []StateID
+	interp.Subscribe(func(s State[resetContext]) { notified = append(notified, s.Value) })
+
+	interp.Send(Event{Type: "NEXT"})
+	interp.Send(Event{Type: "FINISH"})
+	if !interp.Done() {
+		t.Fatal("Expected machine to be done")
+	}
+
+	interp.Reset()
+
+	state := interp.State()
+	if state.Value != "draft" {
+		t.Errorf("Expected initial leaf 'draft', got %s", state.Value)
+	}
+	if !slices.Equal(state.Context.Visits, []StateID{"draft"}) {
+		t.Errorf("Expected a fresh context with the entry action applied, got %v", state.Context.Visits)
+	}
+	if interp.Done() {
+		t.Error("Expected machine not to be done after reset")
+	}
+	if notified[len(notified)-1] != "draft" {
+		t.Errorf("Expected subscribers to be notified of the reset, got %v", notified)
+	}
+}
+
+// TestReset_ClearsTimersAndHistory tests that Reset cancels timers and forgets history
+func TestReset_ClearsTimersAndHistory(t *testing.T) {
+	machine, err := NewMachine[resetContext]("reset_history").
+		WithInitial("work").
+		State("work").
+		WithInitial("draft").
+		History("hist").Default("draft").End().
+		State("draft").On("NEXT").Target("review").End().End().
+		State("review").After(time.Minute).Target("expired").End().
+		On("BREAK").Target("paused").
+		Done().
+		State("paused").On("RESUME").Target("hist").Done().
+		State("expired").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	// Record 'review' as the history of 'work'
+	interp.Send(Event{Type: "NEXT"})
+	interp.Send(Event{Type: "BREAK"})
+	interp.Send(Event{Type: "RESUME"})
+	if interp.State().Value != "review" || clock.Pending() != 1 {
+		t.Fatalf("Expected 'review' with a pending timer, got %s with %d", interp.State().Value, clock.Pending())
+	}
+
+	interp.Reset()
+	if clock.Pending() != 0 {
+		t.Errorf("Expected timers to be canceled, got %d pending", clock.Pending())
+	}
+	clock.Advance(time.Minute)
+	if interp.State().Value != "draft" {
+		t.Errorf("Expected 'draft' after reset, got %s", interp.State().Value)
+	}
+
+	// Without history, the history state falls back to its default
+	interp.Send(Event{Type: "BREAK"})
+	interp.Send(Event{Type: "RESUME"})
+	if interp.State().Value != "draft" {
+		t.Errorf("Expected history default 'draft', got %s", interp.State().Value)
+	}
+}
+
+// TestReset_StoppedInterpreter tests that Reset starts a stopped interpreter
+func TestReset_StoppedInterpreter(t *testing.T) {
+	machine, err := NewMachine[resetContext]("reset_stopped").
+		WithInitial("draft").
+		State("draft").On("NEXT").Target("review").Done().
+		State("review").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Reset()
+
+	if interp.State().Value != "draft" {
+		t.Errorf("Expected 'draft', got %s", interp.State().Value)
+	}
+	interp.Send(Event{Type: "NEXT"})
+	if interp.State().Value != "review" {
+		t.Errorf("Expected 'review', got %s", interp.State().Value)
+	}
+	interp.Stop()
+}
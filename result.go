@@ -0,0 +1,88 @@
+package statekit
+
+// TransitionResult describes how the interpreter handled an event sent with SendResult
+type TransitionResult struct {
+	// Handled is true if the event caused a transition
+	Handled bool
+	// Queued is true if the event was sent while another event was being
+	// processed (for example from an action). It is processed later, so the
+	// outcome is not known and the other fields are empty.
+	Queued bool
+	// Deferred is true if the event was not handled but held by an active state
+	// that defers it (see StateBuilder.Defer); it is re-delivered after the
+	// next transition
+	Deferred bool
+	// From is the state before the event (the parallel state ID when in a parallel state)
+	From StateID
+	// To is the state after the event and any eventless transitions it triggered
+	To StateID
+	// Actions lists the exit, transition, and entry actions executed, in order
+	Actions []ActionType
+	// Regions lists the parallel regions that transitioned, in declaration order
+	Regions []RegionTransition
+	// Err is the error returned by a fallible action that vetoed a transition.
+	// A vetoed transition leaves the state unchanged, so Handled is false unless
+	// another parallel region transitioned. With WithStrictPayloads or
+	// WithStrictEvents, Err also reports a rejected payload or an unhandled event.
+	// A panicking fallible action, or with WithAbortOnPanic any panicking action,
+	// is reported with an error wrapping ErrActionPanic.
+	Err error
+}
+
+// RegionTransition describes a transition taken within one parallel region
+type RegionTransition struct {
+	Region StateID
+	From   StateID
+	To     StateID
+}
+
+// SendResult processes an event like Send and reports what happened.
+// Unhandled events return a result with Handled set to false.
+func (i *Interpreter[C]) SendResult(event Event) TransitionResult {
+	result := &TransitionResult{}
+	if !i.process(i.sendStep(event, result)) {
+		return TransitionResult{Queued: true}
+	}
+	return *result
+}
+
+// SendAll processes the events in order like SendResult and returns one result
+// per event. The batch claims the event queue once: each event, and any events it
+// raises, is processed to completion before the next one, so the final state is
+// the same as sending the events one at a time.
+//
+// If SendAll is called while another event is being processed, every event is
+// queued and each result has Queued set.
+func (i *Interpreter[C]) SendAll(events ...Event) []TransitionResult {
+	results := make([]TransitionResult, len(events))
+	steps := make([]func() bool, len(events))
+	for n, event := range events {
+		steps[n] = i.sendStep(event, &results[n])
+	}
+	if !i.process(steps...) {
+		for n := range results {
+			results[n] = TransitionResult{Queued: true}
+		}
+	}
+	return results
+}
+
+// sendStep returns the queue step that sends the event and records its outcome in result
+func (i *Interpreter[C]) sendStep(event Event, result *TransitionResult) func() bool {
+	return func() bool {
+		result.From = i.state.Value
+		i.result = result
+		result.Handled = i.sendUnlocked(event)
+		i.result = nil
+		if !result.Handled && !result.Deferred && result.Err == nil && i.opts.strictEvents && i.started {
+			if err := i.checkHandled(event); err != nil {
+				result.Err = err
+				if i.opts.unhandledEvent != nil {
+					i.opts.unhandledEvent(event, err)
+				}
+			}
+		}
+		result.To = i.state.Value
+		return result.Handled
+	}
+}
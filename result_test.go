@@ -0,0 +1,232 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestSendResult_Transition tests the reported states and actions of a transition
+func TestSendResult_Transition(t *testing.T) {
+	noop := func(ctx *struct{}, e Event) {}
+
+	machine, err := NewMachine[struct{}]("result").
+		WithInitial("idle").
+		WithAction("exitIdle", noop).
+		WithAction("start", noop).
+		WithAction("enterRunning", noop).
+		WithGuard("never", func(ctx struct{}, e Event) bool { return false }).
+		State("idle").
+		OnExit("exitIdle").
+		On("START").Target("running").Do("start").
+		On("SKIP").Target("running").Guard("never").
+		Done().
+		State("running").
+		OnEntry("enterRunning").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	// Unknown event
+	result := interp.SendResult(Event{Type: "UNKNOWN"})
+	if result.Handled || result.From != "idle" || result.To != "idle" || len(result.Actions) != 0 {
+		t.Errorf("Expected unhandled result in 'idle', got %+v", result)
+	}
+
+	// Guard fails
+	if result := interp.SendResult(Event{Type: "SKIP"}); result.Handled {
+		t.Errorf("Expected guarded event to be unhandled, got %+v", result)
+	}
+
+	result = interp.SendResult(Event{Type: "START"})
+	if !result.Handled || result.Queued {
+		t.Fatalf("Expected handled result, got %+v", result)
+	}
+	if result.From != "idle" || result.To != "running" {
+		t.Errorf("Expected idle → running, got %s → %s", result.From, result.To)
+	}
+	expected := []ActionType{"exitIdle", "start", "enterRunning"}
+	if !slices.Equal(result.Actions, expected) {
+		t.Errorf("Expected actions %v, got %v", expected, result.Actions)
+	}
+}
+
+// TestSendResult_ParallelRegions tests that results list the regions that transitioned
+func TestSendResult_ParallelRegions(t *testing.T) {
+	machine, err := NewMachine[struct{}]("result_parallel").
+		WithInitial("editor").
+		State("editor").Parallel().
+		Region("bold").
+		WithInitial("bold_off").
+		State("bold_off").On("TOGGLE_BOLD").Target("bold_on").On("RESET").Target("bold_off").EndState().
+		State("bold_on").On("RESET").Target("bold_off").EndState().
+		EndRegion().
+		Region("italic").
+		WithInitial("italic_off").
+		State("italic_off").On("RESET").Target("italic_off").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "TOGGLE_BOLD"})
+	expected := []RegionTransition{{Region: "bold", From: "bold_off", To: "bold_on"}}
+	if !result.Handled || !slices.Equal(result.Regions, expected) {
+		t.Errorf("Expected regions %v, got %+v", expected, result)
+	}
+	if result.From != "editor" || result.To != "editor" {
+		t.Errorf("Expected editor → editor, got %s → %s", result.From, result.To)
+	}
+
+	result = interp.SendResult(Event{Type: "RESET"})
+	expected = []RegionTransition{
+		{Region: "bold", From: "bold_on", To: "bold_off"},
+		{Region: "italic", From: "italic_off", To: "italic_off"},
+	}
+	if !slices.Equal(result.Regions, expected) {
+		t.Errorf("Expected regions %v, got %v", expected, result.Regions)
+	}
+}
+
+// TestSendResult_Queued tests that events sent during processing report as queued
+func TestSendResult_Queued(t *testing.T) {
+	var interp *Interpreter[struct{}]
+	var raised TransitionResult
+
+	machine, err := NewMachine[struct{}]("result_queued").
+		WithInitial("a").
+		WithAction("raise", func(ctx *struct{}, e Event) {
+			raised = interp.SendResult(Event{Type: "NEXT"})
+		}).
+		State("a").On("GO").Target("b").Do("raise").Done().
+		State("b").On("NEXT").Target("c").Done().
+		State("c").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp = NewInterpreter(machine)
+	interp.Start()
+	result := interp.SendResult(Event{Type: "GO"})
+
+	if !raised.Queued || raised.Handled {
+		t.Errorf("Expected raised event to be queued, got %+v", raised)
+	}
+	// The outer result covers only the GO event; NEXT runs afterwards
+	if result.To != "b" {
+		t.Errorf("Expected outer result to end in 'b', got %s", result.To)
+	}
+	if interp.State().Value != "c" {
+		t.Errorf("Expected state 'c', got %s", interp.State().Value)
+	}
+}
+
+// TestSendAll_MatchesIndividualSends tests that a batch ends in the same state as individual sends
+func TestSendAll_MatchesIndividualSends(t *testing.T) {
+	// FINISH raises a done event that must be processed before APPROVE is handled
+	machine, err := NewMachine[struct{}]("batch").
+		WithInitial("idle").
+		State("idle").On("START").Target("working").Done().
+		State("working").
+		WithInitial("busy").
+		State("busy").On("FINISH").Target("finished").End().End().
+		State("finished").Final().End().
+		OnDone().Target("review").
+		Done().
+		State("review").On("APPROVE").Target("approved").Done().
+		State("approved").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	events := []Event{{Type: "START"}, {Type: "UNKNOWN"}, {Type: "FINISH"}, {Type: "APPROVE"}}
+
+	single := NewInterpreter(machine)
+	single.Start()
+	for _, e := range events {
+		single.Send(e)
+	}
+
+	batch := NewInterpreter(machine)
+	batch.Start()
+	results := batch.SendAll(events...)
+
+	if batch.State().Value != single.State().Value || batch.State().Value != "approved" {
+		t.Errorf("Expected both interpreters in 'approved', got batch %s and individual %s",
+			batch.State().Value, single.State().Value)
+	}
+	if len(results) != len(events) {
+		t.Fatalf("Expected %d results, got %d", len(events), len(results))
+	}
+	handled := []bool{true, false, true, true}
+	for n, result := range results {
+		if result.Handled != handled[n] || result.Queued {
+			t.Errorf("Expected result %d handled=%v, got %+v", n, handled[n], result)
+		}
+	}
+	if results[2].From != "busy" || results[2].To != "finished" {
+		t.Errorf("Expected busy → finished, got %s → %s", results[2].From, results[2].To)
+	}
+	if results[3].From != "review" || results[3].To != "approved" {
+		t.Errorf("Expected the done event to be processed before APPROVE, got %s → %s", results[3].From, results[3].To)
+	}
+}
+
+// TestSendAll_Queued tests that a batch sent while processing an event is queued
+func TestSendAll_Queued(t *testing.T) {
+	var results []TransitionResult
+	var interp *Interpreter[struct{}]
+
+	machine, err := NewMachine[struct{}]("batch_queued").
+		WithInitial("a").
+		WithAction("sendBatch", func(ctx *struct{}, e Event) {
+			results = interp.SendAll(Event{Type: "NEXT"}, Event{Type: "NEXT"})
+		}).
+		State("a").On("GO").Target("b").Do("sendBatch").Done().
+		State("b").On("NEXT").Target("c").Done().
+		State("c").On("NEXT").Target("d").Done().
+		State("d").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp = NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "GO"})
+
+	if len(results) != 2 || !results[0].Queued || !results[1].Queued {
+		t.Errorf("Expected two queued results, got %+v", results)
+	}
+	if interp.State().Value != "d" {
+		t.Errorf("Expected queued events to be processed, got %s", interp.State().Value)
+	}
+}
+
+// TestSendAll_Empty tests that an empty batch returns no results
+func TestSendAll_Empty(t *testing.T) {
+	machine, err := NewMachine[struct{}]("batch_empty").
+		WithInitial("idle").
+		State("idle").On("START").Target("working").Done().
+		State("working").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	if results := interp.SendAll(); len(results) != 0 {
+		t.Errorf("Expected no results, got %+v", results)
+	}
+}
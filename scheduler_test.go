@@ -0,0 +1,130 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+type stockContext struct {
+	InStock bool
+	Log     []string
+}
+
+// TestSchedulerAction_RaiseFromEntry tests that an event raised by an entry
+// action is processed after the transition that entered the state
+func TestSchedulerAction_RaiseFromEntry(t *testing.T) {
+	// The entry action of 'checking' raises IN_STOCK when the item is in stock
+	machine, err := NewMachine[stockContext]("stock").
+		WithInitial("idle").
+		WithContext(stockContext{InStock: true}).
+		WithSchedulerAction("checkStock", func(ctx *stockContext, e Event, sched Scheduler) {
+			ctx.Log = append(ctx.Log, "checkStock:"+string(e.Type))
+			if ctx.InStock {
+				sched.Raise(Event{Type: "IN_STOCK"})
+			}
+		}).
+		WithAction("enterChecking", func(ctx *stockContext, e Event) {
+			ctx.Log = append(ctx.Log, "enterChecking:"+string(e.Type))
+		}).
+		WithAction("enterReserved", func(ctx *stockContext, e Event) {
+			ctx.Log = append(ctx.Log, "enterReserved:"+string(e.Type))
+		}).
+		State("idle").On("ORDER").Target("checking").Done().
+		State("checking").
+		OnEntry("checkStock").OnEntry("enterChecking").
+		On("IN_STOCK").Target("reserved").
+		Done().
+		State("reserved").OnEntry("enterReserved").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	result := interp.SendResult(Event{Type: "ORDER"})
+
+	if !interp.Matches("reserved") {
+		t.Fatalf("Expected the raised event to reach 'reserved', got %s", interp.State().Value)
+	}
+	if result.To != "checking" {
+		t.Errorf("Expected the ORDER result to end in 'checking', got %s", result.To)
+	}
+	expected := []string{"checkStock:ORDER", "enterChecking:ORDER", "enterReserved:IN_STOCK"}
+	if log := interp.State().Context.Log; !slices.Equal(log, expected) {
+		t.Errorf("Expected log %v, got %v", expected, log)
+	}
+
+	// Without stock, nothing is raised
+	interp.Reset()
+	interp.UpdateContext(func(ctx *stockContext) { ctx.InStock = false })
+	interp.Send(Event{Type: "ORDER"})
+	if !interp.Matches("checking") {
+		t.Errorf("Expected to stay in 'checking', got %s", interp.State().Value)
+	}
+}
+
+// TestSchedulerAction_RaiseOnStart tests raising from the entry action of the initial state
+func TestSchedulerAction_RaiseOnStart(t *testing.T) {
+	machine, err := NewMachine[stockContext]("stock").
+		WithInitial("checking").
+		WithSchedulerAction("checkStock", func(ctx *stockContext, e Event, sched Scheduler) {
+			sched.Raise(Event{Type: "IN_STOCK"})
+		}).
+		State("checking").
+		OnEntry("checkStock").
+		On("IN_STOCK").Target("reserved").
+		Done().
+		State("reserved").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	var states []StateID
+	interp := NewInterpreter(machine)
+	interp.Subscribe(func(s State[stockContext]) { states = append(states, s.Value) })
+	interp.Start()
+
+	if !slices.Equal(states, []StateID{"checking", "reserved"}) {
+		t.Errorf("Expected listeners to see 'checking' then 'reserved', got %v", states)
+	}
+}
+
+// TestSchedulerAction_Registry tests scheduler actions in the reflection DSL
+// and when linking a serialized machine
+func TestSchedulerAction_Registry(t *testing.T) {
+	registry := NewActionRegistry[stockContext]().
+		WithSchedulerAction("checkStock", func(ctx *stockContext, e Event, sched Scheduler) {
+			sched.Raise(Event{Type: "IN_STOCK"})
+		})
+
+	type Machine struct {
+		MachineDef `id:"stock" initial:"checking"`
+		Checking   StateNode `on:"IN_STOCK->reserved" entry:"checkStock"`
+		Reserved   StateNode
+	}
+	machine, err := FromStruct[Machine](registry)
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	data, err := MarshalConfig(machine)
+	if err != nil {
+		t.Fatalf("Failed to marshal machine: %v", err)
+	}
+	restored, err := UnmarshalConfig(data, registry)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal machine: %v", err)
+	}
+
+	for name, m := range map[string]*ir.MachineConfig[stockContext]{"reflection": machine, "serialized": restored} {
+		interp := NewInterpreter(m)
+		interp.Start()
+		if !interp.Matches("reserved") {
+			t.Errorf("%s: expected the raised event to reach 'reserved', got %s", name, interp.State().Value)
+		}
+	}
+}
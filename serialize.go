@@ -0,0 +1,71 @@
+package statekit
+
+import (
+	"fmt"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// MarshalConfig serializes the structure of a machine as JSON for storage,
+// tooling, and diffing. States, transitions, delays, and history are stored
+// faithfully; actions, guards, services, and delays are stored by name only.
+// The context must be JSON-marshalable.
+func MarshalConfig[C any](machine *ir.MachineConfig[C]) ([]byte, error) {
+	return ir.MarshalConfig(machine)
+}
+
+// UnmarshalConfig rebuilds a machine serialized by MarshalConfig, linking each
+// name to its implementation in the registry. Combined guards registered with
+// WithGuardAnd, WithGuardOr, and WithGuardNot are linked as well.
+// Names missing from the registry are reported as validation errors.
+func UnmarshalConfig[C any](data []byte, registry *ActionRegistry[C]) (*ir.MachineConfig[C], error) {
+	// Resolve the registry into a scratch machine so combined guards are composed
+	var ctx C
+	scratch := ir.NewMachineConfig[C]("", "", ctx)
+	errs := &ir.ValidationError{}
+	if registry != nil {
+		for name, action := range registry.actions {
+			scratch.Actions[name] = action
+		}
+		for name, action := range registry.fallibleActions {
+			scratch.FallibleActions[name] = action
+		}
+		for name, action := range registry.schedulerActions {
+			scratch.SchedulerActions[name] = action
+		}
+		for name, guard := range registry.guards {
+			scratch.Guards[name] = guard
+		}
+		for name, guard := range registry.stateGuards {
+			scratch.StateGuards[name] = guard
+		}
+		for name, service := range registry.services {
+			scratch.Services[name] = service
+		}
+		for name, resolver := range registry.delays {
+			scratch.Delays[name] = resolver
+		}
+		instantiateGuards(scratch, registry.guardFactories, guardReferences(scratch, registry.composites), errs)
+		composeGuards(scratch, registry.composites, errs)
+	}
+	if errs.HasIssues() {
+		return nil, fmt.Errorf("validation failed: %w", errs)
+	}
+
+	linked := ir.Registry[C]{
+		Actions:          scratch.Actions,
+		FallibleActions:  scratch.FallibleActions,
+		SchedulerActions: scratch.SchedulerActions,
+		Guards:           scratch.Guards,
+		StateGuards:      scratch.StateGuards,
+		Services:         scratch.Services,
+		Delays:           scratch.Delays,
+	}
+	if registry != nil {
+		linked.GuardFactories = registry.guardFactories
+		linked.Outputs = registry.outputs
+		linked.EventSchemas = registry.eventSchemas
+		linked.ContextValidator = registry.contextValidator
+	}
+	return ir.UnmarshalConfig(data, linked)
+}
@@ -0,0 +1,238 @@
+package statekit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type mediaContext struct {
+	Title  string `json:"title"`
+	Volume int    `json:"volume"`
+}
+
+// mediaRegistry provides the implementations of the media machine's names
+func mediaRegistry() *ActionRegistry[mediaContext] {
+	noop := func(ctx *mediaContext, e Event) {}
+	return NewActionRegistry[mediaContext]().
+		WithAction("log", noop).
+		WithAction("louder", func(ctx *mediaContext, e Event) { ctx.Volume++ }).
+		WithFallibleAction("checkLicense", func(ctx *mediaContext, e Event) error { return nil }).
+		WithGuard("hasTitle", func(ctx mediaContext, e Event) bool { return ctx.Title != "" }).
+		WithGuard("isLoud", func(ctx mediaContext, e Event) bool { return ctx.Volume > 10 }).
+		WithGuardAnd("canPlay", "hasTitle", "isLoud").
+		WithDelay("idleTimeout", func(ctx mediaContext, e Event) time.Duration { return time.Minute }).
+		WithService("prefetch", func(ctx context.Context, c mediaContext, e Event) (any, error) {
+			return nil, nil
+		})
+}
+
+// TestMarshalConfig_RoundTrip tests that the machine structure survives serialization
+func TestMarshalConfig_RoundTrip(t *testing.T) {
+	// A machine using parallel, history, and delayed transitions
+	noop := func(ctx *mediaContext, e Event) {}
+	original, err := NewMachine[mediaContext]("media").
+		WithInitial("library").
+		WithContext(mediaContext{Title: "intro", Volume: 5}).
+		WithAction("log", noop).
+		WithAction("louder", func(ctx *mediaContext, e Event) { ctx.Volume++ }).
+		WithFallibleAction("checkLicense", func(ctx *mediaContext, e Event) error { return nil }).
+		WithGuard("hasTitle", func(ctx mediaContext, e Event) bool { return ctx.Title != "" }).
+		WithGuard("isLoud", func(ctx mediaContext, e Event) bool { return ctx.Volume > 10 }).
+		WithGuardAnd("canPlay", "hasTitle", "isLoud").
+		WithDelay("idleTimeout", func(ctx mediaContext, e Event) time.Duration { return time.Minute }).
+		WithService("prefetch", func(ctx context.Context, c mediaContext, e Event) (any, error) {
+			return nil, nil
+		}).
+		State("library").
+		Meta("title", "Library").
+		OnEntry("log").
+		Invoke("prefetch").
+		Defer("PAUSE").
+		On("PLAY").Target("player").Guard("hasTitle").DoFallible("checkLicense").
+		On("RESUME").Target("hist").
+		AfterDelay("idleTimeout").Target("sleeping").Repeat().
+		Done().
+		State("player").Parallel().
+		Region("playback").
+		WithInitial("playing").
+		State("playing").
+		On("PAUSE").Target("paused").
+		After(30*time.Second).Target("paused").
+		EndState().
+		State("paused").On("PAUSE").Target("playing").Bubble().EndState().
+		EndRegion().
+		Region("volume").
+		WithInitial("normal").
+		State("normal").On("UP").Target("normal").Internal().Do("louder").EndState().
+		EndRegion().
+		On("STOP").Target("library").Guard("canPlay").DoWhen("isLoud", "log").
+		Done().
+		State("history_holder").
+		WithInitial("first").
+		WithInitialGuarded("isLoud", "second").
+		History("hist").Deep().Default("first").End().
+		State("first").On("NEXT").Target("second").End().End().
+		State("second").Always().Target("sleeping").Guard("isLoud").End().
+		Done().
+		State("sleeping").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	data, err := MarshalConfig(original)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	restored, err := UnmarshalConfig(data, mediaRegistry())
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	if restored.ID != original.ID || restored.Initial != original.Initial {
+		t.Errorf("Expected id %q initial %q, got %q %q", original.ID, original.Initial, restored.ID, restored.Initial)
+	}
+	if restored.Context != original.Context {
+		t.Errorf("Expected context %+v, got %+v", original.Context, restored.Context)
+	}
+	if !reflect.DeepEqual(restored.States, original.States) {
+		for id, state := range original.States {
+			if !reflect.DeepEqual(restored.States[id], state) {
+				t.Errorf("State %q differs:\nexpected %+v\ngot      %+v", id, state, restored.States[id])
+			}
+		}
+		t.Fatal("Restored states differ from the original")
+	}
+
+	for name := range original.Guards {
+		if restored.GetGuard(name) == nil {
+			t.Errorf("Expected guard %q to be linked", name)
+		}
+	}
+
+	// Serialization is deterministic
+	again, err := MarshalConfig(restored)
+	if err != nil {
+		t.Fatalf("Failed to marshal restored config: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("Expected identical JSON after round trip:\n%s\n%s", data, again)
+	}
+}
+
+// TestMarshalConfig_RestoredMachineRuns tests that a restored machine behaves like the original
+func TestMarshalConfig_RestoredMachineRuns(t *testing.T) {
+	original, err := NewMachine[mediaContext]("media").
+		WithInitial("library").
+		WithContext(mediaContext{Title: "intro", Volume: 5}).
+		WithAction("louder", func(ctx *mediaContext, e Event) { ctx.Volume++ }).
+		WithDelay("idleTimeout", func(ctx mediaContext, e Event) time.Duration { return time.Minute }).
+		State("library").
+		On("PLAY").Target("player").
+		AfterDelay("idleTimeout").Target("sleeping").
+		Done().
+		State("player").Parallel().
+		Region("playback").
+		WithInitial("playing").
+		State("playing").EndState().
+		EndRegion().
+		Region("volume").
+		WithInitial("normal").
+		State("normal").On("UP").Target("normal").Internal().Do("louder").EndState().
+		EndRegion().
+		Done().
+		State("sleeping").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	data, err := MarshalConfig(original)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	machine, err := UnmarshalConfig(data, mediaRegistry())
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+	defer interp.Stop()
+
+	// The named delay is linked to its resolver
+	clock.Advance(time.Minute)
+	if interp.State().Value != "sleeping" {
+		t.Errorf("Expected delayed transition to 'sleeping', got %s", interp.State().Value)
+	}
+
+	interp = NewInterpreter(machine)
+	interp.Start()
+	defer interp.Stop()
+
+	interp.Send(Event{Type: "PLAY"})
+	if !interp.Matches("player") || interp.State().ActiveInParallel["playback"] != "playing" {
+		t.Fatalf("Expected to be playing, got %+v", interp.State())
+	}
+
+	// The internal transition keeps its action
+	interp.Send(Event{Type: "UP"})
+	if interp.State().Context.Volume != 6 {
+		t.Errorf("Expected volume 6, got %d", interp.State().Context.Volume)
+	}
+}
+
+// TestUnmarshalConfig_MissingRegistryEntries tests that unlinked names are reported
+func TestUnmarshalConfig_MissingRegistryEntries(t *testing.T) {
+	original, err := NewMachine[mediaContext]("media").
+		WithInitial("library").
+		WithAction("log", func(ctx *mediaContext, e Event) {}).
+		WithAction("louder", func(ctx *mediaContext, e Event) { ctx.Volume++ }).
+		WithGuard("hasTitle", func(ctx mediaContext, e Event) bool { return ctx.Title != "" }).
+		WithDelay("idleTimeout", func(ctx mediaContext, e Event) time.Duration { return time.Minute }).
+		WithService("prefetch", func(ctx context.Context, c mediaContext, e Event) (any, error) {
+			return nil, nil
+		}).
+		State("library").
+		OnEntry("log").
+		Invoke("prefetch").
+		On("PLAY").Target("playing").Guard("hasTitle").Do("louder").
+		AfterDelay("idleTimeout").Target("sleeping").
+		Done().
+		State("playing").Done().
+		State("sleeping").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+	data, err := MarshalConfig(original)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	registry := NewActionRegistry[mediaContext]().
+		WithAction("log", func(ctx *mediaContext, e Event) {})
+
+	_, err = UnmarshalConfig(data, registry)
+	if err == nil {
+		t.Fatal("Expected error for missing registry entries")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %T", err)
+	}
+	for _, code := range []string{ir.ErrCodeMissingAction, ir.ErrCodeMissingGuard, ir.ErrCodeMissingDelay, ir.ErrCodeMissingService} {
+		if !containsIssueCode(validationErr, code) {
+			t.Errorf("Expected %s issue, got %v", code, validationErr)
+		}
+	}
+}
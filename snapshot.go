@@ -0,0 +1,177 @@
+package statekit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// Snapshot captures the persistent position of an interpreter so it can be
+// stored and later restored. Snapshots are JSON-marshalable as long as the
+// context type C is.
+type Snapshot[C any] struct {
+	// Value is the current leaf state (or parallel state when in parallel)
+	Value StateID `json:"value"`
+	// Context is the current context value
+	Context C `json:"context"`
+	// ActiveInParallel maps region ID to its current leaf state
+	ActiveInParallel map[StateID]StateID `json:"activeInParallel,omitempty"`
+	// ShallowHistory maps compound state ID to its last active child
+	ShallowHistory map[StateID]StateID `json:"shallowHistory,omitempty"`
+	// DeepHistory maps compound state ID to its last active leaf
+	DeepHistory map[StateID]StateID `json:"deepHistory,omitempty"`
+	// Output is the output of the machine once it reached its top-level final state
+	Output any `json:"output,omitempty"`
+}
+
+// Snapshot returns the current position, context, and history of the interpreter.
+// The returned maps are copies and do not alias interpreter state.
+func (i *Interpreter[C]) Snapshot() Snapshot[C] {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.snapshotUnlocked()
+}
+
+// snapshotUnlocked returns the current position, context, and history (caller must hold mu)
+func (i *Interpreter[C]) snapshotUnlocked() Snapshot[C] {
+	return Snapshot[C]{
+		Value:            i.state.Value,
+		Context:          i.copyContextUnlocked(),
+		ActiveInParallel: copyStateMap(i.state.ActiveInParallel),
+		ShallowHistory:   copyStateMap(i.shallowHistory),
+		DeepHistory:      copyStateMap(i.deepHistory),
+		Output:           i.output,
+	}
+}
+
+// Restore rebuilds the interpreter from a snapshot without running entry actions.
+// Delayed transitions of the active states are rescheduled with their full delay,
+// and services invoked by the active states are started again. Named delays are
+// resolved again with the restored context and an empty event.
+// The output of a snapshot in a top-level final state is restored; if the
+// snapshot has none, it is computed again with the restored context and an
+// empty event. The interpreter is considered started after a successful restore.
+func (i *Interpreter[C]) Restore(snapshot Snapshot[C]) error {
+	return i.RestoreWithElapsed(snapshot, 0)
+}
+
+// RestoreWithElapsed is like Restore, but treats elapsed as time already spent in
+// the restored states. Delayed transitions are rescheduled with the remaining time,
+// and fire immediately if their delay has already passed.
+func (i *Interpreter[C]) RestoreWithElapsed(snapshot Snapshot[C], elapsed time.Duration) error {
+	i.mu.Lock()
+
+	stateConfig := i.machine.GetState(snapshot.Value)
+	if stateConfig == nil {
+		i.mu.Unlock()
+		return fmt.Errorf("restore: state %q not found", snapshot.Value)
+	}
+	for regionID, leafID := range snapshot.ActiveInParallel {
+		if i.machine.GetState(regionID) == nil {
+			i.mu.Unlock()
+			return fmt.Errorf("restore: region %q not found", regionID)
+		}
+		if i.machine.GetState(leafID) == nil {
+			i.mu.Unlock()
+			return fmt.Errorf("restore: state %q not found in region %q", leafID, regionID)
+		}
+	}
+
+	i.restoreUnlocked(snapshot, stateConfig, elapsed)
+	state := i.copyStateUnlocked()
+	i.mu.Unlock()
+
+	i.notify(state)
+	return nil
+}
+
+// restoreUnlocked rebuilds the interpreter from a validated snapshot whose
+// state is stateConfig (caller must hold mu)
+func (i *Interpreter[C]) restoreUnlocked(snapshot Snapshot[C], stateConfig *ir.StateConfig, elapsed time.Duration) {
+	// Drop any timers and services from a previous run
+	i.cancelAllTimers()
+	i.cancelAllInvocations()
+
+	i.state = State[C]{
+		Value:            snapshot.Value,
+		Context:          snapshot.Context,
+		ActiveInParallel: copyStateMap(snapshot.ActiveInParallel),
+	}
+	if i.state.ActiveInParallel == nil {
+		i.state.ActiveInParallel = make(map[ir.StateID]ir.StateID)
+	}
+	i.shallowHistory = copyStateMap(snapshot.ShallowHistory)
+	if i.shallowHistory == nil {
+		i.shallowHistory = make(map[ir.StateID]ir.StateID)
+	}
+	i.deepHistory = copyStateMap(snapshot.DeepHistory)
+	if i.deepHistory == nil {
+		i.deepHistory = make(map[ir.StateID]ir.StateID)
+	}
+
+	i.currentParallel = ""
+	if stateConfig.IsParallel() {
+		i.currentParallel = snapshot.Value
+	}
+	i.started = true
+	i.output, i.hasOutput = nil, false
+	if output := i.machine.GetOutput(snapshot.Value); output != nil && stateConfig.IsFinal() && stateConfig.Parent == "" {
+		i.output, i.hasOutput = snapshot.Output, true
+		if snapshot.Output == nil {
+			i.output = output(i.state.Context, Event{})
+		}
+	}
+	i.deferred = nil
+
+	// Re-arm delayed transitions and restart invoked services for every active state
+	for _, stateID := range i.activeStatesUnlocked() {
+		i.scheduleDelayedTransitionsElapsed(stateID, Event{}, elapsed)
+		i.startInvocations(i.machine.GetState(stateID), Event{})
+	}
+}
+
+// activeStatesUnlocked returns all active state IDs in root-to-leaf order,
+// including ancestors and the states of every parallel region (caller must hold mu)
+func (i *Interpreter[C]) activeStatesUnlocked() []ir.StateID {
+	active := i.machine.GetPath(i.state.Value)
+
+	if i.currentParallel == "" {
+		return active
+	}
+
+	parallelState := i.machine.GetState(i.currentParallel)
+	if parallelState == nil {
+		return active
+	}
+	return i.appendRegionStates(active, parallelState)
+}
+
+// appendRegionStates appends the active states of each region of a parallel
+// state, including the regions of nested parallel states (caller must hold mu)
+func (i *Interpreter[C]) appendRegionStates(active []ir.StateID, parallelState *ir.StateConfig) []ir.StateID {
+	for _, regionID := range parallelState.Children {
+		leafID, ok := i.state.ActiveInParallel[regionID]
+		if !ok {
+			continue
+		}
+		active = append(active, i.getEntryPath(regionID, leafID)...)
+		if leafConfig := i.machine.GetState(leafID); leafConfig != nil && leafConfig.IsParallel() && leafID != regionID {
+			active = i.appendRegionStates(active, leafConfig)
+		}
+	}
+	return active
+}
+
+// copyStateMap returns a copy of a state ID map, or nil if the map is empty
+func copyStateMap(m map[ir.StateID]ir.StateID) map[ir.StateID]ir.StateID {
+	if len(m) == 0 {
+		return nil
+	}
+	result := make(map[ir.StateID]ir.StateID, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
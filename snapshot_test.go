@@ -0,0 +1,245 @@
+package statekit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type checkoutContext struct {
+	OrderID string `json:"orderId"`
+	Items   int    `json:"items"`
+}
+
+// TestSnapshot_JSONRoundTrip tests persisting and restoring through encoding/json
+func TestSnapshot_JSONRoundTrip(t *testing.T) {
+	entries := 0
+	build := func() *Interpreter[checkoutContext] {
+		machine, err := NewMachine[checkoutContext]("order").
+			WithInitial("cart").
+			WithAction("countEntry", func(ctx *checkoutContext, e Event) {
+				entries++
+			}).
+			State("cart").
+			OnEntry("countEntry").
+			On("CHECKOUT").Target("checkout").
+			On("RESUME").Target("hist").
+			Done().
+			State("checkout").
+			OnEntry("countEntry").
+			WithInitial("shipping").
+			On("CANCEL").Target("cart").End().
+			History("hist").Deep().Default("shipping").End().
+			State("shipping").OnEntry("countEntry").On("NEXT").Target("payment").End().End().
+			State("payment").OnEntry("countEntry").End().
+			Done().
+			Build()
+		if err != nil {
+			t.Fatalf("Failed to build machine: %v", err)
+		}
+		return NewInterpreter(machine)
+	}
+
+	original := build()
+	original.Start()
+	original.UpdateContext(func(ctx *checkoutContext) {
+		ctx.OrderID = "A-1"
+		ctx.Items = 3
+	})
+	original.Send(Event{Type: "CHECKOUT"})
+	original.Send(Event{Type: "NEXT"})
+	original.Send(Event{Type: "CANCEL"})
+
+	data, err := json.Marshal(original.Snapshot())
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+
+	var decoded Snapshot[checkoutContext]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %v", err)
+	}
+
+	restored := build()
+	entriesBefore := entries
+	if err := restored.Restore(decoded); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+
+	if entries != entriesBefore {
+		t.Errorf("Expected no entry actions on restore, got %d", entries-entriesBefore)
+	}
+	if restored.State().Value != "cart" {
+		t.Errorf("Expected state 'cart', got %s", restored.State().Value)
+	}
+	if restored.State().Context.OrderID != "A-1" || restored.State().Context.Items != 3 {
+		t.Errorf("Expected restored context, got %+v", restored.State().Context)
+	}
+
+	// Deep history survives the round trip
+	if decoded.DeepHistory["checkout"] != "payment" {
+		t.Errorf("Expected deep history 'payment', got %s", decoded.DeepHistory["checkout"])
+	}
+	restored.Send(Event{Type: "RESUME"})
+	if restored.State().Value != "payment" {
+		t.Errorf("Expected state 'payment', got %s", restored.State().Value)
+	}
+}
+
+// TestSnapshot_History tests that history targets resolve after restore
+func TestSnapshot_History(t *testing.T) {
+	machine, err := NewMachine[struct{}]("history_restore").
+		WithInitial("active").
+		State("active").
+		WithInitial("one").
+		On("PAUSE").Target("paused").End().
+		History("hist").Shallow().Default("one").End().
+		State("one").On("NEXT").Target("two").End().End().
+		State("two").End().
+		Done().
+		State("paused").
+		On("RESUME").Target("hist").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	original := NewInterpreter(machine)
+	original.Start()
+	original.Send(Event{Type: "NEXT"})
+	original.Send(Event{Type: "PAUSE"})
+
+	data, err := json.Marshal(original.Snapshot())
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	var decoded Snapshot[struct{}]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %v", err)
+	}
+
+	restored := NewInterpreter(machine)
+	if err := restored.Restore(decoded); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	restored.Send(Event{Type: "RESUME"})
+
+	if restored.State().Value != "two" {
+		t.Errorf("Expected history to resume 'two', got %s", restored.State().Value)
+	}
+}
+
+// TestSnapshot_Parallel tests round-tripping a parallel configuration
+func TestSnapshot_Parallel(t *testing.T) {
+	machine, err := NewMachine[struct{}]("parallel_restore").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("left").
+		WithInitial("l1").
+		State("l1").On("LEFT").Target("l2").EndState().
+		State("l2").EndState().
+		EndRegion().
+		Region("right").
+		WithInitial("r1").
+		State("r1").On("RIGHT").Target("r2").EndState().
+		State("r2").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	original := NewInterpreter(machine)
+	original.Start()
+	original.Send(Event{Type: "LEFT"})
+
+	data, err := json.Marshal(original.Snapshot())
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	var decoded Snapshot[struct{}]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %v", err)
+	}
+
+	restored := NewInterpreter(machine)
+	if err := restored.Restore(decoded); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+
+	if restored.State().ActiveInParallel["left"] != "l2" {
+		t.Errorf("Expected left 'l2', got %s", restored.State().ActiveInParallel["left"])
+	}
+
+	// Events continue to broadcast to regions
+	restored.Send(Event{Type: "RIGHT"})
+	if restored.State().ActiveInParallel["right"] != "r2" {
+		t.Errorf("Expected right 'r2', got %s", restored.State().ActiveInParallel["right"])
+	}
+
+	restored.Stop()
+}
+
+// TestSnapshot_RestoreDelayed tests that delayed transitions are re-armed on restore
+func TestSnapshot_RestoreDelayed(t *testing.T) {
+	machine, err := NewMachine[struct{}]("delayed_restore").
+		WithInitial("waiting").
+		State("waiting").
+		After(200 * time.Millisecond).Target("timeout").
+		Done().
+		State("timeout").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	snapshot := Snapshot[struct{}]{Value: "waiting"}
+	clock := statekittest.NewFakeClock()
+
+	// Full delay: should not have fired yet
+	full := NewInterpreter(machine, WithClock(clock))
+	if err := full.Restore(snapshot); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	defer full.Stop()
+
+	// Elapsed delay: only the remaining time is waited
+	partial := NewInterpreter(machine, WithClock(clock))
+	if err := partial.RestoreWithElapsed(snapshot, 180*time.Millisecond); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	defer partial.Stop()
+
+	clock.Advance(20 * time.Millisecond)
+
+	if full.State().Value != "waiting" {
+		t.Errorf("Expected full-delay restore to still be 'waiting', got %s", full.State().Value)
+	}
+	if partial.State().Value != "timeout" {
+		t.Errorf("Expected elapsed restore to reach 'timeout', got %s", partial.State().Value)
+	}
+}
+
+// TestSnapshot_RestoreUnknownState tests that restoring an unknown state fails
+func TestSnapshot_RestoreUnknownState(t *testing.T) {
+	machine, err := NewMachine[struct{}]("bad_restore").
+		WithInitial("idle").
+		State("idle").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	if err := interp.Restore(Snapshot[struct{}]{Value: "missing"}); err == nil {
+		t.Error("Expected error restoring unknown state")
+	}
+	if interp.State().Value != "" {
+		t.Errorf("Expected interpreter to be unchanged, got %s", interp.State().Value)
+	}
+}
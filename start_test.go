@@ -0,0 +1,365 @@
+package statekit
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type signupContext struct {
+	Email string
+}
+
+var errMissingEmail = errors.New("email is required")
+
+// TestStartErr_RejectsInvalidContext tests that a failing context validator prevents the start
+func TestStartErr_RejectsInvalidContext(t *testing.T) {
+	machine, err := NewMachine[signupContext]("signup").
+		WithInitial("pending").
+		WithContextValidator(func(ctx signupContext) error {
+			if ctx.Email == "" {
+				return errMissingEmail
+			}
+			return nil
+		}).
+		State("pending").On("CONFIRM").Target("confirmed").Done().
+		State("confirmed").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	err = interp.StartErr()
+	if !errors.Is(err, errMissingEmail) {
+		t.Fatalf("Expected errMissingEmail, got %v", err)
+	}
+	if interp.State().Value != "" {
+		t.Errorf("Expected interpreter not to be started, got state %q", interp.State().Value)
+	}
+
+	// Events are ignored until the interpreter starts
+	interp.Send(Event{Type: "CONFIRM"})
+	if interp.State().Value != "" {
+		t.Errorf("Expected event to be ignored, got state %q", interp.State().Value)
+	}
+
+	// Start also refuses to start
+	interp.Start()
+	if interp.State().Value != "" {
+		t.Errorf("Expected Start not to start, got state %q", interp.State().Value)
+	}
+
+	// Fixing the context allows the start
+	interp.UpdateContext(func(ctx *signupContext) { ctx.Email = "ada@example.com" })
+	if err := interp.StartErr(); err != nil {
+		t.Fatalf("Expected start to succeed, got %v", err)
+	}
+	if interp.State().Value != "pending" {
+		t.Errorf("Expected state 'pending', got %q", interp.State().Value)
+	}
+
+	// Starting again is a no-op
+	if err := interp.StartErr(); err != nil {
+		t.Errorf("Expected nil on second start, got %v", err)
+	}
+}
+
+// TestStartErr_ValidContext tests that a valid initial context starts normally
+func TestStartErr_ValidContext(t *testing.T) {
+	machine, err := NewMachine[signupContext]("signup").
+		WithInitial("pending").
+		WithContext(signupContext{Email: "ada@example.com"}).
+		WithContextValidator(func(ctx signupContext) error {
+			if ctx.Email == "" {
+				return errMissingEmail
+			}
+			return nil
+		}).
+		State("pending").On("CONFIRM").Target("confirmed").Done().
+		State("confirmed").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	if err := interp.StartErr(); err != nil {
+		t.Fatalf("Expected start to succeed, got %v", err)
+	}
+	interp.Send(Event{Type: "CONFIRM"})
+	if interp.State().Value != "confirmed" {
+		t.Errorf("Expected state 'confirmed', got %q", interp.State().Value)
+	}
+}
+
+// TestFromStruct_ContextValidator tests the context validator on the action registry
+func TestFromStruct_ContextValidator(t *testing.T) {
+	registry := NewActionRegistry[ReflectTestContext]().
+		WithContextValidator(func(ctx ReflectTestContext) error {
+			if ctx.Count == 0 {
+				return errors.New("count must be set")
+			}
+			return nil
+		})
+
+	machine, err := FromStruct[SimpleReflectMachine, ReflectTestContext](registry)
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	if err := interp.StartErr(); err == nil {
+		t.Error("Expected zero-value context to be rejected")
+	}
+}
+
+// ignoredTracer records the events reported as ignored
+type ignoredTracer struct {
+	NopTracer
+	ignored []EventType
+}
+
+func (r *ignoredTracer) OnEventIgnored(event EventType) {
+	r.ignored = append(r.ignored, event)
+}
+
+// TestInterpreter_BeforeStart tests every query and Send on an interpreter that is not started
+func TestInterpreter_BeforeStart(t *testing.T) {
+	machine, err := NewMachine[signupContext]("signup").
+		WithInitial("pending").
+		WithContext(signupContext{Email: "ada@example.com"}).
+		State("pending").
+		On("CONFIRM").Target("confirmed").
+		After(time.Minute).Target("expired").
+		Done().
+		State("confirmed").Final().Done().
+		State("expired").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tracer := &ignoredTracer{}
+	interp := NewInterpreter(machine, WithTracer(tracer))
+
+	if interp.IsStarted() {
+		t.Error("Expected IsStarted to be false before Start")
+	}
+	if state := interp.State(); state.Value != "" || state.Context.Email != "ada@example.com" {
+		t.Errorf("Expected an empty state with the machine's context, got %+v", state)
+	}
+	if interp.Matches("") || interp.Matches("pending") {
+		t.Error("Expected Matches to be false before Start")
+	}
+	if interp.MatchesAny("", "pending") {
+		t.Error("Expected MatchesAny to be false before Start")
+	}
+	if interp.MatchesAll("pending") {
+		t.Error("Expected MatchesAll to be false before Start")
+	}
+	if interp.MatchesPath("pending") {
+		t.Error("Expected MatchesPath to be false before Start")
+	}
+	if interp.Done() {
+		t.Error("Expected Done to be false before Start")
+	}
+	if interp.Can("CONFIRM") {
+		t.Error("Expected Can to be false before Start")
+	}
+	if events := interp.NextEvents(); len(events) != 0 {
+		t.Errorf("Expected no next events, got %v", events)
+	}
+	if active := interp.ActiveStates(); active != nil {
+		t.Errorf("Expected no active states, got %v", active)
+	}
+	if timers := interp.PendingTimers(); len(timers) != 0 {
+		t.Errorf("Expected no pending timers, got %v", timers)
+	}
+	if _, ok := interp.Output(); ok {
+		t.Error("Expected no output before Start")
+	}
+
+	result := interp.SendResult(Event{Type: "CONFIRM"})
+	if result.Handled || !errors.Is(result.Err, ErrNotStarted) {
+		t.Errorf("Expected an unhandled result with ErrNotStarted, got %+v", result)
+	}
+	interp.Send(Event{Type: "CONFIRM"})
+	if interp.State().Value != "" {
+		t.Errorf("Expected events to be ignored, got state %q", interp.State().Value)
+	}
+	if !slices.Equal(tracer.ignored, []EventType{"CONFIRM", "CONFIRM"}) {
+		t.Errorf("Expected both events to be reported as ignored, got %v", tracer.ignored)
+	}
+	if stats := interp.Stats(); stats.EventsReceived != 0 || stats.EventsIgnored != 0 {
+		t.Errorf("Expected events before Start not to be counted, got %+v", stats)
+	}
+
+	interp.Start()
+	defer interp.Stop()
+	if !interp.IsStarted() || !interp.Matches("pending") {
+		t.Fatalf("Expected a started interpreter in 'pending', got %q", interp.State().Value)
+	}
+
+	// A stopped interpreter keeps its last state but matches nothing
+	interp.Stop()
+	if interp.IsStarted() {
+		t.Error("Expected IsStarted to be false after Stop")
+	}
+	if interp.State().Value != "pending" {
+		t.Errorf("Expected State to keep 'pending' after Stop, got %q", interp.State().Value)
+	}
+	if interp.Matches("pending") {
+		t.Error("Expected Matches to be false after Stop")
+	}
+}
+
+type portalContext struct {
+	Log []string
+}
+
+// TestWithInitialState tests starting directly in a nested leaf, entering its
+// ancestors root to leaf
+func TestWithInitialState(t *testing.T) {
+	machine, err := NewMachine[portalContext]("portal").
+		WithInitial("home").
+		WithAction("enterApp", func(ctx *portalContext, e Event) { ctx.Log = append(ctx.Log, "app:"+string(e.Type)) }).
+		WithAction("enterSettings", func(ctx *portalContext, e Event) { ctx.Log = append(ctx.Log, "settings:"+string(e.Type)) }).
+		WithAction("enterProfile", func(ctx *portalContext, e Event) { ctx.Log = append(ctx.Log, "profile:"+string(e.Type)) }).
+		State("home").Done().
+		State("app").
+		WithInitial("dashboard").
+		OnEntry("enterApp").
+		On("HOME").Target("home").End().
+		State("dashboard").End().
+		State("settings").
+		WithInitial("profile").
+		OnEntry("enterSettings").
+		State("profile").OnEntry("enterProfile").End().
+		End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithInitialState("profile"))
+	if err := interp.StartErr(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !interp.MatchesAll("app", "settings", "profile") || interp.Matches("home") {
+		t.Errorf("Expected app > settings > profile, got %v", interp.ActiveStates())
+	}
+	expected := []string{"app:statekit.init", "settings:statekit.init", "profile:statekit.init"}
+	if log := interp.State().Context.Log; !slices.Equal(log, expected) {
+		t.Errorf("Expected entry log %v, got %v", expected, log)
+	}
+
+	// Transitions of the ancestors apply
+	interp.Send(Event{Type: "HOME"})
+	if !interp.Matches("home") {
+		t.Errorf("Expected 'home', got %s", interp.State().Value)
+	}
+
+	// Reset returns to the overridden initial state
+	interp.Reset()
+	if !interp.Matches("profile") {
+		t.Errorf("Expected Reset to enter 'profile', got %s", interp.State().Value)
+	}
+	interp.Stop()
+}
+
+// TestWithInitialState_Compound tests that a compound initial state is
+// resolved to its initial leaf
+func TestWithInitialState_Compound(t *testing.T) {
+	machine, err := NewMachine[struct{}]("portal").
+		WithInitial("home").
+		State("home").Done().
+		State("app").
+		WithInitial("dashboard").
+		State("dashboard").End().
+		State("settings").End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithInitialState("app"))
+	interp.Start()
+
+	if interp.State().Value != "dashboard" {
+		t.Errorf("Expected 'dashboard', got %s", interp.State().Value)
+	}
+}
+
+// TestWithoutInitialEntryActions tests entering the initial states without
+// running their entry actions
+func TestWithoutInitialEntryActions(t *testing.T) {
+	machine, err := NewMachine[portalContext]("portal").
+		WithInitial("home").
+		WithAction("enterApp", func(ctx *portalContext, e Event) { ctx.Log = append(ctx.Log, "app:"+string(e.Type)) }).
+		WithAction("enterSettings", func(ctx *portalContext, e Event) { ctx.Log = append(ctx.Log, "settings:"+string(e.Type)) }).
+		WithAction("enterProfile", func(ctx *portalContext, e Event) { ctx.Log = append(ctx.Log, "profile:"+string(e.Type)) }).
+		State("home").On("OPEN").Target("app").Done().
+		State("app").
+		WithInitial("dashboard").
+		OnEntry("enterApp").
+		On("HOME").Target("home").End().
+		State("dashboard").End().
+		State("settings").
+		WithInitial("profile").
+		OnEntry("enterSettings").
+		State("profile").OnEntry("enterProfile").After(time.Minute).Target("saved").End().End().
+		State("saved").End().
+		End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithInitialState("profile"), WithoutInitialEntryActions())
+	interp.Start()
+
+	if !interp.MatchesAll("app", "settings", "profile") {
+		t.Errorf("Expected app > settings > profile, got %v", interp.ActiveStates())
+	}
+	if log := interp.State().Context.Log; len(log) != 0 {
+		t.Errorf("Expected no entry actions, got %v", log)
+	}
+	if timers := interp.PendingTimers(); len(timers) != 1 {
+		t.Errorf("Expected the delayed transition of 'profile' to be scheduled, got %v", timers)
+	}
+
+	// Later entries run their actions
+	interp.Send(Event{Type: "HOME"})
+	interp.Send(Event{Type: "OPEN"})
+	if log := interp.State().Context.Log; !slices.Equal(log, []string{"app:OPEN"}) {
+		t.Errorf("Expected entry actions after the start, got %v", log)
+	}
+	interp.Stop()
+}
+
+// TestWithInitialState_Unknown tests that an unknown initial state prevents the start
+func TestWithInitialState_Unknown(t *testing.T) {
+	machine, err := NewMachine[struct{}]("portal").
+		WithInitial("home").
+		State("home").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithInitialState("missing"))
+
+	if err := interp.StartErr(); err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("Expected an error naming the missing state, got %v", err)
+	}
+	if interp.IsStarted() {
+		t.Error("Expected the interpreter to stay stopped")
+	}
+}
@@ -0,0 +1,119 @@
+// Package statekittest provides helpers for testing statekit machines.
+package statekittest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/clock"
+)
+
+// FakeClock is a manually advanced clock for testing delayed transitions
+// without sleeping. Pass it to an interpreter with statekit.WithClock.
+//
+// Timers never fire on their own; they run synchronously, in order of their
+// due time, when Advance moves the clock past them.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	seq    uint64
+}
+
+var _ clock.Clock = (*FakeClock)(nil)
+
+// NewFakeClock creates a fake clock starting at the Unix epoch
+func NewFakeClock() *FakeClock {
+	return &FakeClock{now: time.Unix(0, 0)}
+}
+
+// Now returns the current fake time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to run once the clock has advanced by d
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) clock.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	t := &fakeTimer{clock: c, when: c.now.Add(d), seq: c.seq, fn: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, running every timer that becomes due.
+// Timers scheduled by a running timer also fire if they are due before the
+// new time. Advance(0) runs timers scheduled with no delay.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+
+	for {
+		t := c.nextDueLocked(target)
+		if t == nil {
+			break
+		}
+		c.now = t.when
+		c.removeLocked(t)
+
+		// Run without holding the lock so the callback can schedule or stop timers
+		c.mu.Unlock()
+		t.fn()
+		c.mu.Lock()
+	}
+
+	c.now = target
+	c.mu.Unlock()
+}
+
+// Pending returns the number of timers that have not fired or been stopped
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// nextDueLocked returns the earliest timer due at or before target (caller must hold mu)
+func (c *FakeClock) nextDueLocked(target time.Time) *fakeTimer {
+	sort.Slice(c.timers, func(a, b int) bool {
+		if !c.timers[a].when.Equal(c.timers[b].when) {
+			return c.timers[a].when.Before(c.timers[b].when)
+		}
+		return c.timers[a].seq < c.timers[b].seq
+	})
+	if len(c.timers) == 0 || c.timers[0].when.After(target) {
+		return nil
+	}
+	return c.timers[0]
+}
+
+// removeLocked removes a timer, reporting whether it was pending (caller must hold mu)
+func (c *FakeClock) removeLocked(t *fakeTimer) bool {
+	for idx, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:idx], c.timers[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer is a pending call scheduled on a FakeClock
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	seq   uint64
+	fn    func()
+}
+
+// Stop cancels the timer
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeLocked(t)
+}
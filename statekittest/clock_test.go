@@ -0,0 +1,68 @@
+package statekittest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock_Advance(t *testing.T) {
+	clock := NewFakeClock()
+	var fired []string
+
+	clock.AfterFunc(200*time.Millisecond, func() { fired = append(fired, "b") })
+	clock.AfterFunc(100*time.Millisecond, func() { fired = append(fired, "a") })
+	clock.AfterFunc(300*time.Millisecond, func() { fired = append(fired, "c") })
+
+	clock.Advance(99 * time.Millisecond)
+	if len(fired) != 0 {
+		t.Fatalf("expected no timers to fire, got %v", fired)
+	}
+
+	clock.Advance(101 * time.Millisecond)
+	if got := len(fired); got != 2 || fired[0] != "a" || fired[1] != "b" {
+		t.Fatalf("expected [a b], got %v", fired)
+	}
+	if clock.Pending() != 1 {
+		t.Errorf("expected 1 pending timer, got %d", clock.Pending())
+	}
+	if want := time.Unix(0, 0).Add(200 * time.Millisecond); !clock.Now().Equal(want) {
+		t.Errorf("expected now %v, got %v", want, clock.Now())
+	}
+}
+
+func TestFakeClock_Stop(t *testing.T) {
+	clock := NewFakeClock()
+	fired := false
+
+	timer := clock.AfterFunc(time.Second, func() { fired = true })
+	if !timer.Stop() {
+		t.Error("expected Stop to report a pending timer")
+	}
+	if timer.Stop() {
+		t.Error("expected second Stop to report false")
+	}
+
+	clock.Advance(2 * time.Second)
+	if fired {
+		t.Error("expected stopped timer not to fire")
+	}
+}
+
+func TestFakeClock_TimerSchedulesTimer(t *testing.T) {
+	clock := NewFakeClock()
+	var fired []time.Duration
+	start := clock.Now()
+
+	clock.AfterFunc(time.Second, func() {
+		fired = append(fired, clock.Now().Sub(start))
+		clock.AfterFunc(time.Second, func() {
+			fired = append(fired, clock.Now().Sub(start))
+		})
+	})
+
+	// Both the timer and the one it schedules are due within the advance
+	clock.Advance(3 * time.Second)
+	if len(fired) != 2 || fired[0] != time.Second || fired[1] != 2*time.Second {
+		t.Errorf("expected timers at [1s 2s], got %v", fired)
+	}
+}
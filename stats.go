@@ -0,0 +1,25 @@
+package statekit
+
+// Stats holds counters of an interpreter's activity since it was created.
+// Queries such as Can and NextEvents are not counted.
+type Stats struct {
+	// EventsReceived counts events processed while the interpreter was started
+	EventsReceived uint64
+	// Transitions counts transitions taken, including eventless, delayed,
+	// internal, and parallel region transitions
+	Transitions uint64
+	// EventsIgnored counts received events that matched no transition
+	EventsIgnored uint64
+	// GuardRejections counts guards that failed while matching an event to a
+	// transition; a rejected transition may still be followed by another match
+	GuardRejections uint64
+	// DelayedFired counts delayed transitions taken when their timer fired
+	DelayedFired uint64
+}
+
+// Stats returns a copy of the interpreter's activity counters
+func (i *Interpreter[C]) Stats() Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
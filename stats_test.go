@@ -0,0 +1,90 @@
+package statekit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+type statsContext struct {
+	Approved bool
+}
+
+// TestStats tests the counters after a known sequence of events
+func TestStats(t *testing.T) {
+	machine, err := NewMachine[statsContext]("stats").
+		WithInitial("draft").
+		WithGuard("isApproved", func(ctx statsContext, e Event) bool { return ctx.Approved }).
+		State("draft").
+		On("PUBLISH").Target("published").Guard("isApproved").
+		On("EDIT").Target("draft").
+		Done().
+		State("published").
+		After(time.Hour).Target("archived").
+		Done().
+		State("archived").Final().Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+
+	interp.Send(Event{Type: "PUBLISH"}) // Blocked by the guard
+	interp.Send(Event{Type: "UNKNOWN"}) // No transition
+	interp.Send(Event{Type: "EDIT"})    // Self-transition
+	interp.UpdateContext(func(ctx *statsContext) { ctx.Approved = true })
+	interp.Can("PUBLISH") // Queries are not counted
+	interp.Send(Event{Type: "PUBLISH"})
+	clock.Advance(time.Hour)
+
+	expected := Stats{
+		EventsReceived:  4,
+		Transitions:     3,
+		EventsIgnored:   2,
+		GuardRejections: 1,
+		DelayedFired:    1,
+	}
+	if stats := interp.Stats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+	if interp.State().Value != "archived" {
+		t.Errorf("Expected state 'archived', got %s", interp.State().Value)
+	}
+}
+
+// TestStats_Parallel tests that each region transition is counted
+func TestStats_Parallel(t *testing.T) {
+	machine, err := NewMachine[statsContext]("stats_parallel").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("a").
+		WithInitial("a1").
+		State("a1").On("GO").Target("a2").EndState().
+		State("a2").EndState().
+		EndRegion().
+		Region("b").
+		WithInitial("b1").
+		State("b1").On("GO").Target("b2").EndState().
+		State("b2").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Send(Event{Type: "GO"}) // Not started: not received
+	interp.Start()
+	interp.Send(Event{Type: "GO"})
+	interp.Send(Event{Type: "GO"})
+
+	expected := Stats{EventsReceived: 2, Transitions: 2, EventsIgnored: 1}
+	if stats := interp.Stats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
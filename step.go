@@ -0,0 +1,87 @@
+package statekit
+
+import (
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// Step computes the transition a machine takes for an event without side
+// effects: it returns the state the machine would be in after the event, and
+// after any eventless transitions and done events it triggers, together with the
+// exit, transition, and entry actions that would run, in order. Step is
+// deterministic, which makes it suitable for property-based tests of
+// transition logic:
+//
+//	next, actions := statekit.Step(machine, current, statekit.Event{Type: "TIMER"})
+//
+// current is a state previously returned by Step or Interpreter.State; a state
+// with an empty Value steps from the start of the machine with its context,
+// returning the initial state and its entry actions; the event is then ignored,
+// like events sent before Start. Use State[C]{Context: machine.Context} to
+// start with the machine's context.
+//
+// No action, fallible action, or service is run, no event is raised, and no
+// timer is armed; guards, delay resolvers, and outputs are called as usual. As
+// actions do not run, the returned context is the context of current, guards
+// see that context, and fallible actions never veto. History is not part of
+// State, so history states resolve to their default targets. If current is not
+// a state of the machine, Step returns it unchanged with no actions.
+func Step[C any](machine *ir.MachineConfig[C], current State[C], event Event) (State[C], []ActionType) {
+	dry := *machine
+	dry.Actions = make(map[ActionType]ir.Action[C], len(machine.Actions))
+	for name := range machine.Actions {
+		dry.Actions[name] = func(*C, Event) {}
+	}
+	dry.SchedulerActions = make(map[ActionType]ir.SchedulerAction[C], len(machine.SchedulerActions))
+	for name := range machine.SchedulerActions {
+		dry.SchedulerActions[name] = func(*C, Event, Scheduler) {}
+	}
+	dry.FallibleActions = make(map[ActionType]ir.FallibleAction[C], len(machine.FallibleActions))
+	for name := range machine.FallibleActions {
+		dry.FallibleActions[name] = func(*C, Event) error { return nil }
+	}
+	dry.Services = nil
+	dry.Context = current.Context
+
+	recorder := &actionRecorder{}
+	interp := NewInterpreter(&dry, WithTracer(recorder), WithClock(idleClock{}))
+	if current.Value == "" {
+		if err := interp.StartErr(); err != nil {
+			return current, nil
+		}
+		return interp.State(), recorder.actions
+	}
+
+	err := interp.Restore(Snapshot[C]{
+		Value:            current.Value,
+		Context:          current.Context,
+		ActiveInParallel: current.ActiveInParallel,
+	})
+	if err != nil {
+		return current, nil
+	}
+	interp.Send(event)
+	return interp.State(), recorder.actions
+}
+
+// actionRecorder is the tracer of Step, recording the actions that would run
+type actionRecorder struct {
+	NopTracer
+	actions []ActionType
+}
+
+func (r *actionRecorder) OnActionStart(name ActionType) {
+	r.actions = append(r.actions, name)
+}
+
+// idleClock is the clock of Step: its timers never fire
+type idleClock struct{}
+
+func (idleClock) AfterFunc(time.Duration, func()) Timer { return idleTimer{} }
+func (idleClock) Now() time.Time                        { return time.Time{} }
+
+// idleTimer is a timer of idleClock
+type idleTimer struct{}
+
+func (idleTimer) Stop() bool { return true }
@@ -0,0 +1,179 @@
+package statekit
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+type stepContext struct {
+	Premium bool
+	Count   int
+}
+
+// TestStep_MatchesInterpreter tests that Step computes the same states and
+// actions as an interpreter for every event of a run. The actions do not change
+// the context, so the interpreter and Step see the same guard results.
+func TestStep_MatchesInterpreter(t *testing.T) {
+	noop := func(ctx *stepContext, e Event) {}
+	isPremium := func(ctx stepContext, e Event) bool { return ctx.Premium }
+
+	tests := []struct {
+		name    string
+		builder *MachineBuilder[stepContext]
+		events  []EventType
+	}{
+		{
+			name: "hierarchical",
+			builder: NewMachine[stepContext]("order").
+				WithInitial("cart").
+				WithContext(stepContext{Premium: true}).
+				WithAction("log", noop).
+				WithAction("charge", noop).
+				WithGuard("isPremium", isPremium).
+				State("cart").
+				OnEntry("log").OnExit("log").
+				On("CHECKOUT").Target("checkout").Do("charge").
+				Done().
+				State("checkout").
+				WithInitial("route").
+				OnEntry("log").OnExit("log").
+				On("CANCEL").Target("cart").End().
+				State("route").
+				Always().Target("express").Guard("isPremium").
+				Always().Target("standard").End().
+				End().
+				State("express").OnEntry("log").
+				On("SHIP").Target("shipped").End().
+				End().
+				State("standard").OnEntry("log").End().
+				Done().
+				State("shipped").Final().OnEntry("log").Done(),
+			events: []EventType{"CHECKOUT", "CANCEL", "UNKNOWN", "CHECKOUT", "SHIP", "CANCEL"},
+		},
+		{
+			name: "done",
+			builder: NewMachine[stepContext]("payment").
+				WithInitial("payment").
+				WithAction("paid", noop).
+				State("payment").
+				WithInitial("entering").
+				State("entering").
+				On("SUBMIT").Target("approved").Do("paid").End().
+				End().
+				State("approved").Final().OnEntry("paid").End().
+				OnDone().Target("shipping").Do("paid").
+				Done().
+				State("shipping").Done(),
+			events: []EventType{"SUBMIT", "SUBMIT"},
+		},
+		{
+			name: "parallel",
+			builder: NewMachine[stepContext]("player").
+				WithInitial("player").
+				WithAction("log", noop).
+				WithGuard("isPremium", isPremium).
+				State("player").Parallel().
+				On("EJECT").Target("ejected").End().
+				Region("playback").
+				WithInitial("paused").
+				State("paused").OnExit("log").On("PLAY").Target("playing").EndState().
+				State("playing").OnEntry("log").On("PAUSE").Target("paused").EndState().
+				EndRegion().
+				Region("quality").
+				WithInitial("normal").
+				State("normal").On("PLAY").Target("high").Guard("isPremium").EndState().
+				State("high").OnEntry("log").EndState().
+				EndRegion().
+				Done().
+				State("ejected").OnEntry("log").Done(),
+			events: []EventType{"PLAY", "PAUSE", "PLAY", "EJECT", "PLAY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine, err := tt.builder.Build()
+			if err != nil {
+				t.Fatalf("Failed to build machine: %v", err)
+			}
+
+			recorder := &actionRecorder{}
+			interp := NewInterpreter(machine, WithTracer(recorder))
+			interp.Start()
+
+			state, actions := Step(machine, State[stepContext]{Context: machine.Context}, Event{})
+			assertStepMatches(t, "start", interp, recorder, state, actions)
+
+			for _, eventType := range tt.events {
+				recorder.actions = nil
+				interp.Send(Event{Type: eventType})
+				state, actions = Step(machine, state, Event{Type: eventType})
+				assertStepMatches(t, string(eventType), interp, recorder, state, actions)
+			}
+		})
+	}
+}
+
+// assertStepMatches compares the result of Step with the interpreter's state
+// and the actions it ran
+func assertStepMatches(t *testing.T, step string, interp *Interpreter[stepContext], recorder *actionRecorder, state State[stepContext], actions []ActionType) {
+	t.Helper()
+	expected := interp.State()
+	if state.Value != expected.Value || !maps.Equal(state.ActiveInParallel, expected.ActiveInParallel) {
+		t.Errorf("%s: expected state %s %v, got %s %v", step, expected.Value, expected.ActiveInParallel, state.Value, state.ActiveInParallel)
+	}
+	if !slices.Equal(actions, recorder.actions) {
+		t.Errorf("%s: expected actions %v, got %v", step, recorder.actions, actions)
+	}
+}
+
+// TestStep_NoSideEffects tests that Step runs no actions and leaves its inputs unchanged
+func TestStep_NoSideEffects(t *testing.T) {
+	var calls int
+	machine, err := NewMachine[stepContext]("counter").
+		WithInitial("idle").
+		WithAction("count", func(ctx *stepContext, e Event) {
+			calls++
+			ctx.Count++
+		}).
+		WithFallibleAction("reject", func(ctx *stepContext, e Event) error {
+			calls++
+			return errInvalidForm
+		}).
+		State("idle").
+		On("GO").Target("running").DoFallible("reject").Do("count").
+		Done().
+		State("running").OnEntry("count").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	current := State[stepContext]{Value: "idle", Context: stepContext{Count: 1}}
+	next, actions := Step(machine, current, Event{Type: "GO"})
+	again, actionsAgain := Step(machine, current, Event{Type: "GO"})
+
+	if calls != 0 {
+		t.Errorf("Expected no action to run, got %d calls", calls)
+	}
+	if next.Value != "running" || next.Context.Count != 1 {
+		t.Errorf("Expected 'running' with the unchanged context, got %+v", next)
+	}
+	expected := []ActionType{"reject", "count", "count"}
+	if !slices.Equal(actions, expected) {
+		t.Errorf("Expected actions %v, got %v", expected, actions)
+	}
+	if again.Value != next.Value || !slices.Equal(actionsAgain, actions) {
+		t.Errorf("Expected Step to be deterministic, got %+v %v", again, actionsAgain)
+	}
+	if current.Value != "idle" {
+		t.Errorf("Expected the current state to be unchanged, got %s", current.Value)
+	}
+
+	// Unknown states are returned unchanged
+	unknown := State[stepContext]{Value: "missing"}
+	if state, actions := Step(machine, unknown, Event{Type: "GO"}); state.Value != "missing" || actions != nil {
+		t.Errorf("Expected an unknown state to be returned unchanged, got %+v %v", state, actions)
+	}
+}
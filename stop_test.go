@@ -0,0 +1,127 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+)
+
+type stopContext struct {
+	Exits  []string
+	Events []EventType
+}
+
+// TestStopWithExit_Parallel tests that every active state exits once, leaf to root
+func TestStopWithExit_Parallel(t *testing.T) {
+	// app > main > editor (parallel with two regions)
+	builder := NewMachine[stopContext]("stop").WithInitial("app")
+	for _, name := range []string{"app", "main", "editor", "text", "typing", "toolbar", "idle"} {
+		builder.WithAction(ActionType("exit_"+name), func(ctx *stopContext, e Event) {
+			ctx.Exits = append(ctx.Exits, name)
+			ctx.Events = append(ctx.Events, e.Type)
+		})
+	}
+
+	machine, err := builder.
+		State("app").
+		WithInitial("main").
+		OnExit("exit_app").
+		State("main").
+		WithInitial("editor").
+		OnExit("exit_main").
+		State("editor").Parallel().
+		OnExit("exit_editor").
+		Region("text").
+		WithInitial("typing").
+		State("typing").OnExit("exit_typing").EndState().
+		EndRegion().
+		Region("toolbar").
+		WithInitial("idle").
+		State("idle").OnExit("exit_idle").EndState().
+		EndRegion().
+		End().
+		End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.StopWithExit()
+
+	expected := []string{"idle", "typing", "editor", "main", "app"}
+	ctx := interp.State().Context
+	if !slices.Equal(ctx.Exits, expected) {
+		t.Errorf("Expected exits %v, got %v", expected, ctx.Exits)
+	}
+	for _, eventType := range ctx.Events {
+		if eventType != StopEvent {
+			t.Errorf("Expected exit event %s, got %s", StopEvent, eventType)
+		}
+	}
+
+	// Stopping again does not exit again
+	interp.StopWithExit()
+	if got := len(interp.State().Context.Exits); got != len(expected) {
+		t.Errorf("Expected %d exits after second stop, got %d", len(expected), got)
+	}
+
+	// Events are ignored once stopped
+	interp.Send(Event{Type: "ANY"})
+	if interp.Can("ANY") {
+		t.Error("Expected stopped interpreter to accept no events")
+	}
+}
+
+// TestStopWithExit_Hierarchy tests exit order without parallel states
+func TestStopWithExit_Hierarchy(t *testing.T) {
+	builder := NewMachine[stopContext]("stop_hierarchy").WithInitial("outer")
+	for _, name := range []string{"outer", "inner"} {
+		builder.WithAction(ActionType("exit_"+name), func(ctx *stopContext, e Event) {
+			ctx.Exits = append(ctx.Exits, name)
+		})
+	}
+
+	machine, err := builder.
+		State("outer").
+		WithInitial("inner").
+		OnExit("exit_outer").
+		State("inner").OnExit("exit_inner").End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.StopWithExit()
+
+	expected := []string{"inner", "outer"}
+	if exits := interp.State().Context.Exits; !slices.Equal(exits, expected) {
+		t.Errorf("Expected exits %v, got %v", expected, exits)
+	}
+}
+
+// TestStop_NoExitActions tests that plain Stop does not run exit actions
+func TestStop_NoExitActions(t *testing.T) {
+	machine, err := NewMachine[stopContext]("stop_plain").
+		WithInitial("idle").
+		WithAction("exit_idle", func(ctx *stopContext, e Event) {
+			ctx.Exits = append(ctx.Exits, "idle")
+		}).
+		State("idle").OnExit("exit_idle").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Stop()
+
+	if exits := interp.State().Context.Exits; len(exits) != 0 {
+		t.Errorf("Expected no exit actions on Stop, got %v", exits)
+	}
+}
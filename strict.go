@@ -0,0 +1,48 @@
+package statekit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnhandledEvent is reported in strict event mode for an event that no
+// active state has a transition for
+var ErrUnhandledEvent = errors.New("unhandled event")
+
+// WithStrictEvents reports events that no active state has a transition for,
+// which usually means a typo in the event type. SendResult reports such an event
+// with an error wrapping ErrUnhandledEvent in Err. Events whose transitions are
+// only blocked by guards are not reported, nor are events raised by the
+// interpreter itself, such as done and error events.
+func WithStrictEvents() InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.strictEvents = true
+	}
+}
+
+// WithUnhandledEventHandler enables strict event mode (see WithStrictEvents)
+// and calls fn with each unhandled event and its error. The handler runs while
+// the event is processed; it may call Send, which queues the event.
+func WithUnhandledEventHandler(fn func(Event, error)) InterpreterOption {
+	return func(o *interpreterOptions) {
+		o.strictEvents = true
+		o.unhandledEvent = fn
+	}
+}
+
+// checkHandled returns an error wrapping ErrUnhandledEvent if no active state
+// has a transition for the event, including wildcard transitions (caller must hold mu)
+func (i *Interpreter[C]) checkHandled(event Event) error {
+	for _, stateID := range i.activeStatesUnlocked() {
+		stateConfig := i.machine.GetState(stateID)
+		if stateConfig == nil {
+			continue
+		}
+		for _, t := range stateConfig.Transitions {
+			if t.Event == event.Type || t.IsWildcard() {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w %q", ErrUnhandledEvent, event.Type)
+}
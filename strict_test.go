@@ -0,0 +1,97 @@
+package statekit
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestStrictEvents_Unhandled tests that strict mode reports an event no active state handles
+func TestStrictEvents_Unhandled(t *testing.T) {
+	machine, err := NewMachine[struct{}]("strict").
+		WithInitial("app").
+		WithGuard("never", func(ctx struct{}, e Event) bool { return false }).
+		State("app").
+		WithInitial("idle").
+		On("RESET").Target("idle").End().
+		State("idle").
+		On("START").Target("running").
+		On("SKIP").Target("running").Guard("never").
+		End().End().
+		State("running").End().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithStrictEvents())
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "STRAT"})
+	if result.Handled || !errors.Is(result.Err, ErrUnhandledEvent) {
+		t.Errorf("Expected ErrUnhandledEvent, got %+v", result)
+	}
+
+	// Events handled by an ancestor or blocked by a guard are not reported
+	if result := interp.SendResult(Event{Type: "SKIP"}); result.Err != nil {
+		t.Errorf("Expected a guarded event not to be reported, got %v", result.Err)
+	}
+	if result := interp.SendResult(Event{Type: "RESET"}); !result.Handled || result.Err != nil {
+		t.Errorf("Expected RESET to be handled by the parent, got %+v", result)
+	}
+
+	// START is only handled by 'idle'
+	interp.Send(Event{Type: "START"})
+	if result := interp.SendResult(Event{Type: "START"}); !errors.Is(result.Err, ErrUnhandledEvent) {
+		t.Errorf("Expected START to be unhandled in 'running', got %+v", result)
+	}
+}
+
+// TestStrictEvents_DefaultIgnores tests that unhandled events are silently ignored by default
+func TestStrictEvents_DefaultIgnores(t *testing.T) {
+	machine, err := NewMachine[struct{}]("strict").
+		WithInitial("idle").
+		State("idle").On("START").Target("running").Done().
+		State("running").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "STRAT"})
+	if result.Handled || result.Err != nil {
+		t.Errorf("Expected the event to be ignored without an error, got %+v", result)
+	}
+}
+
+// TestStrictEvents_Handler tests that the unhandled event handler receives unknown events
+func TestStrictEvents_Handler(t *testing.T) {
+	machine, err := NewMachine[struct{}]("strict").
+		WithInitial("idle").
+		State("idle").On("START").Target("running").Done().
+		State("running").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	var unhandled []EventType
+	interp := NewInterpreter(machine, WithUnhandledEventHandler(func(e Event, err error) {
+		if !errors.Is(err, ErrUnhandledEvent) {
+			t.Errorf("Expected ErrUnhandledEvent, got %v", err)
+		}
+		unhandled = append(unhandled, e.Type)
+	}))
+	interp.Start()
+
+	interp.Send(Event{Type: "STRAT"})
+	interp.Send(Event{Type: "START"})
+	interp.Send(Event{Type: "STOP"})
+
+	if len(unhandled) != 2 || unhandled[0] != "STRAT" || unhandled[1] != "STOP" {
+		t.Errorf("Expected STRAT and STOP to be unhandled, got %v", unhandled)
+	}
+}
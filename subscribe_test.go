@@ -0,0 +1,174 @@
+package statekit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+// TestSubscribe_StartAndSend tests that listeners fire on Start and each transition
+func TestSubscribe_StartAndSend(t *testing.T) {
+	machine, err := NewMachine[struct{}]("subscribe").
+		WithInitial("idle").
+		State("idle").
+		On("START").Target("running").
+		Done().
+		State("running").
+		On("STOP").Target("idle").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+
+	var seen []StateID
+	interp.Subscribe(func(s State[struct{}]) {
+		seen = append(seen, s.Value)
+	})
+
+	interp.Start()
+	interp.Send(Event{Type: "START"})
+	interp.Send(Event{Type: "UNKNOWN"}) // No transition, no notification
+	interp.Send(Event{Type: "STOP"})
+
+	expected := []StateID{"idle", "running", "idle"}
+	if len(seen) != len(expected) {
+		t.Fatalf("Expected %d notifications, got %d: %v", len(expected), len(seen), seen)
+	}
+	for idx, id := range expected {
+		if seen[idx] != id {
+			t.Errorf("Notification %d: expected %s, got %s", idx, id, seen[idx])
+		}
+	}
+}
+
+// TestSubscribe_Unsubscribe tests that unsubscribed listeners are no longer called
+func TestSubscribe_Unsubscribe(t *testing.T) {
+	machine, err := NewMachine[struct{}]("unsubscribe").
+		WithInitial("a").
+		State("a").On("NEXT").Target("b").Done().
+		State("b").On("NEXT").Target("a").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	count := 0
+	unsubscribe := interp.Subscribe(func(s State[struct{}]) {
+		count++
+	})
+
+	interp.Send(Event{Type: "NEXT"})
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+	interp.Send(Event{Type: "NEXT"})
+
+	if count != 1 {
+		t.Errorf("Expected 1 notification, got %d", count)
+	}
+}
+
+// TestSubscribe_ListenerCanReadState tests that listeners may call back into the interpreter
+func TestSubscribe_ListenerCanReadState(t *testing.T) {
+	machine, err := NewMachine[struct{}]("reentrant").
+		WithInitial("idle").
+		State("idle").On("GO").Target("busy").Done().
+		State("busy").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	var current StateID
+	interp.Subscribe(func(s State[struct{}]) {
+		current = interp.State().Value // Would deadlock if called under lock
+	})
+
+	interp.Send(Event{Type: "GO"})
+	if current != "busy" {
+		t.Errorf("Expected 'busy', got %s", current)
+	}
+}
+
+// TestSubscribe_DelayedTransition tests that timer-fired transitions notify listeners
+func TestSubscribe_DelayedTransition(t *testing.T) {
+	machine, err := NewMachine[struct{}]("subscribe_delayed").
+		WithInitial("waiting").
+		State("waiting").
+		After(20 * time.Millisecond).Target("done").
+		Done().
+		State("done").Final().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+
+	var seen []StateID
+	interp.Subscribe(func(s State[struct{}]) {
+		seen = append(seen, s.Value)
+	})
+
+	interp.Start()
+	clock.Advance(20 * time.Millisecond)
+	interp.Stop()
+
+	if len(seen) != 2 || seen[1] != "done" {
+		t.Errorf("Expected notifications [waiting done], got %v", seen)
+	}
+}
+
+// TestSubscribe_ParallelRegions tests that region transitions notify with a copied snapshot
+func TestSubscribe_ParallelRegions(t *testing.T) {
+	machine, err := NewMachine[struct{}]("subscribe_parallel").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("left").
+		WithInitial("l1").
+		State("l1").On("MOVE").Target("l2").EndState().
+		State("l2").EndState().
+		EndRegion().
+		Region("right").
+		WithInitial("r1").
+		State("r1").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+
+	var snapshots []State[struct{}]
+	interp.Subscribe(func(s State[struct{}]) {
+		snapshots = append(snapshots, s)
+	})
+
+	interp.Start()
+	interp.Send(Event{Type: "MOVE"})
+
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d", len(snapshots))
+	}
+	if snapshots[0].ActiveInParallel["left"] != "l1" {
+		t.Errorf("Expected first snapshot to retain 'l1', got %s", snapshots[0].ActiveInParallel["left"])
+	}
+	if snapshots[1].ActiveInParallel["left"] != "l2" {
+		t.Errorf("Expected second snapshot 'l2', got %s", snapshots[1].ActiveInParallel["left"])
+	}
+
+	interp.Stop()
+}
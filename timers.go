@@ -0,0 +1,68 @@
+package statekit
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/internal/clock"
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+// PendingTimer is a delayed transition waiting for its timer to fire, as
+// reported by PendingTimers
+type PendingTimer struct {
+	// StateID is the state that declares the delayed transition
+	StateID StateID
+	// Target is the target of the delayed transition
+	Target StateID
+	// Delay is the named delay of the transition, if any
+	Delay DelayType
+	// RemainingMs is the time until the timer fires, in milliseconds
+	RemainingMs int64
+}
+
+// Remaining returns the time until the timer fires
+func (p PendingTimer) Remaining() time.Duration {
+	return time.Duration(p.RemainingMs) * time.Millisecond
+}
+
+// scheduledTimer is an armed delayed transition timer and its deadline
+type scheduledTimer struct {
+	timer    Timer
+	stateID  ir.StateID
+	index    int
+	trans    *ir.TransitionConfig
+	deadline time.Time
+}
+
+// PendingTimers returns the armed delayed transitions, ordered by the time
+// remaining until they fire, for example to display countdowns. The remaining
+// time is measured on the interpreter's clock (see WithClock) and is never
+// negative; a timer whose transition is blocked by a guard when it fires is no
+// longer reported. The result is empty when the interpreter is not started.
+func (i *Interpreter[C]) PendingTimers() []PendingTimer {
+	now := clock.Now(i.opts.clock)
+
+	i.timersMu.Lock()
+	scheduled := make([]*scheduledTimer, 0, len(i.timers))
+	for _, timer := range i.timers {
+		scheduled = append(scheduled, timer)
+	}
+	i.timersMu.Unlock()
+
+	slices.SortFunc(scheduled, func(a, b *scheduledTimer) int {
+		return cmp.Or(a.deadline.Compare(b.deadline), cmp.Compare(a.stateID, b.stateID), cmp.Compare(a.index, b.index))
+	})
+
+	pending := make([]PendingTimer, 0, len(scheduled))
+	for _, timer := range scheduled {
+		pending = append(pending, PendingTimer{
+			StateID:     timer.stateID,
+			Target:      timer.trans.Target,
+			Delay:       timer.trans.DelayName,
+			RemainingMs: max(timer.deadline.Sub(now), 0).Milliseconds(),
+		})
+	}
+	return pending
+}
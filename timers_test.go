@@ -0,0 +1,127 @@
+package statekit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+// TestPendingTimers_Countdown tests that the remaining time decreases as the clock advances
+func TestPendingTimers_Countdown(t *testing.T) {
+	machine, err := NewMachine[struct{}]("countdown").
+		WithInitial("waiting").
+		State("waiting").
+		After(time.Second).Target("timeout").
+		After(5 * time.Second).Target("expired").
+		On("CANCEL").Target("cancelled").
+		Done().
+		State("timeout").Done().
+		State("expired").Done().
+		State("cancelled").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	if pending := interp.PendingTimers(); len(pending) != 0 {
+		t.Errorf("Expected no pending timers before Start, got %+v", pending)
+	}
+
+	interp.Start()
+	defer interp.Stop()
+
+	pending := interp.PendingTimers()
+	if len(pending) != 2 {
+		t.Fatalf("Expected 2 pending timers, got %+v", pending)
+	}
+	expected := PendingTimer{StateID: "waiting", Target: "timeout", RemainingMs: 1000}
+	if pending[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, pending[0])
+	}
+	if pending[1].Target != "expired" || pending[1].RemainingMs != 5000 {
+		t.Errorf("Expected 'expired' timer with 5000ms remaining, got %+v", pending[1])
+	}
+
+	clock.Advance(400 * time.Millisecond)
+	pending = interp.PendingTimers()
+	if pending[0].RemainingMs != 600 || pending[0].Remaining() != 600*time.Millisecond {
+		t.Errorf("Expected 600ms remaining, got %+v", pending[0])
+	}
+
+	interp.Send(Event{Type: "CANCEL"})
+	if pending := interp.PendingTimers(); len(pending) != 0 {
+		t.Errorf("Expected timers canceled on exit, got %+v", pending)
+	}
+}
+
+// TestPendingTimers_Fired tests that fired and restored timers are reported correctly
+func TestPendingTimers_Fired(t *testing.T) {
+	machine, err := NewMachine[struct{}]("fired").
+		WithInitial("first").
+		State("first").After(time.Second).Target("second").Done().
+		State("second").After(2 * time.Second).Target("first").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock))
+	interp.Start()
+	defer interp.Stop()
+
+	clock.Advance(time.Second)
+	pending := interp.PendingTimers()
+	if len(pending) != 1 || pending[0].StateID != "second" || pending[0].RemainingMs != 2000 {
+		t.Fatalf("Expected the 'second' timer with 2000ms remaining, got %+v", pending)
+	}
+
+	// A restored timer counts down from the time already elapsed
+	snapshot := interp.Snapshot()
+	restored := NewInterpreter(machine, WithClock(clock))
+	if err := restored.RestoreWithElapsed(snapshot, 1500*time.Millisecond); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	defer restored.Stop()
+	if pending := restored.PendingTimers(); len(pending) != 1 || pending[0].RemainingMs != 500 {
+		t.Errorf("Expected 500ms remaining after restore, got %+v", pending)
+	}
+}
+
+// afterFuncClock is a Clock that only schedules timers and does not implement TimeSource
+type afterFuncClock struct {
+	fake *statekittest.FakeClock
+}
+
+func (c afterFuncClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.fake.AfterFunc(d, f)
+}
+
+// TestPendingTimers_ClockWithoutNow tests that a clock without Now still
+// schedules timers, and that the remaining time is measured in real time
+func TestPendingTimers_ClockWithoutNow(t *testing.T) {
+	machine, err := NewMachine[struct{}]("no_now").
+		WithInitial("waiting").
+		State("waiting").After(time.Second).Target("timeout").Done().
+		State("timeout").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	fake := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(afterFuncClock{fake: fake}))
+	interp.Start()
+	defer interp.Stop()
+
+	if pending := interp.PendingTimers(); len(pending) != 1 || pending[0].RemainingMs <= 0 || pending[0].RemainingMs > 1000 {
+		t.Errorf("Expected a pending timer with up to 1000ms remaining, got %+v", pending)
+	}
+	fake.Advance(time.Second)
+	if !interp.Matches("timeout") {
+		t.Errorf("Expected the timer to fire, got %s", interp.State().Value)
+	}
+}
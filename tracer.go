@@ -0,0 +1,43 @@
+package statekit
+
+// Tracer receives callbacks for every decision an interpreter makes. Set one
+// with WithTracer to log or record machine behavior while debugging.
+//
+// Callbacks run synchronously while the interpreter lock is held, so they must
+// not call State(), Can(), or other methods that take the lock. Sending events
+// from a callback is allowed; they are queued.
+type Tracer interface {
+	// OnTransition is called when a transition is taken, before its exit,
+	// transition, and entry actions run. from is the active leaf state and to
+	// is the resolved target leaf (or parallel state). Eventless and delayed
+	// transitions report the event that triggered them, which may be empty.
+	OnTransition(from, to StateID, event EventType)
+	// OnActionStart is called before an action runs
+	OnActionStart(name ActionType)
+	// OnActionEnd is called after an action returns
+	OnActionEnd(name ActionType)
+	// OnGuardEval is called after a guard is evaluated while selecting a
+	// transition. Results reused within a dispatch (see Guard) are reported
+	// too, so it is called for every guard checked, even if the guard ran once.
+	OnGuardEval(name GuardType, result bool)
+	// OnEventIgnored is called when an event causes no transition
+	OnEventIgnored(event EventType)
+}
+
+// EventTracer is an optional extension of Tracer for tracers that need the
+// full event, including its payload and metadata such as the correlation ID.
+// If the tracer set with WithTracer implements it, OnEvent is called when the
+// interpreter starts processing an event, before any other callback for it.
+type EventTracer interface {
+	OnEvent(event Event)
+}
+
+// NopTracer is a Tracer that ignores every callback. Embed it in a struct to
+// implement only the callbacks you need.
+type NopTracer struct{}
+
+func (NopTracer) OnTransition(from, to StateID, event EventType) {}
+func (NopTracer) OnActionStart(name ActionType)                  {}
+func (NopTracer) OnActionEnd(name ActionType)                    {}
+func (NopTracer) OnGuardEval(name GuardType, result bool)        {}
+func (NopTracer) OnEventIgnored(event EventType)                 {}
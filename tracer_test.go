@@ -0,0 +1,157 @@
+package statekit
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// parallelTracer records transitions and guard evaluations
+type parallelTracer struct {
+	NopTracer
+	transitions []string
+	guards      int
+}
+
+func (r *parallelTracer) OnTransition(from, to StateID, event EventType) {
+	r.transitions = append(r.transitions, string(from)+"->"+string(to))
+}
+
+func (r *parallelTracer) OnGuardEval(name GuardType, result bool) {
+	r.guards++
+}
+
+// TestTracer_ParallelRegions tests that region transitions are traced in declaration order
+func TestTracer_ParallelRegions(t *testing.T) {
+	machine, err := NewMachine[struct{}]("traced").
+		WithInitial("active").
+		State("active").Parallel().
+		Region("upload").
+		WithInitial("uploading").
+		State("uploading").
+		On("FINISH").Target("uploaded").
+		EndState().
+		State("uploaded").EndState().
+		EndRegion().
+		Region("download").
+		WithInitial("downloading").
+		State("downloading").
+		On("FINISH").Target("downloaded").
+		EndState().
+		State("downloaded").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tracer := &parallelTracer{}
+	interp := NewInterpreter(machine, WithTracer(tracer))
+	interp.Start()
+	interp.Send(Event{Type: "FINISH"})
+
+	expected := []string{"uploading->uploaded", "downloading->downloaded"}
+	if !slices.Equal(tracer.transitions, expected) {
+		t.Errorf("Expected transitions %v, got %v", expected, tracer.transitions)
+	}
+}
+
+// TestTracer_QueriesNotTraced tests that Can and NextEvents neither report
+// guard evaluations nor count guard rejections
+func TestTracer_QueriesNotTraced(t *testing.T) {
+	machine, err := NewMachine[struct{}]("queries").
+		WithInitial("idle").
+		WithGuard("allowed", func(ctx struct{}, e Event) bool { return true }).
+		WithGuard("denied", func(ctx struct{}, e Event) bool { return false }).
+		State("idle").
+		On("GO").Target("running").Guard("allowed").
+		On("SKIP").Target("running").Guard("denied").
+		Done().
+		State("running").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tracer := &parallelTracer{}
+	interp := NewInterpreter(machine, WithTracer(tracer))
+	interp.Start()
+
+	if !interp.Can("GO") {
+		t.Error("Expected Can(GO) to be true")
+	}
+	if interp.Can("SKIP") {
+		t.Error("Expected Can(SKIP) to be false")
+	}
+	interp.NextEvents()
+	if tracer.guards != 0 {
+		t.Errorf("Expected no traced guard evaluations from queries, got %d", tracer.guards)
+	}
+	if stats := interp.Stats(); stats.GuardRejections != 0 {
+		t.Errorf("Expected no guard rejections counted from queries, got %d", stats.GuardRejections)
+	}
+
+	interp.Send(Event{Type: "GO"})
+	if tracer.guards != 1 {
+		t.Errorf("Expected 1 traced guard evaluation, got %d", tracer.guards)
+	}
+}
+
+// eventTracer records the events it receives with their metadata
+type eventTracer struct {
+	NopTracer
+	events []Event
+}
+
+func (r *eventTracer) OnEvent(event Event) {
+	r.events = append(r.events, event)
+}
+
+// TestTracer_EventMetadata tests that event metadata reaches actions and event tracers
+func TestTracer_EventMetadata(t *testing.T) {
+	var seen []Event
+	record := func(ctx *struct{}, e Event) { seen = append(seen, e) }
+
+	machine, err := NewMachine[struct{}]("metadata").
+		WithInitial("idle").
+		WithAction("record", record).
+		WithGuard("fromOrders", func(ctx struct{}, e Event) bool {
+			return e.CorrelationID == "order-7"
+		}).
+		State("idle").
+		On("PAY").Target("paid").Guard("fromOrders").Do("record").
+		Done().
+		State("paid").OnEntry("record").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tracer := &eventTracer{}
+	interp := NewInterpreter(machine, WithTracer(tracer))
+	interp.Start()
+
+	sentAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := NewEvent("PAY").
+		WithPayload(100).
+		WithID("evt-1").
+		WithCorrelationID("order-7").
+		WithTime(sentAt)
+	interp.Send(event)
+
+	if interp.State().Value != "paid" {
+		t.Fatalf("Expected the guard to pass on the correlation ID, got %s", interp.State().Value)
+	}
+	if len(seen) != 2 || seen[0] != event || seen[1] != event {
+		t.Errorf("Expected the transition and entry actions to receive %+v, got %+v", event, seen)
+	}
+	if len(tracer.events) != 1 || tracer.events[0] != event {
+		t.Errorf("Expected the tracer to receive %+v, got %+v", event, tracer.events)
+	}
+
+	// Literals without metadata keep working
+	if literal := (Event{Type: "PAY", Payload: 100}); NewEvent("PAY").WithPayload(100) != literal {
+		t.Errorf("Expected NewEvent to match the equivalent literal")
+	}
+}
@@ -0,0 +1,70 @@
+package statekit
+
+import "time"
+
+// TransitionLogEntry is a transition recorded with WithHistoryLog
+type TransitionLogEntry struct {
+	// From and To are the leaf states before and after the transition; in a
+	// parallel region, the region's leaf states
+	From StateID
+	To   StateID
+	// Event is the event that triggered the transition; empty for eventless
+	// and delayed transitions
+	Event EventType
+	// Timestamp is the time of the transition on the interpreter's clock
+	Timestamp time.Time
+}
+
+// WithHistoryLog keeps an audit trail of the last n transitions taken,
+// including eventless, delayed, internal, and parallel region transitions,
+// read with TransitionLog. Once n transitions are recorded, each new one
+// evicts the oldest. The log is kept across Reset. Values less than 1 are
+// ignored.
+//
+// Unlike history states, which remember where to resume a compound state, the
+// log is a chronological record for auditing and debugging.
+func WithHistoryLog(n int) InterpreterOption {
+	return func(o *interpreterOptions) {
+		if n > 0 {
+			o.historyLog = n
+		}
+	}
+}
+
+// transitionLog is a ring buffer of the most recent transitions
+type transitionLog struct {
+	entries []TransitionLogEntry
+	next    int // index of the slot to write next once the buffer is full
+}
+
+func newTransitionLog(size int) *transitionLog {
+	return &transitionLog{entries: make([]TransitionLogEntry, 0, size)}
+}
+
+// add records a transition, evicting the oldest when full
+func (l *transitionLog) add(entry TransitionLogEntry) {
+	if len(l.entries) < cap(l.entries) {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+}
+
+// list returns the recorded transitions, oldest first
+func (l *transitionLog) list() []TransitionLogEntry {
+	if l == nil {
+		return nil
+	}
+	list := make([]TransitionLogEntry, 0, len(l.entries))
+	list = append(list, l.entries[l.next:]...)
+	return append(list, l.entries[:l.next]...)
+}
+
+// TransitionLog returns the transitions recorded with WithHistoryLog, oldest
+// first. It returns nil if the option is not set.
+func (i *Interpreter[C]) TransitionLog() []TransitionLogEntry {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.transitionLog.list()
+}
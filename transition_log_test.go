@@ -0,0 +1,105 @@
+package statekit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/statekit/statekittest"
+)
+
+// TestTransitionLog_Sequence tests that the log records a known sequence with timestamps
+func TestTransitionLog_Sequence(t *testing.T) {
+	machine, err := NewMachine[struct{}]("light").
+		WithInitial("green").
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").After(time.Second).Target("red").Done().
+		State("red").On("TIMER").Target("green").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock), WithHistoryLog(10))
+	interp.Start()
+	defer interp.Stop()
+
+	interp.Send(Event{Type: "TIMER"})
+	clock.Advance(time.Second)
+	interp.Send(Event{Type: "IGNORED"})
+	clock.Advance(time.Second)
+	interp.Send(Event{Type: "TIMER"})
+
+	start := time.Unix(0, 0)
+	expected := []TransitionLogEntry{
+		{From: "green", To: "yellow", Event: "TIMER", Timestamp: start},
+		{From: "yellow", To: "red", Timestamp: start.Add(time.Second)},
+		{From: "red", To: "green", Event: "TIMER", Timestamp: start.Add(2 * time.Second)},
+	}
+	if log := interp.TransitionLog(); !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected log:\n%+v\ngot:\n%+v", expected, log)
+	}
+}
+
+// TestTransitionLog_EvictsOldest tests that the log keeps only the last N transitions
+func TestTransitionLog_EvictsOldest(t *testing.T) {
+	machine, err := NewMachine[struct{}]("light").
+		WithInitial("green").
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").After(time.Second).Target("red").Done().
+		State("red").On("TIMER").Target("green").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	clock := statekittest.NewFakeClock()
+	interp := NewInterpreter(machine, WithClock(clock), WithHistoryLog(2))
+	interp.Start()
+	defer interp.Stop()
+
+	// Two full cycles: six transitions
+	for range 2 {
+		interp.Send(Event{Type: "TIMER"})
+		clock.Advance(time.Second)
+		interp.Send(Event{Type: "TIMER"})
+	}
+
+	log := interp.TransitionLog()
+	if len(log) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", log)
+	}
+	if log[0].From != "yellow" || log[0].To != "red" || log[1].From != "red" || log[1].To != "green" {
+		t.Errorf("Expected the last two transitions oldest first, got %+v", log)
+	}
+	if want := time.Unix(2, 0); !log[0].Timestamp.Equal(want) || !log[1].Timestamp.Equal(want) {
+		t.Errorf("Expected timestamps of the second cycle, got %+v", log)
+	}
+
+	// The log is kept across Reset
+	interp.Reset()
+	if len(interp.TransitionLog()) != 2 {
+		t.Errorf("Expected the log to be kept across reset, got %+v", interp.TransitionLog())
+	}
+}
+
+// TestTransitionLog_Disabled tests that nothing is recorded without the option
+func TestTransitionLog_Disabled(t *testing.T) {
+	machine, err := NewMachine[struct{}]("light").
+		WithInitial("green").
+		State("green").On("TIMER").Target("yellow").Done().
+		State("yellow").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithHistoryLog(0))
+	interp.Start()
+	interp.Send(Event{Type: "TIMER"})
+
+	if log := interp.TransitionLog(); log != nil {
+		t.Errorf("Expected no log, got %+v", log)
+	}
+}
@@ -16,17 +16,209 @@ type (
 	GuardType = ir.GuardType
 	// Event represents a runtime event with optional payload
 	Event = ir.Event
+	// ServiceType identifies a named invoked service
+	ServiceType = ir.ServiceType
+	// DelayType identifies a named delay
+	DelayType = ir.DelayType
 	// HistoryType specifies how history states remember previous states (v2.0)
 	HistoryType = ir.HistoryType
+	// Edge is a declared transition, as listed by MachineConfig.Transitions
+	Edge = ir.Edge
+	// StateConfig is a state of a machine, as visited by MachineConfig.Walk
+	StateConfig = ir.StateConfig
 )
 
 // Action is a side-effect function executed during transitions.
 // It receives a pointer to the context for modification and the triggering event.
-type Action[C any] func(ctx *C, event Event)
+//
+// Entry, exit, and transition actions run as part of a transition receive the
+// event that triggered it. Entry actions run by Start() receive a synthetic
+// event of type InitEvent.
+type Action[C any] = ir.Action[C]
+
+// Scheduler lets a scheduler action raise events on its interpreter. It is
+// only valid during the action call.
+type Scheduler = ir.Scheduler
+
+// SchedulerAction is an action that can also raise events through a
+// Scheduler. A raised event is queued like an event sent from an action: it is
+// processed after the current event, its transitions, and its eventless
+// transitions complete, before events sent from outside since. An entry action
+// can use it to move on immediately when a condition holds:
+//
+//	WithSchedulerAction("checkStock", func(ctx *Order, e statekit.Event, sched statekit.Scheduler) {
+//	    if ctx.InStock {
+//	        sched.Raise(statekit.Event{Type: "RESERVE"})
+//	    }
+//	})
+//
+// Scheduler actions are registered with WithSchedulerAction and referenced by
+// name wherever an action can be, e.g. OnEntry or Do.
+type SchedulerAction[C any] = ir.SchedulerAction[C]
+
+// FallibleAction is a transition action that can veto its transition by
+// returning an error. Fallible actions run before any exit action, on a copy of
+// the context made with the context cloner (see WithContextCloner). If every
+// fallible action succeeds, the copy becomes the context and the transition
+// proceeds. Otherwise the copy is discarded, no state is exited or entered, and
+// the error is reported in TransitionResult.Err.
+//
+// With the default shallow cloner, changes made through pointers, slices, or
+// maps in the context are not rolled back on a veto; use WithContextCloner with
+// DeepCopy for full rollback.
+type FallibleAction[C any] = ir.FallibleAction[C]
 
 // Guard is a predicate that determines if a transition should occur.
 // It receives the current context (by value) and the triggering event.
-type Guard[C any] func(ctx C, event Event) bool
+//
+// Guards must be pure: their result may only depend on the context and the
+// event. While an event is dispatched, the interpreter evaluates each guard at
+// most once and reuses its result for every transition that references it,
+// until an action runs and may have changed the context, so a guard may be
+// called fewer times than it is referenced.
+type Guard[C any] = ir.Guard[C]
+
+// StateSnapshot is a read-only view of the interpreter's active states, passed
+// to state guards. Value returns the current state like State().Value, and
+// Matches behaves like Interpreter.Matches. It is only valid during the call.
+type StateSnapshot = ir.StateSnapshot
+
+// StateGuard is a guard that also receives a snapshot of the active states, for
+// conditions on the machine's state that In cannot express, such as the state
+// of another parallel region combined with the context:
+//
+//	WithStateGuard("canPlay", func(ctx Player, e statekit.Event, snap statekit.StateSnapshot) bool {
+//	    return snap.Matches("online") || ctx.Downloaded
+//	})
+//
+// State guards are registered with WithStateGuard and referenced by name like
+// other guards. They cannot be used as components of combined guards.
+type StateGuard[C any] = ir.StateGuard[C]
+
+// GuardFactory creates a guard from the arguments of a guard call, so one
+// parameterized guard can be reused with different arguments:
+//
+//	WithGuardFactory("hasAtLeast", func(args []string) statekit.Guard[Cart] {
+//	    n, err := strconv.Atoi(args[0])
+//	    if err != nil {
+//	        return nil
+//	    }
+//	    return func(ctx Cart, e statekit.Event) bool { return ctx.Items >= n }
+//	})
+//
+// Transitions reference a call by name, e.g. Guard("hasAtLeast(3)"). Arguments
+// are separated by commas and passed as trimmed strings; the factory returns nil
+// to reject them, which Build reports as an INVALID_GUARD_ARGS issue.
+type GuardFactory[C any] = ir.GuardFactory[C]
+
+// In returns a guard that passes while the given state is active, as reported
+// by Matches: the state itself, one of its descendants, or a parallel region
+// state. Use it to coordinate parallel regions:
+//
+//	State("r1_waiting").On("ADVANCE").Target("r1_done").Guard(statekit.In("r2_ready"))
+//
+// In-state guards are evaluated by the interpreter and need not be registered.
+// They cannot be used as components of combined guards.
+func In(id StateID) GuardType {
+	return ir.InStateGuard(id)
+}
+
+// NewEvent returns an event of the given type. Chain the Event methods to add a
+// payload and metadata:
+//
+//	interp.Send(statekit.NewEvent("PAY").
+//	    WithPayload(payment).
+//	    WithCorrelationID(requestID).
+//	    WithTime(time.Now()))
+//
+// It is equivalent to the literal Event{Type: eventType}.
+func NewEvent(eventType EventType) Event {
+	return Event{Type: eventType}
+}
+
+// PayloadOf returns the event payload as type T.
+// If the payload is nil or not of type T, it returns the zero value and false.
+func PayloadOf[T any](e Event) (T, bool) {
+	payload, ok := e.Payload.(T)
+	return payload, ok
+}
+
+// TypedAction adapts a function that receives a concrete payload type into an Action.
+// The function is only called when the event payload is of type T; events with a
+// missing or mismatched payload are ignored.
+func TypedAction[C any, T any](fn func(ctx *C, payload T, event Event)) Action[C] {
+	return func(ctx *C, event Event) {
+		if payload, ok := PayloadOf[T](event); ok {
+			fn(ctx, payload, event)
+		}
+	}
+}
+
+// TypedGuard adapts a predicate on a concrete payload type into a Guard, so
+// guards need no type assertion:
+//
+//	WithGuard("hasAmount", statekit.TypedGuard(func(ctx Order, p Payment) bool {
+//	    return p.Amount > 0
+//	}))
+//
+// The guard fails when the event payload is missing or not of type T.
+func TypedGuard[C any, T any](fn func(ctx C, payload T) bool) Guard[C] {
+	return func(ctx C, event Event) bool {
+		payload, ok := PayloadOf[T](event)
+		return ok && fn(ctx, payload)
+	}
+}
+
+// Service is a long-running function invoked while a state is active.
+// The context.Context is canceled when the invoking state is exited or the
+// interpreter is stopped. On success the interpreter receives a
+// "done.invoke.<name>" event carrying the result as payload; on failure it
+// receives "error.invoke.<name>" carrying the error.
+type Service[C any] = ir.Service[C]
+
+// DelayResolver computes the duration of a named delay from the context and the
+// event that entered the state. It is called each time the state is entered.
+type DelayResolver[C any] = ir.DelayResolver[C]
+
+// Output computes the result of a machine from the context and the event
+// that entered its top-level final state
+type Output[C any] = ir.Output[C]
+
+// DoneInvokeEvent returns the event type sent when the named service completes
+func DoneInvokeEvent(name ServiceType) EventType {
+	return EventType("done.invoke." + string(name))
+}
+
+// ErrorInvokeEvent returns the event type sent when the named service fails
+func ErrorInvokeEvent(name ServiceType) EventType {
+	return EventType("error.invoke." + string(name))
+}
+
+// DoneStateEvent returns the event raised when the given state completes: when
+// the active child of a compound state is a final state, or when every region
+// of a parallel state has reached a final state
+func DoneStateEvent(id StateID) EventType {
+	return EventType("done.state." + string(id))
+}
+
+// WildcardEvent is the event type of catch-all transitions. A wildcard
+// transition fires for any event that no specific transition in the same
+// state handles, before the event bubbles up to the parent state.
+const WildcardEvent = ir.WildcardEvent
+
+// InitEvent is the type of the synthetic event passed to entry actions,
+// guards of eventless transitions, and invoked services when Start() enters
+// the initial state
+const InitEvent EventType = "statekit.init"
+
+// StopEvent is the type of the synthetic event passed to exit actions when
+// StopWithExit exits the active states
+const StopEvent EventType = "statekit.stop"
+
+// ErrorEvent is the type of the synthetic event passed to exit and entry
+// actions when a vetoed transition routes the machine to its error target
+// (see MachineBuilder.WithErrorTarget). Its payload is the veto error.
+const ErrorEvent EventType = "statekit.error"
 
 // Re-export constants
 const (
@@ -47,3 +47,74 @@ func TestEvent_Creation(t *testing.T) {
 		t.Errorf("expected count 1, got %v", payload["count"])
 	}
 }
+
+func TestPayloadOf(t *testing.T) {
+	event := Event{Type: "RENAME", Payload: "alice"}
+
+	name, ok := PayloadOf[string](event)
+	if !ok || name != "alice" {
+		t.Errorf("expected ('alice', true), got (%q, %v)", name, ok)
+	}
+
+	// Mismatched type returns zero value and false
+	count, ok := PayloadOf[int](event)
+	if ok || count != 0 {
+		t.Errorf("expected (0, false), got (%d, %v)", count, ok)
+	}
+
+	// Nil payload returns zero value and false
+	ptr, ok := PayloadOf[*counterContext](Event{Type: "EMPTY"})
+	if ok || ptr != nil {
+		t.Errorf("expected (nil, false), got (%v, %v)", ptr, ok)
+	}
+}
+
+func TestTypedAction(t *testing.T) {
+	action := TypedAction(func(ctx *counterContext, amount int, e Event) {
+		ctx.Count += amount
+	})
+
+	ctx := counterContext{}
+	action(&ctx, Event{Type: "ADD", Payload: 5})
+	action(&ctx, Event{Type: "ADD", Payload: "five"}) // Ignored: wrong type
+	action(&ctx, Event{Type: "ADD"})                  // Ignored: no payload
+
+	if ctx.Count != 5 {
+		t.Errorf("expected count 5, got %d", ctx.Count)
+	}
+}
+
+type paymentPayload struct {
+	Amount int
+}
+
+func TestTypedGuard(t *testing.T) {
+	machine, err := NewMachine[counterContext]("payment").
+		WithInitial("pending").
+		WithGuard("hasAmount", TypedGuard(func(ctx counterContext, p paymentPayload) bool {
+			return p.Amount > 0
+		})).
+		State("pending").On("PAY").Target("paid").Guard("hasAmount").Done().
+		State("paid").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	for _, payload := range []any{nil, "100", 100, paymentPayload{Amount: 0}} {
+		if result := interp.SendResult(Event{Type: "PAY", Payload: payload}); result.Handled {
+			t.Errorf("expected payload %#v to fail the guard", payload)
+		}
+	}
+	if interp.State().Value != "pending" {
+		t.Fatalf("expected state 'pending', got %s", interp.State().Value)
+	}
+
+	interp.Send(Event{Type: "PAY", Payload: paymentPayload{Amount: 100}})
+	if interp.State().Value != "paid" {
+		t.Errorf("expected state 'paid', got %s", interp.State().Value)
+	}
+}
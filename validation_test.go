@@ -1,8 +1,10 @@
 package statekit
 
 import (
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/felixgeelhaar/statekit/internal/ir"
 )
@@ -129,6 +131,151 @@ func TestBuild_Validation_ErrorMessage(t *testing.T) {
 	}
 }
 
+func TestBuild_Validation_DuplicateState(t *testing.T) {
+	_, err := NewMachine[struct{}]("test").
+		WithInitial("editing").
+		State("editing").
+		WithInitial("idle").
+		State("idle").End().
+		Done().
+		State("reviewing").
+		WithInitial("idle").
+		State("idle").End().
+		Done().
+		Build()
+
+	if err == nil {
+		t.Fatal("expected validation error for duplicate state")
+	}
+
+	valErr, ok := err.(*ir.ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %T", err)
+	}
+
+	var issue *ir.ValidationIssue
+	for i := range valErr.Issues {
+		if valErr.Issues[i].Code == ir.ErrCodeDuplicateState {
+			issue = &valErr.Issues[i]
+		}
+	}
+	if issue == nil {
+		t.Fatalf("expected DUPLICATE_STATE error, got: %v", err)
+	}
+	if got := strings.Join(issue.Path, "."); got != "states.reviewing.idle" {
+		t.Errorf("expected path 'states.reviewing.idle', got %q", got)
+	}
+	if !strings.Contains(issue.Message, "'editing'") || !strings.Contains(issue.Message, "'reviewing'") {
+		t.Errorf("expected message to name both parents, got %q", issue.Message)
+	}
+}
+
+func TestBuild_Validation_FinalHasTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		final func(*StateBuilder[struct{}]) *MachineBuilder[struct{}]
+	}{
+		{"event", func(b *StateBuilder[struct{}]) *MachineBuilder[struct{}] {
+			return b.On("RESTART").Target("active").Done()
+		}},
+		{"delayed", func(b *StateBuilder[struct{}]) *MachineBuilder[struct{}] {
+			return b.After(time.Second).Target("active").Done()
+		}},
+		{"eventless", func(b *StateBuilder[struct{}]) *MachineBuilder[struct{}] {
+			return b.Always().Target("active").Done()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewMachine[struct{}]("test").
+				WithInitial("active").
+				State("active").On("FINISH").Target("done").Done()
+			_, err := tt.final(builder.State("done").Final()).Build()
+
+			var valErr *ir.ValidationError
+			if !errors.As(err, &valErr) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			if !containsIssueCode(valErr, ir.ErrCodeFinalHasTransition) {
+				t.Errorf("expected FINAL_HAS_TRANSITION error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuild_Validation_InitialGuarded(t *testing.T) {
+	_, err := NewMachine[struct{}]("test").
+		WithInitial("parent").
+		State("parent").
+		WithInitial("a").
+		WithInitialGuarded("undefinedGuard", "a").
+		WithInitialGuarded(In("a"), "elsewhere").
+		State("a").End().
+		Done().
+		State("elsewhere").Done().
+		Build()
+
+	var valErr *ir.ValidationError
+	if !errors.As(err, &valErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if !containsIssueCode(valErr, ir.ErrCodeMissingGuard) {
+		t.Errorf("expected MISSING_GUARD error, got: %v", err)
+	}
+	if !containsIssueCode(valErr, ir.ErrCodeCompoundInvalidInitial) {
+		t.Errorf("expected COMPOUND_INVALID_INITIAL error, got: %v", err)
+	}
+}
+
+func TestBuild_Validation_ShadowedTransition(t *testing.T) {
+	builder := NewMachine[struct{}]("test").
+		WithInitial("idle").
+		WithGuard("isReady", func(ctx struct{}, e Event) bool { return true }).
+		State("idle").
+		On("X").Target("a").
+		On("X").Target("b").Guard("isReady").
+		Done().
+		State("a").Done().
+		State("b").Done()
+
+	// Shadowed transitions are legal and only reported by BuildLint
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("expected Build to accept a shadowed transition, got %v", err)
+	}
+	_, err := builder.BuildLint()
+
+	var valErr *ir.ValidationError
+	if !errors.As(err, &valErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if !containsIssueCode(valErr, ir.ErrCodeShadowedTransition) {
+		t.Fatalf("expected SHADOWED_TRANSITION error, got: %v", err)
+	}
+	for _, issue := range valErr.Issues {
+		if issue.Code == ir.ErrCodeShadowedTransition {
+			if got := strings.Join(issue.Path, "."); got != "states.idle.transitions.1" {
+				t.Errorf("expected path 'states.idle.transitions.1', got %q", got)
+			}
+		}
+	}
+
+	// A guarded transition followed by a fallback is not shadowed
+	_, err = NewMachine[struct{}]("test").
+		WithInitial("idle").
+		WithGuard("isReady", func(ctx struct{}, e Event) bool { return true }).
+		State("idle").
+		On("X").Target("b").Guard("isReady").
+		On("X").Target("a").
+		Done().
+		State("a").Done().
+		State("b").Done().
+		BuildLint()
+	if err != nil {
+		t.Errorf("expected guarded transition with fallback to be valid, got %v", err)
+	}
+}
+
 func containsIssueCode(err *ir.ValidationError, code string) bool {
 	for _, issue := range err.Issues {
 		if issue.Code == code {
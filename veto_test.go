@@ -0,0 +1,319 @@
+package statekit
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/internal/ir"
+)
+
+type vetoContext struct {
+	Attempts int
+	Valid    bool
+	Log      []string
+}
+
+var errInvalidForm = errors.New("form is invalid")
+
+// TestFallibleAction_Veto tests that a failing fallible action leaves the machine untouched
+func TestFallibleAction_Veto(t *testing.T) {
+	machine, err := NewMachine[vetoContext]("veto").
+		WithInitial("editing").
+		WithAction("exitEditing", func(ctx *vetoContext, e Event) {
+			ctx.Log = append(ctx.Log, "exitEditing")
+		}).
+		WithAction("submit", func(ctx *vetoContext, e Event) {
+			ctx.Log = append(ctx.Log, "submit")
+		}).
+		WithFallibleAction("validate", func(ctx *vetoContext, e Event) error {
+			ctx.Attempts++
+			ctx.Log = append(ctx.Log, "validate")
+			if !ctx.Valid {
+				return errInvalidForm
+			}
+			return nil
+		}).
+		State("editing").
+		OnExit("exitEditing").
+		On("SUBMIT").Target("submitted").DoFallible("validate").Do("submit").
+		Done().
+		State("submitted").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine, WithContextCloner(DeepCopy[vetoContext]))
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "SUBMIT"})
+
+	if result.Handled {
+		t.Error("Expected vetoed transition to be unhandled")
+	}
+	if !errors.Is(result.Err, errInvalidForm) {
+		t.Errorf("Expected result error to wrap errInvalidForm, got %v", result.Err)
+	}
+	if !slices.Equal(result.Actions, []ActionType{"validate"}) {
+		t.Errorf("Expected only the fallible action to run, got %v", result.Actions)
+	}
+
+	state := interp.State()
+	if state.Value != "editing" {
+		t.Errorf("Expected state 'editing', got %s", state.Value)
+	}
+	// Context changes made by the vetoing action are discarded
+	if state.Context.Attempts != 0 || len(state.Context.Log) != 0 {
+		t.Errorf("Expected context to be rolled back, got %+v", state.Context)
+	}
+}
+
+// TestFallibleAction_Success tests that passing fallible actions commit their changes and run first
+func TestFallibleAction_Success(t *testing.T) {
+	machine, err := NewMachine[vetoContext]("veto").
+		WithInitial("editing").
+		WithAction("exitEditing", func(ctx *vetoContext, e Event) {
+			ctx.Log = append(ctx.Log, "exitEditing")
+		}).
+		WithAction("submit", func(ctx *vetoContext, e Event) {
+			ctx.Log = append(ctx.Log, "submit")
+		}).
+		WithFallibleAction("validate", func(ctx *vetoContext, e Event) error {
+			ctx.Attempts++
+			ctx.Log = append(ctx.Log, "validate")
+			if !ctx.Valid {
+				return errInvalidForm
+			}
+			return nil
+		}).
+		State("editing").
+		OnExit("exitEditing").
+		On("SUBMIT").Target("submitted").DoFallible("validate").Do("submit").
+		Done().
+		State("submitted").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.UpdateContext(func(ctx *vetoContext) { ctx.Valid = true })
+
+	result := interp.SendResult(Event{Type: "SUBMIT"})
+
+	if !result.Handled || result.Err != nil {
+		t.Fatalf("Expected handled transition without error, got %+v", result)
+	}
+
+	state := interp.State()
+	if state.Value != "submitted" {
+		t.Errorf("Expected state 'submitted', got %s", state.Value)
+	}
+	if state.Context.Attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", state.Context.Attempts)
+	}
+	expected := []string{"validate", "exitEditing", "submit"}
+	if !slices.Equal(state.Context.Log, expected) {
+		t.Errorf("Expected log %v, got %v", expected, state.Context.Log)
+	}
+}
+
+// TestFallibleAction_ParallelRegion tests that a veto in one region does not stop other regions
+func TestFallibleAction_ParallelRegion(t *testing.T) {
+	machine, err := NewMachine[vetoContext]("veto_parallel").
+		WithInitial("active").
+		WithFallibleAction("reject", func(ctx *vetoContext, e Event) error {
+			return errInvalidForm
+		}).
+		State("active").Parallel().
+		Region("left").
+		WithInitial("l1").
+		State("l1").On("GO").Target("l2").DoFallible("reject").EndState().
+		State("l2").EndState().
+		EndRegion().
+		Region("right").
+		WithInitial("r1").
+		State("r1").On("GO").Target("r2").EndState().
+		State("r2").EndState().
+		EndRegion().
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "GO"})
+
+	if !result.Handled {
+		t.Error("Expected the right region transition to be handled")
+	}
+	if !errors.Is(result.Err, errInvalidForm) {
+		t.Errorf("Expected result error to wrap errInvalidForm, got %v", result.Err)
+	}
+	state := interp.State()
+	if state.ActiveInParallel["left"] != "l1" || state.ActiveInParallel["right"] != "r2" {
+		t.Errorf("Expected left 'l1' and right 'r2', got %v", state.ActiveInParallel)
+	}
+}
+
+// TestFallibleAction_Validation tests that undefined fallible actions are reported
+func TestFallibleAction_Validation(t *testing.T) {
+	_, err := NewMachine[vetoContext]("veto_missing").
+		WithInitial("editing").
+		State("editing").
+		On("SUBMIT").Target("submitted").DoFallible("missing").
+		Done().
+		State("submitted").Done().
+		Build()
+	if err == nil {
+		t.Fatal("Expected validation error for missing fallible action")
+	}
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %T", err)
+	}
+	if validationErr.Issues[0].Code != ir.ErrCodeMissingAction {
+		t.Errorf("Expected MISSING_ACTION, got %s", validationErr.Issues[0].Code)
+	}
+}
+
+// TestErrorTarget tests that a vetoed transition routes the machine to the
+// error target with the veto error as the event payload
+func TestErrorTarget(t *testing.T) {
+	machine, err := NewMachine[vetoContext]("veto_error").
+		WithInitial("editing").
+		WithErrorTarget("failed").
+		WithAction("exitEditing", func(ctx *vetoContext, e Event) {
+			ctx.Log = append(ctx.Log, "exitEditing:"+string(e.Type))
+		}).
+		WithAction("recordError", func(ctx *vetoContext, e Event) {
+			if err, ok := e.Payload.(error); ok && errors.Is(err, errInvalidForm) {
+				ctx.Log = append(ctx.Log, "recordError:"+err.Error())
+			}
+		}).
+		WithFallibleAction("validate", func(ctx *vetoContext, e Event) error {
+			return errInvalidForm
+		}).
+		State("editing").
+		OnExit("exitEditing").
+		On("SUBMIT").Target("submitted").DoFallible("validate").
+		Done().
+		State("submitted").Done().
+		State("failed").OnEntry("recordError").
+		On("RETRY").Target("editing").
+		Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+
+	result := interp.SendResult(Event{Type: "SUBMIT"})
+
+	if result.Handled || !errors.Is(result.Err, errInvalidForm) {
+		t.Errorf("Expected the veto to be reported, got %+v", result)
+	}
+	if !interp.Matches("failed") {
+		t.Fatalf("Expected state 'failed', got %s", interp.State().Value)
+	}
+	expected := []string{
+		"exitEditing:statekit.error",
+		`recordError:action "validate" vetoed transition: form is invalid`,
+	}
+	if log := interp.State().Context.Log; !slices.Equal(log, expected) {
+		t.Errorf("Expected log %v, got %v", expected, log)
+	}
+
+	// The error state is a regular state
+	interp.Send(Event{Type: "RETRY"})
+	if !interp.Matches("editing") {
+		t.Errorf("Expected state 'editing' after RETRY, got %s", interp.State().Value)
+	}
+}
+
+// TestErrorTarget_ParallelRegion tests that a veto in a region exits all
+// regions once the other regions have handled the event
+func TestErrorTarget_ParallelRegion(t *testing.T) {
+	var exited []string
+	machine, err := NewMachine[vetoContext]("veto_error_parallel").
+		WithInitial("active").
+		WithErrorTarget("failed").
+		WithAction("exitRight", func(ctx *vetoContext, e Event) {
+			exited = append(exited, string(e.Type))
+		}).
+		WithFallibleAction("reject", func(ctx *vetoContext, e Event) error {
+			return errInvalidForm
+		}).
+		State("active").Parallel().
+		Region("left").
+		WithInitial("l1").
+		State("l1").On("GO").Target("l2").DoFallible("reject").EndState().
+		State("l2").EndState().
+		EndRegion().
+		Region("right").
+		WithInitial("r1").
+		State("r1").On("GO").Target("r2").EndState().
+		State("r2").OnExit("exitRight").EndState().
+		EndRegion().
+		Done().
+		State("failed").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	interp := NewInterpreter(machine)
+	interp.Start()
+	interp.Send(Event{Type: "GO"})
+
+	state := interp.State()
+	if state.Value != "failed" || len(state.ActiveInParallel) != 0 {
+		t.Errorf("Expected 'failed' with no active regions, got %+v", state)
+	}
+	if !slices.Equal(exited, []string{string(ErrorEvent)}) {
+		t.Errorf("Expected 'r2' to be exited with the error event, got %v", exited)
+	}
+}
+
+// TestErrorTarget_BuildStrict tests that a state entered only as the error
+// target is not reported as unreachable
+func TestErrorTarget_BuildStrict(t *testing.T) {
+	_, err := NewMachine[vetoContext]("veto_error_strict").
+		WithInitial("editing").
+		WithErrorTarget("failed").
+		WithFallibleAction("validate", func(ctx *vetoContext, e Event) error {
+			return errInvalidForm
+		}).
+		State("editing").On("SUBMIT").Target("submitted").DoFallible("validate").Done().
+		State("submitted").Done().
+		State("failed").Done().
+		BuildStrict()
+	if err != nil {
+		t.Errorf("Expected the error target to be reachable, got %v", err)
+	}
+}
+
+// TestErrorTarget_Validation tests that an undefined error target is reported
+func TestErrorTarget_Validation(t *testing.T) {
+	_, err := NewMachine[vetoContext]("veto_error_missing").
+		WithInitial("editing").
+		WithErrorTarget("missing").
+		State("editing").Done().
+		Build()
+
+	var validationErr *ir.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if !containsIssueCode(validationErr, ir.ErrCodeInvalidTarget) {
+		t.Errorf("Expected INVALID_TARGET, got %v", validationErr)
+	}
+}
@@ -0,0 +1,105 @@
+package statekit
+
+import (
+	"testing"
+
+	"github.com/felixgeelhaar/statekit/export"
+)
+
+// TestWildcard_Precedence tests that specific transitions win over wildcards in
+// the same state, and that each state is checked before bubbling to its parent
+func TestWildcard_Precedence(t *testing.T) {
+	//	app (* → parentCaught, PING → pong)
+	//	├── strict (GO → went)
+	//	├── lenient (GO → went, * → childCaught unless IGNORED)
+	//	└── went
+	machine, err := NewMachine[struct{}]("wildcard").
+		WithInitial("app").
+		WithGuard("notIgnored", func(ctx struct{}, e Event) bool {
+			return e.Type != "IGNORED"
+		}).
+		State("app").
+		WithInitial("strict").
+		OnAny().Target("parentCaught").
+		On("PING").Target("pong").End().
+		State("strict").
+		On("GO").Target("went").End().
+		End().
+		State("lenient").
+		OnAny().Target("childCaught").Guard("notIgnored").
+		On("GO").Target("went").End().
+		End().
+		State("went").End().
+		Done().
+		State("parentCaught").Done().
+		State("childCaught").Done().
+		State("pong").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		initial  StateID
+		event    EventType
+		expected StateID
+	}{
+		{"child specific beats parent wildcard", "strict", "GO", "went"},
+		{"unhandled event reaches parent wildcard", "strict", "FOO", "parentCaught"},
+		{"parent specific beats parent wildcard", "strict", "PING", "pong"},
+		{"child specific beats child wildcard", "lenient", "GO", "went"},
+		{"child wildcard beats parent specific", "lenient", "PING", "childCaught"},
+		{"child wildcard catches unknown event", "lenient", "FOO", "childCaught"},
+		{"guarded child wildcard bubbles to parent", "lenient", "IGNORED", "parentCaught"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interp := NewInterpreter(machine, WithInitialState(tt.initial))
+			interp.Start()
+			interp.Send(Event{Type: tt.event})
+
+			if interp.State().Value != tt.expected {
+				t.Errorf("Expected state %s, got %s", tt.expected, interp.State().Value)
+			}
+		})
+	}
+}
+
+// TestWildcard_XStateExport tests that wildcard transitions export under the "*" key
+func TestWildcard_XStateExport(t *testing.T) {
+	machine, err := NewMachine[struct{}]("wildcard").
+		WithInitial("app").
+		WithGuard("notIgnored", func(ctx struct{}, e Event) bool {
+			return e.Type != "IGNORED"
+		}).
+		State("app").
+		WithInitial("strict").
+		OnAny().Target("parentCaught").End().
+		State("strict").End().
+		State("lenient").
+		OnAny().Target("childCaught").Guard("notIgnored").End().
+		End().
+		Done().
+		State("parentCaught").Done().
+		State("childCaught").Done().
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build machine: %v", err)
+	}
+
+	exported, err := export.NewXStateExporter(machine).Export()
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	app := exported.States["app"]
+	if trans, ok := app.On["*"]; !ok || trans.Target != "parentCaught" {
+		t.Errorf("Expected app '*' transition to parentCaught, got %+v", app.On)
+	}
+	lenient := app.States["lenient"]
+	if trans, ok := lenient.On["*"]; !ok || trans.Guard != "notIgnored" {
+		t.Errorf("Expected guarded '*' transition on lenient, got %+v", lenient.On)
+	}
+}